@@ -11,7 +11,11 @@ import (
 
 	"github.com/soliton-go/framework/core/config"
 	"github.com/soliton-go/framework/core/logger"
+	"github.com/soliton-go/framework/cqrs"
 	"github.com/soliton-go/framework/orm"
+	"github.com/soliton-go/framework/projection"
+	"github.com/soliton-go/framework/web/admin"
+	"github.com/soliton-go/framework/web/middleware"
 
 	userapp "github.com/soliton-go/application/internal/application/user"
 	interfaceshttp "github.com/soliton-go/application/internal/interfaces/http"
@@ -32,9 +36,77 @@ func main() {
 			config.NewConfig,
 			logger.NewLogger,
 			orm.NewGormDB,
-			func() event.EventBus { return event.NewLocalEventBus() },
+			// 工作单元：跨仓储的写操作共用一个事务，嵌套调用使用保存点
+			orm.NewUnitOfWork,
+			event.NewDeadLetterStore,
+			event.NewScheduler,
+			event.NewEventRecorder,
+			// 事件传输由 event.transport 配置选择（gochannel / sql / redis / nats）
+			func(lc fx.Lifecycle, cfg *config.Config, db *gorm.DB, dlq *event.DeadLetterStore, scheduler *event.Scheduler, recorder *event.EventRecorder, logger *zap.Logger) (event.EventBus, error) {
+				bus, err := event.NewEventBusFromConfig(cfg, db,
+					event.WithDefaultRetryPolicy(event.DefaultRetryPolicy),
+					event.WithDeadLetterStore(dlq),
+					event.WithScheduler(scheduler),
+					event.WithRecorder(recorder),
+					event.WithLogging(logger),
+					event.WithTracing(event.W3CTraceContext{}),
+				)
+				if err != nil {
+					return nil, err
+				}
+				lc.Append(fx.Hook{
+					OnStop: func(ctx context.Context) error { return bus.Close() },
+				})
+				return bus, nil
+			},
+			admin.NewDeadLetterHandler,
+			// 事件记录与重放：已发布事件写入 event_records，可重放给单个命名处理器
+			event.NewReplayer,
+			admin.NewEventReplayHandler,
+			// 读模型投影：按检查点读取事件记录，更新各模块注册的读模型
+			projection.NewProjector,
+			admin.NewProjectionHandler,
+			event.NewSyncDispatcher,
+			// 仓储保存聚合后，领域事件先在同一事务内交给同步处理器，再写入 Outbox 由中继异步发布
+			func(dispatcher *event.SyncDispatcher, outbox *event.Outbox) event.Publisher {
+				return event.Publishers{dispatcher, outbox}
+			},
+			event.NewOutbox,
+			func(outbox *event.Outbox, bus event.EventBus) *event.OutboxRelay {
+				return event.NewOutboxRelay(outbox, bus)
+			},
+			// 延迟事件：PublishAt / PublishAfter 写入调度表，到期后由轮询器发布到事件总线
+			func(scheduler *event.Scheduler) event.ScheduledPublisher { return scheduler },
+			func(scheduler *event.Scheduler, bus event.EventBus) *event.SchedulePoller {
+				return event.NewSchedulePoller(scheduler, bus)
+			},
+			// CQRS 总线：各模块把命令与查询处理器注册到总线，HTTP 处理器经总线分发。
+			// 中间件依次记录日志、恢复 panic、校验 validate 标签，命令还包在数据库事务中；
+			// 接入认证后可加入 cqrs.Authorization，调用方由 HTTP 中间件通过 cqrs.WithCaller 写入上下文。
+			// cqrs.WithAsync 允许经 DispatchAsync 把耗时命令写入 async_commands 表，由 CommandWorker 在后台执行
+			func(logger *zap.Logger, db *gorm.DB) *cqrs.InMemoryCommandBus {
+				return cqrs.NewCommandBus(cqrs.WithAsync(db), cqrs.WithMiddleware(
+					cqrs.Logging(logger),
+					cqrs.Recoverer(logger),
+					cqrs.Validation(cqrs.ValidateStruct(), cqrs.ValidateSelf),
+					cqrs.Transaction(db),
+				))
+			},
+			func(logger *zap.Logger) *cqrs.InMemoryQueryBus {
+				return cqrs.NewQueryBus(cqrs.WithMiddleware(
+					cqrs.Logging(logger),
+					cqrs.Recoverer(logger),
+					cqrs.Validation(cqrs.ValidateStruct(), cqrs.ValidateSelf),
+				))
+			},
+			func(bus *cqrs.InMemoryCommandBus, logger *zap.Logger) *cqrs.CommandWorker {
+				return cqrs.NewCommandWorker(bus, cqrs.WithWorkerLogger(logger))
+			},
+			cqrs.NewGetCommandHandler,
+			interfaceshttp.NewCommandHandler,
 		// soliton-gen:providers
 			NewRouter,
+			NewAdminRouter,
 		),
 
 		userapp.Module,
@@ -115,6 +187,44 @@ func main() {
 		}),
 		// soliton-gen:routes
 
+		// 启动 Outbox 中继
+		fx.Invoke(event.MigrateOutbox),
+		fx.Invoke(StartOutboxRelay),
+
+		// 启动延迟事件轮询器
+		fx.Invoke(event.MigrateSchedule),
+		fx.Invoke(StartSchedulePoller),
+
+		// 死信队列及管理接口（管理接口均挂在 AdminRouter 上，需携带 admin.token 配置的令牌）
+		fx.Invoke(event.MigrateDeadLetters),
+		fx.Invoke(func(r AdminRouter, h *admin.DeadLetterHandler) {
+			h.RegisterRoutes(r)
+		}),
+
+		// 事件记录及重放接口
+		fx.Invoke(event.MigrateEventRecords),
+		fx.Invoke(func(r AdminRouter, h *admin.EventReplayHandler) {
+			h.RegisterRoutes(r)
+		}),
+
+		// 启动读模型投影及管理接口
+		fx.Invoke(projection.MigrateCheckpoints),
+		fx.Invoke(StartProjector),
+		fx.Invoke(func(r AdminRouter, h *admin.ProjectionHandler) {
+			h.RegisterRoutes(r)
+		}),
+
+		// 启动异步命令执行器，命令状态经 GET /api/commands/:id 查询
+		fx.Invoke(cqrs.MigrateAsyncCommands),
+		fx.Invoke(StartCommandWorker),
+		fx.Invoke(func(r *gin.Engine, queries *cqrs.InMemoryQueryBus, getCommand *cqrs.GetCommandHandler, h *interfaceshttp.CommandHandler) error {
+			if err := cqrs.RegisterQuery(queries, getCommand.Handle); err != nil {
+				return err
+			}
+			h.RegisterRoutes(r)
+			return nil
+		}),
+
 		// 启动服务器
 		fx.Invoke(StartServer),
 	).Run()
@@ -123,6 +233,7 @@ func main() {
 // NewRouter 创建 Gin 引擎并注册基础路由。
 func NewRouter() *gin.Engine {
 	r := gin.Default()
+	r.Use(middleware.CorrelationID())
 
 	// 健康检查
 	r.GET("/health", func(c *gin.Context) {
@@ -132,6 +243,49 @@ func NewRouter() *gin.Engine {
 	return r
 }
 
+// AdminRouter 是管理接口（死信、事件重放、投影）的路由组。
+type AdminRouter struct {
+	gin.IRouter
+}
+
+// NewAdminRouter 创建管理接口路由组，请求须携带 Authorization: Bearer <admin.token>；
+// 未配置 admin.token（或环境变量 ADMIN_TOKEN）时管理接口拒绝所有请求。
+func NewAdminRouter(r *gin.Engine, cfg *config.Config) AdminRouter {
+	return AdminRouter{r.Group("", admin.RequireToken(cfg.GetString("admin.token")))}
+}
+
+// StartOutboxRelay 启动 Outbox 中继，将事务内写入的领域事件发布到事件总线（带 Fx 生命周期管理）。
+func StartOutboxRelay(lc fx.Lifecycle, relay *event.OutboxRelay) {
+	lc.Append(fx.Hook{
+		OnStart: relay.Start,
+		OnStop:  relay.Stop,
+	})
+}
+
+// StartSchedulePoller 启动延迟事件轮询器，将到期的调度事件发布到事件总线（带 Fx 生命周期管理）。
+func StartSchedulePoller(lc fx.Lifecycle, poller *event.SchedulePoller) {
+	lc.Append(fx.Hook{
+		OnStart: poller.Start,
+		OnStop:  poller.Stop,
+	})
+}
+
+// StartProjector 启动读模型投影，将新记录的事件应用到已注册的投影（带 Fx 生命周期管理）。
+func StartProjector(lc fx.Lifecycle, projector *projection.Projector) {
+	lc.Append(fx.Hook{
+		OnStart: projector.Start,
+		OnStop:  projector.Stop,
+	})
+}
+
+// StartCommandWorker 启动异步命令执行器，执行经 DispatchAsync 提交的命令（带 Fx 生命周期管理）。
+func StartCommandWorker(lc fx.Lifecycle, worker *cqrs.CommandWorker) {
+	lc.Append(fx.Hook{
+		OnStart: worker.Start,
+		OnStop:  worker.Stop,
+	})
+}
+
 // StartServer 启动 HTTP 服务器（带 Fx 生命周期管理）。
 func StartServer(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger, r *gin.Engine) {
 	addr := fmt.Sprintf("%s:%d", cfg.GetString("server.host"), cfg.GetInt("server.port"))
@@ -8,7 +8,10 @@ import (
 
 	"github.com/soliton-go/framework/core/config"
 	"github.com/soliton-go/framework/core/logger"
+	"github.com/soliton-go/framework/cqrs"
+	"github.com/soliton-go/framework/event"
 	"github.com/soliton-go/framework/orm"
+	"github.com/soliton-go/framework/projection"
 
 	userapp "github.com/soliton-go/application/internal/application/user"
 	orderapp "github.com/soliton-go/application/internal/application/order"
@@ -49,6 +52,24 @@ func main() {
 }
 
 func migrateAll(db *gorm.DB) error {
+	if err := event.MigrateOutbox(db); err != nil {
+		return err
+	}
+	if err := event.MigrateDeadLetters(db); err != nil {
+		return err
+	}
+	if err := event.MigrateSchedule(db); err != nil {
+		return err
+	}
+	if err := event.MigrateEventRecords(db); err != nil {
+		return err
+	}
+	if err := projection.MigrateCheckpoints(db); err != nil {
+		return err
+	}
+	if err := cqrs.MigrateAsyncCommands(db); err != nil {
+		return err
+	}
 	if err := userapp.RegisterMigration(db); err != nil {
 		return err
 	}
@@ -2,8 +2,11 @@ package inventoryapp
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 
 	"github.com/soliton-go/application/internal/domain/inventory"
 )
@@ -30,8 +33,6 @@ type CreateInventoryCommand struct {
 type CreateInventoryHandler struct {
 	repo inventory.InventoryRepository
 	service *inventory.InventoryDomainService
-	// 可选：添加事件总线用于发布领域事件
-	// eventBus event.EventBus
 }
 
 func NewCreateInventoryHandler(repo inventory.InventoryRepository, service *inventory.InventoryDomainService) *CreateInventoryHandler {
@@ -40,19 +41,11 @@ func NewCreateInventoryHandler(repo inventory.InventoryRepository, service *inve
 
 func (h *CreateInventoryHandler) Handle(ctx context.Context, cmd CreateInventoryCommand) (*inventory.Inventory, error) {
 	entity := inventory.NewInventory(cmd.ID, cmd.ProductId, cmd.WarehouseId, cmd.LocationCode, cmd.Stock, cmd.ReservedStock, cmd.AvailableStock, cmd.SafetyStock, cmd.RestockLevel, cmd.Status, cmd.LastStockedAt, cmd.LastCheckedAt, cmd.Notes, cmd.Metadata)
+	// 保存成功后，仓储会自动发布实体产生的领域事件（见 event.PublishingRepository）
 	if err := h.repo.Save(ctx, entity); err != nil {
 		return nil, err
 	}
 
-	// 可选：发布领域事件
-	// 取消注释以启用事件发布：
-	// events := entity.PullDomainEvents()
-	// if len(events) > 0 {
-	//     if err := h.eventBus.Publish(ctx, events...); err != nil {
-	//         return nil, err
-	//     }
-	// }
-
 	return entity, nil
 }
 
@@ -111,6 +104,51 @@ func NewDeleteInventoryHandler(repo inventory.InventoryRepository, service *inve
 	return &DeleteInventoryHandler{repo: repo, service: service}
 }
 
+// Handle 加载 Inventory 并记录删除事件后删除，使投影等订阅者收到 InventoryDeletedEvent；
+// Inventory 不存在时视为已删除。
 func (h *DeleteInventoryHandler) Handle(ctx context.Context, cmd DeleteInventoryCommand) error {
-	return h.repo.Delete(ctx, inventory.InventoryID(cmd.ID))
+	entity, err := h.repo.Find(ctx, inventory.InventoryID(cmd.ID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	entity.MarkDeleted()
+	return h.repo.Remove(ctx, entity)
+}
+
+// ImportInventoriesCommand 是批量导入 Inventory 的命令，经 DispatchAsync 异步执行。
+// 命令以 JSON 存入 async_commands 表，字段需可 JSON 序列化。
+type ImportInventoriesCommand struct {
+	Items []CreateInventoryCommand `validate:"required,min=1"`
+}
+
+// ImportInventoriesResult 是批量导入的结果，作为异步命令的结果保存。
+type ImportInventoriesResult struct {
+	Created int      `json:"created"`
+	IDs     []string `json:"ids"`
+}
+
+// ImportInventoriesHandler 处理 ImportInventoriesCommand。
+// 总线的事务中间件使整批导入在同一事务中提交，任一条失败则全部回滚。
+type ImportInventoriesHandler struct {
+	create *CreateInventoryHandler
+}
+
+func NewImportInventoriesHandler(create *CreateInventoryHandler) *ImportInventoriesHandler {
+	return &ImportInventoriesHandler{create: create}
+}
+
+func (h *ImportInventoriesHandler) Handle(ctx context.Context, cmd ImportInventoriesCommand) (*ImportInventoriesResult, error) {
+	result := &ImportInventoriesResult{IDs: make([]string, 0, len(cmd.Items))}
+	for i, item := range cmd.Items {
+		entity, err := h.create.Handle(ctx, item)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		result.IDs = append(result.IDs, string(entity.ID))
+	}
+	result.Created = len(result.IDs)
+	return result, nil
 }
@@ -24,6 +24,11 @@ type CreateInventoryRequest struct {
 	Metadata datatypes.JSON `json:"metadata"`
 }
 
+// ImportInventoriesRequest 是批量导入 Inventory 的请求体。
+type ImportInventoriesRequest struct {
+	Items []CreateInventoryRequest `json:"items" binding:"required,min=1,dive"`
+}
+
 // UpdateInventoryRequest 是更新 Inventory 的请求体。
 type UpdateInventoryRequest struct {
 	ProductId *string `json:"product_id,omitempty"`
@@ -57,6 +62,7 @@ type InventoryResponse struct {
 	LastCheckedAt *time.Time `json:"last_checked_at"`
 	Notes string `json:"notes"`
 	Metadata datatypes.JSON `json:"metadata"`
+	Version   int64     `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -78,6 +84,7 @@ func ToInventoryResponse(e *inventory.Inventory) InventoryResponse {
 		LastCheckedAt: e.LastCheckedAt,
 		Notes: e.Notes,
 		Metadata: e.Metadata,
+		Version:   e.Version,
 		CreatedAt: e.CreatedAt,
 		UpdatedAt: e.UpdatedAt,
 	}
@@ -1,6 +1,10 @@
 package inventoryapp
 
 import (
+	"errors"
+
+	"github.com/soliton-go/framework/cqrs"
+	"github.com/soliton-go/framework/event"
 	"go.uber.org/fx"
 
 	"github.com/soliton-go/application/internal/domain/inventory"
@@ -11,8 +15,8 @@ import (
 // Module 提供 Inventory 的所有 Fx 依赖。
 var Module = fx.Options(
 	// Repository
-	fx.Provide(func(db *gorm.DB) inventory.InventoryRepository {
-		return persistence.NewInventoryRepository(db)
+	fx.Provide(func(db *gorm.DB, publisher event.Publisher) inventory.InventoryRepository {
+		return persistence.NewInventoryRepository(db, publisher)
 	}),
 
 	// Domain Services
@@ -22,6 +26,7 @@ var Module = fx.Options(
 	fx.Provide(NewCreateInventoryHandler),
 	fx.Provide(NewUpdateInventoryHandler),
 	fx.Provide(NewDeleteInventoryHandler),
+	fx.Provide(NewImportInventoriesHandler),
 
 	// Query Handlers
 	fx.Provide(NewGetInventoryHandler),
@@ -31,20 +36,24 @@ var Module = fx.Options(
 	// soliton-gen:services
 	// soliton-gen:event-handlers
 
-	// 可选：注册到 CQRS 总线
-	// 取消注释以启用 CQRS 模式：
-	// fx.Invoke(func(cmdBus *cqrs.InMemoryCommandBus, queryBus *cqrs.InMemoryQueryBus,
-	//     createHandler *CreateInventoryHandler,
-	//     updateHandler *UpdateInventoryHandler,
-	//     deleteHandler *DeleteInventoryHandler,
-	//     getHandler *GetInventoryHandler,
-	//     listHandler *ListInventorysHandler) {
-	//     cmdBus.Register(CreateInventoryCommand{}, createHandler.Handle)
-	//     cmdBus.Register(UpdateInventoryCommand{}, updateHandler.Handle)
-	//     cmdBus.Register(DeleteInventoryCommand{}, deleteHandler.Handle)
-	//     queryBus.Register(GetInventoryQuery{}, getHandler.Handle)
-	//     queryBus.Register(ListInventorysQuery{}, listHandler.Handle)
-	// }),
+	// 注册到 CQRS 总线：HTTP 处理器经总线分发，总线中间件（校验、事务、授权、日志）对每个命令与查询生效。
+	// 处理器签名在编译期检查，重复注册返回错误。
+	fx.Invoke(func(cmdBus *cqrs.InMemoryCommandBus, queryBus *cqrs.InMemoryQueryBus,
+		createHandler *CreateInventoryHandler,
+		updateHandler *UpdateInventoryHandler,
+		deleteHandler *DeleteInventoryHandler,
+		importHandler *ImportInventoriesHandler,
+		getHandler *GetInventoryHandler,
+		listHandler *ListInventorysHandler) error {
+		return errors.Join(
+			cqrs.RegisterCommand(cmdBus, createHandler.Handle),
+			cqrs.RegisterCommand(cmdBus, updateHandler.Handle),
+			cqrs.RegisterVoidCommand(cmdBus, deleteHandler.Handle),
+			cqrs.RegisterCommand(cmdBus, importHandler.Handle), // 经 DispatchAsync 异步执行
+			cqrs.RegisterQuery(queryBus, getHandler.Handle),
+			cqrs.RegisterQuery(queryBus, listHandler.Handle),
+		)
+	}),
 )
 
 // RegisterMigration 注册 Inventory 表的数据库迁移。
@@ -4,6 +4,8 @@ import (
 	"context"
 	"strings"
 
+	"github.com/soliton-go/framework/orm"
+
 	"github.com/soliton-go/application/internal/domain/inventory"
 )
 
@@ -29,17 +31,22 @@ func (h *GetInventoryHandler) Handle(ctx context.Context, query GetInventoryQuer
 type ListInventorysQuery struct {
 	Page     int // 页码（从 1 开始）
 	PageSize int // 每页数量（默认: 20, 最大: 100）
-	SortBy   string // 排序字段（默认: id）
-	SortOrder string // 排序方式（asc/desc）
+	SortBy   string // 排序字段（默认: id），须在仓储的排序白名单中
+	SortOrder string // 排序方式（asc/desc，默认: desc）
+	Filters  []orm.Condition // 过滤条件，按 AND 组合
+	Cursor    string // 游标（上一页的 next_cursor）；设置 Cursor 或 Limit 时按游标分页，忽略页码
+	Limit     int    // 游标分页的每页数量（默认: 20, 最大: 100）
+	WithTotal bool   // 游标分页时是否统计总数
 }
 
 // ListInventorysResult 是分页查询结果。
 type ListInventorysResult struct {
 	Items      []*inventory.Inventory
-	Total      int64
+	Total      int64 // 游标分页且未要求统计时为 -1
 	Page       int
 	PageSize   int
 	TotalPages int
+	NextCursor string // 游标分页时下一页的游标，为空表示没有更多数据
 }
 
 // ListInventorysHandler 处理 ListInventorysQuery。
@@ -65,43 +72,23 @@ func (h *ListInventorysHandler) Handle(ctx context.Context, query ListInventorys
 		pageSize = 100
 	}
 
-	// 排序字段白名单
-	sortBy := strings.ToLower(strings.TrimSpace(query.SortBy))
-	sortOrder := strings.ToLower(strings.TrimSpace(query.SortOrder))
-	if sortOrder != "asc" && sortOrder != "desc" {
-		sortOrder = "desc"
-	}
-	allowedSorts := map[string]struct{}{
-		"id":         {},
-		"created_at": {},
-		"updated_at": {},
-		"product_id": {},
-		"warehouse_id": {},
-		"location_code": {},
-		"stock": {},
-		"reserved_stock": {},
-		"available_stock": {},
-		"safety_stock": {},
-		"restock_level": {},
-		"status": {},
-		"last_stocked_at": {},
-		"last_checked_at": {},
-		"notes": {},
-		"metadata": {},
-	}
-	if _, ok := allowedSorts[sortBy]; !ok {
+	// 排序字段和过滤列由仓储校验，无效时返回 orm.ErrInvalidCriteria
+	sortBy := query.SortBy
+	if strings.TrimSpace(sortBy) == "" {
 		sortBy = "id"
 	}
+	criteria := orm.NewCriteria(query.Filters...).
+		OrderBy(orm.ParseSort(sortBy, query.SortOrder))
 
-	// 获取总数和分页数据
-	items, total, err := h.repo.FindPaginated(ctx, page, pageSize, sortBy, sortOrder)
-	if err != nil {
-		return nil, err
+	if query.Cursor != "" || query.Limit > 0 {
+		return h.handleCursor(ctx, criteria, query)
 	}
 
-	totalPages := int(total) / pageSize
-	if int(total)%pageSize > 0 {
-		totalPages++
+	criteria = criteria.Paginate(page, pageSize)
+
+	items, total, err := h.repo.FindByCriteria(ctx, criteria)
+	if err != nil {
+		return nil, err
 	}
 
 	return &ListInventorysResult{
@@ -109,6 +96,37 @@ func (h *ListInventorysHandler) Handle(ctx context.Context, query ListInventorys
 		Total:      total,
 		Page:       page,
 		PageSize:   pageSize,
-		TotalPages: totalPages,
+		TotalPages: criteria.TotalPages(total),
 	}, nil
 }
+
+// handleCursor 按游标分页：每页只读取 Limit+1 行，不随翻页深度变慢，仅在 WithTotal 时统计总数。
+func (h *ListInventorysHandler) handleCursor(ctx context.Context, criteria orm.Criteria, query ListInventorysQuery) (*ListInventorysResult, error) {
+	limit := query.Limit
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	criteria = criteria.After(query.Cursor, limit)
+	if query.WithTotal {
+		criteria = criteria.WithTotal()
+	}
+
+	page, err := h.repo.FindByCursor(ctx, criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ListInventorysResult{
+		Items:      page.Items,
+		Total:      -1,
+		PageSize:   limit,
+		NextCursor: page.NextCursor,
+	}
+	if page.Total != nil {
+		result.Total = *page.Total
+	}
+	return result, nil
+}
@@ -79,6 +79,7 @@ func (s *InventoryService) ReserveStock(ctx context.Context, req ReserveStockSer
 	}
 	entity.ReservedStock = reserved
 	entity.AvailableStock = entity.Stock - entity.ReservedStock
+	entity.AddDomainEvent(inventory.NewStockReservedEvent(entity.ID.String(), req.Quantity, entity.ReservedStock, entity.AvailableStock))
 	if err := s.repo.Save(ctx, entity); err != nil {
 		return nil, err
 	}
@@ -5,15 +5,21 @@ import (
 	"testing"
 	"time"
 
+	"github.com/soliton-go/framework/event"
+	"github.com/soliton-go/framework/event/eventtest"
+	"github.com/soliton-go/framework/orm"
+
 	"github.com/soliton-go/application/internal/domain/inventory"
 )
 
+// inventoryRepoStub 保存时像 PublishingRepository 一样发布聚合的领域事件。
 type inventoryRepoStub struct {
-	items map[string]*inventory.Inventory
+	items     map[string]*inventory.Inventory
+	publisher event.Publisher
 }
 
-func newInventoryRepoStub() *inventoryRepoStub {
-	return &inventoryRepoStub{items: map[string]*inventory.Inventory{}}
+func newInventoryRepoStub(publisher event.Publisher) *inventoryRepoStub {
+	return &inventoryRepoStub{items: map[string]*inventory.Inventory{}, publisher: publisher}
 }
 
 func (r *inventoryRepoStub) Find(ctx context.Context, id inventory.InventoryID) (*inventory.Inventory, error) {
@@ -34,7 +40,7 @@ func (r *inventoryRepoStub) FindAll(ctx context.Context) ([]*inventory.Inventory
 
 func (r *inventoryRepoStub) Save(ctx context.Context, entity *inventory.Inventory) error {
 	r.items[entity.ID.String()] = entity
-	return nil
+	return r.publisher.Publish(ctx, entity.PullDomainEvents()...)
 }
 
 func (r *inventoryRepoStub) Delete(ctx context.Context, id inventory.InventoryID) error {
@@ -42,13 +48,23 @@ func (r *inventoryRepoStub) Delete(ctx context.Context, id inventory.InventoryID
 	return nil
 }
 
-func (r *inventoryRepoStub) FindPaginated(ctx context.Context, page, pageSize int, sortBy, sortOrder string) ([]*inventory.Inventory, int64, error) {
+func (r *inventoryRepoStub) Remove(ctx context.Context, entity *inventory.Inventory) error {
+	return r.Delete(ctx, entity.ID)
+}
+
+func (r *inventoryRepoStub) FindByCriteria(ctx context.Context, criteria orm.Criteria) ([]*inventory.Inventory, int64, error) {
 	items, _ := r.FindAll(ctx)
 	return items, int64(len(items)), nil
 }
 
+func (r *inventoryRepoStub) FindByCursor(ctx context.Context, criteria orm.Criteria) (orm.CursorPage[*inventory.Inventory], error) {
+	items, _ := r.FindAll(ctx)
+	return orm.CursorPage[*inventory.Inventory]{Items: items}, nil
+}
+
 func TestInventoryServiceReserveAndRelease(t *testing.T) {
-	repo := newInventoryRepoStub()
+	bus := eventtest.NewBus()
+	repo := newInventoryRepoStub(bus)
 	service := NewInventoryService(repo)
 
 	entity := inventory.NewInventory(
@@ -70,6 +86,8 @@ func TestInventoryServiceReserveAndRelease(t *testing.T) {
 	if err := repo.Save(context.Background(), entity); err != nil {
 		t.Fatalf("save failed: %v", err)
 	}
+	eventtest.ExpectEvents(t, bus, "inventory.created")
+	bus.Reset()
 
 	reserveResp, err := service.ReserveStock(context.Background(), ReserveStockServiceRequest{
 		InventoryId: "inv-1",
@@ -81,6 +99,17 @@ func TestInventoryServiceReserveAndRelease(t *testing.T) {
 	if reserveResp.ReservedStock != 5 || reserveResp.AvailableStock != 5 {
 		t.Fatalf("unexpected stock after reserve: %+v", reserveResp)
 	}
+	reserved := eventtest.ExpectEvent[inventory.StockReservedEvent](t, bus)
+	if reserved.AggregateID != "inv-1" {
+		t.Fatalf("unexpected aggregate id: %s", reserved.AggregateID)
+	}
+	eventtest.ExpectPayload(t, reserved, map[string]any{
+		"inventory_id":    "inv-1",
+		"quantity":        3,
+		"reserved_stock":  5,
+		"available_stock": 5,
+	})
+	bus.Reset()
 
 	releaseResp, err := service.ReleaseStock(context.Background(), ReleaseStockServiceRequest{
 		InventoryId: "inv-1",
@@ -92,10 +121,46 @@ func TestInventoryServiceReserveAndRelease(t *testing.T) {
 	if releaseResp.ReservedStock != 3 || releaseResp.AvailableStock != 7 {
 		t.Fatalf("unexpected stock after release: %+v", releaseResp)
 	}
+	eventtest.ExpectNoEvents(t, bus)
+}
+
+func TestInventoryServiceReserveInsufficientStock(t *testing.T) {
+	bus := eventtest.NewBus()
+	repo := newInventoryRepoStub(bus)
+	service := NewInventoryService(repo)
+
+	entity := inventory.NewInventory(
+		"inv-3",
+		"prod-3",
+		"wh-1",
+		"C1",
+		2,
+		0,
+		2,
+		0,
+		0,
+		inventory.InventoryStatusActive,
+		nil,
+		nil,
+		"",
+		nil,
+	)
+	if err := repo.Save(context.Background(), entity); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	bus.Reset()
+
+	if _, err := service.ReserveStock(context.Background(), ReserveStockServiceRequest{
+		InventoryId: "inv-3",
+		Quantity:    5,
+	}); err == nil {
+		t.Fatal("expected insufficient stock error")
+	}
+	eventtest.ExpectNoEvents(t, bus)
 }
 
 func TestInventoryServiceStockInOut(t *testing.T) {
-	repo := newInventoryRepoStub()
+	repo := newInventoryRepoStub(eventtest.NewBus())
 	service := NewInventoryService(repo)
 
 	now := time.Now()
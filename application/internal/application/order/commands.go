@@ -2,7 +2,9 @@ package orderapp
 
 import (
 	"context"
+	"errors"
 	"time"
+	"gorm.io/gorm"
 
 	"github.com/soliton-go/application/internal/domain/order"
 )
@@ -48,8 +50,6 @@ type CreateOrderCommand struct {
 type CreateOrderHandler struct {
 	repo order.OrderRepository
 	service *order.OrderDomainService
-	// 可选：添加事件总线用于发布领域事件
-	// eventBus event.EventBus
 }
 
 func NewCreateOrderHandler(repo order.OrderRepository, service *order.OrderDomainService) *CreateOrderHandler {
@@ -58,19 +58,11 @@ func NewCreateOrderHandler(repo order.OrderRepository, service *order.OrderDomai
 
 func (h *CreateOrderHandler) Handle(ctx context.Context, cmd CreateOrderCommand) (*order.Order, error) {
 	entity := order.NewOrder(cmd.ID, cmd.UserId, cmd.OrderNo, cmd.TotalAmount, cmd.DiscountAmount, cmd.TaxAmount, cmd.ShippingFee, cmd.FinalAmount, cmd.Currency, cmd.PaymentMethod, cmd.PaymentStatus, cmd.OrderStatus, cmd.ShippingMethod, cmd.TrackingNumber, cmd.ReceiverName, cmd.ReceiverPhone, cmd.ReceiverEmail, cmd.ReceiverAddress, cmd.ReceiverCity, cmd.ReceiverState, cmd.ReceiverCountry, cmd.ReceiverPostalCode, cmd.Notes, cmd.PaidAt, cmd.ShippedAt, cmd.DeliveredAt, cmd.CancelledAt, cmd.RefundAmount, cmd.RefundReason, cmd.ItemCount, cmd.Weight, cmd.IsGift, cmd.GiftMessage)
+	// 保存成功后，仓储会自动发布实体产生的领域事件（见 event.PublishingRepository）
 	if err := h.repo.Save(ctx, entity); err != nil {
 		return nil, err
 	}
 
-	// 可选：发布领域事件
-	// 取消注释以启用事件发布：
-	// events := entity.PullDomainEvents()
-	// if len(events) > 0 {
-	//     if err := h.eventBus.Publish(ctx, events...); err != nil {
-	//         return nil, err
-	//     }
-	// }
-
 	return entity, nil
 }
 
@@ -148,6 +140,16 @@ func NewDeleteOrderHandler(repo order.OrderRepository, service *order.OrderDomai
 	return &DeleteOrderHandler{repo: repo, service: service}
 }
 
+// Handle 加载 Order 并记录删除事件后删除，使投影等订阅者收到 OrderDeletedEvent；
+// Order 不存在时视为已删除。
 func (h *DeleteOrderHandler) Handle(ctx context.Context, cmd DeleteOrderCommand) error {
-	return h.repo.Delete(ctx, order.OrderID(cmd.ID))
+	entity, err := h.repo.Find(ctx, order.OrderID(cmd.ID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	entity.MarkDeleted()
+	return h.repo.Remove(ctx, entity)
 }
@@ -0,0 +1,105 @@
+package orderapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/event"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/soliton-go/application/internal/domain/order"
+	"github.com/soliton-go/application/internal/infrastructure/persistence"
+)
+
+// projectingPublisher 像投影器一样按注册表解码事件，再直接交给投影，代替 Outbox、事件总线与投影器。
+type projectingPublisher struct {
+	projection *UserOrderSummaryProjection
+}
+
+func (p projectingPublisher) Publish(ctx context.Context, events ...ddd.DomainEvent) error {
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		decoded, err := event.GlobalRegistry().Create(e.EventName())
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(payload, decoded); err != nil {
+			return err
+		}
+		if err := p.projection.Apply(ctx, decoded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestDeleteOrderUpdatesUserOrderSummary(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+	if err := persistence.MigrateOrder(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateUserOrderSummary(db); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := persistence.NewOrderRepository(db, projectingPublisher{projection: NewUserOrderSummaryProjection(db)})
+	service := order.NewOrderDomainService(repo)
+	create := NewCreateOrderHandler(repo, service)
+	remove := NewDeleteOrderHandler(repo, service)
+	summaries := NewGetUserOrderSummaryHandler(db)
+	ctx := context.Background()
+
+	for _, cmd := range []CreateOrderCommand{
+		{ID: "order-1", UserId: "user-1", FinalAmount: 100, OrderStatus: order.OrderOrderStatusPending},
+		{ID: "order-2", UserId: "user-1", FinalAmount: 250, OrderStatus: order.OrderOrderStatusPending},
+	} {
+		if _, err := create.Handle(ctx, cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	summary, err := summaries.Handle(ctx, GetUserOrderSummaryQuery{UserId: "user-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.OrderCount != 2 || summary.TotalAmount != 350 {
+		t.Fatalf("summary before delete = %d orders, %d total; want 2, 350", summary.OrderCount, summary.TotalAmount)
+	}
+
+	if err := remove.Handle(ctx, DeleteOrderCommand{ID: "order-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Find(ctx, order.OrderID("order-1")); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Find deleted order = %v, want not found", err)
+	}
+	summary, err = summaries.Handle(ctx, GetUserOrderSummaryQuery{UserId: "user-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.OrderCount != 1 || summary.TotalAmount != 250 {
+		t.Errorf("summary after delete = %d orders, %d total; want 1, 250", summary.OrderCount, summary.TotalAmount)
+	}
+
+	if err := remove.Handle(ctx, DeleteOrderCommand{ID: "order-2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := summaries.Handle(ctx, GetUserOrderSummaryQuery{UserId: "user-1"}); !errors.Is(err, ErrUserOrderSummaryNotFound) {
+		t.Errorf("summary after deleting every order = %v, want ErrUserOrderSummaryNotFound", err)
+	}
+
+	// 删除不存在的订单视为已删除。
+	if err := remove.Handle(ctx, DeleteOrderCommand{ID: "order-1"}); err != nil {
+		t.Errorf("deleting a missing order = %v, want nil", err)
+	}
+}
@@ -113,6 +113,7 @@ type OrderResponse struct {
 	Weight float64 `json:"weight"`
 	IsGift bool `json:"is_gift"`
 	GiftMessage string `json:"gift_message"`
+	Version   int64     `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -153,6 +154,7 @@ func ToOrderResponse(e *order.Order) OrderResponse {
 		Weight: e.Weight,
 		IsGift: e.IsGift,
 		GiftMessage: e.GiftMessage,
+		Version:   e.Version,
 		CreatedAt: e.CreatedAt,
 		UpdatedAt: e.UpdatedAt,
 	}
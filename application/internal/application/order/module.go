@@ -1,6 +1,11 @@
 package orderapp
 
 import (
+	"errors"
+
+	"github.com/soliton-go/framework/cqrs"
+	"github.com/soliton-go/framework/event"
+	"github.com/soliton-go/framework/projection"
 	"go.uber.org/fx"
 
 	"github.com/soliton-go/application/internal/domain/order"
@@ -11,8 +16,8 @@ import (
 // Module 提供 Order 的所有 Fx 依赖。
 var Module = fx.Options(
 	// Repository
-	fx.Provide(func(db *gorm.DB) order.OrderRepository {
-		return persistence.NewOrderRepository(db)
+	fx.Provide(func(db *gorm.DB, publisher event.Publisher) order.OrderRepository {
+		return persistence.NewOrderRepository(db, publisher)
 	}),
 
 	// Domain Services
@@ -26,27 +31,44 @@ var Module = fx.Options(
 	// Query Handlers
 	fx.Provide(NewGetOrderHandler),
 	fx.Provide(NewListOrdersHandler),
+
+	// Projections：用户订单汇总读模型由订单事件维护，查询直接读取读模型
+	fx.Provide(NewUserOrderSummaryProjection),
+	fx.Provide(NewGetUserOrderSummaryHandler),
+	fx.Invoke(func(projector *projection.Projector, queryBus *cqrs.InMemoryQueryBus,
+		summary *UserOrderSummaryProjection,
+		summaryHandler *GetUserOrderSummaryHandler) error {
+		return errors.Join(
+			projector.Register(summary),
+			cqrs.RegisterQuery(queryBus, summaryHandler.Handle),
+		)
+	}),
 	
 	// soliton-gen:services
 	// soliton-gen:event-handlers
 
-	// 可选：注册到 CQRS 总线
-	// 取消注释以启用 CQRS 模式：
-	// fx.Invoke(func(cmdBus *cqrs.InMemoryCommandBus, queryBus *cqrs.InMemoryQueryBus,
-	//     createHandler *CreateOrderHandler,
-	//     updateHandler *UpdateOrderHandler,
-	//     deleteHandler *DeleteOrderHandler,
-	//     getHandler *GetOrderHandler,
-	//     listHandler *ListOrdersHandler) {
-	//     cmdBus.Register(CreateOrderCommand{}, createHandler.Handle)
-	//     cmdBus.Register(UpdateOrderCommand{}, updateHandler.Handle)
-	//     cmdBus.Register(DeleteOrderCommand{}, deleteHandler.Handle)
-	//     queryBus.Register(GetOrderQuery{}, getHandler.Handle)
-	//     queryBus.Register(ListOrdersQuery{}, listHandler.Handle)
-	// }),
+	// 注册到 CQRS 总线：HTTP 处理器经总线分发，总线中间件（校验、事务、授权、日志）对每个命令与查询生效。
+	// 处理器签名在编译期检查，重复注册返回错误。
+	fx.Invoke(func(cmdBus *cqrs.InMemoryCommandBus, queryBus *cqrs.InMemoryQueryBus,
+		createHandler *CreateOrderHandler,
+		updateHandler *UpdateOrderHandler,
+		deleteHandler *DeleteOrderHandler,
+		getHandler *GetOrderHandler,
+		listHandler *ListOrdersHandler) error {
+		return errors.Join(
+			cqrs.RegisterCommand(cmdBus, createHandler.Handle),
+			cqrs.RegisterCommand(cmdBus, updateHandler.Handle),
+			cqrs.RegisterVoidCommand(cmdBus, deleteHandler.Handle),
+			cqrs.RegisterQuery(queryBus, getHandler.Handle),
+			cqrs.RegisterQuery(queryBus, listHandler.Handle),
+		)
+	}),
 )
 
 // RegisterMigration 注册 Order 表的数据库迁移。
 func RegisterMigration(db *gorm.DB) error {
-	return persistence.MigrateOrder(db)
+	if err := persistence.MigrateOrder(db); err != nil {
+		return err
+	}
+	return MigrateUserOrderSummary(db)
 }
@@ -0,0 +1,195 @@
+package orderapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/soliton-go/application/internal/domain/order"
+)
+
+// UserOrderSummary 是按用户汇总订单的读模型，由 UserOrderSummaryProjection 维护。
+type UserOrderSummary struct {
+	UserId         string    `gorm:"primaryKey;size:255" json:"user_id"`
+	OrderCount     int64     `gorm:"not null;default:0" json:"order_count"`
+	CancelledCount int64     `gorm:"not null;default:0" json:"cancelled_count"`
+	TotalAmount    int64     `gorm:"not null;default:0" json:"total_amount"` // 未取消、未退货订单的实付金额合计
+	LastOrderAt    time.Time `json:"last_order_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 返回 GORM 映射的数据库表名。
+func (UserOrderSummary) TableName() string {
+	return "user_order_summaries"
+}
+
+// userOrderSummaryEntry 记录投影看到的每个订单的最新状态，汇总由它重新计算，
+// 因此订单改换用户、修改金额或状态时，汇总保持正确。
+type userOrderSummaryEntry struct {
+	OrderId     string    `gorm:"primaryKey;size:255"`
+	UserId      string    `gorm:"size:255;index"`
+	FinalAmount int64     `gorm:"not null;default:0"`
+	OrderStatus string    `gorm:"size:50"`
+	OrderedAt   time.Time
+}
+
+// TableName 返回 GORM 映射的数据库表名。
+func (userOrderSummaryEntry) TableName() string {
+	return "user_order_summary_entries"
+}
+
+// MigrateUserOrderSummary 创建用户订单汇总读模型的表。
+func MigrateUserOrderSummary(db *gorm.DB) error {
+	return orm.Primary(db).AutoMigrate(&UserOrderSummary{}, &userOrderSummaryEntry{})
+}
+
+// UserOrderSummaryProjection 根据订单事件维护 UserOrderSummary。
+type UserOrderSummaryProjection struct {
+	db *gorm.DB
+}
+
+// NewUserOrderSummaryProjection 创建 UserOrderSummaryProjection 实例。
+func NewUserOrderSummaryProjection(db *gorm.DB) *UserOrderSummaryProjection {
+	return &UserOrderSummaryProjection{db: db}
+}
+
+// Name 返回投影名称（检查点名称）。
+func (p *UserOrderSummaryProjection) Name() string {
+	return "order.user_order_summary"
+}
+
+// Topics 返回投影消费的事件主题。
+func (p *UserOrderSummaryProjection) Topics() []string {
+	return []string{"order.*"}
+}
+
+// Apply 根据一个订单事件更新读模型。ctx 携带推进检查点的事务。
+func (p *UserOrderSummaryProjection) Apply(ctx context.Context, e ddd.DomainEvent) error {
+	switch evt := e.(type) {
+	case *order.OrderCreatedEvent:
+		return p.upsert(ctx, evt.OrderID, evt.UserId, evt.FinalAmount, evt.OrderStatus, ddd.MetadataOf(e).OccurredAt)
+	case *order.OrderUpdatedEvent:
+		return p.upsert(ctx, evt.OrderID, evt.UserId, evt.FinalAmount, evt.OrderStatus, time.Time{})
+	case *order.OrderDeletedEvent:
+		return p.remove(ctx, evt.OrderID)
+	}
+	return nil
+}
+
+// Reset 清空读模型，用于重建。
+func (p *UserOrderSummaryProjection) Reset(ctx context.Context) error {
+	db := orm.Conn(ctx, p.db).Session(&gorm.Session{AllowGlobalUpdate: true})
+	if err := db.Delete(&userOrderSummaryEntry{}).Error; err != nil {
+		return err
+	}
+	return db.Delete(&UserOrderSummary{}).Error
+}
+
+func (p *UserOrderSummaryProjection) upsert(ctx context.Context, orderId, userId string, finalAmount int64, status order.OrderOrderStatus, orderedAt time.Time) error {
+	db := orm.Conn(ctx, p.db)
+	var previous userOrderSummaryEntry
+	if err := db.Where("order_id = ?", orderId).Limit(1).Find(&previous).Error; err != nil {
+		return err
+	}
+	if orderedAt.IsZero() {
+		orderedAt = previous.OrderedAt
+	}
+	entry := userOrderSummaryEntry{
+		OrderId:     orderId,
+		UserId:      userId,
+		FinalAmount: finalAmount,
+		OrderStatus: string(status),
+		OrderedAt:   orderedAt,
+	}
+	if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&entry).Error; err != nil {
+		return err
+	}
+	if previous.UserId != "" && previous.UserId != userId {
+		if err := p.summarize(ctx, previous.UserId); err != nil {
+			return err
+		}
+	}
+	return p.summarize(ctx, userId)
+}
+
+func (p *UserOrderSummaryProjection) remove(ctx context.Context, orderId string) error {
+	db := orm.Conn(ctx, p.db)
+	var entry userOrderSummaryEntry
+	if err := db.Where("order_id = ?", orderId).Limit(1).Find(&entry).Error; err != nil || entry.OrderId == "" {
+		return err
+	}
+	if err := db.Delete(&entry).Error; err != nil {
+		return err
+	}
+	return p.summarize(ctx, entry.UserId)
+}
+
+// summarize 根据订单条目重新计算一个用户的汇总，用户没有订单时删除汇总。
+func (p *UserOrderSummaryProjection) summarize(ctx context.Context, userId string) error {
+	db := orm.Conn(ctx, p.db)
+	cancelled := []string{string(order.OrderOrderStatusCancelled), string(order.OrderOrderStatusReturned)}
+	var totals struct {
+		OrderCount     int64
+		CancelledCount int64
+		TotalAmount    int64
+	}
+	err := db.Model(&userOrderSummaryEntry{}).
+		Select("COUNT(*) AS order_count, "+
+			"COALESCE(SUM(CASE WHEN order_status IN ? THEN 1 ELSE 0 END), 0) AS cancelled_count, "+
+			"COALESCE(SUM(CASE WHEN order_status IN ? THEN 0 ELSE final_amount END), 0) AS total_amount", cancelled, cancelled).
+		Where("user_id = ?", userId).
+		Scan(&totals).Error
+	if err != nil {
+		return err
+	}
+	if totals.OrderCount == 0 {
+		return db.Where("user_id = ?", userId).Delete(&UserOrderSummary{}).Error
+	}
+	var last userOrderSummaryEntry
+	if err := db.Where("user_id = ?", userId).Order("ordered_at DESC").Limit(1).Find(&last).Error; err != nil {
+		return err
+	}
+	summary := UserOrderSummary{
+		UserId:         userId,
+		OrderCount:     totals.OrderCount,
+		CancelledCount: totals.CancelledCount,
+		TotalAmount:    totals.TotalAmount,
+		LastOrderAt:    last.OrderedAt,
+	}
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&summary).Error
+}
+
+// GetUserOrderSummaryQuery 是获取用户订单汇总的查询，读取投影维护的读模型而不是订单表。
+type GetUserOrderSummaryQuery struct {
+	UserId string
+}
+
+// ErrUserOrderSummaryNotFound 表示用户没有订单汇总。
+var ErrUserOrderSummaryNotFound = errors.New("user order summary not found")
+
+// GetUserOrderSummaryHandler 处理 GetUserOrderSummaryQuery。
+type GetUserOrderSummaryHandler struct {
+	db *gorm.DB
+}
+
+func NewGetUserOrderSummaryHandler(db *gorm.DB) *GetUserOrderSummaryHandler {
+	return &GetUserOrderSummaryHandler{db: db}
+}
+
+func (h *GetUserOrderSummaryHandler) Handle(ctx context.Context, query GetUserOrderSummaryQuery) (*UserOrderSummary, error) {
+	var summary UserOrderSummary
+	err := orm.Conn(ctx, h.db).Where("user_id = ?", query.UserId).First(&summary).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrUserOrderSummaryNotFound, query.UserId)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
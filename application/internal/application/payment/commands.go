@@ -2,8 +2,10 @@ package paymentapp
 
 import (
 	"context"
+	"errors"
 	"time"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 
 	"github.com/soliton-go/application/internal/domain/payment"
 )
@@ -29,8 +31,6 @@ type CreatePaymentCommand struct {
 type CreatePaymentHandler struct {
 	repo payment.PaymentRepository
 	service *payment.PaymentDomainService
-	// 可选：添加事件总线用于发布领域事件
-	// eventBus event.EventBus
 }
 
 func NewCreatePaymentHandler(repo payment.PaymentRepository, service *payment.PaymentDomainService) *CreatePaymentHandler {
@@ -39,19 +39,11 @@ func NewCreatePaymentHandler(repo payment.PaymentRepository, service *payment.Pa
 
 func (h *CreatePaymentHandler) Handle(ctx context.Context, cmd CreatePaymentCommand) (*payment.Payment, error) {
 	entity := payment.NewPayment(cmd.ID, cmd.OrderId, cmd.UserId, cmd.Amount, cmd.Currency, cmd.Method, cmd.Status, cmd.Provider, cmd.ProviderTxnId, cmd.PaidAt, cmd.RefundedAt, cmd.FailureReason, cmd.Metadata)
+	// 保存成功后，仓储会自动发布实体产生的领域事件（见 event.PublishingRepository）
 	if err := h.repo.Save(ctx, entity); err != nil {
 		return nil, err
 	}
 
-	// 可选：发布领域事件
-	// 取消注释以启用事件发布：
-	// events := entity.PullDomainEvents()
-	// if len(events) > 0 {
-	//     if err := h.eventBus.Publish(ctx, events...); err != nil {
-	//         return nil, err
-	//     }
-	// }
-
 	return entity, nil
 }
 
@@ -109,6 +101,16 @@ func NewDeletePaymentHandler(repo payment.PaymentRepository, service *payment.Pa
 	return &DeletePaymentHandler{repo: repo, service: service}
 }
 
+// Handle 加载 Payment 并记录删除事件后删除，使投影等订阅者收到 PaymentDeletedEvent；
+// Payment 不存在时视为已删除。
 func (h *DeletePaymentHandler) Handle(ctx context.Context, cmd DeletePaymentCommand) error {
-	return h.repo.Delete(ctx, payment.PaymentID(cmd.ID))
+	entity, err := h.repo.Find(ctx, payment.PaymentID(cmd.ID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	entity.MarkDeleted()
+	return h.repo.Remove(ctx, entity)
 }
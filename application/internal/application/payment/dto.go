@@ -54,6 +54,7 @@ type PaymentResponse struct {
 	RefundedAt *time.Time `json:"refunded_at"`
 	FailureReason string `json:"failure_reason"`
 	Metadata datatypes.JSON `json:"metadata"`
+	Version   int64     `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -74,6 +75,7 @@ func ToPaymentResponse(e *payment.Payment) PaymentResponse {
 		RefundedAt: e.RefundedAt,
 		FailureReason: e.FailureReason,
 		Metadata: e.Metadata,
+		Version:   e.Version,
 		CreatedAt: e.CreatedAt,
 		UpdatedAt: e.UpdatedAt,
 	}
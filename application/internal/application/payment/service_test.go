@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 
+	"github.com/soliton-go/framework/orm"
+
 	"github.com/soliton-go/application/internal/domain/payment"
 )
 
@@ -41,11 +43,20 @@ func (r *paymentRepoStub) Delete(ctx context.Context, id payment.PaymentID) erro
 	return nil
 }
 
-func (r *paymentRepoStub) FindPaginated(ctx context.Context, page, pageSize int, sortBy, sortOrder string) ([]*payment.Payment, int64, error) {
+func (r *paymentRepoStub) Remove(ctx context.Context, entity *payment.Payment) error {
+	return r.Delete(ctx, entity.ID)
+}
+
+func (r *paymentRepoStub) FindByCriteria(ctx context.Context, criteria orm.Criteria) ([]*payment.Payment, int64, error) {
 	items, _ := r.FindAll(ctx)
 	return items, int64(len(items)), nil
 }
 
+func (r *paymentRepoStub) FindByCursor(ctx context.Context, criteria orm.Criteria) (orm.CursorPage[*payment.Payment], error) {
+	items, _ := r.FindAll(ctx)
+	return orm.CursorPage[*payment.Payment]{Items: items}, nil
+}
+
 func TestPaymentServiceAuthorizeAndCapture(t *testing.T) {
 	repo := newPaymentRepoStub()
 	service := NewPaymentService(repo)
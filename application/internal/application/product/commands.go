@@ -2,7 +2,9 @@ package productapp
 
 import (
 	"context"
+	"errors"
 	"time"
+	"gorm.io/gorm"
 
 	"github.com/soliton-go/application/internal/domain/product"
 )
@@ -59,8 +61,6 @@ type CreateProductCommand struct {
 type CreateProductHandler struct {
 	repo product.ProductRepository
 	service *product.ProductDomainService
-	// 可选：添加事件总线用于发布领域事件
-	// eventBus event.EventBus
 }
 
 func NewCreateProductHandler(repo product.ProductRepository, service *product.ProductDomainService) *CreateProductHandler {
@@ -69,19 +69,11 @@ func NewCreateProductHandler(repo product.ProductRepository, service *product.Pr
 
 func (h *CreateProductHandler) Handle(ctx context.Context, cmd CreateProductCommand) (*product.Product, error) {
 	entity := product.NewProduct(cmd.ID, cmd.Sku, cmd.Name, cmd.Slug, cmd.Description, cmd.ShortDescription, cmd.Brand, cmd.Category, cmd.Subcategory, cmd.Price, cmd.OriginalPrice, cmd.CostPrice, cmd.DiscountPercentage, cmd.Stock, cmd.ReservedStock, cmd.SoldCount, cmd.ViewCount, cmd.Rating, cmd.ReviewCount, cmd.Weight, cmd.Length, cmd.Width, cmd.Height, cmd.Color, cmd.Size, cmd.Material, cmd.Manufacturer, cmd.CountryOfOrigin, cmd.Barcode, cmd.Status, cmd.IsFeatured, cmd.IsNew, cmd.IsOnSale, cmd.IsDigital, cmd.RequiresShipping, cmd.IsTaxable, cmd.TaxRate, cmd.MinOrderQuantity, cmd.MaxOrderQuantity, cmd.Tags, cmd.Images, cmd.VideoUrl, cmd.PublishedAt, cmd.DiscontinuedAt)
+	// 保存成功后，仓储会自动发布实体产生的领域事件（见 event.PublishingRepository）
 	if err := h.repo.Save(ctx, entity); err != nil {
 		return nil, err
 	}
 
-	// 可选：发布领域事件
-	// 取消注释以启用事件发布：
-	// events := entity.PullDomainEvents()
-	// if len(events) > 0 {
-	//     if err := h.eventBus.Publish(ctx, events...); err != nil {
-	//         return nil, err
-	//     }
-	// }
-
 	return entity, nil
 }
 
@@ -170,6 +162,16 @@ func NewDeleteProductHandler(repo product.ProductRepository, service *product.Pr
 	return &DeleteProductHandler{repo: repo, service: service}
 }
 
+// Handle 加载 Product 并记录删除事件后删除，使投影等订阅者收到 ProductDeletedEvent；
+// Product 不存在时视为已删除。
 func (h *DeleteProductHandler) Handle(ctx context.Context, cmd DeleteProductCommand) error {
-	return h.repo.Delete(ctx, product.ProductID(cmd.ID))
+	entity, err := h.repo.Find(ctx, product.ProductID(cmd.ID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	entity.MarkDeleted()
+	return h.repo.Remove(ctx, entity)
 }
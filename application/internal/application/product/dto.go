@@ -146,6 +146,7 @@ type ProductResponse struct {
 	VideoUrl string `json:"video_url"`
 	PublishedAt time.Time `json:"published_at"`
 	DiscontinuedAt time.Time `json:"discontinued_at"`
+	Version   int64     `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -197,6 +198,7 @@ func ToProductResponse(e *product.Product) ProductResponse {
 		VideoUrl: e.VideoUrl,
 		PublishedAt: e.PublishedAt,
 		DiscontinuedAt: e.DiscontinuedAt,
+		Version:   e.Version,
 		CreatedAt: e.CreatedAt,
 		UpdatedAt: e.UpdatedAt,
 	}
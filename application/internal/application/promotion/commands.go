@@ -2,8 +2,10 @@ package promotionapp
 
 import (
 	"context"
+	"errors"
 	"time"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 
 	"github.com/soliton-go/application/internal/domain/promotion"
 )
@@ -32,8 +34,6 @@ type CreatePromotionCommand struct {
 type CreatePromotionHandler struct {
 	repo promotion.PromotionRepository
 	service *promotion.PromotionDomainService
-	// 可选：添加事件总线用于发布领域事件
-	// eventBus event.EventBus
 }
 
 func NewCreatePromotionHandler(repo promotion.PromotionRepository, service *promotion.PromotionDomainService) *CreatePromotionHandler {
@@ -42,19 +42,11 @@ func NewCreatePromotionHandler(repo promotion.PromotionRepository, service *prom
 
 func (h *CreatePromotionHandler) Handle(ctx context.Context, cmd CreatePromotionCommand) (*promotion.Promotion, error) {
 	entity := promotion.NewPromotion(cmd.ID, cmd.Code, cmd.Name, cmd.Description, cmd.DiscountType, cmd.DiscountValue, cmd.Currency, cmd.MinOrderAmount, cmd.MaxDiscountAmount, cmd.UsageLimit, cmd.UsedCount, cmd.PerUserLimit, cmd.StartsAt, cmd.EndsAt, cmd.Status, cmd.Metadata)
+	// 保存成功后，仓储会自动发布实体产生的领域事件（见 event.PublishingRepository）
 	if err := h.repo.Save(ctx, entity); err != nil {
 		return nil, err
 	}
 
-	// 可选：发布领域事件
-	// 取消注释以启用事件发布：
-	// events := entity.PullDomainEvents()
-	// if len(events) > 0 {
-	//     if err := h.eventBus.Publish(ctx, events...); err != nil {
-	//         return nil, err
-	//     }
-	// }
-
 	return entity, nil
 }
 
@@ -115,6 +107,16 @@ func NewDeletePromotionHandler(repo promotion.PromotionRepository, service *prom
 	return &DeletePromotionHandler{repo: repo, service: service}
 }
 
+// Handle 加载 Promotion 并记录删除事件后删除，使投影等订阅者收到 PromotionDeletedEvent；
+// Promotion 不存在时视为已删除。
 func (h *DeletePromotionHandler) Handle(ctx context.Context, cmd DeletePromotionCommand) error {
-	return h.repo.Delete(ctx, promotion.PromotionID(cmd.ID))
+	entity, err := h.repo.Find(ctx, promotion.PromotionID(cmd.ID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	entity.MarkDeleted()
+	return h.repo.Remove(ctx, entity)
 }
@@ -63,6 +63,7 @@ type PromotionResponse struct {
 	EndsAt *time.Time `json:"ends_at"`
 	Status string `json:"status"`
 	Metadata datatypes.JSON `json:"metadata"`
+	Version   int64     `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -86,6 +87,7 @@ func ToPromotionResponse(e *promotion.Promotion) PromotionResponse {
 		EndsAt: e.EndsAt,
 		Status: string(e.Status),
 		Metadata: e.Metadata,
+		Version:   e.Version,
 		CreatedAt: e.CreatedAt,
 		UpdatedAt: e.UpdatedAt,
 	}
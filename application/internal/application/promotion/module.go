@@ -1,6 +1,10 @@
 package promotionapp
 
 import (
+	"errors"
+
+	"github.com/soliton-go/framework/cqrs"
+	"github.com/soliton-go/framework/event"
 	"go.uber.org/fx"
 
 	"github.com/soliton-go/application/internal/domain/promotion"
@@ -11,8 +15,8 @@ import (
 // Module 提供 Promotion 的所有 Fx 依赖。
 var Module = fx.Options(
 	// Repository
-	fx.Provide(func(db *gorm.DB) promotion.PromotionRepository {
-		return persistence.NewPromotionRepository(db)
+	fx.Provide(func(db *gorm.DB, publisher event.Publisher) promotion.PromotionRepository {
+		return persistence.NewPromotionRepository(db, publisher)
 	}),
 
 	// Domain Services
@@ -31,20 +35,22 @@ var Module = fx.Options(
 	// soliton-gen:services
 	// soliton-gen:event-handlers
 
-	// 可选：注册到 CQRS 总线
-	// 取消注释以启用 CQRS 模式：
-	// fx.Invoke(func(cmdBus *cqrs.InMemoryCommandBus, queryBus *cqrs.InMemoryQueryBus,
-	//     createHandler *CreatePromotionHandler,
-	//     updateHandler *UpdatePromotionHandler,
-	//     deleteHandler *DeletePromotionHandler,
-	//     getHandler *GetPromotionHandler,
-	//     listHandler *ListPromotionsHandler) {
-	//     cmdBus.Register(CreatePromotionCommand{}, createHandler.Handle)
-	//     cmdBus.Register(UpdatePromotionCommand{}, updateHandler.Handle)
-	//     cmdBus.Register(DeletePromotionCommand{}, deleteHandler.Handle)
-	//     queryBus.Register(GetPromotionQuery{}, getHandler.Handle)
-	//     queryBus.Register(ListPromotionsQuery{}, listHandler.Handle)
-	// }),
+	// 注册到 CQRS 总线：HTTP 处理器经总线分发，总线中间件（校验、事务、授权、日志）对每个命令与查询生效。
+	// 处理器签名在编译期检查，重复注册返回错误。
+	fx.Invoke(func(cmdBus *cqrs.InMemoryCommandBus, queryBus *cqrs.InMemoryQueryBus,
+		createHandler *CreatePromotionHandler,
+		updateHandler *UpdatePromotionHandler,
+		deleteHandler *DeletePromotionHandler,
+		getHandler *GetPromotionHandler,
+		listHandler *ListPromotionsHandler) error {
+		return errors.Join(
+			cqrs.RegisterCommand(cmdBus, createHandler.Handle),
+			cqrs.RegisterCommand(cmdBus, updateHandler.Handle),
+			cqrs.RegisterVoidCommand(cmdBus, deleteHandler.Handle),
+			cqrs.RegisterQuery(queryBus, getHandler.Handle),
+			cqrs.RegisterQuery(queryBus, listHandler.Handle),
+		)
+	}),
 )
 
 // RegisterMigration 注册 Promotion 表的数据库迁移。
@@ -2,7 +2,9 @@ package reviewapp
 
 import (
 	"context"
+	"errors"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 
 	"github.com/soliton-go/application/internal/domain/review"
 )
@@ -27,8 +29,6 @@ type CreateReviewCommand struct {
 type CreateReviewHandler struct {
 	repo review.ReviewRepository
 	service *review.ReviewDomainService
-	// 可选：添加事件总线用于发布领域事件
-	// eventBus event.EventBus
 }
 
 func NewCreateReviewHandler(repo review.ReviewRepository, service *review.ReviewDomainService) *CreateReviewHandler {
@@ -37,19 +37,11 @@ func NewCreateReviewHandler(repo review.ReviewRepository, service *review.Review
 
 func (h *CreateReviewHandler) Handle(ctx context.Context, cmd CreateReviewCommand) (*review.Review, error) {
 	entity := review.NewReview(cmd.ID, cmd.ProductId, cmd.UserId, cmd.OrderId, cmd.Rating, cmd.Title, cmd.Content, cmd.Status, cmd.IsAnonymous, cmd.HelpfulCount, cmd.Reply, cmd.Images)
+	// 保存成功后，仓储会自动发布实体产生的领域事件（见 event.PublishingRepository）
 	if err := h.repo.Save(ctx, entity); err != nil {
 		return nil, err
 	}
 
-	// 可选：发布领域事件
-	// 取消注释以启用事件发布：
-	// events := entity.PullDomainEvents()
-	// if len(events) > 0 {
-	//     if err := h.eventBus.Publish(ctx, events...); err != nil {
-	//         return nil, err
-	//     }
-	// }
-
 	return entity, nil
 }
 
@@ -106,6 +98,16 @@ func NewDeleteReviewHandler(repo review.ReviewRepository, service *review.Review
 	return &DeleteReviewHandler{repo: repo, service: service}
 }
 
+// Handle 加载 Review 并记录删除事件后删除，使投影等订阅者收到 ReviewDeletedEvent；
+// Review 不存在时视为已删除。
 func (h *DeleteReviewHandler) Handle(ctx context.Context, cmd DeleteReviewCommand) error {
-	return h.repo.Delete(ctx, review.ReviewID(cmd.ID))
+	entity, err := h.repo.Find(ctx, review.ReviewID(cmd.ID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	entity.MarkDeleted()
+	return h.repo.Remove(ctx, entity)
 }
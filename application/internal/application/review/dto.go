@@ -51,6 +51,7 @@ type ReviewResponse struct {
 	HelpfulCount int `json:"helpful_count"`
 	Reply string `json:"reply"`
 	Images datatypes.JSON `json:"images"`
+	Version   int64     `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -70,6 +71,7 @@ func ToReviewResponse(e *review.Review) ReviewResponse {
 		HelpfulCount: e.HelpfulCount,
 		Reply: e.Reply,
 		Images: e.Images,
+		Version:   e.Version,
 		CreatedAt: e.CreatedAt,
 		UpdatedAt: e.UpdatedAt,
 	}
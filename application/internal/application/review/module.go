@@ -1,6 +1,11 @@
 package reviewapp
 
 import (
+	"errors"
+
+	"github.com/soliton-go/framework/cqrs"
+	"github.com/soliton-go/framework/event"
+	"github.com/soliton-go/framework/projection"
 	"go.uber.org/fx"
 
 	"github.com/soliton-go/application/internal/domain/review"
@@ -11,8 +16,8 @@ import (
 // Module 提供 Review 的所有 Fx 依赖。
 var Module = fx.Options(
 	// Repository
-	fx.Provide(func(db *gorm.DB) review.ReviewRepository {
-		return persistence.NewReviewRepository(db)
+	fx.Provide(func(db *gorm.DB, publisher event.Publisher) review.ReviewRepository {
+		return persistence.NewReviewRepository(db, publisher)
 	}),
 
 	// Domain Services
@@ -26,28 +31,45 @@ var Module = fx.Options(
 	// Query Handlers
 	fx.Provide(NewGetReviewHandler),
 	fx.Provide(NewListReviewsHandler),
+
+	// Projections：商品评分读模型由评价事件维护，查询直接读取读模型
+	fx.Provide(NewProductRatingProjection),
+	fx.Provide(NewGetProductRatingHandler),
+	fx.Invoke(func(projector *projection.Projector, queryBus *cqrs.InMemoryQueryBus,
+		rating *ProductRatingProjection,
+		ratingHandler *GetProductRatingHandler) error {
+		return errors.Join(
+			projector.Register(rating),
+			cqrs.RegisterQuery(queryBus, ratingHandler.Handle),
+		)
+	}),
 	
 	fx.Provide(NewReviewService),
 	// soliton-gen:services
 	// soliton-gen:event-handlers
 
-	// 可选：注册到 CQRS 总线
-	// 取消注释以启用 CQRS 模式：
-	// fx.Invoke(func(cmdBus *cqrs.InMemoryCommandBus, queryBus *cqrs.InMemoryQueryBus,
-	//     createHandler *CreateReviewHandler,
-	//     updateHandler *UpdateReviewHandler,
-	//     deleteHandler *DeleteReviewHandler,
-	//     getHandler *GetReviewHandler,
-	//     listHandler *ListReviewsHandler) {
-	//     cmdBus.Register(CreateReviewCommand{}, createHandler.Handle)
-	//     cmdBus.Register(UpdateReviewCommand{}, updateHandler.Handle)
-	//     cmdBus.Register(DeleteReviewCommand{}, deleteHandler.Handle)
-	//     queryBus.Register(GetReviewQuery{}, getHandler.Handle)
-	//     queryBus.Register(ListReviewsQuery{}, listHandler.Handle)
-	// }),
+	// 注册到 CQRS 总线：HTTP 处理器经总线分发，总线中间件（校验、事务、授权、日志）对每个命令与查询生效。
+	// 处理器签名在编译期检查，重复注册返回错误。
+	fx.Invoke(func(cmdBus *cqrs.InMemoryCommandBus, queryBus *cqrs.InMemoryQueryBus,
+		createHandler *CreateReviewHandler,
+		updateHandler *UpdateReviewHandler,
+		deleteHandler *DeleteReviewHandler,
+		getHandler *GetReviewHandler,
+		listHandler *ListReviewsHandler) error {
+		return errors.Join(
+			cqrs.RegisterCommand(cmdBus, createHandler.Handle),
+			cqrs.RegisterCommand(cmdBus, updateHandler.Handle),
+			cqrs.RegisterVoidCommand(cmdBus, deleteHandler.Handle),
+			cqrs.RegisterQuery(queryBus, getHandler.Handle),
+			cqrs.RegisterQuery(queryBus, listHandler.Handle),
+		)
+	}),
 )
 
 // RegisterMigration 注册 Review 表的数据库迁移。
 func RegisterMigration(db *gorm.DB) error {
-	return persistence.MigrateReview(db)
+	if err := persistence.MigrateReview(db); err != nil {
+		return err
+	}
+	return MigrateProductRating(db)
 }
@@ -0,0 +1,174 @@
+package reviewapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/soliton-go/application/internal/domain/review"
+)
+
+// ProductRating 是按商品汇总评分的读模型，只统计审核通过的评价，由 ProductRatingProjection 维护。
+type ProductRating struct {
+	ProductId     string    `gorm:"primaryKey;size:255" json:"product_id"`
+	ReviewCount   int64     `gorm:"not null;default:0" json:"review_count"`
+	RatingTotal   int64     `gorm:"not null;default:0" json:"rating_total"`
+	AverageRating float64   `gorm:"not null;default:0" json:"average_rating"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 返回 GORM 映射的数据库表名。
+func (ProductRating) TableName() string {
+	return "product_ratings"
+}
+
+// productRatingEntry 记录投影看到的每条评价的最新评分和状态，商品评分由它重新计算。
+type productRatingEntry struct {
+	ReviewId  string `gorm:"primaryKey;size:255"`
+	ProductId string `gorm:"size:255;index"`
+	Rating    int    `gorm:"not null;default:0"`
+	Status    string `gorm:"size:50"`
+}
+
+// TableName 返回 GORM 映射的数据库表名。
+func (productRatingEntry) TableName() string {
+	return "product_rating_entries"
+}
+
+// MigrateProductRating 创建商品评分读模型的表。
+func MigrateProductRating(db *gorm.DB) error {
+	return orm.Primary(db).AutoMigrate(&ProductRating{}, &productRatingEntry{})
+}
+
+// ProductRatingProjection 根据评价事件维护 ProductRating。
+type ProductRatingProjection struct {
+	db *gorm.DB
+}
+
+// NewProductRatingProjection 创建 ProductRatingProjection 实例。
+func NewProductRatingProjection(db *gorm.DB) *ProductRatingProjection {
+	return &ProductRatingProjection{db: db}
+}
+
+// Name 返回投影名称（检查点名称）。
+func (p *ProductRatingProjection) Name() string {
+	return "review.product_rating"
+}
+
+// Topics 返回投影消费的事件主题。
+func (p *ProductRatingProjection) Topics() []string {
+	return []string{"review.*"}
+}
+
+// Apply 根据一个评价事件更新读模型。ctx 携带推进检查点的事务。
+func (p *ProductRatingProjection) Apply(ctx context.Context, e ddd.DomainEvent) error {
+	switch evt := e.(type) {
+	case *review.ReviewCreatedEvent:
+		return p.upsert(ctx, evt.ReviewID, evt.ProductId, evt.Rating, evt.Status)
+	case *review.ReviewUpdatedEvent:
+		return p.upsert(ctx, evt.ReviewID, evt.ProductId, evt.Rating, evt.Status)
+	case *review.ReviewDeletedEvent:
+		return p.remove(ctx, evt.ReviewID)
+	}
+	return nil
+}
+
+// Reset 清空读模型，用于重建。
+func (p *ProductRatingProjection) Reset(ctx context.Context) error {
+	db := orm.Conn(ctx, p.db).Session(&gorm.Session{AllowGlobalUpdate: true})
+	if err := db.Delete(&productRatingEntry{}).Error; err != nil {
+		return err
+	}
+	return db.Delete(&ProductRating{}).Error
+}
+
+func (p *ProductRatingProjection) upsert(ctx context.Context, reviewId, productId string, rating int, status review.ReviewStatus) error {
+	db := orm.Conn(ctx, p.db)
+	var previous productRatingEntry
+	if err := db.Where("review_id = ?", reviewId).Limit(1).Find(&previous).Error; err != nil {
+		return err
+	}
+	entry := productRatingEntry{ReviewId: reviewId, ProductId: productId, Rating: rating, Status: string(status)}
+	if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&entry).Error; err != nil {
+		return err
+	}
+	if previous.ProductId != "" && previous.ProductId != productId {
+		if err := p.summarize(ctx, previous.ProductId); err != nil {
+			return err
+		}
+	}
+	return p.summarize(ctx, productId)
+}
+
+func (p *ProductRatingProjection) remove(ctx context.Context, reviewId string) error {
+	db := orm.Conn(ctx, p.db)
+	var entry productRatingEntry
+	if err := db.Where("review_id = ?", reviewId).Limit(1).Find(&entry).Error; err != nil || entry.ReviewId == "" {
+		return err
+	}
+	if err := db.Delete(&entry).Error; err != nil {
+		return err
+	}
+	return p.summarize(ctx, entry.ProductId)
+}
+
+// summarize 根据评价条目重新计算一个商品的评分，商品没有审核通过的评价时删除评分。
+func (p *ProductRatingProjection) summarize(ctx context.Context, productId string) error {
+	db := orm.Conn(ctx, p.db)
+	var totals struct {
+		ReviewCount int64
+		RatingTotal int64
+	}
+	err := db.Model(&productRatingEntry{}).
+		Select("COUNT(*) AS review_count, COALESCE(SUM(rating), 0) AS rating_total").
+		Where("product_id = ? AND status = ?", productId, string(review.ReviewStatusApproved)).
+		Scan(&totals).Error
+	if err != nil {
+		return err
+	}
+	if totals.ReviewCount == 0 {
+		return db.Where("product_id = ?", productId).Delete(&ProductRating{}).Error
+	}
+	rating := ProductRating{
+		ProductId:     productId,
+		ReviewCount:   totals.ReviewCount,
+		RatingTotal:   totals.RatingTotal,
+		AverageRating: float64(totals.RatingTotal) / float64(totals.ReviewCount),
+	}
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&rating).Error
+}
+
+// GetProductRatingQuery 是获取商品评分的查询，读取投影维护的读模型而不是评价表。
+type GetProductRatingQuery struct {
+	ProductId string
+}
+
+// ErrProductRatingNotFound 表示商品还没有审核通过的评价。
+var ErrProductRatingNotFound = errors.New("product rating not found")
+
+// GetProductRatingHandler 处理 GetProductRatingQuery。
+type GetProductRatingHandler struct {
+	db *gorm.DB
+}
+
+func NewGetProductRatingHandler(db *gorm.DB) *GetProductRatingHandler {
+	return &GetProductRatingHandler{db: db}
+}
+
+func (h *GetProductRatingHandler) Handle(ctx context.Context, query GetProductRatingQuery) (*ProductRating, error) {
+	var rating ProductRating
+	err := orm.Conn(ctx, h.db).Where("product_id = ?", query.ProductId).First(&rating).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrProductRatingNotFound, query.ProductId)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rating, nil
+}
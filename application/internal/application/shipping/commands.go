@@ -2,7 +2,9 @@ package shippingapp
 
 import (
 	"context"
+	"errors"
 	"time"
+	"gorm.io/gorm"
 
 	"github.com/soliton-go/application/internal/domain/shipping"
 )
@@ -31,8 +33,6 @@ type CreateShippingCommand struct {
 type CreateShippingHandler struct {
 	repo shipping.ShippingRepository
 	service *shipping.ShippingDomainService
-	// 可选：添加事件总线用于发布领域事件
-	// eventBus event.EventBus
 }
 
 func NewCreateShippingHandler(repo shipping.ShippingRepository, service *shipping.ShippingDomainService) *CreateShippingHandler {
@@ -41,19 +41,11 @@ func NewCreateShippingHandler(repo shipping.ShippingRepository, service *shippin
 
 func (h *CreateShippingHandler) Handle(ctx context.Context, cmd CreateShippingCommand) (*shipping.Shipping, error) {
 	entity := shipping.NewShipping(cmd.ID, cmd.OrderId, cmd.Carrier, cmd.ShippingMethod, cmd.TrackingNumber, cmd.Status, cmd.ShippedAt, cmd.DeliveredAt, cmd.ReceiverName, cmd.ReceiverPhone, cmd.ReceiverAddress, cmd.ReceiverCity, cmd.ReceiverState, cmd.ReceiverCountry, cmd.ReceiverPostalCode, cmd.Notes)
+	// 保存成功后，仓储会自动发布实体产生的领域事件（见 event.PublishingRepository）
 	if err := h.repo.Save(ctx, entity); err != nil {
 		return nil, err
 	}
 
-	// 可选：发布领域事件
-	// 取消注释以启用事件发布：
-	// events := entity.PullDomainEvents()
-	// if len(events) > 0 {
-	//     if err := h.eventBus.Publish(ctx, events...); err != nil {
-	//         return nil, err
-	//     }
-	// }
-
 	return entity, nil
 }
 
@@ -114,6 +106,16 @@ func NewDeleteShippingHandler(repo shipping.ShippingRepository, service *shippin
 	return &DeleteShippingHandler{repo: repo, service: service}
 }
 
+// Handle 加载 Shipping 并记录删除事件后删除，使投影等订阅者收到 ShippingDeletedEvent；
+// Shipping 不存在时视为已删除。
 func (h *DeleteShippingHandler) Handle(ctx context.Context, cmd DeleteShippingCommand) error {
-	return h.repo.Delete(ctx, shipping.ShippingID(cmd.ID))
+	entity, err := h.repo.Find(ctx, shipping.ShippingID(cmd.ID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	entity.MarkDeleted()
+	return h.repo.Remove(ctx, entity)
 }
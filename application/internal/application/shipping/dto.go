@@ -62,6 +62,7 @@ type ShippingResponse struct {
 	ReceiverCountry string `json:"receiver_country"`
 	ReceiverPostalCode string `json:"receiver_postal_code"`
 	Notes string `json:"notes"`
+	Version   int64     `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -85,6 +86,7 @@ func ToShippingResponse(e *shipping.Shipping) ShippingResponse {
 		ReceiverCountry: e.ReceiverCountry,
 		ReceiverPostalCode: e.ReceiverPostalCode,
 		Notes: e.Notes,
+		Version:   e.Version,
 		CreatedAt: e.CreatedAt,
 		UpdatedAt: e.UpdatedAt,
 	}
@@ -2,6 +2,8 @@ package userapp
 
 import (
 	"context"
+	"errors"
+	"gorm.io/gorm"
 
 	"github.com/soliton-go/application/internal/domain/user"
 )
@@ -17,8 +19,6 @@ type CreateUserCommand struct {
 type CreateUserHandler struct {
 	repo user.UserRepository
 	service *user.UserDomainService
-	// 可选：添加事件总线用于发布领域事件
-	// eventBus event.EventBus
 }
 
 func NewCreateUserHandler(repo user.UserRepository, service *user.UserDomainService) *CreateUserHandler {
@@ -27,19 +27,11 @@ func NewCreateUserHandler(repo user.UserRepository, service *user.UserDomainServ
 
 func (h *CreateUserHandler) Handle(ctx context.Context, cmd CreateUserCommand) (*user.User, error) {
 	entity := user.NewUser(cmd.ID, cmd.Username, cmd.Email)
+	// 保存成功后，仓储会自动发布实体产生的领域事件（见 event.PublishingRepository）
 	if err := h.repo.Save(ctx, entity); err != nil {
 		return nil, err
 	}
 
-	// 可选：发布领域事件
-	// 取消注释以启用事件发布：
-	// events := entity.PullDomainEvents()
-	// if len(events) > 0 {
-	//     if err := h.eventBus.Publish(ctx, events...); err != nil {
-	//         return nil, err
-	//     }
-	// }
-
 	return entity, nil
 }
 
@@ -87,6 +79,16 @@ func NewDeleteUserHandler(repo user.UserRepository, service *user.UserDomainServ
 	return &DeleteUserHandler{repo: repo, service: service}
 }
 
+// Handle 加载 User 并记录删除事件后删除，使投影等订阅者收到 UserDeletedEvent；
+// User 不存在时视为已删除。
 func (h *DeleteUserHandler) Handle(ctx context.Context, cmd DeleteUserCommand) error {
-	return h.repo.Delete(ctx, user.UserID(cmd.ID))
+	entity, err := h.repo.Find(ctx, user.UserID(cmd.ID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	entity.MarkDeleted()
+	return h.repo.Remove(ctx, entity)
 }
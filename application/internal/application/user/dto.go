@@ -23,6 +23,7 @@ type UserResponse struct {
 	ID        string    `json:"id"`
 	Username string `json:"username"`
 	Email string `json:"email"`
+	Version   int64     `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -33,6 +34,7 @@ func ToUserResponse(e *user.User) UserResponse {
 		ID:        string(e.ID),
 		Username: e.Username,
 		Email: e.Email,
+		Version:   e.Version,
 		CreatedAt: e.CreatedAt,
 		UpdatedAt: e.UpdatedAt,
 	}
@@ -30,11 +30,11 @@ func (h *UserActivatedHandler) Handle(ctx context.Context, evt ddd.DomainEvent)
 	return nil
 }
 
-// RegisterUserActivatedHandler 注册事件处理器。
+// RegisterUserActivatedHandler 注册事件处理器，处理器名用于事件重放（soliton-gen events replay --handler）。
 func RegisterUserActivatedHandler(lc fx.Lifecycle, bus event.EventBus, handler *UserActivatedHandler) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			return bus.Subscribe(ctx, "user.activated", handler.Handle)
+			return bus.Subscribe(ctx, "user.activated", handler.Handle, event.WithHandlerName("user.UserActivatedHandler"))
 		},
 		OnStop: func(ctx context.Context) error {
 			return nil
@@ -1,6 +1,10 @@
 package userapp
 
 import (
+	"errors"
+
+	"github.com/soliton-go/framework/cqrs"
+	"github.com/soliton-go/framework/event"
 	"go.uber.org/fx"
 
 	"github.com/soliton-go/application/internal/domain/user"
@@ -11,8 +15,8 @@ import (
 // Module 提供 User 的所有 Fx 依赖。
 var Module = fx.Options(
 	// Repository
-	fx.Provide(func(db *gorm.DB) user.UserRepository {
-		return persistence.NewUserRepository(db)
+	fx.Provide(func(db *gorm.DB, publisher event.Publisher) user.UserRepository {
+		return persistence.NewUserRepository(db, publisher)
 	}),
 
 	// Domain Services
@@ -30,20 +34,22 @@ var Module = fx.Options(
 	// soliton-gen:services
 	// soliton-gen:event-handlers
 
-	// 可选：注册到 CQRS 总线
-	// 取消注释以启用 CQRS 模式：
-	// fx.Invoke(func(cmdBus *cqrs.InMemoryCommandBus, queryBus *cqrs.InMemoryQueryBus,
-	//     createHandler *CreateUserHandler,
-	//     updateHandler *UpdateUserHandler,
-	//     deleteHandler *DeleteUserHandler,
-	//     getHandler *GetUserHandler,
-	//     listHandler *ListUsersHandler) {
-	//     cmdBus.Register(CreateUserCommand{}, createHandler.Handle)
-	//     cmdBus.Register(UpdateUserCommand{}, updateHandler.Handle)
-	//     cmdBus.Register(DeleteUserCommand{}, deleteHandler.Handle)
-	//     queryBus.Register(GetUserQuery{}, getHandler.Handle)
-	//     queryBus.Register(ListUsersQuery{}, listHandler.Handle)
-	// }),
+	// 注册到 CQRS 总线：HTTP 处理器经总线分发，总线中间件（校验、事务、授权、日志）对每个命令与查询生效。
+	// 处理器签名在编译期检查，重复注册返回错误。
+	fx.Invoke(func(cmdBus *cqrs.InMemoryCommandBus, queryBus *cqrs.InMemoryQueryBus,
+		createHandler *CreateUserHandler,
+		updateHandler *UpdateUserHandler,
+		deleteHandler *DeleteUserHandler,
+		getHandler *GetUserHandler,
+		listHandler *ListUsersHandler) error {
+		return errors.Join(
+			cqrs.RegisterCommand(cmdBus, createHandler.Handle),
+			cqrs.RegisterCommand(cmdBus, updateHandler.Handle),
+			cqrs.RegisterVoidCommand(cmdBus, deleteHandler.Handle),
+			cqrs.RegisterQuery(queryBus, getHandler.Handle),
+			cqrs.RegisterQuery(queryBus, listHandler.Handle),
+		)
+	}),
 )
 
 // RegisterMigration 注册 User 表的数据库迁移。
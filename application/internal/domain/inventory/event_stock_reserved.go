@@ -0,0 +1,38 @@
+package inventory
+
+import (
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/event"
+)
+
+// StockReservedEvent 在预占库存后发布。
+type StockReservedEvent struct {
+	ddd.BaseDomainEvent
+	InventoryId    string `json:"inventory_id"`
+	Quantity       int    `json:"quantity"`
+	ReservedStock  int    `json:"reserved_stock"`
+	AvailableStock int    `json:"available_stock"`
+}
+
+// EventName 返回事件名称（主题）。
+func (e StockReservedEvent) EventName() string {
+	return "inventory.stock_reserved"
+}
+
+// NewStockReservedEvent 创建一个新的事件实例。
+func NewStockReservedEvent(inventoryId string, quantity int, reservedStock int, availableStock int) StockReservedEvent {
+	return StockReservedEvent{
+		BaseDomainEvent: ddd.NewAggregateEvent("inventory", inventoryId),
+		InventoryId:     inventoryId,
+		Quantity:        quantity,
+		ReservedStock:   reservedStock,
+		AvailableStock:  availableStock,
+	}
+}
+
+// init 将事件注册到全局注册表。
+func init() {
+	event.RegisterEvent("inventory.stock_reserved", func() ddd.DomainEvent {
+		return &StockReservedEvent{}
+	})
+}
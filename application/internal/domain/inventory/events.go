@@ -19,7 +19,7 @@ func (e InventoryCreatedEvent) EventName() string {
 
 func NewInventoryCreatedEvent(id string) InventoryCreatedEvent {
 	return InventoryCreatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("inventory", id),
 		InventoryID: id,
 	}
 }
@@ -36,7 +36,7 @@ func (e InventoryUpdatedEvent) EventName() string {
 
 func NewInventoryUpdatedEvent(id string) InventoryUpdatedEvent {
 	return InventoryUpdatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("inventory", id),
 		InventoryID: id,
 	}
 }
@@ -54,7 +54,7 @@ func (e InventoryDeletedEvent) EventName() string {
 
 func NewInventoryDeletedEvent(id string) InventoryDeletedEvent {
 	return InventoryDeletedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("inventory", id),
 		InventoryID: id,
 		DeletedAt: time.Now(),
 	}
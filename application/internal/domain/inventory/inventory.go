@@ -29,21 +29,22 @@ const (
 type Inventory struct {
 	ddd.BaseAggregateRoot
 	ID InventoryID `gorm:"primaryKey"`
-	ProductId string `gorm:"size:255"` // 商品ID
-	WarehouseId string `gorm:"size:255"` // 仓库ID
+	ProductId string `gorm:"size:255;index"` // 商品ID
+	WarehouseId string `gorm:"size:255;index"` // 仓库ID
 	LocationCode string `gorm:"size:255"` // 库位编码
 	Stock int `gorm:"not null;default:0"` // 当前库存
 	ReservedStock int `gorm:"not null;default:0"` // 预占库存
 	AvailableStock int `gorm:"not null;default:0"` // 可用库存
 	SafetyStock int `gorm:"not null;default:0"` // 安全库存
 	RestockLevel int `gorm:"not null;default:0"` // 补货阈值
-	Status InventoryStatus `gorm:"size:50;default:'active'"` // 库存状态
+	Status InventoryStatus `gorm:"size:50;index;default:'active'"` // 库存状态
 	LastStockedAt *time.Time  // 最近入库时间
 	LastCheckedAt *time.Time  // 最近盘点时间
 	Notes string `gorm:"size:255"` // 备注
 	Metadata datatypes.JSON  // 扩展信息
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	Version int64 `gorm:"not null;default:0"` // 乐观锁版本号
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;index"`
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
@@ -118,7 +119,22 @@ func (e *Inventory) Update(productId *string, warehouseId *string, locationCode
 	e.AddDomainEvent(NewInventoryUpdatedEvent(string(e.ID)))
 }
 
+// MarkDeleted 记录 InventoryDeletedEvent，随后由仓储的 Remove 删除实体并发布该事件。
+func (e *Inventory) MarkDeleted() {
+	e.AddDomainEvent(NewInventoryDeletedEvent(string(e.ID)))
+}
+
 // GetID 返回实体 ID。
 func (e *Inventory) GetID() ddd.ID {
 	return e.ID
 }
+
+// GetVersion 返回乐观锁版本号。
+func (e *Inventory) GetVersion() int64 {
+	return e.Version
+}
+
+// SetVersion 设置乐观锁版本号（由仓储在保存成功后维护）。
+func (e *Inventory) SetVersion(version int64) {
+	e.Version = version
+}
@@ -10,34 +10,46 @@ import (
 // OrderCreatedEvent 在创建 Order 时发布。
 type OrderCreatedEvent struct {
 	ddd.BaseDomainEvent
-	OrderID string `json:"order_id"`
+	OrderID     string           `json:"order_id"`
+	UserId      string           `json:"user_id"`
+	FinalAmount int64            `json:"final_amount"`
+	OrderStatus OrderOrderStatus `json:"order_status"`
 }
 
 func (e OrderCreatedEvent) EventName() string {
 	return "order.created"
 }
 
-func NewOrderCreatedEvent(id string) OrderCreatedEvent {
+func NewOrderCreatedEvent(id string, userId string, finalAmount int64, orderStatus OrderOrderStatus) OrderCreatedEvent {
 	return OrderCreatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
-		OrderID: id,
+		BaseDomainEvent: ddd.NewAggregateEvent("order", id),
+		OrderID:         id,
+		UserId:          userId,
+		FinalAmount:     finalAmount,
+		OrderStatus:     orderStatus,
 	}
 }
 
 // OrderUpdatedEvent 在更新 Order 时发布。
 type OrderUpdatedEvent struct {
 	ddd.BaseDomainEvent
-	OrderID string `json:"order_id"`
+	OrderID     string           `json:"order_id"`
+	UserId      string           `json:"user_id"`
+	FinalAmount int64            `json:"final_amount"`
+	OrderStatus OrderOrderStatus `json:"order_status"`
 }
 
 func (e OrderUpdatedEvent) EventName() string {
 	return "order.updated"
 }
 
-func NewOrderUpdatedEvent(id string) OrderUpdatedEvent {
+func NewOrderUpdatedEvent(id string, userId string, finalAmount int64, orderStatus OrderOrderStatus) OrderUpdatedEvent {
 	return OrderUpdatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
-		OrderID: id,
+		BaseDomainEvent: ddd.NewAggregateEvent("order", id),
+		OrderID:         id,
+		UserId:          userId,
+		FinalAmount:     finalAmount,
+		OrderStatus:     orderStatus,
 	}
 }
 
@@ -54,7 +66,7 @@ func (e OrderDeletedEvent) EventName() string {
 
 func NewOrderDeletedEvent(id string) OrderDeletedEvent {
 	return OrderDeletedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("order", id),
 		OrderID: id,
 		DeletedAt: time.Now(),
 	}
@@ -63,7 +63,7 @@ const (
 type Order struct {
 	ddd.BaseAggregateRoot
 	ID OrderID `gorm:"primaryKey"`
-	UserId string `gorm:"size:255"`
+	UserId string `gorm:"size:255;index"`
 	OrderNo string `gorm:"size:255"`
 	TotalAmount int64 `gorm:"not null;default:0"`
 	DiscountAmount int64 `gorm:"not null;default:0"`
@@ -71,10 +71,10 @@ type Order struct {
 	ShippingFee int64 `gorm:"not null;default:0"`
 	FinalAmount int64 `gorm:"not null;default:0"`
 	Currency string `gorm:"size:255"`
-	PaymentMethod OrderPaymentMethod `gorm:"size:50;default:'credit_card'"`
-	PaymentStatus OrderPaymentStatus `gorm:"size:50;default:'pending'"`
-	OrderStatus OrderOrderStatus `gorm:"size:50;default:'pending'"`
-	ShippingMethod OrderShippingMethod `gorm:"size:50;default:'standard'"`
+	PaymentMethod OrderPaymentMethod `gorm:"size:50;index;default:'credit_card'"`
+	PaymentStatus OrderPaymentStatus `gorm:"size:50;index;default:'pending'"`
+	OrderStatus OrderOrderStatus `gorm:"size:50;index;default:'pending'"`
+	ShippingMethod OrderShippingMethod `gorm:"size:50;index;default:'standard'"`
 	TrackingNumber string `gorm:"size:255"`
 	ReceiverName string `gorm:"size:255"`
 	ReceiverPhone string `gorm:"size:255"`
@@ -95,8 +95,9 @@ type Order struct {
 	Weight float64 `gorm:"default:0"`
 	IsGift bool `gorm:"default:false"`
 	GiftMessage string `gorm:"size:255"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	Version int64 `gorm:"not null;default:0"` // 乐观锁版本号
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;index"`
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
@@ -142,7 +143,7 @@ func NewOrder(id string, userId string, orderNo string, totalAmount int64, disco
 		IsGift: isGift,
 		GiftMessage: giftMessage,
 	}
-	e.AddDomainEvent(NewOrderCreatedEvent(id))
+	e.AddDomainEvent(NewOrderCreatedEvent(id, userId, finalAmount, orderStatus))
 	return e
 }
 
@@ -244,10 +245,25 @@ func (e *Order) Update(userId *string, orderNo *string, totalAmount *int64, disc
 	if giftMessage != nil {
 		e.GiftMessage = *giftMessage
 	}
-	e.AddDomainEvent(NewOrderUpdatedEvent(string(e.ID)))
+	e.AddDomainEvent(NewOrderUpdatedEvent(string(e.ID), e.UserId, e.FinalAmount, e.OrderStatus))
+}
+
+// MarkDeleted 记录 OrderDeletedEvent，随后由仓储的 Remove 删除实体并发布该事件。
+func (e *Order) MarkDeleted() {
+	e.AddDomainEvent(NewOrderDeletedEvent(string(e.ID)))
 }
 
 // GetID 返回实体 ID。
 func (e *Order) GetID() ddd.ID {
 	return e.ID
 }
+
+// GetVersion 返回乐观锁版本号。
+func (e *Order) GetVersion() int64 {
+	return e.Version
+}
+
+// SetVersion 设置乐观锁版本号（由仓储在保存成功后维护）。
+func (e *Order) SetVersion(version int64) {
+	e.Version = version
+}
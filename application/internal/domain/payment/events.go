@@ -19,7 +19,7 @@ func (e PaymentCreatedEvent) EventName() string {
 
 func NewPaymentCreatedEvent(id string) PaymentCreatedEvent {
 	return PaymentCreatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("payment", id),
 		PaymentID: id,
 	}
 }
@@ -36,7 +36,7 @@ func (e PaymentUpdatedEvent) EventName() string {
 
 func NewPaymentUpdatedEvent(id string) PaymentUpdatedEvent {
 	return PaymentUpdatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("payment", id),
 		PaymentID: id,
 	}
 }
@@ -54,7 +54,7 @@ func (e PaymentDeletedEvent) EventName() string {
 
 func NewPaymentDeletedEvent(id string) PaymentDeletedEvent {
 	return PaymentDeletedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("payment", id),
 		PaymentID: id,
 		DeletedAt: time.Now(),
 	}
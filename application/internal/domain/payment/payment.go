@@ -45,20 +45,21 @@ const (
 type Payment struct {
 	ddd.BaseAggregateRoot
 	ID PaymentID `gorm:"primaryKey"`
-	OrderId string `gorm:"size:255"` // 订单ID
-	UserId string `gorm:"size:255"` // 用户ID
+	OrderId string `gorm:"size:255;index"` // 订单ID
+	UserId string `gorm:"size:255;index"` // 用户ID
 	Amount float64 `gorm:"default:0"` // 支付金额
 	Currency string `gorm:"size:255"` // 币种
-	Method PaymentMethod `gorm:"size:50;default:'credit_card'"` // 支付方式
-	Status PaymentStatus `gorm:"size:50;default:'pending'"` // 支付状态
+	Method PaymentMethod `gorm:"size:50;index;default:'credit_card'"` // 支付方式
+	Status PaymentStatus `gorm:"size:50;index;default:'pending'"` // 支付状态
 	Provider string `gorm:"size:255"` // 支付渠道
-	ProviderTxnId string `gorm:"size:255"` // 渠道交易号
+	ProviderTxnId string `gorm:"size:255;index"` // 渠道交易号
 	PaidAt *time.Time  // 支付完成时间
 	RefundedAt *time.Time  // 退款完成时间
 	FailureReason string `gorm:"size:255"` // 失败原因
 	Metadata datatypes.JSON  // 扩展信息
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	Version int64 `gorm:"not null;default:0"` // 乐观锁版本号
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;index"`
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
@@ -129,7 +130,22 @@ func (e *Payment) Update(orderId *string, userId *string, amount *float64, curre
 	e.AddDomainEvent(NewPaymentUpdatedEvent(string(e.ID)))
 }
 
+// MarkDeleted 记录 PaymentDeletedEvent，随后由仓储的 Remove 删除实体并发布该事件。
+func (e *Payment) MarkDeleted() {
+	e.AddDomainEvent(NewPaymentDeletedEvent(string(e.ID)))
+}
+
 // GetID 返回实体 ID。
 func (e *Payment) GetID() ddd.ID {
 	return e.ID
 }
+
+// GetVersion 返回乐观锁版本号。
+func (e *Payment) GetVersion() int64 {
+	return e.Version
+}
+
+// SetVersion 设置乐观锁版本号（由仓储在保存成功后维护）。
+func (e *Payment) SetVersion(version int64) {
+	e.Version = version
+}
@@ -9,6 +9,10 @@ import (
 // PaymentRepository 定义 Payment 的持久化接口。
 type PaymentRepository interface {
 	orm.Repository[*Payment, PaymentID]
-	// FindPaginated 返回分页数据和总数。
-	FindPaginated(ctx context.Context, page, pageSize int, sortBy, sortOrder string) ([]*Payment, int64, error)
+	// FindByCriteria 按条件、排序和分页查询，返回当前页数据和总数。
+	orm.CriteriaRepository[*Payment]
+	// FindByCursor 按游标分页查询，适合大表的连续翻页。
+	orm.CursorRepository[*Payment]
+	// Remove 删除实体并发布其记录的领域事件（如 MarkDeleted 记录的删除事件）。
+	Remove(ctx context.Context, entity *Payment) error
 }
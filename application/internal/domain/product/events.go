@@ -19,7 +19,7 @@ func (e ProductCreatedEvent) EventName() string {
 
 func NewProductCreatedEvent(id string) ProductCreatedEvent {
 	return ProductCreatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("product", id),
 		ProductID: id,
 	}
 }
@@ -36,7 +36,7 @@ func (e ProductUpdatedEvent) EventName() string {
 
 func NewProductUpdatedEvent(id string) ProductUpdatedEvent {
 	return ProductUpdatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("product", id),
 		ProductID: id,
 	}
 }
@@ -54,7 +54,7 @@ func (e ProductDeletedEvent) EventName() string {
 
 func NewProductDeletedEvent(id string) ProductDeletedEvent {
 	return ProductDeletedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("product", id),
 		ProductID: id,
 		DeletedAt: time.Now(),
 	}
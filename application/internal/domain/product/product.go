@@ -58,7 +58,7 @@ type Product struct {
 	Manufacturer string `gorm:"size:255"`
 	CountryOfOrigin string `gorm:"size:255"`
 	Barcode string `gorm:"size:255"`
-	Status ProductStatus `gorm:"size:50;default:'draft'"`
+	Status ProductStatus `gorm:"size:50;index;default:'draft'"`
 	IsFeatured bool `gorm:"default:false"`
 	IsNew bool `gorm:"default:false"`
 	IsOnSale bool `gorm:"default:false"`
@@ -73,8 +73,9 @@ type Product struct {
 	VideoUrl string `gorm:"size:255"`
 	PublishedAt time.Time `gorm:"type:timestamp"`
 	DiscontinuedAt time.Time `gorm:"type:timestamp"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	Version int64 `gorm:"not null;default:0"` // 乐观锁版本号
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;index"`
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
@@ -269,7 +270,22 @@ func (e *Product) Update(sku *string, name *string, slug *string, description *s
 	e.AddDomainEvent(NewProductUpdatedEvent(string(e.ID)))
 }
 
+// MarkDeleted 记录 ProductDeletedEvent，随后由仓储的 Remove 删除实体并发布该事件。
+func (e *Product) MarkDeleted() {
+	e.AddDomainEvent(NewProductDeletedEvent(string(e.ID)))
+}
+
 // GetID 返回实体 ID。
 func (e *Product) GetID() ddd.ID {
 	return e.ID
 }
+
+// GetVersion 返回乐观锁版本号。
+func (e *Product) GetVersion() int64 {
+	return e.Version
+}
+
+// SetVersion 设置乐观锁版本号（由仓储在保存成功后维护）。
+func (e *Product) SetVersion(version int64) {
+	e.Version = version
+}
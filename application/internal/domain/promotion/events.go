@@ -19,7 +19,7 @@ func (e PromotionCreatedEvent) EventName() string {
 
 func NewPromotionCreatedEvent(id string) PromotionCreatedEvent {
 	return PromotionCreatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("promotion", id),
 		PromotionID: id,
 	}
 }
@@ -36,7 +36,7 @@ func (e PromotionUpdatedEvent) EventName() string {
 
 func NewPromotionUpdatedEvent(id string) PromotionUpdatedEvent {
 	return PromotionUpdatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("promotion", id),
 		PromotionID: id,
 	}
 }
@@ -54,7 +54,7 @@ func (e PromotionDeletedEvent) EventName() string {
 
 func NewPromotionDeletedEvent(id string) PromotionDeletedEvent {
 	return PromotionDeletedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("promotion", id),
 		PromotionID: id,
 		DeletedAt: time.Now(),
 	}
@@ -42,7 +42,7 @@ type Promotion struct {
 	Code string `gorm:"size:255"` // 优惠码
 	Name string `gorm:"size:255"` // 活动名称
 	Description string `gorm:"size:255"` // 活动说明
-	DiscountType PromotionDiscountType `gorm:"size:50;default:'percentage'"` // 优惠类型
+	DiscountType PromotionDiscountType `gorm:"size:50;index;default:'percentage'"` // 优惠类型
 	DiscountValue int64 `gorm:"not null;default:0"` // 优惠值
 	Currency string `gorm:"size:255"` // 币种
 	MinOrderAmount int64 `gorm:"not null;default:0"` // 最低订单金额
@@ -52,10 +52,11 @@ type Promotion struct {
 	PerUserLimit int `gorm:"not null;default:0"` // 单用户限次
 	StartsAt *time.Time  // 开始时间
 	EndsAt *time.Time  // 结束时间
-	Status PromotionStatus `gorm:"size:50;default:'draft'"` // 活动状态
+	Status PromotionStatus `gorm:"size:50;index;default:'draft'"` // 活动状态
 	Metadata datatypes.JSON  // 扩展信息
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	Version int64 `gorm:"not null;default:0"` // 乐观锁版本号
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;index"`
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
@@ -138,7 +139,22 @@ func (e *Promotion) Update(code *string, name *string, description *string, disc
 	e.AddDomainEvent(NewPromotionUpdatedEvent(string(e.ID)))
 }
 
+// MarkDeleted 记录 PromotionDeletedEvent，随后由仓储的 Remove 删除实体并发布该事件。
+func (e *Promotion) MarkDeleted() {
+	e.AddDomainEvent(NewPromotionDeletedEvent(string(e.ID)))
+}
+
 // GetID 返回实体 ID。
 func (e *Promotion) GetID() ddd.ID {
 	return e.ID
 }
+
+// GetVersion 返回乐观锁版本号。
+func (e *Promotion) GetVersion() int64 {
+	return e.Version
+}
+
+// SetVersion 设置乐观锁版本号（由仓储在保存成功后维护）。
+func (e *Promotion) SetVersion(version int64) {
+	e.Version = version
+}
@@ -10,34 +10,46 @@ import (
 // ReviewCreatedEvent 在创建 Review 时发布。
 type ReviewCreatedEvent struct {
 	ddd.BaseDomainEvent
-	ReviewID string `json:"review_id"`
+	ReviewID  string       `json:"review_id"`
+	ProductId string       `json:"product_id"`
+	Rating    int          `json:"rating"`
+	Status    ReviewStatus `json:"status"`
 }
 
 func (e ReviewCreatedEvent) EventName() string {
 	return "review.created"
 }
 
-func NewReviewCreatedEvent(id string) ReviewCreatedEvent {
+func NewReviewCreatedEvent(id string, productId string, rating int, status ReviewStatus) ReviewCreatedEvent {
 	return ReviewCreatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
-		ReviewID: id,
+		BaseDomainEvent: ddd.NewAggregateEvent("review", id),
+		ReviewID:        id,
+		ProductId:       productId,
+		Rating:          rating,
+		Status:          status,
 	}
 }
 
 // ReviewUpdatedEvent 在更新 Review 时发布。
 type ReviewUpdatedEvent struct {
 	ddd.BaseDomainEvent
-	ReviewID string `json:"review_id"`
+	ReviewID  string       `json:"review_id"`
+	ProductId string       `json:"product_id"`
+	Rating    int          `json:"rating"`
+	Status    ReviewStatus `json:"status"`
 }
 
 func (e ReviewUpdatedEvent) EventName() string {
 	return "review.updated"
 }
 
-func NewReviewUpdatedEvent(id string) ReviewUpdatedEvent {
+func NewReviewUpdatedEvent(id string, productId string, rating int, status ReviewStatus) ReviewUpdatedEvent {
 	return ReviewUpdatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
-		ReviewID: id,
+		BaseDomainEvent: ddd.NewAggregateEvent("review", id),
+		ReviewID:        id,
+		ProductId:       productId,
+		Rating:          rating,
+		Status:          status,
 	}
 }
 
@@ -54,7 +66,7 @@ func (e ReviewDeletedEvent) EventName() string {
 
 func NewReviewDeletedEvent(id string) ReviewDeletedEvent {
 	return ReviewDeletedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("review", id),
 		ReviewID: id,
 		DeletedAt: time.Now(),
 	}
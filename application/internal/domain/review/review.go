@@ -30,19 +30,20 @@ const (
 type Review struct {
 	ddd.BaseAggregateRoot
 	ID ReviewID `gorm:"primaryKey"`
-	ProductId string `gorm:"size:255"` // 商品ID
-	UserId string `gorm:"size:255"` // 用户ID
-	OrderId string `gorm:"size:255"` // 订单ID
+	ProductId string `gorm:"size:255;index"` // 商品ID
+	UserId string `gorm:"size:255;index"` // 用户ID
+	OrderId string `gorm:"size:255;index"` // 订单ID
 	Rating int `gorm:"not null;default:0"` // 评分
 	Title string `gorm:"size:255"` // 标题
 	Content string `gorm:"size:255"` // 评价内容
-	Status ReviewStatus `gorm:"size:50;default:'pending'"` // 审核状态
+	Status ReviewStatus `gorm:"size:50;index;default:'pending'"` // 审核状态
 	IsAnonymous bool `gorm:"default:false"` // 是否匿名
 	HelpfulCount int `gorm:"not null;default:0"` // 有用数
 	Reply string `gorm:"size:255"` // 官方回复
 	Images datatypes.JSON  // 图片列表
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	Version int64 `gorm:"not null;default:0"` // 乐观锁版本号
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;index"`
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
@@ -67,7 +68,7 @@ func NewReview(id string, productId string, userId string, orderId string, ratin
 		Reply: reply,
 		Images: images,
 	}
-	e.AddDomainEvent(NewReviewCreatedEvent(id))
+	e.AddDomainEvent(NewReviewCreatedEvent(id, productId, rating, status))
 	return e
 }
 
@@ -106,10 +107,25 @@ func (e *Review) Update(productId *string, userId *string, orderId *string, rati
 	if images != nil {
 		e.Images = *images
 	}
-	e.AddDomainEvent(NewReviewUpdatedEvent(string(e.ID)))
+	e.AddDomainEvent(NewReviewUpdatedEvent(string(e.ID), e.ProductId, e.Rating, e.Status))
+}
+
+// MarkDeleted 记录 ReviewDeletedEvent，随后由仓储的 Remove 删除实体并发布该事件。
+func (e *Review) MarkDeleted() {
+	e.AddDomainEvent(NewReviewDeletedEvent(string(e.ID)))
 }
 
 // GetID 返回实体 ID。
 func (e *Review) GetID() ddd.ID {
 	return e.ID
 }
+
+// GetVersion 返回乐观锁版本号。
+func (e *Review) GetVersion() int64 {
+	return e.Version
+}
+
+// SetVersion 设置乐观锁版本号（由仓储在保存成功后维护）。
+func (e *Review) SetVersion(version int64) {
+	e.Version = version
+}
@@ -19,7 +19,7 @@ func (e ShippingCreatedEvent) EventName() string {
 
 func NewShippingCreatedEvent(id string) ShippingCreatedEvent {
 	return ShippingCreatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("shipping", id),
 		ShippingID: id,
 	}
 }
@@ -36,7 +36,7 @@ func (e ShippingUpdatedEvent) EventName() string {
 
 func NewShippingUpdatedEvent(id string) ShippingUpdatedEvent {
 	return ShippingUpdatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("shipping", id),
 		ShippingID: id,
 	}
 }
@@ -54,7 +54,7 @@ func (e ShippingDeletedEvent) EventName() string {
 
 func NewShippingDeletedEvent(id string) ShippingDeletedEvent {
 	return ShippingDeletedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("shipping", id),
 		ShippingID: id,
 		DeletedAt: time.Now(),
 	}
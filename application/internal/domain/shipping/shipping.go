@@ -40,11 +40,11 @@ const (
 type Shipping struct {
 	ddd.BaseAggregateRoot
 	ID ShippingID `gorm:"primaryKey"`
-	OrderId string `gorm:"size:255"` // 订单ID
+	OrderId string `gorm:"size:255;index"` // 订单ID
 	Carrier string `gorm:"size:255"` // 物流承运商
-	ShippingMethod ShippingShippingMethod `gorm:"size:50;default:'standard'"` // 配送方式
+	ShippingMethod ShippingShippingMethod `gorm:"size:50;index;default:'standard'"` // 配送方式
 	TrackingNumber string `gorm:"size:255"` // 物流单号
-	Status ShippingStatus `gorm:"size:50;default:'pending'"` // 物流状态
+	Status ShippingStatus `gorm:"size:50;index;default:'pending'"` // 物流状态
 	ShippedAt *time.Time  // 发货时间
 	DeliveredAt *time.Time  // 签收时间
 	ReceiverName string `gorm:"size:255"` // 收件人姓名
@@ -55,8 +55,9 @@ type Shipping struct {
 	ReceiverCountry string `gorm:"size:255"` // 收件人国家
 	ReceiverPostalCode string `gorm:"size:255"` // 邮编
 	Notes string `gorm:"size:255"` // 备注
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	Version int64 `gorm:"not null;default:0"` // 乐观锁版本号
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;index"`
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
@@ -139,7 +140,22 @@ func (e *Shipping) Update(orderId *string, carrier *string, shippingMethod *Ship
 	e.AddDomainEvent(NewShippingUpdatedEvent(string(e.ID)))
 }
 
+// MarkDeleted 记录 ShippingDeletedEvent，随后由仓储的 Remove 删除实体并发布该事件。
+func (e *Shipping) MarkDeleted() {
+	e.AddDomainEvent(NewShippingDeletedEvent(string(e.ID)))
+}
+
 // GetID 返回实体 ID。
 func (e *Shipping) GetID() ddd.ID {
 	return e.ID
 }
+
+// GetVersion 返回乐观锁版本号。
+func (e *Shipping) GetVersion() int64 {
+	return e.Version
+}
+
+// SetVersion 设置乐观锁版本号（由仓储在保存成功后维护）。
+func (e *Shipping) SetVersion(version int64) {
+	e.Version = version
+}
@@ -19,7 +19,7 @@ func (e UserCreatedEvent) EventName() string {
 
 func NewUserCreatedEvent(id string) UserCreatedEvent {
 	return UserCreatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("user", id),
 		UserID: id,
 	}
 }
@@ -36,7 +36,7 @@ func (e UserUpdatedEvent) EventName() string {
 
 func NewUserUpdatedEvent(id string) UserUpdatedEvent {
 	return UserUpdatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("user", id),
 		UserID: id,
 	}
 }
@@ -54,7 +54,7 @@ func (e UserDeletedEvent) EventName() string {
 
 func NewUserDeletedEvent(id string) UserDeletedEvent {
 	return UserDeletedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("user", id),
 		UserID: id,
 		DeletedAt: time.Now(),
 	}
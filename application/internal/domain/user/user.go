@@ -20,8 +20,9 @@ type User struct {
 	ID UserID `gorm:"primaryKey"`
 	Username string `gorm:"size:255"` // 用户名
 	Email string `gorm:"size:255"` // 邮箱
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	Version int64 `gorm:"not null;default:0"` // 乐观锁版本号
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;index"`
 }
 
 // TableName 返回 GORM 映射的数据库表名。
@@ -51,7 +52,22 @@ func (e *User) Update(username *string, email *string) {
 	e.AddDomainEvent(NewUserUpdatedEvent(string(e.ID)))
 }
 
+// MarkDeleted 记录 UserDeletedEvent，随后由仓储的 Remove 删除实体并发布该事件。
+func (e *User) MarkDeleted() {
+	e.AddDomainEvent(NewUserDeletedEvent(string(e.ID)))
+}
+
 // GetID 返回实体 ID。
 func (e *User) GetID() ddd.ID {
 	return e.ID
 }
+
+// GetVersion 返回乐观锁版本号。
+func (e *User) GetVersion() int64 {
+	return e.Version
+}
+
+// SetVersion 设置乐观锁版本号（由仓储在保存成功后维护）。
+func (e *User) SetVersion(version int64) {
+	e.Version = version
+}
@@ -2,50 +2,49 @@ package persistence
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/soliton-go/application/internal/domain/inventory"
+	"github.com/soliton-go/framework/event"
 	"github.com/soliton-go/framework/orm"
 	"gorm.io/gorm"
 )
 
 type InventoryRepoImpl struct {
-	*orm.GormRepository[*inventory.Inventory, inventory.InventoryID]
-	db *gorm.DB
+	*event.PublishingRepository[*inventory.Inventory, inventory.InventoryID]
+	criteria *orm.GormRepository[*inventory.Inventory, inventory.InventoryID]
+	db       *gorm.DB
 }
 
-func NewInventoryRepository(db *gorm.DB) inventory.InventoryRepository {
+// NewInventoryRepository 创建仓储；保存聚合根后将其领域事件交给 publisher。
+func NewInventoryRepository(db *gorm.DB, publisher event.Publisher) inventory.InventoryRepository {
+	// 列表只允许按带索引的列排序
+	repo := orm.NewGormRepository[*inventory.Inventory, inventory.InventoryID](db,
+		orm.WithSortable("id", "created_at", "updated_at", "product_id", "warehouse_id", "status"),
+	)
 	return &InventoryRepoImpl{
-		GormRepository: orm.NewGormRepository[*inventory.Inventory, inventory.InventoryID](db),
-		db:             db,
+		PublishingRepository: event.NewPublishingRepository[*inventory.Inventory, inventory.InventoryID](
+			repo,
+			publisher,
+			event.WithPublishTransaction(db),
+		),
+		criteria: repo,
+		db:       db,
 	}
 }
 
-// FindPaginated 返回分页数据和总数。
-func (r *InventoryRepoImpl) FindPaginated(ctx context.Context, page, pageSize int, sortBy, sortOrder string) ([]*inventory.Inventory, int64, error) {
-	var entities []*inventory.Inventory
-	var total int64
-
-	// 查询总数
-	baseQuery := r.db.WithContext(ctx).Model(&inventory.Inventory{})
-	if err := baseQuery.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// 获取分页数据
-	offset := (page - 1) * pageSize
-	query := r.db.WithContext(ctx).Offset(offset).Limit(pageSize)
-	if sortBy != "" {
-		query = query.Order(fmt.Sprintf("%s %s", sortBy, sortOrder))
-	}
-	if err := query.Find(&entities).Error; err != nil {
-		return nil, 0, err
-	}
+// FindByCriteria 按条件、排序和分页查询，返回当前页数据和总数。
+// 列名须是 Inventory 的数据库列名，排序字段限于仓储的排序白名单，否则返回 orm.ErrInvalidCriteria。
+func (r *InventoryRepoImpl) FindByCriteria(ctx context.Context, criteria orm.Criteria) ([]*inventory.Inventory, int64, error) {
+	return r.criteria.FindByCriteria(ctx, criteria)
+}
 
-	return entities, total, nil
+// FindByCursor 返回 criteria.Cursor 之后的至多 criteria.Limit 条数据和下一页的游标。
+// 游标只能用于签发时的排序方式，否则返回 orm.ErrInvalidCriteria。
+func (r *InventoryRepoImpl) FindByCursor(ctx context.Context, criteria orm.Criteria) (orm.CursorPage[*inventory.Inventory], error) {
+	return r.criteria.FindByCursor(ctx, criteria)
 }
 
 // MigrateInventory 创建数据库表（如不存在）。
 func MigrateInventory(db *gorm.DB) error {
-	return db.AutoMigrate(&inventory.Inventory{})
+	return orm.Primary(db).AutoMigrate(&inventory.Inventory{})
 }
@@ -2,50 +2,49 @@ package persistence
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/soliton-go/application/internal/domain/order"
+	"github.com/soliton-go/framework/event"
 	"github.com/soliton-go/framework/orm"
 	"gorm.io/gorm"
 )
 
 type OrderRepoImpl struct {
-	*orm.GormRepository[*order.Order, order.OrderID]
-	db *gorm.DB
+	*event.PublishingRepository[*order.Order, order.OrderID]
+	criteria *orm.GormRepository[*order.Order, order.OrderID]
+	db       *gorm.DB
 }
 
-func NewOrderRepository(db *gorm.DB) order.OrderRepository {
+// NewOrderRepository 创建仓储；保存聚合根后将其领域事件交给 publisher。
+func NewOrderRepository(db *gorm.DB, publisher event.Publisher) order.OrderRepository {
+	// 列表只允许按带索引的列排序
+	repo := orm.NewGormRepository[*order.Order, order.OrderID](db,
+		orm.WithSortable("id", "created_at", "updated_at", "user_id", "payment_method", "payment_status", "order_status", "shipping_method"),
+	)
 	return &OrderRepoImpl{
-		GormRepository: orm.NewGormRepository[*order.Order, order.OrderID](db),
-		db:             db,
+		PublishingRepository: event.NewPublishingRepository[*order.Order, order.OrderID](
+			repo,
+			publisher,
+			event.WithPublishTransaction(db),
+		),
+		criteria: repo,
+		db:       db,
 	}
 }
 
-// FindPaginated 返回分页数据和总数。
-func (r *OrderRepoImpl) FindPaginated(ctx context.Context, page, pageSize int, sortBy, sortOrder string) ([]*order.Order, int64, error) {
-	var entities []*order.Order
-	var total int64
-
-	// 查询总数
-	baseQuery := r.db.WithContext(ctx).Model(&order.Order{})
-	if err := baseQuery.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// 获取分页数据
-	offset := (page - 1) * pageSize
-	query := r.db.WithContext(ctx).Offset(offset).Limit(pageSize)
-	if sortBy != "" {
-		query = query.Order(fmt.Sprintf("%s %s", sortBy, sortOrder))
-	}
-	if err := query.Find(&entities).Error; err != nil {
-		return nil, 0, err
-	}
+// FindByCriteria 按条件、排序和分页查询，返回当前页数据和总数。
+// 列名须是 Order 的数据库列名，排序字段限于仓储的排序白名单，否则返回 orm.ErrInvalidCriteria。
+func (r *OrderRepoImpl) FindByCriteria(ctx context.Context, criteria orm.Criteria) ([]*order.Order, int64, error) {
+	return r.criteria.FindByCriteria(ctx, criteria)
+}
 
-	return entities, total, nil
+// FindByCursor 返回 criteria.Cursor 之后的至多 criteria.Limit 条数据和下一页的游标。
+// 游标只能用于签发时的排序方式，否则返回 orm.ErrInvalidCriteria。
+func (r *OrderRepoImpl) FindByCursor(ctx context.Context, criteria orm.Criteria) (orm.CursorPage[*order.Order], error) {
+	return r.criteria.FindByCursor(ctx, criteria)
 }
 
 // MigrateOrder 创建数据库表（如不存在）。
 func MigrateOrder(db *gorm.DB) error {
-	return db.AutoMigrate(&order.Order{})
+	return orm.Primary(db).AutoMigrate(&order.Order{})
 }
@@ -2,50 +2,49 @@ package persistence
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/soliton-go/application/internal/domain/product"
+	"github.com/soliton-go/framework/event"
 	"github.com/soliton-go/framework/orm"
 	"gorm.io/gorm"
 )
 
 type ProductRepoImpl struct {
-	*orm.GormRepository[*product.Product, product.ProductID]
-	db *gorm.DB
+	*event.PublishingRepository[*product.Product, product.ProductID]
+	criteria *orm.GormRepository[*product.Product, product.ProductID]
+	db       *gorm.DB
 }
 
-func NewProductRepository(db *gorm.DB) product.ProductRepository {
+// NewProductRepository 创建仓储；保存聚合根后将其领域事件交给 publisher。
+func NewProductRepository(db *gorm.DB, publisher event.Publisher) product.ProductRepository {
+	// 列表只允许按带索引的列排序
+	repo := orm.NewGormRepository[*product.Product, product.ProductID](db,
+		orm.WithSortable("id", "created_at", "updated_at", "status"),
+	)
 	return &ProductRepoImpl{
-		GormRepository: orm.NewGormRepository[*product.Product, product.ProductID](db),
-		db:             db,
+		PublishingRepository: event.NewPublishingRepository[*product.Product, product.ProductID](
+			repo,
+			publisher,
+			event.WithPublishTransaction(db),
+		),
+		criteria: repo,
+		db:       db,
 	}
 }
 
-// FindPaginated 返回分页数据和总数。
-func (r *ProductRepoImpl) FindPaginated(ctx context.Context, page, pageSize int, sortBy, sortOrder string) ([]*product.Product, int64, error) {
-	var entities []*product.Product
-	var total int64
-
-	// 查询总数
-	baseQuery := r.db.WithContext(ctx).Model(&product.Product{})
-	if err := baseQuery.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// 获取分页数据
-	offset := (page - 1) * pageSize
-	query := r.db.WithContext(ctx).Offset(offset).Limit(pageSize)
-	if sortBy != "" {
-		query = query.Order(fmt.Sprintf("%s %s", sortBy, sortOrder))
-	}
-	if err := query.Find(&entities).Error; err != nil {
-		return nil, 0, err
-	}
+// FindByCriteria 按条件、排序和分页查询，返回当前页数据和总数。
+// 列名须是 Product 的数据库列名，排序字段限于仓储的排序白名单，否则返回 orm.ErrInvalidCriteria。
+func (r *ProductRepoImpl) FindByCriteria(ctx context.Context, criteria orm.Criteria) ([]*product.Product, int64, error) {
+	return r.criteria.FindByCriteria(ctx, criteria)
+}
 
-	return entities, total, nil
+// FindByCursor 返回 criteria.Cursor 之后的至多 criteria.Limit 条数据和下一页的游标。
+// 游标只能用于签发时的排序方式，否则返回 orm.ErrInvalidCriteria。
+func (r *ProductRepoImpl) FindByCursor(ctx context.Context, criteria orm.Criteria) (orm.CursorPage[*product.Product], error) {
+	return r.criteria.FindByCursor(ctx, criteria)
 }
 
 // MigrateProduct 创建数据库表（如不存在）。
 func MigrateProduct(db *gorm.DB) error {
-	return db.AutoMigrate(&product.Product{})
+	return orm.Primary(db).AutoMigrate(&product.Product{})
 }
@@ -2,50 +2,49 @@ package persistence
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/soliton-go/application/internal/domain/promotion"
+	"github.com/soliton-go/framework/event"
 	"github.com/soliton-go/framework/orm"
 	"gorm.io/gorm"
 )
 
 type PromotionRepoImpl struct {
-	*orm.GormRepository[*promotion.Promotion, promotion.PromotionID]
-	db *gorm.DB
+	*event.PublishingRepository[*promotion.Promotion, promotion.PromotionID]
+	criteria *orm.GormRepository[*promotion.Promotion, promotion.PromotionID]
+	db       *gorm.DB
 }
 
-func NewPromotionRepository(db *gorm.DB) promotion.PromotionRepository {
+// NewPromotionRepository 创建仓储；保存聚合根后将其领域事件交给 publisher。
+func NewPromotionRepository(db *gorm.DB, publisher event.Publisher) promotion.PromotionRepository {
+	// 列表只允许按带索引的列排序
+	repo := orm.NewGormRepository[*promotion.Promotion, promotion.PromotionID](db,
+		orm.WithSortable("id", "created_at", "updated_at", "discount_type", "status"),
+	)
 	return &PromotionRepoImpl{
-		GormRepository: orm.NewGormRepository[*promotion.Promotion, promotion.PromotionID](db),
-		db:             db,
+		PublishingRepository: event.NewPublishingRepository[*promotion.Promotion, promotion.PromotionID](
+			repo,
+			publisher,
+			event.WithPublishTransaction(db),
+		),
+		criteria: repo,
+		db:       db,
 	}
 }
 
-// FindPaginated 返回分页数据和总数。
-func (r *PromotionRepoImpl) FindPaginated(ctx context.Context, page, pageSize int, sortBy, sortOrder string) ([]*promotion.Promotion, int64, error) {
-	var entities []*promotion.Promotion
-	var total int64
-
-	// 查询总数
-	baseQuery := r.db.WithContext(ctx).Model(&promotion.Promotion{})
-	if err := baseQuery.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// 获取分页数据
-	offset := (page - 1) * pageSize
-	query := r.db.WithContext(ctx).Offset(offset).Limit(pageSize)
-	if sortBy != "" {
-		query = query.Order(fmt.Sprintf("%s %s", sortBy, sortOrder))
-	}
-	if err := query.Find(&entities).Error; err != nil {
-		return nil, 0, err
-	}
+// FindByCriteria 按条件、排序和分页查询，返回当前页数据和总数。
+// 列名须是 Promotion 的数据库列名，排序字段限于仓储的排序白名单，否则返回 orm.ErrInvalidCriteria。
+func (r *PromotionRepoImpl) FindByCriteria(ctx context.Context, criteria orm.Criteria) ([]*promotion.Promotion, int64, error) {
+	return r.criteria.FindByCriteria(ctx, criteria)
+}
 
-	return entities, total, nil
+// FindByCursor 返回 criteria.Cursor 之后的至多 criteria.Limit 条数据和下一页的游标。
+// 游标只能用于签发时的排序方式，否则返回 orm.ErrInvalidCriteria。
+func (r *PromotionRepoImpl) FindByCursor(ctx context.Context, criteria orm.Criteria) (orm.CursorPage[*promotion.Promotion], error) {
+	return r.criteria.FindByCursor(ctx, criteria)
 }
 
 // MigratePromotion 创建数据库表（如不存在）。
 func MigratePromotion(db *gorm.DB) error {
-	return db.AutoMigrate(&promotion.Promotion{})
+	return orm.Primary(db).AutoMigrate(&promotion.Promotion{})
 }
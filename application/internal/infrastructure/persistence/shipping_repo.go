@@ -2,50 +2,49 @@ package persistence
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/soliton-go/application/internal/domain/shipping"
+	"github.com/soliton-go/framework/event"
 	"github.com/soliton-go/framework/orm"
 	"gorm.io/gorm"
 )
 
 type ShippingRepoImpl struct {
-	*orm.GormRepository[*shipping.Shipping, shipping.ShippingID]
-	db *gorm.DB
+	*event.PublishingRepository[*shipping.Shipping, shipping.ShippingID]
+	criteria *orm.GormRepository[*shipping.Shipping, shipping.ShippingID]
+	db       *gorm.DB
 }
 
-func NewShippingRepository(db *gorm.DB) shipping.ShippingRepository {
+// NewShippingRepository 创建仓储；保存聚合根后将其领域事件交给 publisher。
+func NewShippingRepository(db *gorm.DB, publisher event.Publisher) shipping.ShippingRepository {
+	// 列表只允许按带索引的列排序
+	repo := orm.NewGormRepository[*shipping.Shipping, shipping.ShippingID](db,
+		orm.WithSortable("id", "created_at", "updated_at", "order_id", "shipping_method", "status"),
+	)
 	return &ShippingRepoImpl{
-		GormRepository: orm.NewGormRepository[*shipping.Shipping, shipping.ShippingID](db),
-		db:             db,
+		PublishingRepository: event.NewPublishingRepository[*shipping.Shipping, shipping.ShippingID](
+			repo,
+			publisher,
+			event.WithPublishTransaction(db),
+		),
+		criteria: repo,
+		db:       db,
 	}
 }
 
-// FindPaginated 返回分页数据和总数。
-func (r *ShippingRepoImpl) FindPaginated(ctx context.Context, page, pageSize int, sortBy, sortOrder string) ([]*shipping.Shipping, int64, error) {
-	var entities []*shipping.Shipping
-	var total int64
-
-	// 查询总数
-	baseQuery := r.db.WithContext(ctx).Model(&shipping.Shipping{})
-	if err := baseQuery.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// 获取分页数据
-	offset := (page - 1) * pageSize
-	query := r.db.WithContext(ctx).Offset(offset).Limit(pageSize)
-	if sortBy != "" {
-		query = query.Order(fmt.Sprintf("%s %s", sortBy, sortOrder))
-	}
-	if err := query.Find(&entities).Error; err != nil {
-		return nil, 0, err
-	}
+// FindByCriteria 按条件、排序和分页查询，返回当前页数据和总数。
+// 列名须是 Shipping 的数据库列名，排序字段限于仓储的排序白名单，否则返回 orm.ErrInvalidCriteria。
+func (r *ShippingRepoImpl) FindByCriteria(ctx context.Context, criteria orm.Criteria) ([]*shipping.Shipping, int64, error) {
+	return r.criteria.FindByCriteria(ctx, criteria)
+}
 
-	return entities, total, nil
+// FindByCursor 返回 criteria.Cursor 之后的至多 criteria.Limit 条数据和下一页的游标。
+// 游标只能用于签发时的排序方式，否则返回 orm.ErrInvalidCriteria。
+func (r *ShippingRepoImpl) FindByCursor(ctx context.Context, criteria orm.Criteria) (orm.CursorPage[*shipping.Shipping], error) {
+	return r.criteria.FindByCursor(ctx, criteria)
 }
 
 // MigrateShipping 创建数据库表（如不存在）。
 func MigrateShipping(db *gorm.DB) error {
-	return db.AutoMigrate(&shipping.Shipping{})
+	return orm.Primary(db).AutoMigrate(&shipping.Shipping{})
 }
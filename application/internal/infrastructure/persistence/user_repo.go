@@ -2,50 +2,49 @@ package persistence
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/soliton-go/application/internal/domain/user"
+	"github.com/soliton-go/framework/event"
 	"github.com/soliton-go/framework/orm"
 	"gorm.io/gorm"
 )
 
 type UserRepoImpl struct {
-	*orm.GormRepository[*user.User, user.UserID]
-	db *gorm.DB
+	*event.PublishingRepository[*user.User, user.UserID]
+	criteria *orm.GormRepository[*user.User, user.UserID]
+	db       *gorm.DB
 }
 
-func NewUserRepository(db *gorm.DB) user.UserRepository {
+// NewUserRepository 创建仓储；保存聚合根后将其领域事件交给 publisher。
+func NewUserRepository(db *gorm.DB, publisher event.Publisher) user.UserRepository {
+	// 列表只允许按带索引的列排序
+	repo := orm.NewGormRepository[*user.User, user.UserID](db,
+		orm.WithSortable("id", "created_at", "updated_at"),
+	)
 	return &UserRepoImpl{
-		GormRepository: orm.NewGormRepository[*user.User, user.UserID](db),
-		db:             db,
+		PublishingRepository: event.NewPublishingRepository[*user.User, user.UserID](
+			repo,
+			publisher,
+			event.WithPublishTransaction(db),
+		),
+		criteria: repo,
+		db:       db,
 	}
 }
 
-// FindPaginated 返回分页数据和总数。
-func (r *UserRepoImpl) FindPaginated(ctx context.Context, page, pageSize int, sortBy, sortOrder string) ([]*user.User, int64, error) {
-	var entities []*user.User
-	var total int64
-
-	// 查询总数
-	baseQuery := r.db.WithContext(ctx).Model(&user.User{})
-	if err := baseQuery.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// 获取分页数据
-	offset := (page - 1) * pageSize
-	query := r.db.WithContext(ctx).Offset(offset).Limit(pageSize)
-	if sortBy != "" {
-		query = query.Order(fmt.Sprintf("%s %s", sortBy, sortOrder))
-	}
-	if err := query.Find(&entities).Error; err != nil {
-		return nil, 0, err
-	}
+// FindByCriteria 按条件、排序和分页查询，返回当前页数据和总数。
+// 列名须是 User 的数据库列名，排序字段限于仓储的排序白名单，否则返回 orm.ErrInvalidCriteria。
+func (r *UserRepoImpl) FindByCriteria(ctx context.Context, criteria orm.Criteria) ([]*user.User, int64, error) {
+	return r.criteria.FindByCriteria(ctx, criteria)
+}
 
-	return entities, total, nil
+// FindByCursor 返回 criteria.Cursor 之后的至多 criteria.Limit 条数据和下一页的游标。
+// 游标只能用于签发时的排序方式，否则返回 orm.ErrInvalidCriteria。
+func (r *UserRepoImpl) FindByCursor(ctx context.Context, criteria orm.Criteria) (orm.CursorPage[*user.User], error) {
+	return r.criteria.FindByCursor(ctx, criteria)
 }
 
 // MigrateUser 创建数据库表（如不存在）。
 func MigrateUser(db *gorm.DB) error {
-	return db.AutoMigrate(&user.User{})
+	return orm.Primary(db).AutoMigrate(&user.User{})
 }
@@ -0,0 +1,39 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soliton-go/framework/cqrs"
+)
+
+// CommandHandler 查询经 DispatchAsync 异步执行的命令的状态和结果。
+type CommandHandler struct {
+	queries *cqrs.InMemoryQueryBus
+}
+
+// NewCommandHandler 创建 CommandHandler 实例。
+func NewCommandHandler(queries *cqrs.InMemoryQueryBus) *CommandHandler {
+	return &CommandHandler{queries: queries}
+}
+
+// RegisterRoutes 注册异步命令相关路由。
+func (h *CommandHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/api/commands/:id", h.Get)
+}
+
+// Get 处理 GET /api/commands/:id
+// 返回命令状态（pending / running / succeeded / failed），成功时包含结果，失败时包含错误信息。
+func (h *CommandHandler) Get(c *gin.Context) {
+	command, err := cqrs.Query[cqrs.GetCommandQuery, *cqrs.AsyncCommand](c.Request.Context(), h.queries, cqrs.GetCommandQuery{ID: c.Param("id")})
+	if errors.Is(err, cqrs.ErrCommandNotFound) {
+		NotFound(c, "command not found")
+		return
+	}
+	if err != nil {
+		InternalError(c, err.Error())
+		return
+	}
+
+	Success(c, command)
+}
@@ -1,9 +1,13 @@
 package http
 
 import (
+	"errors"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/soliton-go/framework/cqrs"
+	"github.com/soliton-go/framework/orm"
 )
 
 // EnumPtr 是一个辅助函数，用于将 *string 转换为枚举类型的 *T。
@@ -39,3 +43,74 @@ func ServiceError(c *gin.Context, err error) {
 		BadRequest(c, message)
 	}
 }
+
+// DispatchError 将 CQRS 总线返回的错误映射为标准 API 响应：
+// 校验失败（含无效的排序或过滤列）、未认证、无权限与并发冲突各有对应响应，处理器 panic 返回不含细节的 500，
+// 其余错误交给 fallback 处理。
+func DispatchError(c *gin.Context, err error, fallback func(c *gin.Context, message string)) {
+	message := err.Error()
+	switch {
+	case errors.Is(err, cqrs.ErrInvalidMessage):
+		ValidationError(c, message)
+	case errors.Is(err, cqrs.ErrUnauthenticated):
+		Unauthorized(c, message)
+	case errors.Is(err, cqrs.ErrForbidden):
+		Forbidden(c, message)
+	case errors.Is(err, orm.ErrInvalidCriteria):
+		ValidationError(c, message)
+	case errors.Is(err, orm.ErrConcurrencyConflict):
+		Conflict(c, message)
+	case errors.Is(err, cqrs.ErrPanic):
+		// panic 的细节已由 cqrs.Recoverer 记入日志，不返回给调用方
+		InternalError(c, "internal server error")
+	default:
+		fallback(c, message)
+	}
+}
+
+// listRanges 是范围过滤参数的后缀及对应的条件。
+var listRanges = []struct {
+	suffix string
+	build  func(column string, value any) orm.Condition
+}{
+	{"_gt", orm.Gt},
+	{"_gte", orm.Gte},
+	{"_lt", orm.Lt},
+	{"_lte", orm.Lte},
+}
+
+// ListFilters 将查询参数转换为列表过滤条件，只接受 columns 中列出的列，其余参数忽略：
+//
+//	?status=paid                  等于（值中的逗号不拆分）
+//	?status=paid&status=shipped   属于其中之一
+//	?total_gte=100                大于等于（另有 _gt、_lt、_lte）
+//	?notes_like=gift              包含
+//	?paid_at_null=true            为空（false 表示不为空）
+func ListFilters(c *gin.Context, columns ...string) []orm.Condition {
+	query := c.Request.URL.Query()
+	var filters []orm.Condition
+	for _, column := range columns {
+		switch values := query[column]; {
+		case len(values) > 1:
+			filters = append(filters, orm.In(column, values...))
+		case len(values) == 1 && values[0] != "":
+			filters = append(filters, orm.Eq(column, values[0]))
+		}
+		for _, r := range listRanges {
+			if v := query.Get(column + r.suffix); v != "" {
+				filters = append(filters, r.build(column, v))
+			}
+		}
+		if v := query.Get(column + "_like"); v != "" {
+			filters = append(filters, orm.Contains(column, v))
+		}
+		if isNull, err := strconv.ParseBool(query.Get(column + "_null")); err == nil {
+			if isNull {
+				filters = append(filters, orm.IsNull(column))
+			} else {
+				filters = append(filters, orm.NotNull(column))
+			}
+		}
+	}
+	return filters
+}
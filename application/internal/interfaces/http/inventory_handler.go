@@ -5,35 +5,21 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/soliton-go/framework/cqrs"
 
 	inventoryapp "github.com/soliton-go/application/internal/application/inventory"
 	"github.com/soliton-go/application/internal/domain/inventory"
 )
 
-// InventoryHandler 处理 Inventory 相关的 HTTP 请求。
+// InventoryHandler 处理 Inventory 相关的 HTTP 请求，命令与查询经 CQRS 总线分发。
 type InventoryHandler struct {
-	createHandler *inventoryapp.CreateInventoryHandler
-	updateHandler *inventoryapp.UpdateInventoryHandler
-	deleteHandler *inventoryapp.DeleteInventoryHandler
-	getHandler    *inventoryapp.GetInventoryHandler
-	listHandler   *inventoryapp.ListInventorysHandler
+	commands *cqrs.InMemoryCommandBus
+	queries  *cqrs.InMemoryQueryBus
 }
 
 // NewInventoryHandler 创建 InventoryHandler 实例。
-func NewInventoryHandler(
-	createHandler *inventoryapp.CreateInventoryHandler,
-	updateHandler *inventoryapp.UpdateInventoryHandler,
-	deleteHandler *inventoryapp.DeleteInventoryHandler,
-	getHandler *inventoryapp.GetInventoryHandler,
-	listHandler *inventoryapp.ListInventorysHandler,
-) *InventoryHandler {
-	return &InventoryHandler{
-		createHandler: createHandler,
-		updateHandler: updateHandler,
-		deleteHandler: deleteHandler,
-		getHandler:    getHandler,
-		listHandler:   listHandler,
-	}
+func NewInventoryHandler(commands *cqrs.InMemoryCommandBus, queries *cqrs.InMemoryQueryBus) *InventoryHandler {
+	return &InventoryHandler{commands: commands, queries: queries}
 }
 
 // RegisterRoutes 注册 Inventory 相关路由。
@@ -41,6 +27,7 @@ func (h *InventoryHandler) RegisterRoutes(r *gin.Engine) {
 	api := r.Group("/api/inventories")
 	{
 		api.POST("", h.Create)
+		api.POST("/import", h.Import)
 		api.GET("", h.List)
 		api.GET("/:id", h.Get)
 		api.PUT("/:id", h.Update)
@@ -74,22 +61,60 @@ func (h *InventoryHandler) Create(c *gin.Context) {
 		Metadata: req.Metadata,
 	}
 
-	entity, err := h.createHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[inventoryapp.CreateInventoryCommand, *inventory.Inventory](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
 	Success(c, inventoryapp.ToInventoryResponse(entity))
 }
 
+// Import 处理 POST /api/inventories/import
+// 导入命令异步执行，立即返回 202 和命令 ID，通过 GET /api/commands/:id 查询执行状态和结果。
+func (h *InventoryHandler) Import(c *gin.Context) {
+	var req inventoryapp.ImportInventoriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	cmd := inventoryapp.ImportInventoriesCommand{Items: make([]inventoryapp.CreateInventoryCommand, len(req.Items))}
+	for i, item := range req.Items {
+		cmd.Items[i] = inventoryapp.CreateInventoryCommand{
+			ID: uuid.New().String(),
+			ProductId: item.ProductId,
+			WarehouseId: item.WarehouseId,
+			LocationCode: item.LocationCode,
+			Stock: item.Stock,
+			ReservedStock: item.ReservedStock,
+			AvailableStock: item.AvailableStock,
+			SafetyStock: item.SafetyStock,
+			RestockLevel: item.RestockLevel,
+			Status: inventory.InventoryStatus(item.Status),
+			LastStockedAt: item.LastStockedAt,
+			LastCheckedAt: item.LastCheckedAt,
+			Notes: item.Notes,
+			Metadata: item.Metadata,
+		}
+	}
+
+	id, err := h.commands.DispatchAsync(c.Request.Context(), cmd)
+	if err != nil {
+		DispatchError(c, err, InternalError)
+		return
+	}
+
+	Accepted(c, gin.H{"command_id": id})
+}
+
 // Get 处理 GET /api/inventorys/:id
 func (h *InventoryHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 
-	entity, err := h.getHandler.Handle(c.Request.Context(), inventoryapp.GetInventoryQuery{ID: id})
+	entity, err := cqrs.Query[inventoryapp.GetInventoryQuery, *inventory.Inventory](c.Request.Context(), h.queries, inventoryapp.GetInventoryQuery{ID: id})
 	if err != nil {
-		NotFound(c, "inventory not found")
+		DispatchError(c, err, func(c *gin.Context, _ string) { NotFound(c, "inventory not found") })
 		return
 	}
 
@@ -97,20 +122,42 @@ func (h *InventoryHandler) Get(c *gin.Context) {
 }
 
 // List 处理 GET /api/inventorys?page=1&page_size=20&sort_by=id&sort_order=desc
+// 游标分页使用 ?limit=20&cursor=<next_cursor>，加 with_total=true 时统计总数
+// 过滤参数见 ListFilters，例如 ?status=active&created_at_gte=2026-01-01
 func (h *InventoryHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	sortBy := c.DefaultQuery("sort_by", "id")
 	sortOrder := c.DefaultQuery("sort_order", "desc")
+	cursor := c.Query("cursor")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	withTotal, _ := strconv.ParseBool(c.Query("with_total"))
 
-	result, err := h.listHandler.Handle(c.Request.Context(), inventoryapp.ListInventorysQuery{
+	result, err := cqrs.Query[inventoryapp.ListInventorysQuery, *inventoryapp.ListInventorysResult](c.Request.Context(), h.queries, inventoryapp.ListInventorysQuery{
 		Page:     page,
 		PageSize: pageSize,
 		SortBy:   sortBy,
 		SortOrder: sortOrder,
+		Filters:  ListFilters(c, "id", "created_at", "updated_at", "product_id", "warehouse_id", "location_code", "stock", "reserved_stock", "available_stock", "safety_stock", "restock_level", "status", "last_stocked_at", "last_checked_at", "notes", "metadata"),
+		Cursor:    cursor,
+		Limit:     limit,
+		WithTotal: withTotal,
 	})
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
+		return
+	}
+
+	if cursor != "" || limit > 0 {
+		data := gin.H{
+			"items":       inventoryapp.ToInventoryResponseList(result.Items),
+			"limit":       result.PageSize,
+			"next_cursor": result.NextCursor,
+		}
+		if result.Total >= 0 {
+			data["total"] = result.Total
+		}
+		Success(c, data)
 		return
 	}
 
@@ -150,9 +197,9 @@ func (h *InventoryHandler) Update(c *gin.Context) {
 		Metadata: req.Metadata,
 	}
 
-	entity, err := h.updateHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[inventoryapp.UpdateInventoryCommand, *inventory.Inventory](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -164,8 +211,8 @@ func (h *InventoryHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
 	cmd := inventoryapp.DeleteInventoryCommand{ID: id}
-	if err := h.deleteHandler.Handle(c.Request.Context(), cmd); err != nil {
-		InternalError(c, err.Error())
+	if err := cqrs.Send(c.Request.Context(), h.commands, cmd); err != nil {
+		DispatchError(c, err, InternalError)
 		return
 	}
 
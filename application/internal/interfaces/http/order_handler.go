@@ -1,39 +1,26 @@
 package http
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/soliton-go/framework/cqrs"
 
 	orderapp "github.com/soliton-go/application/internal/application/order"
 	"github.com/soliton-go/application/internal/domain/order"
 )
 
-// OrderHandler 处理 Order 相关的 HTTP 请求。
+// OrderHandler 处理 Order 相关的 HTTP 请求，命令与查询经 CQRS 总线分发。
 type OrderHandler struct {
-	createHandler *orderapp.CreateOrderHandler
-	updateHandler *orderapp.UpdateOrderHandler
-	deleteHandler *orderapp.DeleteOrderHandler
-	getHandler    *orderapp.GetOrderHandler
-	listHandler   *orderapp.ListOrdersHandler
+	commands *cqrs.InMemoryCommandBus
+	queries  *cqrs.InMemoryQueryBus
 }
 
 // NewOrderHandler 创建 OrderHandler 实例。
-func NewOrderHandler(
-	createHandler *orderapp.CreateOrderHandler,
-	updateHandler *orderapp.UpdateOrderHandler,
-	deleteHandler *orderapp.DeleteOrderHandler,
-	getHandler *orderapp.GetOrderHandler,
-	listHandler *orderapp.ListOrdersHandler,
-) *OrderHandler {
-	return &OrderHandler{
-		createHandler: createHandler,
-		updateHandler: updateHandler,
-		deleteHandler: deleteHandler,
-		getHandler:    getHandler,
-		listHandler:   listHandler,
-	}
+func NewOrderHandler(commands *cqrs.InMemoryCommandBus, queries *cqrs.InMemoryQueryBus) *OrderHandler {
+	return &OrderHandler{commands: commands, queries: queries}
 }
 
 // RegisterRoutes 注册 Order 相关路由。
@@ -47,6 +34,7 @@ func (h *OrderHandler) RegisterRoutes(r *gin.Engine) {
 		api.PATCH("/:id", h.Update)
 		api.DELETE("/:id", h.Delete)
 	}
+	r.GET("/api/users/:id/order-summary", h.UserSummary)
 }
 
 // Create 处理 POST /api/orders
@@ -93,9 +81,9 @@ func (h *OrderHandler) Create(c *gin.Context) {
 		GiftMessage: req.GiftMessage,
 	}
 
-	entity, err := h.createHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[orderapp.CreateOrderCommand, *order.Order](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -106,9 +94,9 @@ func (h *OrderHandler) Create(c *gin.Context) {
 func (h *OrderHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 
-	entity, err := h.getHandler.Handle(c.Request.Context(), orderapp.GetOrderQuery{ID: id})
+	entity, err := cqrs.Query[orderapp.GetOrderQuery, *order.Order](c.Request.Context(), h.queries, orderapp.GetOrderQuery{ID: id})
 	if err != nil {
-		NotFound(c, "order not found")
+		DispatchError(c, err, func(c *gin.Context, _ string) { NotFound(c, "order not found") })
 		return
 	}
 
@@ -116,20 +104,42 @@ func (h *OrderHandler) Get(c *gin.Context) {
 }
 
 // List 处理 GET /api/orders?page=1&page_size=20&sort_by=id&sort_order=desc
+// 游标分页使用 ?limit=20&cursor=<next_cursor>，加 with_total=true 时统计总数
+// 过滤参数见 ListFilters，例如 ?status=active&created_at_gte=2026-01-01
 func (h *OrderHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	sortBy := c.DefaultQuery("sort_by", "id")
 	sortOrder := c.DefaultQuery("sort_order", "desc")
+	cursor := c.Query("cursor")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	withTotal, _ := strconv.ParseBool(c.Query("with_total"))
 
-	result, err := h.listHandler.Handle(c.Request.Context(), orderapp.ListOrdersQuery{
+	result, err := cqrs.Query[orderapp.ListOrdersQuery, *orderapp.ListOrdersResult](c.Request.Context(), h.queries, orderapp.ListOrdersQuery{
 		Page:     page,
 		PageSize: pageSize,
 		SortBy:   sortBy,
 		SortOrder: sortOrder,
+		Filters:  ListFilters(c, "id", "created_at", "updated_at", "user_id", "order_no", "total_amount", "discount_amount", "tax_amount", "shipping_fee", "final_amount", "currency", "payment_method", "payment_status", "order_status", "shipping_method", "tracking_number", "receiver_name", "receiver_phone", "receiver_email", "receiver_address", "receiver_city", "receiver_state", "receiver_country", "receiver_postal_code", "notes", "paid_at", "shipped_at", "delivered_at", "cancelled_at", "refund_amount", "refund_reason", "item_count", "weight", "is_gift", "gift_message"),
+		Cursor:    cursor,
+		Limit:     limit,
+		WithTotal: withTotal,
 	})
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
+		return
+	}
+
+	if cursor != "" || limit > 0 {
+		data := gin.H{
+			"items":       orderapp.ToOrderResponseList(result.Items),
+			"limit":       result.PageSize,
+			"next_cursor": result.NextCursor,
+		}
+		if result.Total >= 0 {
+			data["total"] = result.Total
+		}
+		Success(c, data)
 		return
 	}
 
@@ -188,9 +198,9 @@ func (h *OrderHandler) Update(c *gin.Context) {
 		GiftMessage: req.GiftMessage,
 	}
 
-	entity, err := h.updateHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[orderapp.UpdateOrderCommand, *order.Order](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -202,10 +212,28 @@ func (h *OrderHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
 	cmd := orderapp.DeleteOrderCommand{ID: id}
-	if err := h.deleteHandler.Handle(c.Request.Context(), cmd); err != nil {
-		InternalError(c, err.Error())
+	if err := cqrs.Send(c.Request.Context(), h.commands, cmd); err != nil {
+		DispatchError(c, err, InternalError)
 		return
 	}
 
 	Success(c, nil)
 }
+
+// UserSummary 处理 GET /api/users/:id/order-summary，读取投影维护的用户订单汇总。
+func (h *OrderHandler) UserSummary(c *gin.Context) {
+	query := orderapp.GetUserOrderSummaryQuery{UserId: c.Param("id")}
+	summary, err := cqrs.Query[orderapp.GetUserOrderSummaryQuery, *orderapp.UserOrderSummary](c.Request.Context(), h.queries, query)
+	if err != nil {
+		DispatchError(c, err, func(c *gin.Context, message string) {
+			if errors.Is(err, orderapp.ErrUserOrderSummaryNotFound) {
+				NotFound(c, message)
+				return
+			}
+			InternalError(c, message)
+		})
+		return
+	}
+
+	Success(c, summary)
+}
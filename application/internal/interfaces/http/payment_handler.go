@@ -5,35 +5,21 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/soliton-go/framework/cqrs"
 
 	paymentapp "github.com/soliton-go/application/internal/application/payment"
 	"github.com/soliton-go/application/internal/domain/payment"
 )
 
-// PaymentHandler 处理 Payment 相关的 HTTP 请求。
+// PaymentHandler 处理 Payment 相关的 HTTP 请求，命令与查询经 CQRS 总线分发。
 type PaymentHandler struct {
-	createHandler *paymentapp.CreatePaymentHandler
-	updateHandler *paymentapp.UpdatePaymentHandler
-	deleteHandler *paymentapp.DeletePaymentHandler
-	getHandler    *paymentapp.GetPaymentHandler
-	listHandler   *paymentapp.ListPaymentsHandler
+	commands *cqrs.InMemoryCommandBus
+	queries  *cqrs.InMemoryQueryBus
 }
 
 // NewPaymentHandler 创建 PaymentHandler 实例。
-func NewPaymentHandler(
-	createHandler *paymentapp.CreatePaymentHandler,
-	updateHandler *paymentapp.UpdatePaymentHandler,
-	deleteHandler *paymentapp.DeletePaymentHandler,
-	getHandler *paymentapp.GetPaymentHandler,
-	listHandler *paymentapp.ListPaymentsHandler,
-) *PaymentHandler {
-	return &PaymentHandler{
-		createHandler: createHandler,
-		updateHandler: updateHandler,
-		deleteHandler: deleteHandler,
-		getHandler:    getHandler,
-		listHandler:   listHandler,
-	}
+func NewPaymentHandler(commands *cqrs.InMemoryCommandBus, queries *cqrs.InMemoryQueryBus) *PaymentHandler {
+	return &PaymentHandler{commands: commands, queries: queries}
 }
 
 // RegisterRoutes 注册 Payment 相关路由。
@@ -73,9 +59,9 @@ func (h *PaymentHandler) Create(c *gin.Context) {
 		Metadata: req.Metadata,
 	}
 
-	entity, err := h.createHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[paymentapp.CreatePaymentCommand, *payment.Payment](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -86,9 +72,9 @@ func (h *PaymentHandler) Create(c *gin.Context) {
 func (h *PaymentHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 
-	entity, err := h.getHandler.Handle(c.Request.Context(), paymentapp.GetPaymentQuery{ID: id})
+	entity, err := cqrs.Query[paymentapp.GetPaymentQuery, *payment.Payment](c.Request.Context(), h.queries, paymentapp.GetPaymentQuery{ID: id})
 	if err != nil {
-		NotFound(c, "payment not found")
+		DispatchError(c, err, func(c *gin.Context, _ string) { NotFound(c, "payment not found") })
 		return
 	}
 
@@ -96,20 +82,42 @@ func (h *PaymentHandler) Get(c *gin.Context) {
 }
 
 // List 处理 GET /api/payments?page=1&page_size=20&sort_by=id&sort_order=desc
+// 游标分页使用 ?limit=20&cursor=<next_cursor>，加 with_total=true 时统计总数
+// 过滤参数见 ListFilters，例如 ?status=active&created_at_gte=2026-01-01
 func (h *PaymentHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	sortBy := c.DefaultQuery("sort_by", "id")
 	sortOrder := c.DefaultQuery("sort_order", "desc")
+	cursor := c.Query("cursor")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	withTotal, _ := strconv.ParseBool(c.Query("with_total"))
 
-	result, err := h.listHandler.Handle(c.Request.Context(), paymentapp.ListPaymentsQuery{
+	result, err := cqrs.Query[paymentapp.ListPaymentsQuery, *paymentapp.ListPaymentsResult](c.Request.Context(), h.queries, paymentapp.ListPaymentsQuery{
 		Page:     page,
 		PageSize: pageSize,
 		SortBy:   sortBy,
 		SortOrder: sortOrder,
+		Filters:  ListFilters(c, "id", "created_at", "updated_at", "order_id", "user_id", "amount", "currency", "method", "status", "provider", "provider_txn_id", "paid_at", "refunded_at", "failure_reason", "metadata"),
+		Cursor:    cursor,
+		Limit:     limit,
+		WithTotal: withTotal,
 	})
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
+		return
+	}
+
+	if cursor != "" || limit > 0 {
+		data := gin.H{
+			"items":       paymentapp.ToPaymentResponseList(result.Items),
+			"limit":       result.PageSize,
+			"next_cursor": result.NextCursor,
+		}
+		if result.Total >= 0 {
+			data["total"] = result.Total
+		}
+		Success(c, data)
 		return
 	}
 
@@ -148,9 +156,9 @@ func (h *PaymentHandler) Update(c *gin.Context) {
 		Metadata: req.Metadata,
 	}
 
-	entity, err := h.updateHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[paymentapp.UpdatePaymentCommand, *payment.Payment](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -162,8 +170,8 @@ func (h *PaymentHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
 	cmd := paymentapp.DeletePaymentCommand{ID: id}
-	if err := h.deleteHandler.Handle(c.Request.Context(), cmd); err != nil {
-		InternalError(c, err.Error())
+	if err := cqrs.Send(c.Request.Context(), h.commands, cmd); err != nil {
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -5,35 +5,21 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/soliton-go/framework/cqrs"
 
 	productapp "github.com/soliton-go/application/internal/application/product"
 	"github.com/soliton-go/application/internal/domain/product"
 )
 
-// ProductHandler 处理 Product 相关的 HTTP 请求。
+// ProductHandler 处理 Product 相关的 HTTP 请求，命令与查询经 CQRS 总线分发。
 type ProductHandler struct {
-	createHandler *productapp.CreateProductHandler
-	updateHandler *productapp.UpdateProductHandler
-	deleteHandler *productapp.DeleteProductHandler
-	getHandler    *productapp.GetProductHandler
-	listHandler   *productapp.ListProductsHandler
+	commands *cqrs.InMemoryCommandBus
+	queries  *cqrs.InMemoryQueryBus
 }
 
 // NewProductHandler 创建 ProductHandler 实例。
-func NewProductHandler(
-	createHandler *productapp.CreateProductHandler,
-	updateHandler *productapp.UpdateProductHandler,
-	deleteHandler *productapp.DeleteProductHandler,
-	getHandler *productapp.GetProductHandler,
-	listHandler *productapp.ListProductsHandler,
-) *ProductHandler {
-	return &ProductHandler{
-		createHandler: createHandler,
-		updateHandler: updateHandler,
-		deleteHandler: deleteHandler,
-		getHandler:    getHandler,
-		listHandler:   listHandler,
-	}
+func NewProductHandler(commands *cqrs.InMemoryCommandBus, queries *cqrs.InMemoryQueryBus) *ProductHandler {
+	return &ProductHandler{commands: commands, queries: queries}
 }
 
 // RegisterRoutes 注册 Product 相关路由。
@@ -104,9 +90,9 @@ func (h *ProductHandler) Create(c *gin.Context) {
 		DiscontinuedAt: req.DiscontinuedAt,
 	}
 
-	entity, err := h.createHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[productapp.CreateProductCommand, *product.Product](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -117,9 +103,9 @@ func (h *ProductHandler) Create(c *gin.Context) {
 func (h *ProductHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 
-	entity, err := h.getHandler.Handle(c.Request.Context(), productapp.GetProductQuery{ID: id})
+	entity, err := cqrs.Query[productapp.GetProductQuery, *product.Product](c.Request.Context(), h.queries, productapp.GetProductQuery{ID: id})
 	if err != nil {
-		NotFound(c, "product not found")
+		DispatchError(c, err, func(c *gin.Context, _ string) { NotFound(c, "product not found") })
 		return
 	}
 
@@ -127,20 +113,42 @@ func (h *ProductHandler) Get(c *gin.Context) {
 }
 
 // List 处理 GET /api/products?page=1&page_size=20&sort_by=id&sort_order=desc
+// 游标分页使用 ?limit=20&cursor=<next_cursor>，加 with_total=true 时统计总数
+// 过滤参数见 ListFilters，例如 ?status=active&created_at_gte=2026-01-01
 func (h *ProductHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	sortBy := c.DefaultQuery("sort_by", "id")
 	sortOrder := c.DefaultQuery("sort_order", "desc")
+	cursor := c.Query("cursor")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	withTotal, _ := strconv.ParseBool(c.Query("with_total"))
 
-	result, err := h.listHandler.Handle(c.Request.Context(), productapp.ListProductsQuery{
+	result, err := cqrs.Query[productapp.ListProductsQuery, *productapp.ListProductsResult](c.Request.Context(), h.queries, productapp.ListProductsQuery{
 		Page:     page,
 		PageSize: pageSize,
 		SortBy:   sortBy,
 		SortOrder: sortOrder,
+		Filters:  ListFilters(c, "id", "created_at", "updated_at", "sku", "name", "slug", "description", "short_description", "brand", "category", "subcategory", "price", "original_price", "cost_price", "discount_percentage", "stock", "reserved_stock", "sold_count", "view_count", "rating", "review_count", "weight", "length", "width", "height", "color", "size", "material", "manufacturer", "country_of_origin", "barcode", "status", "is_featured", "is_new", "is_on_sale", "is_digital", "requires_shipping", "is_taxable", "tax_rate", "min_order_quantity", "max_order_quantity", "tags", "images", "video_url", "published_at", "discontinued_at"),
+		Cursor:    cursor,
+		Limit:     limit,
+		WithTotal: withTotal,
 	})
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
+		return
+	}
+
+	if cursor != "" || limit > 0 {
+		data := gin.H{
+			"items":       productapp.ToProductResponseList(result.Items),
+			"limit":       result.PageSize,
+			"next_cursor": result.NextCursor,
+		}
+		if result.Total >= 0 {
+			data["total"] = result.Total
+		}
+		Success(c, data)
 		return
 	}
 
@@ -210,9 +218,9 @@ func (h *ProductHandler) Update(c *gin.Context) {
 		DiscontinuedAt: req.DiscontinuedAt,
 	}
 
-	entity, err := h.updateHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[productapp.UpdateProductCommand, *product.Product](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -224,8 +232,8 @@ func (h *ProductHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
 	cmd := productapp.DeleteProductCommand{ID: id}
-	if err := h.deleteHandler.Handle(c.Request.Context(), cmd); err != nil {
-		InternalError(c, err.Error())
+	if err := cqrs.Send(c.Request.Context(), h.commands, cmd); err != nil {
+		DispatchError(c, err, InternalError)
 		return
 	}
 
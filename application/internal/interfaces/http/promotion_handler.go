@@ -5,35 +5,21 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/soliton-go/framework/cqrs"
 
 	promotionapp "github.com/soliton-go/application/internal/application/promotion"
 	"github.com/soliton-go/application/internal/domain/promotion"
 )
 
-// PromotionHandler 处理 Promotion 相关的 HTTP 请求。
+// PromotionHandler 处理 Promotion 相关的 HTTP 请求，命令与查询经 CQRS 总线分发。
 type PromotionHandler struct {
-	createHandler *promotionapp.CreatePromotionHandler
-	updateHandler *promotionapp.UpdatePromotionHandler
-	deleteHandler *promotionapp.DeletePromotionHandler
-	getHandler    *promotionapp.GetPromotionHandler
-	listHandler   *promotionapp.ListPromotionsHandler
+	commands *cqrs.InMemoryCommandBus
+	queries  *cqrs.InMemoryQueryBus
 }
 
 // NewPromotionHandler 创建 PromotionHandler 实例。
-func NewPromotionHandler(
-	createHandler *promotionapp.CreatePromotionHandler,
-	updateHandler *promotionapp.UpdatePromotionHandler,
-	deleteHandler *promotionapp.DeletePromotionHandler,
-	getHandler *promotionapp.GetPromotionHandler,
-	listHandler *promotionapp.ListPromotionsHandler,
-) *PromotionHandler {
-	return &PromotionHandler{
-		createHandler: createHandler,
-		updateHandler: updateHandler,
-		deleteHandler: deleteHandler,
-		getHandler:    getHandler,
-		listHandler:   listHandler,
-	}
+func NewPromotionHandler(commands *cqrs.InMemoryCommandBus, queries *cqrs.InMemoryQueryBus) *PromotionHandler {
+	return &PromotionHandler{commands: commands, queries: queries}
 }
 
 // RegisterRoutes 注册 Promotion 相关路由。
@@ -76,9 +62,9 @@ func (h *PromotionHandler) Create(c *gin.Context) {
 		Metadata: req.Metadata,
 	}
 
-	entity, err := h.createHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[promotionapp.CreatePromotionCommand, *promotion.Promotion](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -89,9 +75,9 @@ func (h *PromotionHandler) Create(c *gin.Context) {
 func (h *PromotionHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 
-	entity, err := h.getHandler.Handle(c.Request.Context(), promotionapp.GetPromotionQuery{ID: id})
+	entity, err := cqrs.Query[promotionapp.GetPromotionQuery, *promotion.Promotion](c.Request.Context(), h.queries, promotionapp.GetPromotionQuery{ID: id})
 	if err != nil {
-		NotFound(c, "promotion not found")
+		DispatchError(c, err, func(c *gin.Context, _ string) { NotFound(c, "promotion not found") })
 		return
 	}
 
@@ -99,20 +85,42 @@ func (h *PromotionHandler) Get(c *gin.Context) {
 }
 
 // List 处理 GET /api/promotions?page=1&page_size=20&sort_by=id&sort_order=desc
+// 游标分页使用 ?limit=20&cursor=<next_cursor>，加 with_total=true 时统计总数
+// 过滤参数见 ListFilters，例如 ?status=active&created_at_gte=2026-01-01
 func (h *PromotionHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	sortBy := c.DefaultQuery("sort_by", "id")
 	sortOrder := c.DefaultQuery("sort_order", "desc")
+	cursor := c.Query("cursor")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	withTotal, _ := strconv.ParseBool(c.Query("with_total"))
 
-	result, err := h.listHandler.Handle(c.Request.Context(), promotionapp.ListPromotionsQuery{
+	result, err := cqrs.Query[promotionapp.ListPromotionsQuery, *promotionapp.ListPromotionsResult](c.Request.Context(), h.queries, promotionapp.ListPromotionsQuery{
 		Page:     page,
 		PageSize: pageSize,
 		SortBy:   sortBy,
 		SortOrder: sortOrder,
+		Filters:  ListFilters(c, "id", "created_at", "updated_at", "code", "name", "description", "discount_type", "discount_value", "currency", "min_order_amount", "max_discount_amount", "usage_limit", "used_count", "per_user_limit", "starts_at", "ends_at", "status", "metadata"),
+		Cursor:    cursor,
+		Limit:     limit,
+		WithTotal: withTotal,
 	})
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
+		return
+	}
+
+	if cursor != "" || limit > 0 {
+		data := gin.H{
+			"items":       promotionapp.ToPromotionResponseList(result.Items),
+			"limit":       result.PageSize,
+			"next_cursor": result.NextCursor,
+		}
+		if result.Total >= 0 {
+			data["total"] = result.Total
+		}
+		Success(c, data)
 		return
 	}
 
@@ -154,9 +162,9 @@ func (h *PromotionHandler) Update(c *gin.Context) {
 		Metadata: req.Metadata,
 	}
 
-	entity, err := h.updateHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[promotionapp.UpdatePromotionCommand, *promotion.Promotion](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -168,8 +176,8 @@ func (h *PromotionHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
 	cmd := promotionapp.DeletePromotionCommand{ID: id}
-	if err := h.deleteHandler.Handle(c.Request.Context(), cmd); err != nil {
-		InternalError(c, err.Error())
+	if err := cqrs.Send(c.Request.Context(), h.commands, cmd); err != nil {
+		DispatchError(c, err, InternalError)
 		return
 	}
 
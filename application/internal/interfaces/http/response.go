@@ -37,6 +37,15 @@ func Success(c *gin.Context, data interface{}) {
 	})
 }
 
+// Accepted 返回 202 响应，表示请求已受理、稍后执行（如异步命令）。
+func Accepted(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusAccepted, Response{
+		Code:    CodeSuccess,
+		Message: "accepted",
+		Data:    data,
+	})
+}
+
 // BadRequest 返回 400 错误响应。
 func BadRequest(c *gin.Context, message string) {
 	c.JSON(http.StatusBadRequest, Response{
@@ -76,3 +85,19 @@ func ValidationError(c *gin.Context, message string) {
 		Message: message,
 	})
 }
+
+// Unauthorized 返回 401 未认证响应。
+func Unauthorized(c *gin.Context, message string) {
+	c.JSON(http.StatusUnauthorized, Response{
+		Code:    CodeUnauthorized,
+		Message: message,
+	})
+}
+
+// Forbidden 返回 403 无权限响应。
+func Forbidden(c *gin.Context, message string) {
+	c.JSON(http.StatusForbidden, Response{
+		Code:    CodeForbidden,
+		Message: message,
+	})
+}
@@ -1,39 +1,26 @@
 package http
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/soliton-go/framework/cqrs"
 
 	reviewapp "github.com/soliton-go/application/internal/application/review"
 	"github.com/soliton-go/application/internal/domain/review"
 )
 
-// ReviewHandler 处理 Review 相关的 HTTP 请求。
+// ReviewHandler 处理 Review 相关的 HTTP 请求，命令与查询经 CQRS 总线分发。
 type ReviewHandler struct {
-	createHandler *reviewapp.CreateReviewHandler
-	updateHandler *reviewapp.UpdateReviewHandler
-	deleteHandler *reviewapp.DeleteReviewHandler
-	getHandler    *reviewapp.GetReviewHandler
-	listHandler   *reviewapp.ListReviewsHandler
+	commands *cqrs.InMemoryCommandBus
+	queries  *cqrs.InMemoryQueryBus
 }
 
 // NewReviewHandler 创建 ReviewHandler 实例。
-func NewReviewHandler(
-	createHandler *reviewapp.CreateReviewHandler,
-	updateHandler *reviewapp.UpdateReviewHandler,
-	deleteHandler *reviewapp.DeleteReviewHandler,
-	getHandler *reviewapp.GetReviewHandler,
-	listHandler *reviewapp.ListReviewsHandler,
-) *ReviewHandler {
-	return &ReviewHandler{
-		createHandler: createHandler,
-		updateHandler: updateHandler,
-		deleteHandler: deleteHandler,
-		getHandler:    getHandler,
-		listHandler:   listHandler,
-	}
+func NewReviewHandler(commands *cqrs.InMemoryCommandBus, queries *cqrs.InMemoryQueryBus) *ReviewHandler {
+	return &ReviewHandler{commands: commands, queries: queries}
 }
 
 // RegisterRoutes 注册 Review 相关路由。
@@ -47,6 +34,7 @@ func (h *ReviewHandler) RegisterRoutes(r *gin.Engine) {
 		api.PATCH("/:id", h.Update)
 		api.DELETE("/:id", h.Delete)
 	}
+	r.GET("/api/products/:id/rating", h.ProductRating)
 }
 
 // Create 处理 POST /api/reviews
@@ -72,9 +60,9 @@ func (h *ReviewHandler) Create(c *gin.Context) {
 		Images: req.Images,
 	}
 
-	entity, err := h.createHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[reviewapp.CreateReviewCommand, *review.Review](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -85,9 +73,9 @@ func (h *ReviewHandler) Create(c *gin.Context) {
 func (h *ReviewHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 
-	entity, err := h.getHandler.Handle(c.Request.Context(), reviewapp.GetReviewQuery{ID: id})
+	entity, err := cqrs.Query[reviewapp.GetReviewQuery, *review.Review](c.Request.Context(), h.queries, reviewapp.GetReviewQuery{ID: id})
 	if err != nil {
-		NotFound(c, "review not found")
+		DispatchError(c, err, func(c *gin.Context, _ string) { NotFound(c, "review not found") })
 		return
 	}
 
@@ -95,20 +83,42 @@ func (h *ReviewHandler) Get(c *gin.Context) {
 }
 
 // List 处理 GET /api/reviews?page=1&page_size=20&sort_by=id&sort_order=desc
+// 游标分页使用 ?limit=20&cursor=<next_cursor>，加 with_total=true 时统计总数
+// 过滤参数见 ListFilters，例如 ?status=active&created_at_gte=2026-01-01
 func (h *ReviewHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	sortBy := c.DefaultQuery("sort_by", "id")
 	sortOrder := c.DefaultQuery("sort_order", "desc")
+	cursor := c.Query("cursor")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	withTotal, _ := strconv.ParseBool(c.Query("with_total"))
 
-	result, err := h.listHandler.Handle(c.Request.Context(), reviewapp.ListReviewsQuery{
+	result, err := cqrs.Query[reviewapp.ListReviewsQuery, *reviewapp.ListReviewsResult](c.Request.Context(), h.queries, reviewapp.ListReviewsQuery{
 		Page:     page,
 		PageSize: pageSize,
 		SortBy:   sortBy,
 		SortOrder: sortOrder,
+		Filters:  ListFilters(c, "id", "created_at", "updated_at", "product_id", "user_id", "order_id", "rating", "title", "content", "status", "is_anonymous", "helpful_count", "reply", "images"),
+		Cursor:    cursor,
+		Limit:     limit,
+		WithTotal: withTotal,
 	})
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
+		return
+	}
+
+	if cursor != "" || limit > 0 {
+		data := gin.H{
+			"items":       reviewapp.ToReviewResponseList(result.Items),
+			"limit":       result.PageSize,
+			"next_cursor": result.NextCursor,
+		}
+		if result.Total >= 0 {
+			data["total"] = result.Total
+		}
+		Success(c, data)
 		return
 	}
 
@@ -146,9 +156,9 @@ func (h *ReviewHandler) Update(c *gin.Context) {
 		Images: req.Images,
 	}
 
-	entity, err := h.updateHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[reviewapp.UpdateReviewCommand, *review.Review](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -160,10 +170,28 @@ func (h *ReviewHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
 	cmd := reviewapp.DeleteReviewCommand{ID: id}
-	if err := h.deleteHandler.Handle(c.Request.Context(), cmd); err != nil {
-		InternalError(c, err.Error())
+	if err := cqrs.Send(c.Request.Context(), h.commands, cmd); err != nil {
+		DispatchError(c, err, InternalError)
 		return
 	}
 
 	Success(c, nil)
 }
+
+// ProductRating 处理 GET /api/products/:id/rating，读取投影维护的商品评分。
+func (h *ReviewHandler) ProductRating(c *gin.Context) {
+	query := reviewapp.GetProductRatingQuery{ProductId: c.Param("id")}
+	rating, err := cqrs.Query[reviewapp.GetProductRatingQuery, *reviewapp.ProductRating](c.Request.Context(), h.queries, query)
+	if err != nil {
+		DispatchError(c, err, func(c *gin.Context, message string) {
+			if errors.Is(err, reviewapp.ErrProductRatingNotFound) {
+				NotFound(c, message)
+				return
+			}
+			InternalError(c, message)
+		})
+		return
+	}
+
+	Success(c, rating)
+}
@@ -5,35 +5,21 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/soliton-go/framework/cqrs"
 
 	shippingapp "github.com/soliton-go/application/internal/application/shipping"
 	"github.com/soliton-go/application/internal/domain/shipping"
 )
 
-// ShippingHandler 处理 Shipping 相关的 HTTP 请求。
+// ShippingHandler 处理 Shipping 相关的 HTTP 请求，命令与查询经 CQRS 总线分发。
 type ShippingHandler struct {
-	createHandler *shippingapp.CreateShippingHandler
-	updateHandler *shippingapp.UpdateShippingHandler
-	deleteHandler *shippingapp.DeleteShippingHandler
-	getHandler    *shippingapp.GetShippingHandler
-	listHandler   *shippingapp.ListShippingsHandler
+	commands *cqrs.InMemoryCommandBus
+	queries  *cqrs.InMemoryQueryBus
 }
 
 // NewShippingHandler 创建 ShippingHandler 实例。
-func NewShippingHandler(
-	createHandler *shippingapp.CreateShippingHandler,
-	updateHandler *shippingapp.UpdateShippingHandler,
-	deleteHandler *shippingapp.DeleteShippingHandler,
-	getHandler *shippingapp.GetShippingHandler,
-	listHandler *shippingapp.ListShippingsHandler,
-) *ShippingHandler {
-	return &ShippingHandler{
-		createHandler: createHandler,
-		updateHandler: updateHandler,
-		deleteHandler: deleteHandler,
-		getHandler:    getHandler,
-		listHandler:   listHandler,
-	}
+func NewShippingHandler(commands *cqrs.InMemoryCommandBus, queries *cqrs.InMemoryQueryBus) *ShippingHandler {
+	return &ShippingHandler{commands: commands, queries: queries}
 }
 
 // RegisterRoutes 注册 Shipping 相关路由。
@@ -76,9 +62,9 @@ func (h *ShippingHandler) Create(c *gin.Context) {
 		Notes: req.Notes,
 	}
 
-	entity, err := h.createHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[shippingapp.CreateShippingCommand, *shipping.Shipping](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -89,9 +75,9 @@ func (h *ShippingHandler) Create(c *gin.Context) {
 func (h *ShippingHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 
-	entity, err := h.getHandler.Handle(c.Request.Context(), shippingapp.GetShippingQuery{ID: id})
+	entity, err := cqrs.Query[shippingapp.GetShippingQuery, *shipping.Shipping](c.Request.Context(), h.queries, shippingapp.GetShippingQuery{ID: id})
 	if err != nil {
-		NotFound(c, "shipping not found")
+		DispatchError(c, err, func(c *gin.Context, _ string) { NotFound(c, "shipping not found") })
 		return
 	}
 
@@ -99,20 +85,42 @@ func (h *ShippingHandler) Get(c *gin.Context) {
 }
 
 // List 处理 GET /api/shippings?page=1&page_size=20&sort_by=id&sort_order=desc
+// 游标分页使用 ?limit=20&cursor=<next_cursor>，加 with_total=true 时统计总数
+// 过滤参数见 ListFilters，例如 ?status=active&created_at_gte=2026-01-01
 func (h *ShippingHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	sortBy := c.DefaultQuery("sort_by", "id")
 	sortOrder := c.DefaultQuery("sort_order", "desc")
+	cursor := c.Query("cursor")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	withTotal, _ := strconv.ParseBool(c.Query("with_total"))
 
-	result, err := h.listHandler.Handle(c.Request.Context(), shippingapp.ListShippingsQuery{
+	result, err := cqrs.Query[shippingapp.ListShippingsQuery, *shippingapp.ListShippingsResult](c.Request.Context(), h.queries, shippingapp.ListShippingsQuery{
 		Page:     page,
 		PageSize: pageSize,
 		SortBy:   sortBy,
 		SortOrder: sortOrder,
+		Filters:  ListFilters(c, "id", "created_at", "updated_at", "order_id", "carrier", "shipping_method", "tracking_number", "status", "shipped_at", "delivered_at", "receiver_name", "receiver_phone", "receiver_address", "receiver_city", "receiver_state", "receiver_country", "receiver_postal_code", "notes"),
+		Cursor:    cursor,
+		Limit:     limit,
+		WithTotal: withTotal,
 	})
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
+		return
+	}
+
+	if cursor != "" || limit > 0 {
+		data := gin.H{
+			"items":       shippingapp.ToShippingResponseList(result.Items),
+			"limit":       result.PageSize,
+			"next_cursor": result.NextCursor,
+		}
+		if result.Total >= 0 {
+			data["total"] = result.Total
+		}
+		Success(c, data)
 		return
 	}
 
@@ -154,9 +162,9 @@ func (h *ShippingHandler) Update(c *gin.Context) {
 		Notes: req.Notes,
 	}
 
-	entity, err := h.updateHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[shippingapp.UpdateShippingCommand, *shipping.Shipping](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -168,8 +176,8 @@ func (h *ShippingHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
 	cmd := shippingapp.DeleteShippingCommand{ID: id}
-	if err := h.deleteHandler.Handle(c.Request.Context(), cmd); err != nil {
-		InternalError(c, err.Error())
+	if err := cqrs.Send(c.Request.Context(), h.commands, cmd); err != nil {
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -5,34 +5,21 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/soliton-go/framework/cqrs"
 
 	userapp "github.com/soliton-go/application/internal/application/user"
+	"github.com/soliton-go/application/internal/domain/user"
 )
 
-// UserHandler 处理 User 相关的 HTTP 请求。
+// UserHandler 处理 User 相关的 HTTP 请求，命令与查询经 CQRS 总线分发。
 type UserHandler struct {
-	createHandler *userapp.CreateUserHandler
-	updateHandler *userapp.UpdateUserHandler
-	deleteHandler *userapp.DeleteUserHandler
-	getHandler    *userapp.GetUserHandler
-	listHandler   *userapp.ListUsersHandler
+	commands *cqrs.InMemoryCommandBus
+	queries  *cqrs.InMemoryQueryBus
 }
 
 // NewUserHandler 创建 UserHandler 实例。
-func NewUserHandler(
-	createHandler *userapp.CreateUserHandler,
-	updateHandler *userapp.UpdateUserHandler,
-	deleteHandler *userapp.DeleteUserHandler,
-	getHandler *userapp.GetUserHandler,
-	listHandler *userapp.ListUsersHandler,
-) *UserHandler {
-	return &UserHandler{
-		createHandler: createHandler,
-		updateHandler: updateHandler,
-		deleteHandler: deleteHandler,
-		getHandler:    getHandler,
-		listHandler:   listHandler,
-	}
+func NewUserHandler(commands *cqrs.InMemoryCommandBus, queries *cqrs.InMemoryQueryBus) *UserHandler {
+	return &UserHandler{commands: commands, queries: queries}
 }
 
 // RegisterRoutes 注册 User 相关路由。
@@ -62,9 +49,9 @@ func (h *UserHandler) Create(c *gin.Context) {
 		Email: req.Email,
 	}
 
-	entity, err := h.createHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[userapp.CreateUserCommand, *user.User](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -75,9 +62,9 @@ func (h *UserHandler) Create(c *gin.Context) {
 func (h *UserHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 
-	entity, err := h.getHandler.Handle(c.Request.Context(), userapp.GetUserQuery{ID: id})
+	entity, err := cqrs.Query[userapp.GetUserQuery, *user.User](c.Request.Context(), h.queries, userapp.GetUserQuery{ID: id})
 	if err != nil {
-		NotFound(c, "user not found")
+		DispatchError(c, err, func(c *gin.Context, _ string) { NotFound(c, "user not found") })
 		return
 	}
 
@@ -85,20 +72,42 @@ func (h *UserHandler) Get(c *gin.Context) {
 }
 
 // List 处理 GET /api/users?page=1&page_size=20&sort_by=id&sort_order=desc
+// 游标分页使用 ?limit=20&cursor=<next_cursor>，加 with_total=true 时统计总数
+// 过滤参数见 ListFilters，例如 ?status=active&created_at_gte=2026-01-01
 func (h *UserHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	sortBy := c.DefaultQuery("sort_by", "id")
 	sortOrder := c.DefaultQuery("sort_order", "desc")
+	cursor := c.Query("cursor")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	withTotal, _ := strconv.ParseBool(c.Query("with_total"))
 
-	result, err := h.listHandler.Handle(c.Request.Context(), userapp.ListUsersQuery{
+	result, err := cqrs.Query[userapp.ListUsersQuery, *userapp.ListUsersResult](c.Request.Context(), h.queries, userapp.ListUsersQuery{
 		Page:     page,
 		PageSize: pageSize,
 		SortBy:   sortBy,
 		SortOrder: sortOrder,
+		Filters:  ListFilters(c, "id", "created_at", "updated_at", "username", "email"),
+		Cursor:    cursor,
+		Limit:     limit,
+		WithTotal: withTotal,
 	})
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
+		return
+	}
+
+	if cursor != "" || limit > 0 {
+		data := gin.H{
+			"items":       userapp.ToUserResponseList(result.Items),
+			"limit":       result.PageSize,
+			"next_cursor": result.NextCursor,
+		}
+		if result.Total >= 0 {
+			data["total"] = result.Total
+		}
+		Success(c, data)
 		return
 	}
 
@@ -127,9 +136,9 @@ func (h *UserHandler) Update(c *gin.Context) {
 		Email: req.Email,
 	}
 
-	entity, err := h.updateHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[userapp.UpdateUserCommand, *user.User](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -141,8 +150,8 @@ func (h *UserHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
 	cmd := userapp.DeleteUserCommand{ID: id}
-	if err := h.deleteHandler.Handle(c.Request.Context(), cmd); err != nil {
-		InternalError(c, err.Error())
+	if err := cqrs.Send(c.Request.Context(), h.commands, cmd); err != nil {
+		DispatchError(c, err, InternalError)
 		return
 	}
 
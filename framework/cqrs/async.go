@@ -0,0 +1,147 @@
+package cqrs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/soliton-go/framework/event"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrAsyncNotConfigured is returned by DispatchAsync when the bus was created without WithAsync.
+	ErrAsyncNotConfigured = errors.New("asynchronous commands not configured")
+	// ErrCommandNotFound is returned by GetCommandHandler for unknown command IDs.
+	ErrCommandNotFound = errors.New("command not found")
+)
+
+// CommandStatus is the state of an asynchronous command.
+type CommandStatus string
+
+// Command statuses.
+const (
+	CommandPending   CommandStatus = "pending"
+	CommandRunning   CommandStatus = "running"
+	CommandSucceeded CommandStatus = "succeeded"
+	CommandFailed    CommandStatus = "failed"
+)
+
+// AsyncCommand is the database row of a command dispatched with DispatchAsync.
+type AsyncCommand struct {
+	ID            string          `gorm:"primaryKey;size:64" json:"id"`
+	Name          string          `gorm:"size:255;not null" json:"name"` // registered type name, see Message.Name
+	Payload       []byte          `gorm:"not null" json:"-"`
+	Status        CommandStatus   `gorm:"size:20;not null;index" json:"status"`
+	Result        json.RawMessage `json:"result,omitempty"` // JSON result of a succeeded command
+	Error         string          `gorm:"type:text" json:"error,omitempty"`
+	Attempts      int             `gorm:"not null;default:0" json:"attempts"`
+	CallerID      string          `gorm:"size:255;index" json:"-"`
+	CallerRoles   string          `gorm:"size:1024" json:"-"`
+	CorrelationID string          `gorm:"size:255" json:"correlation_id,omitempty"`
+	LockedUntil   *time.Time      `gorm:"index" json:"-"`
+	CreatedAt     time.Time       `gorm:"autoCreateTime;index" json:"created_at"`
+	StartedAt     *time.Time      `json:"started_at,omitempty"`
+	FinishedAt    *time.Time      `json:"finished_at,omitempty"`
+}
+
+// TableName overrides the GORM table name.
+func (AsyncCommand) TableName() string {
+	return "async_commands"
+}
+
+// MigrateAsyncCommands creates the asynchronous command table if it does not exist.
+func MigrateAsyncCommands(db *gorm.DB) error {
+	return orm.Primary(db).AutoMigrate(&AsyncCommand{})
+}
+
+// WithAsync lets the command bus accept commands through DispatchAsync, stored in the
+// async_commands table on db until a CommandWorker runs them.
+func WithAsync(db *gorm.DB) BusOption {
+	return func(r *registry) {
+		r.jobs = db
+	}
+}
+
+// DispatchAsync stores cmd and returns its ID without running it; a CommandWorker runs it later
+// through the registered handler and the bus middleware. The command is JSON-encoded, so its
+// exported fields must survive a JSON round trip.
+//
+// It joins the transaction carried by ctx (see orm.Transaction). The caller and correlation ID
+// of ctx are stored with the command and restored when it runs.
+func (b *InMemoryCommandBus) DispatchAsync(ctx context.Context, cmd any) (string, error) {
+	if b.jobs == nil {
+		return "", ErrAsyncNotConfigured
+	}
+	msgType := reflect.TypeOf(cmd)
+	if _, err := b.lookup(msgType); err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal command %s: %w", msgType, err)
+	}
+
+	row := AsyncCommand{
+		ID:            uuid.NewString(),
+		Name:          msgType.String(),
+		Payload:       payload,
+		Status:        CommandPending,
+		CorrelationID: event.CorrelationIDFromContext(ctx),
+	}
+	if caller, ok := CallerFromContext(ctx); ok {
+		row.CallerID = caller.ID
+		row.CallerRoles = strings.Join(caller.Roles, ",")
+	}
+	if err := orm.Conn(ctx, b.jobs).Create(&row).Error; err != nil {
+		return "", err
+	}
+	return row.ID, nil
+}
+
+type commandIDKey struct{}
+
+// CommandIDFromContext returns the ID of the asynchronous command being handled, if any.
+func CommandIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(commandIDKey{}).(string)
+	return id
+}
+
+// GetCommandQuery asks for the status of an asynchronous command.
+type GetCommandQuery struct {
+	ID string
+}
+
+// GetCommandHandler handles GetCommandQuery. A command sent by an authenticated caller is only
+// visible to the same caller.
+type GetCommandHandler struct {
+	db *gorm.DB
+}
+
+// NewGetCommandHandler creates a GetCommandHandler.
+func NewGetCommandHandler(db *gorm.DB) *GetCommandHandler {
+	return &GetCommandHandler{db: db}
+}
+
+// Handle returns the command, failing with an error wrapping ErrCommandNotFound if it does not
+// exist or belongs to another caller.
+func (h *GetCommandHandler) Handle(ctx context.Context, query GetCommandQuery) (*AsyncCommand, error) {
+	var row AsyncCommand
+	err := orm.Conn(ctx, h.db).Where("id = ?", query.ID).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrCommandNotFound, query.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if caller, ok := CallerFromContext(ctx); row.CallerID != "" && (!ok || caller.ID != row.CallerID) {
+		return nil, fmt.Errorf("%w: %s", ErrCommandNotFound, query.ID)
+	}
+	return &row, nil
+}
@@ -2,90 +2,267 @@ package cqrs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrHandlerNotFound is returned when no handler is registered for a command or query type.
+	ErrHandlerNotFound = errors.New("no handler registered")
+	// ErrHandlerAlreadyRegistered is returned when a second handler is registered for the same type.
+	ErrHandlerAlreadyRegistered = errors.New("handler already registered")
+	// ErrResultType is returned when a dispatch expects a different result type than the handler returns.
+	ErrResultType = errors.New("unexpected result type")
+	// ErrInvalidHandler is returned when a handler registered by reflection has an unsupported signature.
+	ErrInvalidHandler = errors.New("invalid handler")
 )
 
+// handlerFunc is a registered handler with its message and result types erased.
+type handlerFunc func(ctx context.Context, msg any) (any, error)
+
+type registration struct {
+	handle     handlerFunc
+	resultType reflect.Type
+}
+
+// registry maps message types to handlers. It is safe for concurrent use.
+type registry struct {
+	kind       string
+	middleware []Middleware
+	jobs       *gorm.DB
+	mu         sync.RWMutex
+	handlers   map[reflect.Type]registration
+	names      map[string]reflect.Type
+}
+
+func (r *registry) init(kind string, opts []BusOption) {
+	r.kind = kind
+	r.handlers = make(map[reflect.Type]registration)
+	r.names = make(map[string]reflect.Type)
+	for _, opt := range opts {
+		opt(r)
+	}
+}
+
+func (r *registry) register(msgType, resultType reflect.Type, handle handlerFunc) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.handlers[msgType]; exists {
+		return fmt.Errorf("%w for %s %s", ErrHandlerAlreadyRegistered, r.kind, msgType)
+	}
+	// Asynchronous commands are stored by type name, so the name must identify one type.
+	if _, exists := r.names[msgType.String()]; exists {
+		return fmt.Errorf("%w for %s %s: another type has the same name", ErrHandlerAlreadyRegistered, r.kind, msgType)
+	}
+	r.names[msgType.String()] = msgType
+	r.handlers[msgType] = registration{handle: r.chain(msgType, handle), resultType: resultType}
+	return nil
+}
+
+func (r *registry) lookup(msgType reflect.Type) (registration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reg, ok := r.handlers[msgType]
+	if !ok {
+		return registration{}, fmt.Errorf("%w for %s %s", ErrHandlerNotFound, r.kind, msgType)
+	}
+	return reg, nil
+}
+
+// lookupName returns the type registered under name and its handler.
+func (r *registry) lookupName(name string) (reflect.Type, registration, error) {
+	r.mu.RLock()
+	msgType, ok := r.names[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, registration{}, fmt.Errorf("%w for %s %s", ErrHandlerNotFound, r.kind, name)
+	}
+	reg, err := r.lookup(msgType)
+	return msgType, reg, err
+}
+
+// dispatch calls the handler of msg and checks that it returns R.
+func dispatch[R any](ctx context.Context, r *registry, msgType reflect.Type, msg any) (R, error) {
+	var zero R
+	reg, err := r.lookup(msgType)
+	if err != nil {
+		return zero, err
+	}
+	if want := reflect.TypeFor[R](); reg.resultType != want {
+		return zero, fmt.Errorf("%w: %s %s returns %s, not %s", ErrResultType, r.kind, msgType, reg.resultType, want)
+	}
+	result, err := reg.handle(ctx, msg)
+	if err != nil {
+		return zero, err
+	}
+	if result == nil {
+		return zero, nil
+	}
+	return result.(R), nil
+}
+
 // CommandBus dispatches commands to handlers.
+// Prefer the generic RegisterCommand and Dispatch functions, which check handler types at compile time.
 type CommandBus interface {
-	Register(cmd any, handler any)
+	Register(cmd any, handler any) error
 	Dispatch(ctx context.Context, cmd any) error
 }
 
-// InMemoryCommandBus is a simple in-memory implementation.
+// InMemoryCommandBus dispatches commands to handlers in the caller's goroutine.
+// Each command type has one handler. It is safe for concurrent use.
 type InMemoryCommandBus struct {
-	handlers map[reflect.Type]reflect.Value
+	registry
 }
 
-func NewCommandBus() *InMemoryCommandBus {
-	return &InMemoryCommandBus{handlers: make(map[reflect.Type]reflect.Value)}
+// NewCommandBus creates an InMemoryCommandBus. Options add middleware around every dispatch.
+func NewCommandBus(opts ...BusOption) *InMemoryCommandBus {
+	b := &InMemoryCommandBus{}
+	b.init(KindCommand, opts)
+	return b
 }
 
-func (b *InMemoryCommandBus) Register(cmd any, handler any) {
-	cmdType := reflect.TypeOf(cmd)
-	// handler should be func(ctx, cmd) error
-	b.handlers[cmdType] = reflect.ValueOf(handler)
+// RegisterCommand registers the handler of commands of type C, which returns a result of type R,
+// e.g. RegisterCommand(bus, createOrderHandler.Handle).
+func RegisterCommand[C, R any](bus *InMemoryCommandBus, handler func(ctx context.Context, cmd C) (R, error)) error {
+	return bus.register(reflect.TypeFor[C](), reflect.TypeFor[R](), func(ctx context.Context, cmd any) (any, error) {
+		return handler(ctx, cmd.(C))
+	})
 }
 
-func (b *InMemoryCommandBus) Dispatch(ctx context.Context, cmd any) error {
-	cmdType := reflect.TypeOf(cmd)
-	handler, ok := b.handlers[cmdType]
-	if !ok {
-		return fmt.Errorf("no handler registered for command: %s", cmdType)
-	}
+// RegisterVoidCommand registers the handler of commands of type C that return no result.
+// Dispatch them with Send, or with Dispatch[C, struct{}].
+func RegisterVoidCommand[C any](bus *InMemoryCommandBus, handler func(ctx context.Context, cmd C) error) error {
+	return bus.register(reflect.TypeFor[C](), reflect.TypeFor[struct{}](), func(ctx context.Context, cmd any) (any, error) {
+		return struct{}{}, handler(ctx, cmd.(C))
+	})
+}
+
+// Dispatch runs the handler of cmd and returns its result.
+// It fails with ErrResultType if the handler registered for C does not return R.
+func Dispatch[C, R any](ctx context.Context, bus *InMemoryCommandBus, cmd C) (R, error) {
+	return dispatch[R](ctx, &bus.registry, reflect.TypeFor[C](), cmd)
+}
 
-	args := []reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(cmd)}
-	results := handler.Call(args)
+// Send runs the handler of cmd and discards its result.
+func Send[C any](ctx context.Context, bus *InMemoryCommandBus, cmd C) error {
+	return bus.Dispatch(ctx, cmd)
+}
 
-	if len(results) > 0 {
-		errVal := results[0]
-		if !errVal.IsNil() {
-			return errVal.Interface().(error)
-		}
+// Register registers handler for the type of cmd. The handler must be a function of the form
+// func(context.Context, C) error or func(context.Context, C) (R, error); other shapes are rejected here
+// rather than failing at dispatch.
+//
+// Deprecated: use RegisterCommand or RegisterVoidCommand.
+func (b *InMemoryCommandBus) Register(cmd any, handler any) error {
+	msgType, resultType, handle, err := reflectHandler(reflect.TypeOf(cmd), handler)
+	if err != nil {
+		return err
 	}
-	return nil
+	return b.register(msgType, resultType, handle)
+}
+
+// Dispatch runs the handler of cmd, discarding its result.
+func (b *InMemoryCommandBus) Dispatch(ctx context.Context, cmd any) error {
+	reg, err := b.lookup(reflect.TypeOf(cmd))
+	if err != nil {
+		return err
+	}
+	_, err = reg.handle(ctx, cmd)
+	return err
 }
 
 // QueryBus dispatches queries to handlers.
+// Prefer the generic RegisterQuery and Query functions, which check handler types at compile time.
 type QueryBus interface {
-	Register(query any, handler any)
+	Register(query any, handler any) error
 	Dispatch(ctx context.Context, query any) (any, error)
 }
 
+// InMemoryQueryBus dispatches queries to handlers in the caller's goroutine.
+// Each query type has one handler. It is safe for concurrent use.
 type InMemoryQueryBus struct {
-	handlers map[reflect.Type]reflect.Value
+	registry
 }
 
-func NewQueryBus() *InMemoryQueryBus {
-	return &InMemoryQueryBus{handlers: make(map[reflect.Type]reflect.Value)}
+// NewQueryBus creates an InMemoryQueryBus. Options add middleware around every dispatch.
+func NewQueryBus(opts ...BusOption) *InMemoryQueryBus {
+	b := &InMemoryQueryBus{}
+	b.init(KindQuery, opts)
+	return b
 }
 
-func (b *InMemoryQueryBus) Register(query any, handler any) {
-	queryType := reflect.TypeOf(query)
-	b.handlers[queryType] = reflect.ValueOf(handler)
+// RegisterQuery registers the handler of queries of type Q, which returns a result of type R,
+// e.g. RegisterQuery(bus, getOrderHandler.Handle).
+func RegisterQuery[Q, R any](bus *InMemoryQueryBus, handler func(ctx context.Context, query Q) (R, error)) error {
+	return bus.register(reflect.TypeFor[Q](), reflect.TypeFor[R](), func(ctx context.Context, query any) (any, error) {
+		return handler(ctx, query.(Q))
+	})
 }
 
+// Query runs the handler of query and returns its result.
+// It fails with ErrResultType if the handler registered for Q does not return R.
+func Query[Q, R any](ctx context.Context, bus *InMemoryQueryBus, query Q) (R, error) {
+	return dispatch[R](ctx, &bus.registry, reflect.TypeFor[Q](), query)
+}
+
+// Register registers handler for the type of query. The handler must be a function of the form
+// func(context.Context, Q) (R, error).
+//
+// Deprecated: use RegisterQuery.
+func (b *InMemoryQueryBus) Register(query any, handler any) error {
+	msgType, resultType, handle, err := reflectHandler(reflect.TypeOf(query), handler)
+	if err != nil {
+		return err
+	}
+	if resultType == reflect.TypeFor[struct{}]() {
+		return fmt.Errorf("%w: query handler for %s must return a result", ErrInvalidHandler, msgType)
+	}
+	return b.register(msgType, resultType, handle)
+}
+
+// Dispatch runs the handler of query and returns its result.
 func (b *InMemoryQueryBus) Dispatch(ctx context.Context, query any) (any, error) {
-	queryType := reflect.TypeOf(query)
-	handler, ok := b.handlers[queryType]
-	if !ok {
-		return nil, fmt.Errorf("no handler registered for query: %s", queryType)
+	reg, err := b.lookup(reflect.TypeOf(query))
+	if err != nil {
+		return nil, err
 	}
+	return reg.handle(ctx, query)
+}
 
-	args := []reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(query)}
-	results := handler.Call(args)
+var (
+	contextType = reflect.TypeFor[context.Context]()
+	errorType   = reflect.TypeFor[error]()
+)
 
-	// Result is (Response, error)
-	var res any
-	if len(results) > 0 {
-		res = results[0].Interface()
+// reflectHandler validates a handler registered without type parameters and wraps it.
+// Handlers returning only an error get the result type struct{}.
+func reflectHandler(msgType reflect.Type, handler any) (reflect.Type, reflect.Type, handlerFunc, error) {
+	fn := reflect.ValueOf(handler)
+	if msgType == nil || !fn.IsValid() || fn.Kind() != reflect.Func || fn.IsNil() {
+		return nil, nil, nil, fmt.Errorf("%w: %T for %v is not a function", ErrInvalidHandler, handler, msgType)
+	}
+	ft := fn.Type()
+	if ft.NumIn() != 2 || ft.In(0) != contextType || !msgType.AssignableTo(ft.In(1)) {
+		return nil, nil, nil, fmt.Errorf("%w: %s must accept (context.Context, %s)", ErrInvalidHandler, ft, msgType)
 	}
 
-	if len(results) > 1 {
-		errVal := results[1]
-		if !errVal.IsNil() {
-			return nil, errVal.Interface().(error)
-		}
+	switch {
+	case ft.NumOut() == 1 && ft.Out(0) == errorType:
+		return msgType, reflect.TypeFor[struct{}](), func(ctx context.Context, msg any) (any, error) {
+			err, _ := fn.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(msg)})[0].Interface().(error)
+			return struct{}{}, err
+		}, nil
+	case ft.NumOut() == 2 && ft.Out(1) == errorType:
+		return msgType, ft.Out(0), func(ctx context.Context, msg any) (any, error) {
+			out := fn.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(msg)})
+			err, _ := out[1].Interface().(error)
+			return out[0].Interface(), err
+		}, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("%w: %s must return error or (result, error)", ErrInvalidHandler, ft)
 	}
-	return res, nil
 }
@@ -0,0 +1,114 @@
+package cqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type createOrder struct {
+	Total int
+}
+
+type getOrder struct {
+	ID string
+}
+
+type order struct {
+	ID    string
+	Total int
+}
+
+func TestDispatchAndQueryReturnTypedResults(t *testing.T) {
+	ctx := context.Background()
+	commands := NewCommandBus()
+	err := RegisterCommand(commands, func(ctx context.Context, cmd createOrder) (order, error) {
+		return order{ID: "order-1", Total: cmd.Total}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := Dispatch[createOrder, order](ctx, commands, createOrder{Total: 10})
+	if err != nil || created != (order{ID: "order-1", Total: 10}) {
+		t.Errorf("Dispatch = %+v, %v; want order-1", created, err)
+	}
+	if err := Send(ctx, commands, createOrder{Total: 20}); err != nil {
+		t.Errorf("Send = %v", err)
+	}
+	if _, err := Dispatch[createOrder, string](ctx, commands, createOrder{}); !errors.Is(err, ErrResultType) {
+		t.Errorf("Dispatch with the wrong result type = %v, want ErrResultType", err)
+	}
+
+	queries := NewQueryBus()
+	err = RegisterQuery(queries, func(ctx context.Context, q getOrder) (*order, error) {
+		return &order{ID: q.ID}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := Query[getOrder, *order](ctx, queries, getOrder{ID: "order-2"})
+	if err != nil || found == nil || found.ID != "order-2" {
+		t.Errorf("Query = %+v, %v; want order-2", found, err)
+	}
+	if _, err := Query[getOrder, order](ctx, queries, getOrder{}); !errors.Is(err, ErrResultType) {
+		t.Errorf("Query with the wrong result type = %v, want ErrResultType", err)
+	}
+}
+
+func TestDispatchWithoutHandler(t *testing.T) {
+	ctx := context.Background()
+	commands := NewCommandBus()
+	queries := NewQueryBus()
+
+	if _, err := Dispatch[createOrder, order](ctx, commands, createOrder{}); !errors.Is(err, ErrHandlerNotFound) {
+		t.Errorf("Dispatch = %v, want ErrHandlerNotFound", err)
+	}
+	if err := commands.Dispatch(ctx, createOrder{}); !errors.Is(err, ErrHandlerNotFound) {
+		t.Errorf("CommandBus.Dispatch = %v, want ErrHandlerNotFound", err)
+	}
+	if _, err := Query[getOrder, order](ctx, queries, getOrder{}); !errors.Is(err, ErrHandlerNotFound) {
+		t.Errorf("Query = %v, want ErrHandlerNotFound", err)
+	}
+	if _, err := queries.Dispatch(ctx, getOrder{}); !errors.Is(err, ErrHandlerNotFound) {
+		t.Errorf("QueryBus.Dispatch = %v, want ErrHandlerNotFound", err)
+	}
+}
+
+func TestRegisterRejectsDuplicates(t *testing.T) {
+	commands := NewCommandBus()
+	handle := func(ctx context.Context, cmd createOrder) error { return nil }
+	if err := RegisterVoidCommand(commands, handle); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		register func() error
+	}{
+		{"same type", func() error { return RegisterVoidCommand(commands, handle) }},
+		{"same type with another result", func() error {
+			return RegisterCommand(commands, func(ctx context.Context, cmd createOrder) (order, error) { return order{}, nil })
+		}},
+		{"same type by reflection", func() error { return commands.Register(createOrder{}, handle) }},
+		{"another type with the same name", func() error {
+			type createOrder struct{}
+			return RegisterVoidCommand(commands, func(ctx context.Context, cmd createOrder) error { return nil })
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.register(); !errors.Is(err, ErrHandlerAlreadyRegistered) {
+				t.Errorf("register = %v, want ErrHandlerAlreadyRegistered", err)
+			}
+		})
+	}
+
+	queries := NewQueryBus()
+	query := func(ctx context.Context, q getOrder) (order, error) { return order{}, nil }
+	if err := RegisterQuery(queries, query); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterQuery(queries, query); !errors.Is(err, ErrHandlerAlreadyRegistered) {
+		t.Errorf("RegisterQuery twice = %v, want ErrHandlerAlreadyRegistered", err)
+	}
+}
@@ -0,0 +1,250 @@
+package cqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/soliton-go/framework/orm"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidMessage is returned when Validation rejects a command or query.
+	ErrInvalidMessage = errors.New("invalid message")
+	// ErrUnauthenticated is returned when Authorization rejects a message sent without a caller.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is returned when Authorization rejects a message sent by a caller.
+	ErrForbidden = errors.New("forbidden")
+	// ErrPanic is returned when Recoverer catches a panicking handler.
+	ErrPanic = errors.New("panic in handler")
+)
+
+// Message kinds.
+const (
+	KindCommand = "command"
+	KindQuery   = "query"
+)
+
+// Message is what bus middleware operates on.
+type Message struct {
+	// Kind is KindCommand or KindQuery.
+	Kind string
+	// Name is the Go type of the payload, e.g. "orderapp.CreateOrderCommand".
+	Name string
+	// Payload is the command or query being dispatched.
+	Payload any
+}
+
+// HandlerFunc handles a dispatched message and returns the handler's result.
+type HandlerFunc func(ctx context.Context, msg Message) (any, error)
+
+// Middleware wraps every dispatch on a bus. The first middleware registered is the outermost.
+// Middleware must return the result of next unchanged, or a nil result with an error.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// BusOption is a functional option for NewCommandBus and NewQueryBus.
+type BusOption func(*registry)
+
+// WithMiddleware appends middleware. It applies to handlers registered after the bus is created,
+// so pass it to the constructor rather than adding it later.
+func WithMiddleware(mw ...Middleware) BusOption {
+	return func(r *registry) {
+		r.middleware = append(r.middleware, mw...)
+	}
+}
+
+// chain wraps a registered handler in the bus middleware.
+func (r *registry) chain(msgType reflect.Type, handle handlerFunc) handlerFunc {
+	if len(r.middleware) == 0 {
+		return handle
+	}
+	final := HandlerFunc(func(ctx context.Context, msg Message) (any, error) {
+		return handle(ctx, msg.Payload)
+	})
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		final = r.middleware[i](final)
+	}
+	kind, name := r.kind, msgType.String()
+	return func(ctx context.Context, payload any) (any, error) {
+		return final(ctx, Message{Kind: kind, Name: name, Payload: payload})
+	}
+}
+
+// Recoverer turns a panicking handler into an error wrapping ErrPanic instead of crashing the process.
+// The panic value and stack are logged to logger only, so that the error, which may reach API
+// responses, does not expose them.
+func Recoverer(logger *zap.Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg Message) (result any, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("recovered from "+msg.Kind+" handler panic",
+						zap.String("kind", msg.Kind),
+						zap.String("name", msg.Name),
+						zap.Any("panic", r),
+						zap.ByteString("stack", debug.Stack()))
+					result, err = nil, fmt.Errorf("%w for %s %s", ErrPanic, msg.Kind, msg.Name)
+				}
+			}()
+			return next(ctx, msg)
+		}
+	}
+}
+
+// Logging logs every dispatch with its duration. Failures are logged as warnings, panics as errors.
+func Logging(logger *zap.Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg Message) (any, error) {
+			start := time.Now()
+			result, err := next(ctx, msg)
+			fields := []zap.Field{
+				zap.String("kind", msg.Kind),
+				zap.String("name", msg.Name),
+				zap.Duration("duration", time.Since(start)),
+			}
+			if caller, ok := CallerFromContext(ctx); ok {
+				fields = append(fields, zap.String("caller_id", caller.ID))
+			}
+			switch {
+			case err == nil:
+				logger.Debug(msg.Kind+" handled", fields...)
+			case errors.Is(err, ErrPanic):
+				logger.Error(msg.Kind+" handler panicked", append(fields, zap.Error(err))...)
+			default:
+				logger.Warn(msg.Kind+" failed", append(fields, zap.Error(err))...)
+			}
+			return result, err
+		}
+	}
+}
+
+// Validator checks a command or query before it is handled.
+type Validator func(payload any) error
+
+// ValidateStruct returns a Validator that checks the `validate` struct tags of the payload with
+// go-playground/validator, e.g. `validate:"required,gt=0"`. Payloads that are not structs pass.
+func ValidateStruct() Validator {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	return func(payload any) error {
+		t := reflect.TypeOf(payload)
+		if t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			return nil
+		}
+		return validate.Struct(payload)
+	}
+}
+
+// ValidateSelf is a Validator that calls the payload's own Validate() error method, if it has one.
+func ValidateSelf(payload any) error {
+	if v, ok := payload.(interface{ Validate() error }); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// Validation rejects messages that fail any of the validators with an error wrapping ErrInvalidMessage,
+// without calling the handler.
+func Validation(validators ...Validator) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg Message) (any, error) {
+			for _, validate := range validators {
+				if err := validate(msg.Payload); err != nil {
+					return nil, fmt.Errorf("%w: %s %s: %w", ErrInvalidMessage, msg.Kind, msg.Name, err)
+				}
+			}
+			return next(ctx, msg)
+		}
+	}
+}
+
+// Transaction runs every command handler inside a transaction on db, passed on through the context
+// (see orm.Transaction), so that repositories, the outbox and synchronous event handlers commit or
+// roll back together. Queries are not wrapped.
+func Transaction(db *gorm.DB) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg Message) (any, error) {
+			if msg.Kind != KindCommand {
+				return next(ctx, msg)
+			}
+			var result any
+			err := orm.Transaction(ctx, db, func(ctx context.Context) error {
+				var err error
+				result, err = next(ctx, msg)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+	}
+}
+
+// Caller identifies who sends a command or query.
+type Caller struct {
+	ID    string
+	Roles []string
+}
+
+// HasRole reports whether the caller has the role.
+func (c Caller) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+type callerKey struct{}
+
+// WithCaller returns a context carrying the caller, e.g. set by an HTTP authentication middleware.
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the caller stored in ctx, if any.
+func CallerFromContext(ctx context.Context) (Caller, bool) {
+	caller, ok := ctx.Value(callerKey{}).(Caller)
+	return caller, ok
+}
+
+// Authorizer decides whether the caller may send msg. authenticated is false if the context
+// carries no caller, in which case caller is the zero Caller.
+type Authorizer func(ctx context.Context, caller Caller, authenticated bool, msg Message) bool
+
+// RequireRoles returns an Authorizer that requires an authenticated caller for every message,
+// and one of the listed roles for the messages named in rules, e.g.
+//
+//	cqrs.RequireRoles(map[string][]string{"orderapp.DeleteOrderCommand": {"admin"}})
+func RequireRoles(rules map[string][]string) Authorizer {
+	return func(_ context.Context, caller Caller, authenticated bool, msg Message) bool {
+		if !authenticated {
+			return false
+		}
+		roles, ok := rules[msg.Name]
+		return !ok || slices.ContainsFunc(roles, caller.HasRole)
+	}
+}
+
+// Authorization checks every message against the caller from the context. Rejected messages fail
+// with an error wrapping ErrUnauthenticated if there is no caller, ErrForbidden otherwise.
+func Authorization(authorize Authorizer) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg Message) (any, error) {
+			caller, authenticated := CallerFromContext(ctx)
+			if !authorize(ctx, caller, authenticated, msg) {
+				if !authenticated {
+					return nil, fmt.Errorf("%w: %s %s requires a caller", ErrUnauthenticated, msg.Kind, msg.Name)
+				}
+				return nil, fmt.Errorf("%w: caller %s may not send %s %s", ErrForbidden, caller.ID, msg.Kind, msg.Name)
+			}
+			return next(ctx, msg)
+		}
+	}
+}
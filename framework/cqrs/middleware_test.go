@@ -0,0 +1,44 @@
+package cqrs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRecovererLogsStackButKeepsItOutOfError(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	handle := Recoverer(zap.New(core))(func(ctx context.Context, msg Message) (any, error) {
+		panic("secret detail")
+	})
+
+	result, err := handle(context.Background(), Message{Kind: KindCommand, Name: "app.DoThing"})
+	if result != nil {
+		t.Fatalf("result = %v, want nil", result)
+	}
+	if !errors.Is(err, ErrPanic) {
+		t.Fatalf("err = %v, want ErrPanic", err)
+	}
+	if strings.Contains(err.Error(), "secret detail") || strings.Contains(err.Error(), "goroutine") {
+		t.Fatalf("error exposes panic details: %q", err.Error())
+	}
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["name"] != "app.DoThing" {
+		t.Errorf("logged name = %v, want app.DoThing", fields["name"])
+	}
+	if fields["panic"] != "secret detail" {
+		t.Errorf("logged panic = %v, want secret detail", fields["panic"])
+	}
+	if stack, _ := fields["stack"].(string); !strings.Contains(stack, "goroutine") {
+		t.Errorf("logged stack = %q, want a stack trace", stack)
+	}
+}
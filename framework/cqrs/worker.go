@@ -0,0 +1,298 @@
+package cqrs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/soliton-go/framework/event"
+	"github.com/soliton-go/framework/orm"
+	"go.uber.org/zap"
+)
+
+// maxErrorLength caps the error stored on a failed command.
+const maxErrorLength = 1024
+
+// CommandWorker runs the commands stored by DispatchAsync through the handlers and middleware of
+// the command bus. Every command is claimed for a lease that is renewed while it runs, so several
+// instances can share the table. A command whose worker stops without finishing it is run again
+// once the lease expires, so handlers of long-running commands should be idempotent.
+// Failed commands are not retried.
+type CommandWorker struct {
+	bus      *InMemoryCommandBus
+	logger   *zap.Logger
+	workers  int
+	interval time.Duration
+	lease    time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// WorkerOption is a functional option for CommandWorker.
+type WorkerOption func(*CommandWorker)
+
+// WithWorkers sets how many commands run concurrently.
+func WithWorkers(n int) WorkerOption {
+	return func(w *CommandWorker) {
+		w.workers = n
+	}
+}
+
+// WithWorkerInterval sets how often an idle worker looks for pending commands.
+func WithWorkerInterval(interval time.Duration) WorkerOption {
+	return func(w *CommandWorker) {
+		w.interval = interval
+	}
+}
+
+// WithWorkerLease sets how long a claimed command is hidden from other workers. The lease is
+// renewed while the command runs.
+func WithWorkerLease(lease time.Duration) WorkerOption {
+	return func(w *CommandWorker) {
+		w.lease = lease
+	}
+}
+
+// WithWorkerLogger sets the logger for failures of the worker itself; failures of commands are
+// recorded on the command and logged by the Logging middleware. Panics that reach the worker are
+// logged here with their stack.
+func WithWorkerLogger(logger *zap.Logger) WorkerOption {
+	return func(w *CommandWorker) {
+		w.logger = logger
+	}
+}
+
+// NewCommandWorker creates a CommandWorker for a bus created with WithAsync.
+func NewCommandWorker(bus *InMemoryCommandBus, opts ...WorkerOption) *CommandWorker {
+	w := &CommandWorker{
+		bus:      bus,
+		logger:   zap.NewNop(),
+		workers:  4,
+		interval: time.Second,
+		lease:    time.Minute,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Start launches the workers. It matches the fx.Hook OnStart signature.
+func (w *CommandWorker) Start(ctx context.Context) error {
+	if w.bus.jobs == nil {
+		return ErrAsyncNotConfigured
+	}
+	runCtx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	for range max(w.workers, 1) {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.loop(runCtx)
+		}()
+	}
+	return nil
+}
+
+// Stop stops claiming commands and waits for the running ones to finish. Commands still running
+// when ctx expires are left to be claimed again after their lease. It matches the fx.Hook OnStop signature.
+func (w *CommandWorker) Stop(ctx context.Context) error {
+	if w.cancel == nil {
+		return nil
+	}
+	w.cancel()
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *CommandWorker) loop(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		ran, err := w.RunNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			w.logger.Error("async command poll failed", zap.Error(err))
+		}
+		if ran && err == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunNext claims the oldest pending command, or one whose lease has expired, and runs it.
+// It reports whether a command was run. The command runs to completion even if ctx is cancelled.
+func (w *CommandWorker) RunNext(ctx context.Context) (bool, error) {
+	row, claimed, err := w.claim(ctx)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	stopRenewing := w.renew(row)
+	result, runErr := w.run(row)
+	stopRenewing()
+
+	now := time.Now()
+	updates := map[string]any{
+		"status":       CommandSucceeded,
+		"finished_at":  &now,
+		"locked_until": nil,
+	}
+	if runErr != nil {
+		updates["status"] = CommandFailed
+		message := runErr.Error()
+		if len(message) > maxErrorLength {
+			message = message[:maxErrorLength]
+		}
+		updates["error"] = message
+	} else if result != nil {
+		updates["result"] = result
+	}
+	// Finishing is tied to the claim, so a worker that lost its lease does not overwrite the
+	// outcome of the worker that claimed the command after it.
+	err = w.bus.jobs.Model(&AsyncCommand{}).
+		Where("id = ? AND status = ? AND attempts = ?", row.ID, CommandRunning, row.Attempts).
+		Updates(updates).Error
+	if err != nil {
+		return true, fmt.Errorf("failed to record outcome of command %s: %w", row.ID, err)
+	}
+	return true, nil
+}
+
+// claim marks the first available command as running for this worker. Candidates taken by
+// another worker in the meantime are skipped.
+func (w *CommandWorker) claim(ctx context.Context) (AsyncCommand, bool, error) {
+	db := orm.Primary(w.bus.jobs).WithContext(ctx)
+	now := time.Now()
+	available := db.Where("status = ?", CommandPending).
+		Or("status = ? AND locked_until < ?", CommandRunning, now)
+
+	var candidates []AsyncCommand
+	err := db.Select("id", "attempts").Where(available).Order("created_at ASC").Limit(max(w.workers, 1)).Find(&candidates).Error
+	if err != nil {
+		return AsyncCommand{}, false, err
+	}
+	for _, candidate := range candidates {
+		until := now.Add(w.lease)
+		result := db.Model(&AsyncCommand{}).
+			Where("id = ? AND attempts = ?", candidate.ID, candidate.Attempts).
+			Where(available).
+			Updates(map[string]any{
+				"status":       CommandRunning,
+				"attempts":     candidate.Attempts + 1,
+				"locked_until": &until,
+				"started_at":   &now,
+			})
+		if result.Error != nil {
+			return AsyncCommand{}, false, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		var row AsyncCommand
+		if err := db.Where("id = ?", candidate.ID).First(&row).Error; err != nil {
+			return AsyncCommand{}, false, err
+		}
+		return row, true, nil
+	}
+	return AsyncCommand{}, false, nil
+}
+
+// renew extends the lease of a running command until the returned function is called.
+func (w *CommandWorker) renew(row AsyncCommand) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(w.lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			until := time.Now().Add(w.lease)
+			err := w.bus.jobs.WithContext(ctx).Model(&AsyncCommand{}).
+				Where("id = ? AND status = ? AND attempts = ?", row.ID, CommandRunning, row.Attempts).
+				Update("locked_until", &until).Error
+			if err != nil && ctx.Err() == nil {
+				w.logger.Warn("failed to renew async command lease", zap.String("command_id", row.ID), zap.Error(err))
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// run decodes the command and calls its handler, returning the JSON-encoded result.
+func (w *CommandWorker) run(row AsyncCommand) (result []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			// The stored error is visible to whoever polls the command, so the details are only logged.
+			w.logger.Error("async command handler panicked",
+				zap.String("command_id", row.ID),
+				zap.String("name", row.Name),
+				zap.Any("panic", r),
+				zap.ByteString("stack", debug.Stack()))
+			err = fmt.Errorf("%w for command %s", ErrPanic, row.Name)
+		}
+	}()
+
+	msgType, reg, err := w.bus.lookupName(row.Name)
+	if err != nil {
+		return nil, err
+	}
+	ptr := reflect.New(msgType)
+	if err := json.Unmarshal(row.Payload, ptr.Interface()); err != nil {
+		return nil, fmt.Errorf("failed to decode command %s: %w", row.Name, err)
+	}
+
+	ctx := context.WithValue(context.Background(), commandIDKey{}, row.ID)
+	if row.CorrelationID != "" {
+		ctx = event.WithCorrelationID(ctx, row.CorrelationID)
+	}
+	if row.CallerID != "" {
+		caller := Caller{ID: row.CallerID}
+		if row.CallerRoles != "" {
+			caller.Roles = strings.Split(row.CallerRoles, ",")
+		}
+		ctx = WithCaller(ctx, caller)
+	}
+
+	out, err := reg.handle(ctx, ptr.Elem().Interface())
+	if err != nil {
+		return nil, err
+	}
+	if reg.resultType == reflect.TypeFor[struct{}]() {
+		return nil, nil
+	}
+	result, err = json.Marshal(out)
+	if err != nil {
+		// The command has run; only its result is lost.
+		w.logger.Warn("failed to encode async command result", zap.String("command_id", row.ID), zap.Error(err))
+		return nil, nil
+	}
+	return result, nil
+}
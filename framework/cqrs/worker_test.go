@@ -0,0 +1,101 @@
+package cqrs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type workerTestCommand struct {
+	Reason string
+}
+
+func openWorkerTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := MigrateAsyncCommands(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// runAsync dispatches cmd asynchronously, runs it with a worker and returns the stored row.
+func runAsync(t *testing.T, db *gorm.DB, bus *InMemoryCommandBus, cmd workerTestCommand, opts ...WorkerOption) AsyncCommand {
+	t.Helper()
+	ctx := context.Background()
+	id, err := bus.DispatchAsync(ctx, cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ran, err := NewCommandWorker(bus, opts...).RunNext(ctx)
+	if err != nil || !ran {
+		t.Fatalf("RunNext = %v, %v; want true, nil", ran, err)
+	}
+	var row AsyncCommand
+	if err := db.First(&row, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return row
+}
+
+func TestCommandWorkerLogsPanicAndStoresSanitizedError(t *testing.T) {
+	db := openWorkerTestDB(t)
+	bus := NewCommandBus(WithAsync(db))
+	err := RegisterVoidCommand(bus, func(ctx context.Context, cmd workerTestCommand) error {
+		panic(cmd.Reason)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	core, logs := observer.New(zap.ErrorLevel)
+
+	row := runAsync(t, db, bus, workerTestCommand{Reason: "secret detail"}, WithWorkerLogger(zap.New(core)))
+
+	if row.Status != CommandFailed {
+		t.Fatalf("status = %s, want %s", row.Status, CommandFailed)
+	}
+	if !strings.Contains(row.Error, ErrPanic.Error()) {
+		t.Errorf("error = %q, want it to report the panic", row.Error)
+	}
+	if strings.Contains(row.Error, "secret detail") || strings.Contains(row.Error, "goroutine") {
+		t.Errorf("stored error exposes panic details: %q", row.Error)
+	}
+	entries := logs.FilterField(zap.String("command_id", row.ID)).All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries for the command, want 1", len(entries))
+	}
+	if stack, _ := entries[0].ContextMap()["stack"].(string); !strings.Contains(stack, "goroutine") {
+		t.Errorf("logged stack = %q, want a stack trace", stack)
+	}
+}
+
+func TestCommandWorkerCapsStoredError(t *testing.T) {
+	db := openWorkerTestDB(t)
+	bus := NewCommandBus(WithAsync(db))
+	err := RegisterVoidCommand(bus, func(ctx context.Context, cmd workerTestCommand) error {
+		return errors.New(cmd.Reason)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := runAsync(t, db, bus, workerTestCommand{Reason: strings.Repeat("x", 4*maxErrorLength)})
+
+	if row.Status != CommandFailed {
+		t.Fatalf("status = %s, want %s", row.Status, CommandFailed)
+	}
+	if len(row.Error) != maxErrorLength {
+		t.Errorf("stored error has %d bytes, want %d", len(row.Error), maxErrorLength)
+	}
+}
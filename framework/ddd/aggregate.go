@@ -17,6 +17,13 @@ type AggregateRoot interface {
 	PullDomainEvents() []DomainEvent
 }
 
+// Versioned is implemented by aggregates that carry an optimistic concurrency version.
+// Repositories use it to reject writes based on a stale copy of the aggregate.
+type Versioned interface {
+	GetVersion() int64
+	SetVersion(version int64)
+}
+
 // BaseAggregateRoot is a base struct for aggregates that handles domain events.
 type BaseAggregateRoot struct {
 	events []DomainEvent
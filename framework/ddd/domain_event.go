@@ -1,6 +1,15 @@
 package ddd
 
-import "time"
+import (
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CurrentSchemaVersion is the initial schema version of an event.
+// Once upcasters are registered, the event registry stamps the newer version on publish.
+const CurrentSchemaVersion = 1
 
 // DomainEvent is the interface that all domain events should implement.
 type DomainEvent interface {
@@ -8,15 +17,89 @@ type DomainEvent interface {
 	OccurredOn() time.Time
 }
 
+// EventMetadata is the standard envelope carried by every domain event.
+type EventMetadata struct {
+	EventID          string    `json:"event_id,omitempty"`
+	AggregateType    string    `json:"aggregate_type,omitempty"`
+	AggregateID      string    `json:"aggregate_id,omitempty"`
+	AggregateVersion int64     `json:"aggregate_version,omitempty"`
+	SchemaVersion    int       `json:"schema_version,omitempty"`
+	CorrelationID    string    `json:"correlation_id,omitempty"`
+	CausationID      string    `json:"causation_id,omitempty"`
+	OccurredAt       time.Time `json:"occurred_on"`
+}
+
 // BaseDomainEvent is a struct that can be embedded in domain events to provide common behavior.
+// Its envelope fields are serialized together with the event payload.
 type BaseDomainEvent struct {
-	occurredOn time.Time
+	EventMetadata
 }
 
+// NewBaseDomainEvent creates an envelope with a fresh event ID and the current time.
 func NewBaseDomainEvent() BaseDomainEvent {
-	return BaseDomainEvent{occurredOn: time.Now()}
+	return BaseDomainEvent{EventMetadata: EventMetadata{
+		EventID:       uuid.NewString(),
+		SchemaVersion: CurrentSchemaVersion,
+		OccurredAt:    time.Now(),
+	}}
+}
+
+// NewAggregateEvent creates an envelope for an event raised by the given aggregate.
+func NewAggregateEvent(aggregateType, aggregateID string) BaseDomainEvent {
+	base := NewBaseDomainEvent()
+	base.AggregateType = aggregateType
+	base.AggregateID = aggregateID
+	return base
 }
 
 func (e BaseDomainEvent) OccurredOn() time.Time {
-	return e.occurredOn
+	return e.OccurredAt
+}
+
+// Metadata returns a copy of the event envelope.
+func (e BaseDomainEvent) Metadata() EventMetadata {
+	return e.EventMetadata
+}
+
+// MutableMetadata returns the envelope for in-place modification.
+func (e *BaseDomainEvent) MutableMetadata() *EventMetadata {
+	return &e.EventMetadata
+}
+
+// MetadataCarrier is implemented by events that embed BaseDomainEvent.
+type MetadataCarrier interface {
+	Metadata() EventMetadata
+}
+
+type mutableMetadataCarrier interface {
+	MutableMetadata() *EventMetadata
+}
+
+// MetadataOf returns the envelope of an event.
+// Events that do not embed BaseDomainEvent only report their occurrence time.
+func MetadataOf(event DomainEvent) EventMetadata {
+	if carrier, ok := event.(MetadataCarrier); ok {
+		return carrier.Metadata()
+	}
+	return EventMetadata{OccurredAt: event.OccurredOn()}
+}
+
+// WithMetadata returns the event with its envelope modified by fn.
+// Pointer events are modified in place; value events are copied, since they cannot be changed through the interface.
+// Events that do not embed BaseDomainEvent are returned unchanged.
+func WithMetadata(event DomainEvent, fn func(meta *EventMetadata)) DomainEvent {
+	if carrier, ok := event.(mutableMetadataCarrier); ok {
+		fn(carrier.MutableMetadata())
+		return event
+	}
+
+	value := reflect.ValueOf(event)
+	ptr := reflect.New(value.Type())
+	ptr.Elem().Set(value)
+	carrier, ok := ptr.Interface().(mutableMetadataCarrier)
+	if !ok {
+		return event
+	}
+	fn(carrier.MutableMetadata())
+	return ptr.Elem().Interface().(DomainEvent)
 }
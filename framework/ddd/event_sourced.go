@@ -0,0 +1,37 @@
+package ddd
+
+// EventSourcedAggregate is the interface for aggregates whose state is rebuilt from their event history.
+// The version is the number of events already persisted in the aggregate's stream.
+type EventSourcedAggregate interface {
+	AggregateRoot
+	Versioned
+	// AggregateType returns the stream prefix for this aggregate, e.g. "payment".
+	AggregateType() string
+	// Apply mutates the aggregate state for a single event.
+	// It is called both when raising new events and when replaying history, so it must not fail.
+	// The creation event is expected to set the aggregate ID.
+	Apply(event DomainEvent)
+}
+
+// BaseEventSourcedAggregate is a base struct for event-sourced aggregates.
+// Embed it and implement GetID, AggregateType and Apply on the concrete type.
+type BaseEventSourcedAggregate struct {
+	BaseAggregateRoot
+	version int64
+}
+
+// GetVersion returns the stream version the aggregate was loaded at.
+func (b *BaseEventSourcedAggregate) GetVersion() int64 {
+	return b.version
+}
+
+// SetVersion sets the stream version. It is maintained by the event-sourced repository.
+func (b *BaseEventSourcedAggregate) SetVersion(version int64) {
+	b.version = version
+}
+
+// Raise applies a new event to the aggregate and records it as uncommitted.
+func Raise(aggregate EventSourcedAggregate, event DomainEvent) {
+	aggregate.Apply(event)
+	aggregate.AddDomainEvent(event)
+}
@@ -0,0 +1,64 @@
+package event
+
+import (
+	"context"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/orm"
+)
+
+// AfterCommitPublisher holds back events published inside a transaction until it has committed
+// (see orm.AfterCommit) and drops them if it rolls back, so that publishers outside the database,
+// such as an EventBus, never announce changes that did not happen. Outside a transaction events
+// are published immediately; in a transaction not opened by orm.Transaction or a UnitOfWork,
+// Publish fails with orm.ErrUnmanagedTransaction.
+//
+// Delivery is at-most-once: an error after the commit cannot undo it and is only logged. Use the
+// Outbox when events must not be lost.
+type AfterCommitPublisher struct {
+	publisher Publisher
+	logger    watermill.LoggerAdapter
+}
+
+// AfterCommitOption is a functional option for AfterCommitPublisher.
+type AfterCommitOption func(*AfterCommitPublisher)
+
+// WithAfterCommitLogger sets the logger for events that failed to publish after the commit.
+func WithAfterCommitLogger(logger watermill.LoggerAdapter) AfterCommitOption {
+	return func(p *AfterCommitPublisher) {
+		p.logger = logger
+	}
+}
+
+// NewAfterCommitPublisher wraps publisher so that it only receives committed events.
+func NewAfterCommitPublisher(publisher Publisher, opts ...AfterCommitOption) *AfterCommitPublisher {
+	p := &AfterCommitPublisher{
+		publisher: publisher,
+		logger:    watermill.NewStdLogger(false, false),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Publish implements Publisher.
+func (p *AfterCommitPublisher) Publish(ctx context.Context, events ...ddd.DomainEvent) error {
+	if _, ok := orm.TxFromContext(ctx); !ok {
+		return p.publisher.Publish(ctx, events...)
+	}
+	if !orm.Managed(ctx) {
+		return orm.ErrUnmanagedTransaction
+	}
+	orm.AfterCommit(ctx, func(ctx context.Context) {
+		if err := p.publisher.Publish(ctx, events...); err != nil {
+			for _, e := range events {
+				p.logger.Error("Failed to publish event after commit", err, watermill.LogFields{
+					"event_name": e.EventName(),
+				})
+			}
+		}
+	})
+	return nil
+}
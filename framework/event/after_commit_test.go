@@ -0,0 +1,62 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+)
+
+func TestAfterCommitPublisherWaitsForCommit(t *testing.T) {
+	db := openTestDB(t)
+	bus := &stubBus{}
+	publisher := NewAfterCommitPublisher(bus, WithAfterCommitLogger(watermill.NopLogger{}))
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+
+	if err := orm.Transaction(ctx, db, func(ctx context.Context) error {
+		if err := publisher.Publish(ctx, newTestEvent("committed")); err != nil {
+			return err
+		}
+		if n := len(bus.events()); n != 0 {
+			t.Errorf("published %d events before the commit", n)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(bus.events()); n != 1 {
+		t.Fatalf("published %d events after the commit, want 1", n)
+	}
+
+	if err := orm.Transaction(ctx, db, func(ctx context.Context) error {
+		if err := publisher.Publish(ctx, newTestEvent("rolled back")); err != nil {
+			return err
+		}
+		return errRollback
+	}); !errors.Is(err, errRollback) {
+		t.Fatal(err)
+	}
+	if n := len(bus.events()); n != 1 {
+		t.Errorf("published %d events after a rollback, want still 1", n)
+	}
+}
+
+func TestAfterCommitPublisherRefusesUnmanagedTransaction(t *testing.T) {
+	db := openTestDB(t)
+	bus := &stubBus{}
+	publisher := NewAfterCommitPublisher(bus)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return publisher.Publish(orm.ContextWithTx(context.Background(), tx), newTestEvent("unmanaged"))
+	})
+	if !errors.Is(err, orm.ErrUnmanagedTransaction) {
+		t.Fatalf("Publish = %v, want orm.ErrUnmanagedTransaction", err)
+	}
+	if n := len(bus.events()); n != 0 {
+		t.Errorf("published %d events", n)
+	}
+}
@@ -0,0 +1,141 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/soliton-go/framework/ddd"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+)
+
+// MetadataContentType is the message metadata key recording the codec of the payload.
+// Messages without it were published before codecs existed and are decoded as JSON.
+const MetadataContentType = "content_type"
+
+// Content types of the built-in codecs.
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeMsgpack  = "application/x-msgpack"
+)
+
+// Codec serializes event payloads.
+type Codec interface {
+	// ContentType identifies the codec in message metadata.
+	ContentType() string
+	Marshal(e ddd.DomainEvent) ([]byte, error)
+	Unmarshal(payload []byte, e ddd.DomainEvent) error
+}
+
+// Built-in codecs. JSONCodec is the default.
+var (
+	JSONCodec     Codec = jsonCodec{}
+	ProtobufCodec Codec = protobufCodec{}
+	MsgpackCodec  Codec = newMsgpackCodec()
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return ContentTypeJSON }
+
+func (jsonCodec) Marshal(e ddd.DomainEvent) ([]byte, error) { return json.Marshal(e) }
+
+func (jsonCodec) Unmarshal(payload []byte, e ddd.DomainEvent) error {
+	return json.Unmarshal(payload, e)
+}
+
+// protobufCodec encodes events that are generated protobuf messages implementing ddd.DomainEvent.
+// The envelope travels in message metadata only, unless the message has fields for it.
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string { return ContentTypeProtobuf }
+
+func (protobufCodec) Marshal(e ddd.DomainEvent) ([]byte, error) {
+	m, ok := e.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("event %s (%T) is not a proto.Message", e.EventName(), e)
+	}
+	return proto.Marshal(m)
+}
+
+func (protobufCodec) Unmarshal(payload []byte, e ddd.DomainEvent) error {
+	m, ok := e.(proto.Message)
+	if !ok {
+		return fmt.Errorf("event %s (%T) is not a proto.Message", e.EventName(), e)
+	}
+	return proto.Unmarshal(payload, m)
+}
+
+// msgpackCodec encodes plain event structs as MessagePack, honouring their json tags
+// so that the same field names are used as with JSON.
+type msgpackCodec struct {
+	handle *codec.MsgpackHandle
+}
+
+func newMsgpackCodec() msgpackCodec {
+	h := &codec.MsgpackHandle{WriteExt: true}
+	h.TypeInfos = codec.NewTypeInfos([]string{"json"})
+	return msgpackCodec{handle: h}
+}
+
+func (c msgpackCodec) ContentType() string { return ContentTypeMsgpack }
+
+func (c msgpackCodec) Marshal(e ddd.DomainEvent) ([]byte, error) {
+	var payload []byte
+	err := codec.NewEncoderBytes(&payload, c.handle).Encode(e)
+	return payload, err
+}
+
+func (c msgpackCodec) Unmarshal(payload []byte, e ddd.DomainEvent) error {
+	return codec.NewDecoderBytes(payload, c.handle).Decode(e)
+}
+
+// Codecs decodes payloads by content type.
+type Codecs map[string]Codec
+
+// DefaultCodecs returns the built-in codecs, keyed by content type.
+func DefaultCodecs() Codecs {
+	return Codecs{
+		ContentTypeJSON:     JSONCodec,
+		ContentTypeProtobuf: ProtobufCodec,
+		ContentTypeMsgpack:  MsgpackCodec,
+	}
+}
+
+// Lookup returns the codec for a content type. An empty content type means JSON.
+func (c Codecs) Lookup(contentType string) (Codec, error) {
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+	if codec, ok := c[contentType]; ok {
+		return codec, nil
+	}
+	return nil, fmt.Errorf("no codec for content type %q", contentType)
+}
+
+// DecodeEvent decodes a payload with the given codec.
+// JSON payloads go through the registry's upcasters. Upcasters work on JSON, so binary
+// payloads are unmarshalled as they are and rely on the format's own compatibility rules
+// (protobuf field numbers, msgpack field names); only versions newer than the current
+// schema are rejected.
+func DecodeEvent(registry EventRegistry, c Codec, eventName string, schemaVersion int, payload []byte) (ddd.DomainEvent, error) {
+	if c == nil || c.ContentType() == ContentTypeJSON {
+		return registry.Decode(eventName, schemaVersion, payload)
+	}
+
+	current := registry.SchemaVersion(eventName)
+	if schemaVersion > current {
+		return nil, fmt.Errorf("%w: %s v%d (current v%d)", ErrUnsupportedSchemaVersion, eventName, schemaVersion, current)
+	}
+	e, err := registry.Create(eventName)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Unmarshal(payload, e); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event %s: %w", eventName, err)
+	}
+	return ddd.WithMetadata(e, func(meta *ddd.EventMetadata) {
+		meta.SchemaVersion = current
+	}), nil
+}
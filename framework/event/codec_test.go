@@ -0,0 +1,119 @@
+package event
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/soliton-go/framework/ddd"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// protoEvent is a protobuf message that is also a domain event.
+type protoEvent struct {
+	*wrapperspb.StringValue
+}
+
+func (protoEvent) EventName() string     { return "test.proto" }
+func (protoEvent) OccurredOn() time.Time { return time.Time{} }
+
+func newCodecRegistry() *DefaultEventRegistry {
+	registry := newTestRegistry()
+	registry.Register("test.proto", func() ddd.DomainEvent { return &protoEvent{StringValue: &wrapperspb.StringValue{}} })
+	return registry
+}
+
+func TestCodecsLookup(t *testing.T) {
+	codecs := DefaultCodecs()
+	tests := []struct {
+		contentType string
+		want        Codec
+	}{
+		{"", JSONCodec},
+		{ContentTypeJSON, JSONCodec},
+		{ContentTypeProtobuf, ProtobufCodec},
+		{ContentTypeMsgpack, MsgpackCodec},
+	}
+	for _, tt := range tests {
+		codec, err := codecs.Lookup(tt.contentType)
+		if err != nil {
+			t.Errorf("Lookup(%q) = %v", tt.contentType, err)
+			continue
+		}
+		if codec.ContentType() != tt.want.ContentType() {
+			t.Errorf("Lookup(%q) = %s, want %s", tt.contentType, codec.ContentType(), tt.want.ContentType())
+		}
+	}
+
+	if _, err := codecs.Lookup("application/xml"); err == nil || err.Error() != `no codec for content type "application/xml"` {
+		t.Errorf("Lookup of an unknown content type = %v, want it named in the error", err)
+	}
+}
+
+func TestDecodeMessageByContentType(t *testing.T) {
+	registry := newCodecRegistry()
+	tests := []struct {
+		name  string
+		codec Codec
+		event ddd.DomainEvent
+		value func(ddd.DomainEvent) string
+	}{
+		{"json", JSONCodec, newTestEvent("json"), func(e ddd.DomainEvent) string { return e.(*testEvent).Value }},
+		{"msgpack", MsgpackCodec, newTestEvent("msgpack"), func(e ddd.DomainEvent) string { return e.(*testEvent).Value }},
+		{"protobuf", ProtobufCodec, protoEvent{StringValue: wrapperspb.String("protobuf")}, func(e ddd.DomainEvent) string { return e.(*protoEvent).GetValue() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := tt.codec.Marshal(tt.event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg := message.NewMessage("message-1", payload)
+			msg.Metadata.Set(MetadataContentType, tt.codec.ContentType())
+
+			e, _, err := decodeMessage(registry, DefaultCodecs(), tt.event.EventName(), msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.value(e); got != tt.name {
+				t.Errorf("decoded %q, want %q", got, tt.name)
+			}
+		})
+	}
+
+	// Messages without a content type were published as JSON.
+	payload, _ := JSONCodec.Marshal(newTestEvent("legacy"))
+	if e, _, err := decodeMessage(registry, DefaultCodecs(), "test.happened", message.NewMessage("message-2", payload)); err != nil || e.(*testEvent).Value != "legacy" {
+		t.Errorf("decoding a message without content type = %v, %v", e, err)
+	}
+
+	msg := message.NewMessage("message-3", payload)
+	msg.Metadata.Set(MetadataContentType, "application/xml")
+	if _, _, err := decodeMessage(registry, DefaultCodecs(), "test.happened", msg); err == nil {
+		t.Error("decoding a message with an unknown content type succeeded")
+	}
+}
+
+func TestDecodeEventBinaryVersions(t *testing.T) {
+	registry := newCodecRegistry()
+	payload, err := MsgpackCodec.Marshal(newTestEvent("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DecodeEvent(registry, MsgpackCodec, "test.happened", 2, payload); !errors.Is(err, ErrUnsupportedSchemaVersion) {
+		t.Errorf("decoding a newer binary payload = %v, want ErrUnsupportedSchemaVersion", err)
+	}
+	e, err := DecodeEvent(registry, MsgpackCodec, "test.happened", 0, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := ddd.MetadataOf(e).SchemaVersion; v != 1 {
+		t.Errorf("decoded schema version %d, want 1", v)
+	}
+
+	if _, err := ProtobufCodec.Marshal(newTestEvent("plain")); err == nil {
+		t.Error("protobuf codec marshalled an event that is not a proto.Message")
+	}
+}
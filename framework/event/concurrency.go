@@ -0,0 +1,162 @@
+package event
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// PartitionKeyFunc returns the ordering key of a message. Messages with the same key are handled
+// one at a time, in the order they were received; different keys are handled in parallel.
+type PartitionKeyFunc func(msg *message.Message) string
+
+// PartitionByAggregate orders messages per aggregate (the aggregate_id metadata).
+// Messages without an aggregate ID are not ordered relative to each other.
+func PartitionByAggregate(msg *message.Message) string {
+	if id := msg.Metadata.Get(MetadataAggregateID); id != "" {
+		return msg.Metadata.Get(MetadataAggregateType) + "/" + id
+	}
+	return msg.UUID
+}
+
+// PartitionByMetadata orders messages by a metadata value, e.g. one set by publish middleware.
+func PartitionByMetadata(key string) PartitionKeyFunc {
+	return func(msg *message.Message) string {
+		if v := msg.Metadata.Get(key); v != "" {
+			return v
+		}
+		return msg.UUID
+	}
+}
+
+// WithWorkers handles up to n messages of the subscription in parallel, keeping messages with the
+// same partition key in order (see WithPartitionKey). The default is 1: messages are handled serially.
+// The order is the one the transport delivers in; the in-memory transport delivers concurrently,
+// so on it workers only bound the parallelism.
+func WithWorkers(n int) SubscribeOption {
+	return func(c *subscriptionConfig) {
+		c.workers = n
+	}
+}
+
+// WithMaxInFlight bounds the number of messages received but not yet acknowledged, including those
+// waiting behind an earlier message with the same key. It defaults to the number of workers and has
+// no effect with a single worker, which reads one message at a time.
+//
+// A nacked message is redelivered after messages already received, so with a limit above 1 it may
+// be handled after later messages with the same key. Handlers that retry through a retry policy
+// keep their key busy until the message is acked or dead-lettered and are not affected; only
+// subscriptions without retries, or whose dead-lettering fails, nack.
+func WithMaxInFlight(n int) SubscribeOption {
+	return func(c *subscriptionConfig) {
+		c.maxInFlight = n
+	}
+}
+
+// WithPartitionKey sets how messages are ordered when the subscription has several workers.
+// The default is PartitionByAggregate.
+func WithPartitionKey(fn PartitionKeyFunc) SubscribeOption {
+	return func(c *subscriptionConfig) {
+		if fn != nil {
+			c.partitionKey = fn
+		}
+	}
+}
+
+// WithConsumerGroup names the consumer group of the subscription on durable transports.
+// Subscriptions with the same group, in this or other instances, compete for messages;
+// without it the transport's configured group is used (see TransportConfig.ConsumerGroup).
+// The in-memory transport ignores it.
+func WithConsumerGroup(name string) SubscribeOption {
+	return func(c *subscriptionConfig) {
+		c.consumerGroup = name
+	}
+}
+
+// inFlight returns the effective worker count and in-flight limit.
+func (c subscriptionConfig) inFlight() (workers, maxInFlight int) {
+	workers = max(c.workers, 1)
+	if workers == 1 {
+		return 1, 1
+	}
+	return workers, max(c.maxInFlight, workers)
+}
+
+type subscriptionKey struct{}
+
+type subscriptionSettings struct {
+	consumerGroup string
+	maxInFlight   int
+}
+
+// withSubscription passes the subscription's consumer group and in-flight limit to the transport.
+func withSubscription(ctx context.Context, cfg subscriptionConfig) context.Context {
+	_, maxInFlight := cfg.inFlight()
+	return context.WithValue(ctx, subscriptionKey{}, subscriptionSettings{
+		consumerGroup: cfg.consumerGroup,
+		maxInFlight:   maxInFlight,
+	})
+}
+
+func subscriptionFromContext(ctx context.Context) subscriptionSettings {
+	s, _ := ctx.Value(subscriptionKey{}).(subscriptionSettings)
+	if s.maxInFlight < 1 {
+		s.maxInFlight = 1
+	}
+	return s
+}
+
+// keyedExecutor runs tasks on a bounded number of workers, one task per key at a time,
+// in submission order.
+type keyedExecutor struct {
+	workers chan struct{}
+	mu      sync.Mutex
+	queues  map[string][]func()
+	wg      sync.WaitGroup
+}
+
+func newKeyedExecutor(workers int) *keyedExecutor {
+	return &keyedExecutor{
+		workers: make(chan struct{}, workers),
+		queues:  make(map[string][]func()),
+	}
+}
+
+func (e *keyedExecutor) submit(key string, task func()) {
+	e.wg.Add(1)
+	e.mu.Lock()
+	if queue, busy := e.queues[key]; busy {
+		e.queues[key] = append(queue, task)
+		e.mu.Unlock()
+		return
+	}
+	e.queues[key] = nil
+	e.mu.Unlock()
+	go e.drain(key, task)
+}
+
+// drain runs task and then the tasks queued behind it for the same key.
+func (e *keyedExecutor) drain(key string, task func()) {
+	for {
+		e.workers <- struct{}{}
+		task()
+		<-e.workers
+		e.wg.Done()
+
+		e.mu.Lock()
+		queue := e.queues[key]
+		if len(queue) == 0 {
+			delete(e.queues, key)
+			e.mu.Unlock()
+			return
+		}
+		task, e.queues[key] = queue[0], queue[1:]
+		e.mu.Unlock()
+	}
+}
+
+// wait blocks until every submitted task has run.
+func (e *keyedExecutor) wait() {
+	e.wg.Wait()
+}
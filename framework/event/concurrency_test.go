@@ -0,0 +1,131 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeyedExecutorKeepsPerKeyOrder(t *testing.T) {
+	const workers = 3
+	executor := newKeyedExecutor(workers)
+
+	var (
+		mu      sync.Mutex
+		handled = make(map[string][]int)
+		running atomic.Int32
+		peak    atomic.Int32
+	)
+	for i := range 20 {
+		for _, key := range []string{"a", "b", "c", "d"} {
+			executor.submit(key, func() {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+
+				mu.Lock()
+				handled[key] = append(handled[key], i)
+				mu.Unlock()
+			})
+		}
+	}
+	executor.wait()
+
+	for key, order := range handled {
+		if len(order) != 20 {
+			t.Fatalf("key %s handled %d tasks, want 20", key, len(order))
+		}
+		for i, got := range order {
+			if got != i {
+				t.Fatalf("key %s handled task %d at position %d", key, got, i)
+			}
+		}
+	}
+	if p := peak.Load(); p > workers {
+		t.Errorf("%d tasks ran at once, want at most %d", p, workers)
+	}
+}
+
+func TestKeyedExecutorRunsKeysInParallel(t *testing.T) {
+	executor := newKeyedExecutor(2)
+	release := make(chan struct{})
+	started := make(chan string, 2)
+	for _, key := range []string{"a", "b"} {
+		executor.submit(key, func() {
+			started <- key
+			<-release
+		})
+	}
+	for range 2 {
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("tasks with different keys did not run in parallel")
+		}
+	}
+	close(release)
+	executor.wait()
+}
+
+func TestSubscriptionInFlight(t *testing.T) {
+	tests := []struct {
+		cfg         subscriptionConfig
+		workers     int
+		maxInFlight int
+	}{
+		{subscriptionConfig{}, 1, 1},
+		{subscriptionConfig{maxInFlight: 8}, 1, 1},
+		{subscriptionConfig{workers: 4}, 4, 4},
+		{subscriptionConfig{workers: 4, maxInFlight: 2}, 4, 4},
+		{subscriptionConfig{workers: 4, maxInFlight: 16}, 4, 16},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("workers=%d,max=%d", tt.cfg.workers, tt.cfg.maxInFlight), func(t *testing.T) {
+			workers, maxInFlight := tt.cfg.inFlight()
+			if workers != tt.workers || maxInFlight != tt.maxInFlight {
+				t.Errorf("inFlight() = %d, %d; want %d, %d", workers, maxInFlight, tt.workers, tt.maxInFlight)
+			}
+			if got := subscriptionFromContext(withSubscription(context.Background(), tt.cfg)).maxInFlight; got != tt.maxInFlight {
+				t.Errorf("transport in-flight limit = %d, want %d", got, tt.maxInFlight)
+			}
+		})
+	}
+}
+
+func TestConsumerGroupsNumberSubscriptionsPerTopic(t *testing.T) {
+	groups := newConsumerGroups("app")
+	ctx := context.Background()
+
+	for _, tt := range []struct{ topic, want string }{
+		{"order.created", "app"},
+		{"order.created", "app-2"},
+		{"order.paid", "app"},
+		{"order.created", "app-3"},
+	} {
+		if got := groups.forSubscription(ctx, tt.topic); got != tt.want {
+			t.Errorf("group for %s = %s, want %s", tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestConsumerGroupsHonourWithConsumerGroup(t *testing.T) {
+	groups := newConsumerGroups("app")
+	named := withSubscription(context.Background(), subscriptionConfig{consumerGroup: "projector"})
+
+	if got := groups.forSubscription(named, "order.created"); got != "projector" {
+		t.Errorf("named group = %s, want projector", got)
+	}
+	// A named subscription does not take a number from the unnamed ones.
+	if got := groups.forSubscription(context.Background(), "order.created"); got != "app" {
+		t.Errorf("first unnamed group = %s, want app", got)
+	}
+}
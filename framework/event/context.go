@@ -0,0 +1,73 @@
+package event
+
+import (
+	"context"
+
+	"github.com/soliton-go/framework/ddd"
+)
+
+type correlationIDKey struct{}
+type causationIDKey struct{}
+type messageIDKey struct{}
+type traceContextKey struct{}
+
+// WithCorrelationID returns a context carrying the correlation ID of the current request or event chain.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationIDFromContext returns the correlation ID stored in ctx, if any.
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// WithCausationID returns a context carrying the ID of the message that caused the current work.
+func WithCausationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, causationIDKey{}, id)
+}
+
+// CausationIDFromContext returns the causation ID stored in ctx, if any.
+func CausationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(causationIDKey{}).(string)
+	return id
+}
+
+// MessageIDFromContext returns the ID of the message being handled, if any.
+// It is set by the event bus before calling a handler.
+func MessageIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(messageIDKey{}).(string)
+	return id
+}
+
+func withMessageID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, messageIDKey{}, id)
+}
+
+type traceContext struct {
+	parent string
+	state  string
+}
+
+// WithTraceContext returns a context carrying W3C traceparent and tracestate values.
+func WithTraceContext(ctx context.Context, traceParent, traceState string) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, traceContext{parent: traceParent, state: traceState})
+}
+
+// TraceContextFromContext returns the W3C traceparent and tracestate stored in ctx, if any.
+func TraceContextFromContext(ctx context.Context) (traceParent, traceState string) {
+	tc, _ := ctx.Value(traceContextKey{}).(traceContext)
+	return tc.parent, tc.state
+}
+
+// ContextForEvent returns the context a handler should run with for the given envelope:
+// it keeps the correlation ID and makes the event the cause of anything published downstream.
+func ContextForEvent(ctx context.Context, meta ddd.EventMetadata) context.Context {
+	if meta.CorrelationID != "" {
+		ctx = WithCorrelationID(ctx, meta.CorrelationID)
+	}
+	if meta.EventID != "" {
+		ctx = WithCausationID(ctx, meta.EventID)
+	}
+	return ctx
+}
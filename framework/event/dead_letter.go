@@ -0,0 +1,174 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrDeadLetterNotFound is returned when a dead letter does not exist.
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	// ErrDeadLetterNotReplayable is returned when replaying a dead letter whose subscription
+	// had no handler name, so the handler that failed is unknown.
+	ErrDeadLetterNotReplayable = errors.New("dead letter has no handler to replay to")
+)
+
+// DeadLetter is the database row for a message that exhausted its retries.
+type DeadLetter struct {
+	ID              uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	MessageID       string     `gorm:"size:64;not null;index" json:"message_id"`
+	Topic           string     `gorm:"size:255;not null;index" json:"topic"`
+	DeadLetterTopic string     `gorm:"size:255;not null" json:"dead_letter_topic"`
+	EventName       string     `gorm:"size:255;index" json:"event_name"`
+	Handler         string     `gorm:"size:255;index" json:"handler"`
+	Payload         []byte     `gorm:"not null" json:"payload"`
+	Metadata        string     `gorm:"type:text" json:"metadata"`
+	Error           string     `gorm:"type:text" json:"error"`
+	Attempts        int        `gorm:"not null;default:0" json:"attempts"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+	ReplayedAt      *time.Time `json:"replayed_at"`
+}
+
+// TableName overrides the GORM table name.
+func (DeadLetter) TableName() string {
+	return "event_dead_letters"
+}
+
+// DeadLetterFilter selects dead letters in List.
+type DeadLetterFilter struct {
+	Topic           string
+	EventName       string
+	Handler         string
+	IncludeReplayed bool
+	Limit           int
+	Offset          int
+}
+
+// MigrateDeadLetters creates the dead-letter table if it does not exist.
+func MigrateDeadLetters(db *gorm.DB) error {
+	return orm.Primary(db).AutoMigrate(&DeadLetter{})
+}
+
+// DeadLetterStore keeps a queryable copy of dead-lettered messages and replays them.
+type DeadLetterStore struct {
+	db *gorm.DB
+}
+
+// NewDeadLetterStore creates a DeadLetterStore.
+func NewDeadLetterStore(db *gorm.DB) *DeadLetterStore {
+	return &DeadLetterStore{db: db}
+}
+
+// Record stores a message that was moved to dlqTopic.
+func (s *DeadLetterStore) Record(ctx context.Context, dlqTopic string, msg *message.Message) error {
+	metadata, err := json.Marshal(msg.Metadata)
+	if err != nil {
+		return err
+	}
+	attempts, _ := strconv.Atoi(msg.Metadata.Get(MetadataDeadLetterAttempts))
+	row := DeadLetter{
+		MessageID:       msg.UUID,
+		Topic:           msg.Metadata.Get(MetadataDeadLetterTopic),
+		DeadLetterTopic: dlqTopic,
+		EventName:       msg.Metadata.Get(MetadataEventName),
+		Handler:         msg.Metadata.Get(MetadataDeadLetterHandler),
+		Payload:         msg.Payload,
+		Metadata:        string(metadata),
+		Error:           msg.Metadata.Get(MetadataDeadLetterError),
+		Attempts:        attempts,
+	}
+	return orm.Primary(s.db).WithContext(ctx).Create(&row).Error
+}
+
+// List returns dead letters matching the filter, newest first, and the total count.
+func (s *DeadLetterStore) List(ctx context.Context, filter DeadLetterFilter) ([]DeadLetter, int64, error) {
+	query := s.db.WithContext(ctx).Model(&DeadLetter{})
+	if filter.Topic != "" {
+		query = query.Where("topic = ?", filter.Topic)
+	}
+	if filter.EventName != "" {
+		query = query.Where("event_name = ?", filter.EventName)
+	}
+	if filter.Handler != "" {
+		query = query.Where("handler = ?", filter.Handler)
+	}
+	if !filter.IncludeReplayed {
+		query = query.Where("replayed_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	var items []DeadLetter
+	err := query.Order("id DESC").Offset(filter.Offset).Limit(limit).Find(&items).Error
+	return items, total, err
+}
+
+// Get returns a single dead letter.
+func (s *DeadLetterStore) Get(ctx context.Context, id uint64) (*DeadLetter, error) {
+	var item DeadLetter
+	result := s.db.WithContext(ctx).Where("id = ?", id).Limit(1).Find(&item)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: %d", ErrDeadLetterNotFound, id)
+	}
+	return &item, nil
+}
+
+// Replay delivers a dead letter again to the handler that failed on it, and marks it replayed.
+// The message is handed to that handler directly, under its retry policy, so the other
+// subscribers of the topic, which already processed it, do not see it twice. Dead letters of
+// subscriptions without WithHandlerName return ErrDeadLetterNotReplayable. Replay requires bus
+// to be a WatermillEventBus on which the handler is subscribed.
+func (s *DeadLetterStore) Replay(ctx context.Context, id uint64, bus EventBus) error {
+	item, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if item.Handler == "" {
+		return fmt.Errorf("%w: %d", ErrDeadLetterNotReplayable, id)
+	}
+	source, ok := bus.(*WatermillEventBus)
+	if !ok {
+		return ErrReplayNotSupported
+	}
+	h, ok := source.handler(item.Handler)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrHandlerNotFound, item.Handler)
+	}
+
+	var metadata message.Metadata
+	if err := json.Unmarshal([]byte(item.Metadata), &metadata); err != nil {
+		return fmt.Errorf("failed to decode metadata of dead letter %d: %w", id, err)
+	}
+	msg := message.NewMessage(item.MessageID, item.Payload)
+	for key, value := range metadata {
+		switch key {
+		case MetadataDeadLetterTopic, MetadataDeadLetterError, MetadataDeadLetterAttempts, MetadataDeadLetterAt, MetadataDeadLetterHandler:
+			continue
+		}
+		msg.Metadata.Set(key, value)
+	}
+
+	if err := source.deliverTo(ctx, h, item.Topic, item.EventName, msg); err != nil {
+		return fmt.Errorf("failed to replay dead letter %d: %w", id, err)
+	}
+	now := time.Now()
+	return orm.Primary(s.db).WithContext(ctx).Model(&DeadLetter{}).Where("id = ?", id).Update("replayed_at", &now).Error
+}
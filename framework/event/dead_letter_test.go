@@ -0,0 +1,195 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/soliton-go/framework/ddd"
+)
+
+func newTestDeadLetterStore(t *testing.T) *DeadLetterStore {
+	t.Helper()
+	db := openTestDB(t)
+	if err := MigrateDeadLetters(db); err != nil {
+		t.Fatal(err)
+	}
+	return NewDeadLetterStore(db)
+}
+
+func newTestDeadLetterBus(store *DeadLetterStore) *WatermillEventBus {
+	return NewLocalEventBus(
+		WithRegistry(newTestRegistry()),
+		WithDeadLetterStore(store),
+		WithDefaultRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+		WithLogger(watermill.NopLogger{}),
+	)
+}
+
+// waitForDeadLetter waits until the store holds a dead letter that was not replayed yet.
+func waitForDeadLetter(t *testing.T, store *DeadLetterStore) DeadLetter {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		items, _, err := store.List(context.Background(), DeadLetterFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) > 0 {
+			return items[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("no dead letter recorded")
+	return DeadLetter{}
+}
+
+// countingHandler counts the events it receives and fails while err is set.
+type countingHandler struct {
+	mu       sync.Mutex
+	received []string
+	replays  int
+	err      error
+}
+
+func (h *countingHandler) Handle(ctx context.Context, e ddd.DomainEvent) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.received = append(h.received, ddd.MetadataOf(e).EventID)
+	if IsReplay(ctx) {
+		h.replays++
+	}
+	return h.err
+}
+
+func (h *countingHandler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.received)
+}
+
+func TestDeadLetterReplayReachesOnlyFailedHandler(t *testing.T) {
+	store := newTestDeadLetterStore(t)
+	bus := newTestDeadLetterBus(store)
+	defer bus.Close()
+	ctx := context.Background()
+
+	audit := &countingHandler{}
+	mailer := &countingHandler{err: errors.New("smtp down")}
+	if err := bus.Subscribe(ctx, "test.happened", audit.Handle, WithHandlerName("test.Audit")); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Subscribe(ctx, "test.happened", mailer.Handle, WithHandlerName("test.Mailer")); err != nil {
+		t.Fatal(err)
+	}
+
+	e := newTestEvent("dead")
+	if err := bus.Publish(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+	item := waitForDeadLetter(t, store)
+	if item.Handler != "test.Mailer" || item.Topic != "test.happened" || item.EventName != "test.happened" {
+		t.Errorf("dead letter for handler %q on %s (%s), want test.Mailer on test.happened", item.Handler, item.Topic, item.EventName)
+	}
+	if item.Attempts != 2 || item.Error != "smtp down" {
+		t.Errorf("dead letter after %d attempts with error %q, want 2 and smtp down", item.Attempts, item.Error)
+	}
+	if audit.count() != 1 {
+		t.Fatalf("audit handled %d events, want 1", audit.count())
+	}
+
+	mailer.mu.Lock()
+	mailer.err = nil
+	mailer.mu.Unlock()
+	if err := store.Replay(ctx, item.ID, bus); err != nil {
+		t.Fatalf("Replay = %v", err)
+	}
+
+	if mailer.count() != 3 || mailer.received[2] != e.EventID || mailer.replays != 0 {
+		t.Errorf("mailer received %v (%d as replays), want the event a third time as a live delivery", mailer.received, mailer.replays)
+	}
+	// A republish would reach the audit handler asynchronously.
+	time.Sleep(100 * time.Millisecond)
+	if audit.count() != 1 {
+		t.Errorf("audit handled %d events after the replay, want 1", audit.count())
+	}
+
+	replayed, err := store.Get(ctx, item.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.ReplayedAt == nil {
+		t.Error("dead letter not marked replayed")
+	}
+	if items, _, _ := store.List(ctx, DeadLetterFilter{}); len(items) != 0 {
+		t.Errorf("List returns %d dead letters after the replay, want none", len(items))
+	}
+}
+
+func TestDeadLetterReplayKeepsFailedDeadLetter(t *testing.T) {
+	store := newTestDeadLetterStore(t)
+	bus := newTestDeadLetterBus(store)
+	defer bus.Close()
+	ctx := context.Background()
+
+	mailer := &countingHandler{err: errors.New("smtp down")}
+	if err := bus.Subscribe(ctx, "test.happened", mailer.Handle, WithHandlerName("test.Mailer")); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Publish(ctx, newTestEvent("dead")); err != nil {
+		t.Fatal(err)
+	}
+	item := waitForDeadLetter(t, store)
+
+	if err := store.Replay(ctx, item.ID, bus); err == nil || !errors.Is(err, mailer.err) {
+		t.Fatalf("Replay = %v, want the handler error", err)
+	}
+	if mailer.count() != 4 {
+		t.Errorf("mailer ran %d times, want 2 attempts live and 2 on replay", mailer.count())
+	}
+	if stored, _ := store.Get(ctx, item.ID); stored.ReplayedAt != nil {
+		t.Error("dead letter marked replayed although the handler failed")
+	}
+}
+
+func TestDeadLetterReplayRefusesUnknownHandler(t *testing.T) {
+	store := newTestDeadLetterStore(t)
+	bus := newTestDeadLetterBus(store)
+	defer bus.Close()
+	ctx := context.Background()
+
+	unnamed := &countingHandler{err: errors.New("failed")}
+	if err := bus.Subscribe(ctx, "test.happened", unnamed.Handle); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Publish(ctx, newTestEvent("dead")); err != nil {
+		t.Fatal(err)
+	}
+	item := waitForDeadLetter(t, store)
+	if item.Handler != "" {
+		t.Fatalf("dead letter of an unnamed subscription recorded handler %q", item.Handler)
+	}
+	if err := store.Replay(ctx, item.ID, bus); !errors.Is(err, ErrDeadLetterNotReplayable) {
+		t.Errorf("Replay of an unnamed dead letter = %v, want ErrDeadLetterNotReplayable", err)
+	}
+
+	// The handler that failed is not subscribed on this bus.
+	if err := store.db.Model(&DeadLetter{}).Where("id = ?", item.ID).Update("handler", "test.Gone").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Replay(ctx, item.ID, bus); !errors.Is(err, ErrHandlerNotFound) {
+		t.Errorf("Replay to a handler that is not subscribed = %v, want ErrHandlerNotFound", err)
+	}
+	if err := store.Replay(ctx, item.ID, &stubBus{}); !errors.Is(err, ErrReplayNotSupported) {
+		t.Errorf("Replay on a bus without named handlers = %v, want ErrReplayNotSupported", err)
+	}
+	if err := store.Replay(ctx, item.ID+1, bus); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Errorf("Replay of a missing dead letter = %v, want ErrDeadLetterNotFound", err)
+	}
+	if unnamed.count() != 2 {
+		t.Errorf("handler ran %d times, want only the 2 live attempts", unnamed.count())
+	}
+}
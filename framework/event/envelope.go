@@ -0,0 +1,135 @@
+package event
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/soliton-go/framework/ddd"
+)
+
+// Message metadata keys carrying the event envelope.
+const (
+	MetadataEventName        = "event_name"
+	MetadataEventID          = "event_id"
+	MetadataOccurredOn       = "occurred_on"
+	MetadataAggregateType    = "aggregate_type"
+	MetadataAggregateID      = "aggregate_id"
+	MetadataAggregateVersion = "aggregate_version"
+	MetadataSchemaVersion    = "schema_version"
+	MetadataCorrelationID    = "correlation_id"
+	MetadataCausationID      = "causation_id"
+)
+
+// stampEnvelope fills in the envelope fields that are only known at publish time.
+// The schema version always comes from the registry: an in-memory event has the current shape,
+// whatever version its constructor stamped.
+func stampEnvelope(ctx context.Context, registry EventRegistry, e ddd.DomainEvent) ddd.DomainEvent {
+	schemaVersion := registry.SchemaVersion(e.EventName())
+	return ddd.WithMetadata(e, func(meta *ddd.EventMetadata) {
+		if meta.EventID == "" {
+			meta.EventID = watermill.NewUUID()
+		}
+		meta.SchemaVersion = schemaVersion
+		if meta.OccurredAt.IsZero() {
+			meta.OccurredAt = time.Now()
+		}
+		if meta.CorrelationID == "" {
+			meta.CorrelationID = CorrelationIDFromContext(ctx)
+		}
+		if meta.CorrelationID == "" {
+			// The first event of a chain correlates with itself.
+			meta.CorrelationID = meta.EventID
+		}
+		if meta.CausationID == "" {
+			meta.CausationID = CausationIDFromContext(ctx)
+		}
+	})
+}
+
+// newEventMessage creates a Watermill message for an event, using the event ID as message UUID
+// so that redeliveries of the same event can be recognised downstream.
+func newEventMessage(e ddd.DomainEvent, payload []byte) *message.Message {
+	meta := ddd.MetadataOf(e)
+	id := meta.EventID
+	if id == "" {
+		id = watermill.NewUUID()
+	}
+
+	msg := message.NewMessage(id, payload)
+	msg.Metadata.Set(MetadataEventName, e.EventName())
+	msg.Metadata.Set(MetadataEventID, id)
+	msg.Metadata.Set(MetadataOccurredOn, e.OccurredOn().Format(time.RFC3339Nano))
+	setIfNotEmpty(msg, MetadataAggregateType, meta.AggregateType)
+	setIfNotEmpty(msg, MetadataAggregateID, meta.AggregateID)
+	if meta.AggregateVersion != 0 {
+		msg.Metadata.Set(MetadataAggregateVersion, strconv.FormatInt(meta.AggregateVersion, 10))
+	}
+	if meta.SchemaVersion != 0 {
+		msg.Metadata.Set(MetadataSchemaVersion, strconv.Itoa(meta.SchemaVersion))
+	}
+	setIfNotEmpty(msg, MetadataCorrelationID, meta.CorrelationID)
+	setIfNotEmpty(msg, MetadataCausationID, meta.CausationID)
+	return msg
+}
+
+// MetadataFromMessage reads the event envelope from Watermill message metadata.
+func MetadataFromMessage(msg *message.Message) ddd.EventMetadata {
+	meta := ddd.EventMetadata{
+		EventID:       msg.Metadata.Get(MetadataEventID),
+		AggregateType: msg.Metadata.Get(MetadataAggregateType),
+		AggregateID:   msg.Metadata.Get(MetadataAggregateID),
+		CorrelationID: msg.Metadata.Get(MetadataCorrelationID),
+		CausationID:   msg.Metadata.Get(MetadataCausationID),
+	}
+	if meta.EventID == "" {
+		meta.EventID = msg.UUID
+	}
+	if v, err := strconv.ParseInt(msg.Metadata.Get(MetadataAggregateVersion), 10, 64); err == nil {
+		meta.AggregateVersion = v
+	}
+	if v, err := strconv.Atoi(msg.Metadata.Get(MetadataSchemaVersion)); err == nil {
+		meta.SchemaVersion = v
+	}
+	if t, err := time.Parse(time.RFC3339Nano, msg.Metadata.Get(MetadataOccurredOn)); err == nil {
+		meta.OccurredAt = t
+	}
+	return meta
+}
+
+// restoreEnvelope copies the message envelope onto a decoded event.
+// Metadata wins over the payload, so events published by older producers still get a full envelope.
+// The schema version is left alone: the event was upcast to the current version when decoded.
+func restoreEnvelope(e ddd.DomainEvent, meta ddd.EventMetadata) ddd.DomainEvent {
+	return ddd.WithMetadata(e, func(target *ddd.EventMetadata) {
+		if meta.EventID != "" {
+			target.EventID = meta.EventID
+		}
+		if meta.AggregateType != "" {
+			target.AggregateType = meta.AggregateType
+		}
+		if meta.AggregateID != "" {
+			target.AggregateID = meta.AggregateID
+		}
+		if meta.AggregateVersion != 0 {
+			target.AggregateVersion = meta.AggregateVersion
+		}
+		if meta.CorrelationID != "" {
+			target.CorrelationID = meta.CorrelationID
+		}
+		if meta.CausationID != "" {
+			target.CausationID = meta.CausationID
+		}
+		if !meta.OccurredAt.IsZero() {
+			target.OccurredAt = meta.OccurredAt
+		}
+	})
+}
+
+func setIfNotEmpty(msg *message.Message, key, value string) {
+	if value != "" {
+		msg.Metadata.Set(key, value)
+	}
+}
@@ -0,0 +1,75 @@
+// Package eventtest helps testing event-driven code: Bus records published events and runs
+// subscribed handlers inline, and the Expect helpers assert on what was published.
+//
+//	bus := eventtest.NewBus()
+//	repo := newRepoStub(bus) // Save publishes the aggregate's events to bus
+//	...
+//	reserved := eventtest.ExpectEvent[inventory.StockReservedEvent](t, bus)
+//	eventtest.ExpectPayload(t, reserved, map[string]any{"quantity": 3})
+package eventtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/event"
+)
+
+// Bus is an in-memory event.EventBus for tests. Publish records the events and runs the handlers
+// subscribed to them before it returns, in subscription order, so tests need not wait for delivery.
+// Events published by handlers are recorded and dispatched too. It also implements event.Publisher,
+// so it can be passed to repositories.
+type Bus struct {
+	mu         sync.Mutex
+	events     []ddd.DomainEvent
+	dispatcher *event.SyncDispatcher
+}
+
+var _ event.EventBus = (*Bus)(nil)
+
+// NewBus creates a Bus. Options configure its inline dispatcher.
+func NewBus(opts ...event.SyncDispatcherOption) *Bus {
+	return &Bus{dispatcher: event.NewSyncDispatcher(opts...)}
+}
+
+// Publish records the events and dispatches them to the subscribed handlers.
+// It returns the first handler error, after recording every event.
+func (b *Bus) Publish(ctx context.Context, events ...ddd.DomainEvent) error {
+	b.mu.Lock()
+	b.events = append(b.events, events...)
+	b.mu.Unlock()
+	return b.dispatcher.Publish(ctx, events...)
+}
+
+// Subscribe registers a handler for a topic or topic pattern (see event.MatchTopic).
+// Subscribe options such as retries do not apply: handler errors are returned by Publish.
+func (b *Bus) Subscribe(ctx context.Context, topic string, handler event.EventHandler, opts ...event.SubscribeOption) error {
+	return b.dispatcher.Subscribe(ctx, topic, handler, opts...)
+}
+
+// Events returns the events published so far, in order.
+func (b *Bus) Events() []ddd.DomainEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]ddd.DomainEvent(nil), b.events...)
+}
+
+// Named returns the published events with the given name, in order.
+func (b *Bus) Named(name string) []ddd.DomainEvent {
+	var named []ddd.DomainEvent
+	for _, e := range b.Events() {
+		if e.EventName() == name {
+			named = append(named, e)
+		}
+	}
+	return named
+}
+
+// Reset forgets the events published so far, e.g. those raised while arranging a test.
+// Subscriptions are kept.
+func (b *Bus) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = nil
+}
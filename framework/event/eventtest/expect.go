@@ -0,0 +1,137 @@
+package eventtest
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/soliton-go/framework/ddd"
+)
+
+// ExpectEvent fails the test unless exactly one event of type T was published, and returns it.
+// T may be the event type or a pointer to it; events published by value and by pointer both match.
+func ExpectEvent[T ddd.DomainEvent](t testing.TB, bus *Bus) T {
+	t.Helper()
+	var matched []T
+	for _, e := range bus.Events() {
+		if typed, ok := as[T](e); ok {
+			matched = append(matched, typed)
+		}
+	}
+	if len(matched) != 1 {
+		var zero T
+		t.Fatalf("expected one %T event, got %d; published: %s", zero, len(matched), names(bus.Events()))
+	}
+	return matched[0]
+}
+
+// ExpectEvents fails the test unless the published events have exactly these names, in order.
+func ExpectEvents(t testing.TB, bus *Bus, names ...string) {
+	t.Helper()
+	published := eventNames(bus.Events())
+	if !slices.Equal(published, names) {
+		t.Fatalf("expected events [%s], published [%s]", strings.Join(names, ", "), strings.Join(published, ", "))
+	}
+}
+
+// ExpectNamed fails the test unless exactly one event with the given name was published, and returns it.
+func ExpectNamed(t testing.TB, bus *Bus, name string) ddd.DomainEvent {
+	t.Helper()
+	named := bus.Named(name)
+	if len(named) != 1 {
+		t.Fatalf("expected one %s event, got %d; published: %s", name, len(named), names(bus.Events()))
+	}
+	return named[0]
+}
+
+// ExpectNoEvents fails the test if any event was published.
+func ExpectNoEvents(t testing.TB, bus *Bus) {
+	t.Helper()
+	if events := bus.Events(); len(events) > 0 {
+		t.Fatalf("expected no events, published: %s", names(events))
+	}
+}
+
+// ExpectPayload fails the test unless the JSON payload of e has the given fields and values.
+// Fields are named by their JSON keys; fields not listed are not checked.
+func ExpectPayload(t testing.TB, e ddd.DomainEvent, fields map[string]any) {
+	t.Helper()
+	actual, err := jsonFields(e)
+	if err != nil {
+		t.Fatalf("failed to encode %s event: %v", e.EventName(), err)
+	}
+	expected, err := jsonFields(fields)
+	if err != nil {
+		t.Fatalf("failed to encode expected fields: %v", err)
+	}
+
+	keys := make([]string, 0, len(expected))
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		value, ok := actual[key]
+		if !ok {
+			t.Errorf("%s event has no field %q", e.EventName(), key)
+			continue
+		}
+		if !reflect.DeepEqual(value, expected[key]) {
+			t.Errorf("%s event field %q = %s, want %s", e.EventName(), key, compact(value), compact(expected[key]))
+		}
+	}
+}
+
+// as returns e as T, dereferencing or taking the address of e if T is its value or pointer type.
+func as[T ddd.DomainEvent](e ddd.DomainEvent) (T, bool) {
+	if typed, ok := e.(T); ok {
+		return typed, true
+	}
+	var zero T
+	target := reflect.TypeOf(&zero).Elem()
+	value := reflect.ValueOf(e)
+	switch {
+	case value.Kind() == reflect.Pointer && !value.IsNil() && value.Elem().Type() == target:
+		return value.Elem().Interface().(T), true
+	case target.Kind() == reflect.Pointer && target.Elem() == value.Type():
+		ptr := reflect.New(value.Type())
+		ptr.Elem().Set(value)
+		if typed, ok := ptr.Interface().(T); ok {
+			return typed, true
+		}
+	}
+	return zero, false
+}
+
+// jsonFields normalises v to its JSON object, so that numbers and times compare as published.
+func jsonFields(v any) (map[string]any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	err = json.Unmarshal(raw, &fields)
+	return fields, err
+}
+
+func compact(v any) string {
+	raw, _ := json.Marshal(v)
+	return string(raw)
+}
+
+func eventNames(events []ddd.DomainEvent) []string {
+	result := make([]string, len(events))
+	for i, e := range events {
+		result[i] = e.EventName()
+	}
+	return result
+}
+
+func names(events []ddd.DomainEvent) string {
+	if len(events) == 0 {
+		return "none"
+	}
+	return strings.Join(eventNames(events), ", ")
+}
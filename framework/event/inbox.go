@@ -0,0 +1,187 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InboxMessage is the database row recording that a subscriber processed a message.
+type InboxMessage struct {
+	MessageID   string    `gorm:"primaryKey;size:64"`
+	Subscriber  string    `gorm:"primaryKey;size:255"`
+	EventName   string    `gorm:"size:255"`
+	ProcessedAt time.Time `gorm:"not null;index"`
+}
+
+// TableName overrides the GORM table name.
+func (InboxMessage) TableName() string {
+	return "event_inbox"
+}
+
+// MigrateInbox creates the inbox table if it does not exist.
+func MigrateInbox(db *gorm.DB) error {
+	return orm.Primary(db).AutoMigrate(&InboxMessage{})
+}
+
+// Inbox makes event handlers idempotent by recording processed message IDs per subscriber.
+// The record is written in the same transaction as the handler's own writes, so a failed
+// handler leaves no trace and the message is processed again on redelivery.
+type Inbox struct {
+	db        *gorm.DB
+	retention time.Duration
+	interval  time.Duration
+	logger    watermill.LoggerAdapter
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// InboxOption is a functional option for Inbox.
+type InboxOption func(*Inbox)
+
+// WithInboxRetention sets how long processed message IDs are kept, and how often they are cleaned up.
+// Redeliveries older than the retention are no longer detected.
+func WithInboxRetention(retention, cleanupInterval time.Duration) InboxOption {
+	return func(i *Inbox) {
+		i.retention = retention
+		i.interval = cleanupInterval
+	}
+}
+
+// WithInboxLogger sets a custom logger.
+func WithInboxLogger(logger watermill.LoggerAdapter) InboxOption {
+	return func(i *Inbox) {
+		i.logger = logger
+	}
+}
+
+// NewInbox creates an Inbox.
+func NewInbox(db *gorm.DB, opts ...InboxOption) *Inbox {
+	inbox := &Inbox{
+		db:        db,
+		retention: 7 * 24 * time.Hour,
+		interval:  time.Hour,
+		logger:    watermill.NewStdLogger(false, false),
+	}
+	for _, opt := range opts {
+		opt(inbox)
+	}
+	return inbox
+}
+
+// Wrap returns a handler that runs handler at most once per message for the named subscriber.
+// The handler receives a context carrying the transaction (see orm.Transaction),
+// which GormRepository picks up automatically.
+func (i *Inbox) Wrap(subscriber string, handler EventHandler) EventHandler {
+	return func(ctx context.Context, e ddd.DomainEvent) error {
+		messageID := MessageIDFromContext(ctx)
+		if messageID == "" {
+			messageID = ddd.MetadataOf(e).EventID
+		}
+		if messageID == "" {
+			// Nothing to deduplicate on.
+			return handler(ctx, e)
+		}
+		return i.once(ctx, subscriber, messageID, e.EventName(), func(ctx context.Context) error {
+			return handler(ctx, e)
+		})
+	}
+}
+
+// WrapRaw is the RawEventHandler counterpart of Wrap.
+func (i *Inbox) WrapRaw(subscriber string, handler RawEventHandler) RawEventHandler {
+	return func(ctx context.Context, eventName string, payload []byte) error {
+		messageID := MessageIDFromContext(ctx)
+		if messageID == "" {
+			return handler(ctx, eventName, payload)
+		}
+		return i.once(ctx, subscriber, messageID, eventName, func(ctx context.Context) error {
+			return handler(ctx, eventName, payload)
+		})
+	}
+}
+
+func (i *Inbox) once(ctx context.Context, subscriber, messageID, eventName string, fn func(ctx context.Context) error) error {
+	return orm.Transaction(ctx, i.db, func(ctx context.Context) error {
+		record := InboxMessage{
+			MessageID:   messageID,
+			Subscriber:  subscriber,
+			EventName:   eventName,
+			ProcessedAt: time.Now(),
+		}
+		result := orm.Conn(ctx, i.db).Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			i.logger.Debug("Skipping duplicate message", watermill.LogFields{
+				"subscriber": subscriber,
+				"event_name": eventName,
+				"message_id": messageID,
+			})
+			return nil
+		}
+		return fn(ctx)
+	})
+}
+
+// Processed reports whether the subscriber already processed the message.
+func (i *Inbox) Processed(ctx context.Context, subscriber, messageID string) (bool, error) {
+	var count int64
+	err := orm.Conn(ctx, i.db).Model(&InboxMessage{}).
+		Where("message_id = ? AND subscriber = ?", messageID, subscriber).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Cleanup deletes records older than the retention period and returns how many were removed.
+func (i *Inbox) Cleanup(ctx context.Context) (int64, error) {
+	result := orm.Primary(i.db).WithContext(ctx).
+		Where("processed_at < ?", time.Now().Add(-i.retention)).
+		Delete(&InboxMessage{})
+	return result.RowsAffected, result.Error
+}
+
+// Start launches periodic cleanup. It matches the fx.Hook OnStart signature.
+func (i *Inbox) Start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(context.Background())
+	i.cancel = cancel
+	i.done = make(chan struct{})
+
+	go func() {
+		defer close(i.done)
+		ticker := time.NewTicker(i.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				if _, err := i.Cleanup(runCtx); err != nil && runCtx.Err() == nil {
+					i.logger.Error("Inbox cleanup failed", err, nil)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops periodic cleanup. It matches the fx.Hook OnStop signature.
+func (i *Inbox) Stop(ctx context.Context) error {
+	if i.cancel == nil {
+		return nil
+	}
+	i.cancel()
+	select {
+	case <-i.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
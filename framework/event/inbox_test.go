@@ -0,0 +1,190 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+)
+
+// inboxEffect is a row a handler writes in the inbox transaction.
+type inboxEffect struct {
+	ID string `gorm:"primaryKey"`
+}
+
+func newTestInbox(t *testing.T, opts ...InboxOption) (*Inbox, *gorm.DB) {
+	t.Helper()
+	db := openTestDB(t)
+	if err := MigrateInbox(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&inboxEffect{}); err != nil {
+		t.Fatal(err)
+	}
+	opts = append([]InboxOption{WithInboxLogger(watermill.NopLogger{})}, opts...)
+	return NewInbox(db, opts...), db
+}
+
+// effectHandler writes an effect per call through the context transaction and fails while err is set.
+type effectHandler struct {
+	db    *gorm.DB
+	calls int
+	err   error
+}
+
+func (h *effectHandler) Handle(ctx context.Context, e ddd.DomainEvent) error {
+	h.calls++
+	effect := inboxEffect{ID: fmt.Sprintf("%s-%d", e.(testEvent).Value, h.calls)}
+	if err := orm.Conn(ctx, h.db).Create(&effect).Error; err != nil {
+		return err
+	}
+	return h.err
+}
+
+func countEffects(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(&inboxEffect{}).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestInboxRunsRedeliveredMessageOnce(t *testing.T) {
+	inbox, db := newTestInbox(t)
+	h := &effectHandler{db: db}
+	handle := inbox.Wrap("test.Projector", h.Handle)
+	e := newTestEvent("once")
+	ctx := withMessageID(context.Background(), "message-1")
+
+	for range 3 {
+		if err := handle(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if h.calls != 1 || countEffects(t, db) != 1 {
+		t.Fatalf("handler ran %d times with %d effects, want once", h.calls, countEffects(t, db))
+	}
+	if processed, err := inbox.Processed(context.Background(), "test.Projector", "message-1"); err != nil || !processed {
+		t.Errorf("Processed = %t, %v; want true", processed, err)
+	}
+
+	// Another subscriber and another message are deduplicated separately.
+	if err := inbox.Wrap("test.Mailer", h.Handle)(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+	if err := handle(withMessageID(context.Background(), "message-2"), e); err != nil {
+		t.Fatal(err)
+	}
+	if h.calls != 3 {
+		t.Errorf("handler ran %d times, want once more per subscriber and per message", h.calls)
+	}
+
+	// Without a message ID in the context the event ID is used.
+	h.calls = 0
+	fresh := newTestEvent("by-event-id")
+	for range 2 {
+		if err := handle(context.Background(), fresh); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if h.calls != 1 {
+		t.Errorf("handler ran %d times for an event delivered twice, want 1", h.calls)
+	}
+}
+
+func TestInboxHandlerErrorRollsBackRecord(t *testing.T) {
+	inbox, db := newTestInbox(t)
+	h := &effectHandler{db: db, err: errors.New("failed")}
+	handle := inbox.Wrap("test.Projector", h.Handle)
+	e := newTestEvent("retried")
+	ctx := withMessageID(context.Background(), "message-1")
+
+	if err := handle(ctx, e); !errors.Is(err, h.err) {
+		t.Fatalf("handle = %v, want the handler error", err)
+	}
+	if processed, _ := inbox.Processed(context.Background(), "test.Projector", "message-1"); processed {
+		t.Error("failed message recorded as processed")
+	}
+	if n := countEffects(t, db); n != 0 {
+		t.Errorf("%d effects kept after the failure, want them rolled back", n)
+	}
+
+	h.err = nil
+	if err := handle(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+	if h.calls != 2 || countEffects(t, db) != 1 {
+		t.Errorf("handler ran %d times with %d effects, want the retry to run", h.calls, countEffects(t, db))
+	}
+	if processed, _ := inbox.Processed(context.Background(), "test.Projector", "message-1"); !processed {
+		t.Error("retried message not recorded as processed")
+	}
+}
+
+func TestInboxWrapRawWithoutMessageID(t *testing.T) {
+	inbox, _ := newTestInbox(t)
+	calls := 0
+	handle := inbox.WrapRaw("test.Raw", func(ctx context.Context, eventName string, payload []byte) error {
+		calls++
+		return nil
+	})
+
+	for range 2 {
+		if err := handle(context.Background(), "test.happened", nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := handle(withMessageID(context.Background(), "message-1"), "test.happened", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Deduplicated only when there is a message ID.
+	if calls != 3 {
+		t.Errorf("handler ran %d times, want 3", calls)
+	}
+}
+
+func TestInboxCleanupRemovesExpiredRecords(t *testing.T) {
+	inbox, db := newTestInbox(t, WithInboxRetention(time.Hour, 10*time.Millisecond))
+	records := []InboxMessage{
+		{MessageID: "old", Subscriber: "s", ProcessedAt: time.Now().Add(-2 * time.Hour)},
+		{MessageID: "older", Subscriber: "s", ProcessedAt: time.Now().Add(-48 * time.Hour)},
+		{MessageID: "recent", Subscriber: "s", ProcessedAt: time.Now()},
+	}
+	if err := db.Create(&records).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := inbox.Cleanup(context.Background()); err != nil || n != 2 {
+		t.Fatalf("Cleanup = %d, %v; want 2, nil", n, err)
+	}
+	if processed, _ := inbox.Processed(context.Background(), "s", "recent"); !processed {
+		t.Error("record within the retention was removed")
+	}
+
+	// The cleanup loop removes records that expire while it runs.
+	expired := InboxMessage{MessageID: "expired", Subscriber: "s", ProcessedAt: time.Now().Add(-2 * time.Hour)}
+	if err := db.Create(&expired).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := inbox.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer inbox.Stop(context.Background())
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if processed, _ := inbox.Processed(context.Background(), "s", "expired"); !processed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cleanup loop did not remove the expired record")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,311 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/soliton-go/framework/ddd"
+	"go.uber.org/zap"
+)
+
+// EventMessage is what publish and handler middleware operate on.
+type EventMessage struct {
+	// Topic is the topic the message is published to or was received from.
+	Topic string
+	// EventName is the registered name of the event.
+	EventName string
+	// Event is the decoded event. It is nil for SubscribeRaw handlers.
+	Event ddd.DomainEvent
+	// Message is the underlying Watermill message. Publish middleware may add metadata to it.
+	Message *message.Message
+}
+
+// PublishFunc publishes a single event message.
+type PublishFunc func(ctx context.Context, m *EventMessage) error
+
+// HandleFunc handles a single delivery of an event message.
+type HandleFunc func(ctx context.Context, m *EventMessage) error
+
+// PublishMiddleware wraps every Publish. The first middleware registered is the outermost.
+type PublishMiddleware func(next PublishFunc) PublishFunc
+
+// HandlerMiddleware wraps every handler attempt. The first middleware registered is the outermost.
+// Errors returned by the chain go through the subscription's retry policy.
+type HandlerMiddleware func(next HandleFunc) HandleFunc
+
+// WithPublishMiddleware appends publish middleware.
+func WithPublishMiddleware(mw ...PublishMiddleware) WatermillEventBusOption {
+	return func(b *WatermillEventBus) {
+		b.publishMiddleware = append(b.publishMiddleware, mw...)
+	}
+}
+
+// WithHandlerMiddleware appends handler middleware. Recoverer is always installed first.
+func WithHandlerMiddleware(mw ...HandlerMiddleware) WatermillEventBusOption {
+	return func(b *WatermillEventBus) {
+		b.handlerMiddleware = append(b.handlerMiddleware, mw...)
+	}
+}
+
+// WithLogging logs every publish and handler attempt with zap.
+func WithLogging(logger *zap.Logger) WatermillEventBusOption {
+	return func(b *WatermillEventBus) {
+		b.publishMiddleware = append(b.publishMiddleware, PublishLogging(logger))
+		b.handlerMiddleware = append(b.handlerMiddleware, HandlerLogging(logger))
+	}
+}
+
+// WithMetrics reports the duration and outcome of every publish and handler attempt.
+func WithMetrics(recorder MetricsRecorder) WatermillEventBusOption {
+	return func(b *WatermillEventBus) {
+		b.publishMiddleware = append(b.publishMiddleware, PublishMetrics(recorder))
+		b.handlerMiddleware = append(b.handlerMiddleware, HandlerMetrics(recorder))
+	}
+}
+
+// WithTracing propagates tracing context from publishers to handlers through message metadata.
+func WithTracing(propagator TracePropagator) WatermillEventBusOption {
+	return func(b *WatermillEventBus) {
+		b.publishMiddleware = append(b.publishMiddleware, PublishTracing(propagator))
+		b.handlerMiddleware = append(b.handlerMiddleware, HandlerTracing(propagator))
+	}
+}
+
+// WithValidation rejects invalid events before they are published and before they are handled.
+func WithValidation(validate Validator) WatermillEventBusOption {
+	return func(b *WatermillEventBus) {
+		b.publishMiddleware = append(b.publishMiddleware, PublishValidation(validate))
+		b.handlerMiddleware = append(b.handlerMiddleware, HandlerValidation(validate))
+	}
+}
+
+func chainPublish(final PublishFunc, mw []PublishMiddleware) PublishFunc {
+	for i := len(mw) - 1; i >= 0; i-- {
+		final = mw[i](final)
+	}
+	return final
+}
+
+func chainHandler(final HandleFunc, mw []HandlerMiddleware) HandleFunc {
+	for i := len(mw) - 1; i >= 0; i-- {
+		final = mw[i](final)
+	}
+	return final
+}
+
+// Permanent marks a handler error as not retryable: the message is dead-lettered immediately
+// instead of going through the remaining attempts of the retry policy.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var target *permanentError
+	return errors.As(err, &target)
+}
+
+// Recoverer turns a panicking handler into an error, so that it is retried or dead-lettered
+// instead of crashing the process. The panic value and stack are logged to logger only; the
+// error, which ends up in dead letters, just names the event.
+func Recoverer(logger watermill.LoggerAdapter) HandlerMiddleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx context.Context, m *EventMessage) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("Event handler panicked", fmt.Errorf("%v", r), watermill.LogFields{
+						"event_name": m.EventName,
+						"message_id": m.Message.UUID,
+						"stack":      string(debug.Stack()),
+					})
+					err = fmt.Errorf("panic in handler for %s", m.EventName)
+				}
+			}()
+			return next(ctx, m)
+		}
+	}
+}
+
+// PublishLogging logs every publish.
+func PublishLogging(logger *zap.Logger) PublishMiddleware {
+	return func(next PublishFunc) PublishFunc {
+		return func(ctx context.Context, m *EventMessage) error {
+			start := time.Now()
+			err := next(ctx, m)
+			fields := append(messageFields(m), zap.Duration("duration", time.Since(start)))
+			if err != nil {
+				logger.Error("event publish failed", append(fields, zap.Error(err))...)
+			} else {
+				logger.Debug("event published", fields...)
+			}
+			return err
+		}
+	}
+}
+
+// HandlerLogging logs every handler attempt.
+func HandlerLogging(logger *zap.Logger) HandlerMiddleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx context.Context, m *EventMessage) error {
+			start := time.Now()
+			err := next(ctx, m)
+			fields := append(messageFields(m), zap.Duration("duration", time.Since(start)))
+			if err != nil {
+				logger.Warn("event handler failed", append(fields, zap.Error(err))...)
+			} else {
+				logger.Debug("event handled", fields...)
+			}
+			return err
+		}
+	}
+}
+
+func messageFields(m *EventMessage) []zap.Field {
+	return []zap.Field{
+		zap.String("topic", m.Topic),
+		zap.String("event_name", m.EventName),
+		zap.String("message_id", m.Message.UUID),
+		zap.String("correlation_id", m.Message.Metadata.Get(MetadataCorrelationID)),
+	}
+}
+
+// MetricsRecorder receives the duration and outcome of publishes and handler attempts,
+// e.g. to feed Prometheus histograms.
+type MetricsRecorder interface {
+	ObservePublish(topic, eventName string, duration time.Duration, err error)
+	ObserveHandle(topic, eventName string, duration time.Duration, err error)
+}
+
+// PublishMetrics times every publish.
+func PublishMetrics(recorder MetricsRecorder) PublishMiddleware {
+	return func(next PublishFunc) PublishFunc {
+		return func(ctx context.Context, m *EventMessage) error {
+			start := time.Now()
+			err := next(ctx, m)
+			recorder.ObservePublish(m.Topic, m.EventName, time.Since(start), err)
+			return err
+		}
+	}
+}
+
+// HandlerMetrics times every handler attempt.
+func HandlerMetrics(recorder MetricsRecorder) HandlerMiddleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx context.Context, m *EventMessage) error {
+			start := time.Now()
+			err := next(ctx, m)
+			recorder.ObserveHandle(m.Topic, m.EventName, time.Since(start), err)
+			return err
+		}
+	}
+}
+
+// TracePropagator carries tracing context in message metadata.
+// An OpenTelemetry propagation.TextMapPropagator can be adapted to it in a few lines.
+type TracePropagator interface {
+	Inject(ctx context.Context, metadata message.Metadata)
+	Extract(ctx context.Context, metadata message.Metadata) context.Context
+}
+
+// W3CTraceContext propagates the W3C traceparent and tracestate values stored with WithTraceContext.
+type W3CTraceContext struct{}
+
+// Message metadata keys used by W3CTraceContext.
+const (
+	MetadataTraceParent = "traceparent"
+	MetadataTraceState  = "tracestate"
+)
+
+// Inject implements TracePropagator.
+func (W3CTraceContext) Inject(ctx context.Context, metadata message.Metadata) {
+	parent, state := TraceContextFromContext(ctx)
+	if parent != "" {
+		metadata.Set(MetadataTraceParent, parent)
+	}
+	if state != "" {
+		metadata.Set(MetadataTraceState, state)
+	}
+}
+
+// Extract implements TracePropagator.
+func (W3CTraceContext) Extract(ctx context.Context, metadata message.Metadata) context.Context {
+	parent := metadata.Get(MetadataTraceParent)
+	if parent == "" {
+		return ctx
+	}
+	return WithTraceContext(ctx, parent, metadata.Get(MetadataTraceState))
+}
+
+// PublishTracing injects the tracing context of ctx into the message metadata.
+func PublishTracing(propagator TracePropagator) PublishMiddleware {
+	return func(next PublishFunc) PublishFunc {
+		return func(ctx context.Context, m *EventMessage) error {
+			propagator.Inject(ctx, m.Message.Metadata)
+			return next(ctx, m)
+		}
+	}
+}
+
+// HandlerTracing restores the tracing context from the message metadata.
+func HandlerTracing(propagator TracePropagator) HandlerMiddleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx context.Context, m *EventMessage) error {
+			return next(propagator.Extract(ctx, m.Message.Metadata), m)
+		}
+	}
+}
+
+// Validator checks an event before it is published or handled.
+type Validator func(e ddd.DomainEvent) error
+
+// ValidateSelf is a Validator that calls the event's own Validate() error method, if it has one.
+func ValidateSelf(e ddd.DomainEvent) error {
+	if v, ok := e.(interface{ Validate() error }); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// PublishValidation refuses to publish invalid events.
+func PublishValidation(validate Validator) PublishMiddleware {
+	return func(next PublishFunc) PublishFunc {
+		return func(ctx context.Context, m *EventMessage) error {
+			if m.Event != nil {
+				if err := validate(m.Event); err != nil {
+					return fmt.Errorf("invalid event %s: %w", m.EventName, err)
+				}
+			}
+			return next(ctx, m)
+		}
+	}
+}
+
+// HandlerValidation dead-letters invalid events without calling the handler.
+// Raw subscriptions are not validated.
+func HandlerValidation(validate Validator) HandlerMiddleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx context.Context, m *EventMessage) error {
+			if m.Event != nil {
+				if err := validate(m.Event); err != nil {
+					return Permanent(fmt.Errorf("invalid event %s: %w", m.EventName, err))
+				}
+			}
+			return next(ctx, m)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/soliton-go/framework/ddd"
+)
+
+// callLog records the order in which middleware and handlers run.
+type callLog struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (l *callLog) add(call string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, call)
+}
+
+func (l *callLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return fmt.Sprint(l.calls)
+}
+
+func (l *callLog) publish(name string) PublishMiddleware {
+	return func(next PublishFunc) PublishFunc {
+		return func(ctx context.Context, m *EventMessage) error {
+			l.add(name + ">")
+			m.Message.Metadata.Set("seen_by", m.Message.Metadata.Get("seen_by")+name)
+			err := next(ctx, m)
+			l.add("<" + name)
+			return err
+		}
+	}
+}
+
+func (l *callLog) handler(name string) HandlerMiddleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx context.Context, m *EventMessage) error {
+			l.add(name + ">")
+			err := next(ctx, m)
+			l.add("<" + name)
+			return err
+		}
+	}
+}
+
+func TestMiddlewareRunsInRegistrationOrder(t *testing.T) {
+	var log callLog
+	seenBy := make(chan string, 1)
+	capture := func(next HandleFunc) HandleFunc {
+		return func(ctx context.Context, m *EventMessage) error {
+			seenBy <- m.Message.Metadata.Get("seen_by")
+			return next(ctx, m)
+		}
+	}
+	bus := NewLocalEventBus(
+		WithRegistry(newTestRegistry()),
+		WithLogger(watermill.NopLogger{}),
+		WithPublishMiddleware(log.publish("p1"), log.publish("p2")),
+		WithHandlerMiddleware(log.handler("h1")),
+		WithHandlerMiddleware(log.handler("h2"), capture),
+	)
+	defer bus.Close()
+	ctx := context.Background()
+
+	err := bus.Subscribe(ctx, "test.happened", func(ctx context.Context, e ddd.DomainEvent) error {
+		log.add("handler")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Publish(ctx, newTestEvent("ordered")); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case got := <-seenBy:
+		// Metadata set by publish middleware reaches the handler, in middleware order.
+		if got != "p1p2" {
+			t.Errorf("handler saw metadata %q, want p1p2", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+	// The handler middleware returns after the handler; wait for it to unwind.
+	deadline := time.Now().Add(5 * time.Second)
+	want := "[p1> p2> <p2 <p1 h1> h2> handler <h2 <h1]"
+	for log.String() != want && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := log.String(); got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+}
+
+func TestRecovererWrapsHandlerMiddleware(t *testing.T) {
+	panicking := func(next HandleFunc) HandleFunc {
+		return func(ctx context.Context, m *EventMessage) error {
+			panic("middleware bug")
+		}
+	}
+	store := newTestDeadLetterStore(t)
+	bus := NewLocalEventBus(
+		WithRegistry(newTestRegistry()),
+		WithLogger(watermill.NopLogger{}),
+		WithDeadLetterStore(store),
+		WithHandlerMiddleware(panicking),
+	)
+	defer bus.Close()
+	ctx := context.Background()
+
+	err := bus.Subscribe(ctx, "test.happened", func(ctx context.Context, e ddd.DomainEvent) error {
+		return nil
+	}, WithRetry(RetryPolicy{MaxAttempts: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Publish(ctx, newTestEvent("panics")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The panic became an error that was dead-lettered.
+	if item := waitForDeadLetter(t, store); item.Error != "panic in handler for test.happened" {
+		t.Errorf("dead letter error = %q, want the recovered panic", item.Error)
+	}
+}
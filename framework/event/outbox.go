@@ -0,0 +1,313 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+)
+
+// OutboxMessage is the database row for an event waiting to be relayed to the event bus.
+type OutboxMessage struct {
+	ID            string     `gorm:"primaryKey;size:64"` // event ID
+	EventName     string     `gorm:"size:255;not null"`
+	Payload       []byte     `gorm:"not null"`
+	Attempts      int        `gorm:"not null;default:0"`
+	LastError     string     `gorm:"size:1024"`
+	NextAttemptAt time.Time  `gorm:"not null;index"`
+	LockedUntil   *time.Time `gorm:"index"`
+	DispatchedAt  *time.Time `gorm:"index"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime"`
+}
+
+// TableName overrides the GORM table name.
+func (OutboxMessage) TableName() string {
+	return "event_outbox"
+}
+
+// MigrateOutbox creates the outbox table if it does not exist.
+func MigrateOutbox(db *gorm.DB) error {
+	return orm.Primary(db).AutoMigrate(&OutboxMessage{})
+}
+
+// Outbox stores domain events in the same transaction as the aggregate that raised them,
+// so that events are never lost or published for a write that was rolled back.
+type Outbox struct {
+	db       *gorm.DB
+	registry EventRegistry
+}
+
+// OutboxOption is a functional option for Outbox.
+type OutboxOption func(*Outbox)
+
+// WithOutboxRegistry sets the registry that provides the schema version of stored events.
+func WithOutboxRegistry(registry EventRegistry) OutboxOption {
+	return func(o *Outbox) {
+		o.registry = registry
+	}
+}
+
+// NewOutbox creates an Outbox.
+func NewOutbox(db *gorm.DB, opts ...OutboxOption) *Outbox {
+	outbox := &Outbox{db: db, registry: GlobalRegistry()}
+	for _, opt := range opts {
+		opt(outbox)
+	}
+	return outbox
+}
+
+// Store writes events to the outbox.
+// It joins the transaction carried by ctx (see orm.Transaction), so call it in the same
+// transaction that saves the aggregate.
+func (o *Outbox) Store(ctx context.Context, events ...ddd.DomainEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([]OutboxMessage, 0, len(events))
+	for _, e := range events {
+		// Fix the envelope now so that every relay attempt publishes the same event ID.
+		e = stampEnvelope(ctx, o.registry, e)
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event %s: %w", e.EventName(), err)
+		}
+		rows = append(rows, OutboxMessage{
+			ID:            ddd.MetadataOf(e).EventID,
+			EventName:     e.EventName(),
+			Payload:       payload,
+			NextAttemptAt: now,
+		})
+	}
+	return orm.Conn(ctx, o.db).Create(&rows).Error
+}
+
+// Publish stores events in the outbox, so that an Outbox can be used as a Publisher.
+func (o *Outbox) Publish(ctx context.Context, events ...ddd.DomainEvent) error {
+	return o.Store(ctx, events...)
+}
+
+// OutboxRelay periodically publishes pending outbox messages and marks them dispatched.
+// Every message is claimed for a lease before it is published, so several instances can relay
+// the same outbox; delivery is still at-least-once if an instance stops after publishing or
+// marking a message fails.
+type OutboxRelay struct {
+	outbox      *Outbox
+	bus         EventBus
+	registry    EventRegistry
+	logger      watermill.LoggerAdapter
+	interval    time.Duration
+	batchSize   int
+	lease       time.Duration
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// OutboxRelayOption is a functional option for OutboxRelay.
+type OutboxRelayOption func(*OutboxRelay)
+
+// WithRelayInterval sets how often the relay polls the outbox.
+func WithRelayInterval(interval time.Duration) OutboxRelayOption {
+	return func(r *OutboxRelay) {
+		r.interval = interval
+	}
+}
+
+// WithRelayBatchSize sets the maximum number of messages published per poll.
+func WithRelayBatchSize(size int) OutboxRelayOption {
+	return func(r *OutboxRelay) {
+		r.batchSize = size
+	}
+}
+
+// WithRelayLease sets how long a claimed message is hidden from other relays. It should exceed
+// the time a batch takes to publish.
+func WithRelayLease(lease time.Duration) OutboxRelayOption {
+	return func(r *OutboxRelay) {
+		r.lease = lease
+	}
+}
+
+// WithRelayRetry sets the maximum publish attempts and the base delay of the exponential backoff.
+// Messages that exhaust their attempts stay in the outbox with their last error.
+func WithRelayRetry(maxAttempts int, backoff, maxBackoff time.Duration) OutboxRelayOption {
+	return func(r *OutboxRelay) {
+		r.maxAttempts = maxAttempts
+		r.backoff = backoff
+		r.maxBackoff = maxBackoff
+	}
+}
+
+// WithRelayRegistry sets the registry used to decode outbox messages.
+func WithRelayRegistry(registry EventRegistry) OutboxRelayOption {
+	return func(r *OutboxRelay) {
+		r.registry = registry
+	}
+}
+
+// WithRelayLogger sets a custom logger.
+func WithRelayLogger(logger watermill.LoggerAdapter) OutboxRelayOption {
+	return func(r *OutboxRelay) {
+		r.logger = logger
+	}
+}
+
+// NewOutboxRelay creates an OutboxRelay that publishes through bus.
+func NewOutboxRelay(outbox *Outbox, bus EventBus, opts ...OutboxRelayOption) *OutboxRelay {
+	relay := &OutboxRelay{
+		outbox:      outbox,
+		bus:         bus,
+		registry:    GlobalRegistry(),
+		logger:      watermill.NewStdLogger(false, false),
+		interval:    time.Second,
+		batchSize:   100,
+		lease:       time.Minute,
+		maxAttempts: 10,
+		backoff:     time.Second,
+		maxBackoff:  5 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(relay)
+	}
+	return relay
+}
+
+// Start launches the background polling loop. It matches the fx.Hook OnStart signature.
+func (r *OutboxRelay) Start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			if _, err := r.DispatchPending(runCtx); err != nil && runCtx.Err() == nil {
+				r.logger.Error("Outbox relay poll failed", err, nil)
+			}
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops the polling loop and waits for the current batch to finish.
+// It matches the fx.Hook OnStop signature.
+func (r *OutboxRelay) Stop(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+	r.cancel()
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DispatchPending publishes one batch of due messages and returns how many were dispatched.
+func (r *OutboxRelay) DispatchPending(ctx context.Context) (int, error) {
+	db := orm.Primary(r.outbox.db).WithContext(ctx)
+	now := time.Now()
+
+	var pending []OutboxMessage
+	err := db.
+		Where("dispatched_at IS NULL AND attempts < ? AND next_attempt_at <= ?", r.maxAttempts, now).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Order("created_at ASC").
+		Limit(r.batchSize).
+		Find(&pending).Error
+	if err != nil {
+		return 0, err
+	}
+
+	dispatched := 0
+	for _, msg := range pending {
+		if ctx.Err() != nil {
+			return dispatched, ctx.Err()
+		}
+		claimed, err := r.claim(ctx, msg)
+		if err != nil {
+			return dispatched, err
+		}
+		if !claimed {
+			continue
+		}
+		if err := r.publish(ctx, msg); err != nil {
+			r.markFailed(ctx, msg, err)
+			continue
+		}
+		now := time.Now()
+		if err := db.Model(&OutboxMessage{}).Where("id = ?", msg.ID).Update("dispatched_at", &now).Error; err != nil {
+			return dispatched, err
+		}
+		dispatched++
+	}
+	return dispatched, nil
+}
+
+// claim locks a pending message for the lease, unless another relay got there first.
+func (r *OutboxRelay) claim(ctx context.Context, msg OutboxMessage) (bool, error) {
+	now := time.Now()
+	until := now.Add(r.lease)
+	result := orm.Primary(r.outbox.db).WithContext(ctx).Model(&OutboxMessage{}).
+		Where("id = ? AND dispatched_at IS NULL", msg.ID).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Update("locked_until", &until)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, msg OutboxMessage) error {
+	// The schema version is read from the payload, so rows written before a deployment are upcast.
+	e, err := r.registry.Decode(msg.EventName, 0, msg.Payload)
+	if err != nil {
+		return err
+	}
+	return r.bus.Publish(ctx, e)
+}
+
+func (r *OutboxRelay) markFailed(ctx context.Context, msg OutboxMessage, cause error) {
+	attempts := msg.Attempts + 1
+	delay := r.backoff << (attempts - 1)
+	if delay <= 0 || delay > r.maxBackoff {
+		delay = r.maxBackoff
+	}
+
+	r.logger.Error("Failed to relay outbox message", cause, watermill.LogFields{
+		"event_name": msg.EventName,
+		"message_id": msg.ID,
+		"attempts":   attempts,
+	})
+
+	lastError := cause.Error()
+	if len(lastError) > 1024 {
+		lastError = lastError[:1024]
+	}
+	err := orm.Primary(r.outbox.db).WithContext(ctx).Model(&OutboxMessage{}).Where("id = ?", msg.ID).Updates(map[string]any{
+		"attempts":        attempts,
+		"last_error":      lastError,
+		"next_attempt_at": time.Now().Add(delay),
+		"locked_until":    nil,
+	}).Error
+	if err != nil {
+		r.logger.Error("Failed to record outbox failure", err, watermill.LogFields{
+			"message_id": msg.ID,
+		})
+	}
+}
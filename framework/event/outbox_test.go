@@ -0,0 +1,275 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+)
+
+// stubBus records the events published through it and fails while err is set.
+// beforePublish, if set, runs once before the first publish.
+type stubBus struct {
+	mu            sync.Mutex
+	published     []ddd.DomainEvent
+	err           error
+	beforePublish func()
+}
+
+func (b *stubBus) Publish(ctx context.Context, events ...ddd.DomainEvent) error {
+	b.mu.Lock()
+	hook := b.beforePublish
+	b.beforePublish = nil
+	b.mu.Unlock()
+	if hook != nil {
+		hook()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	b.published = append(b.published, events...)
+	return nil
+}
+
+func (b *stubBus) Subscribe(ctx context.Context, topic string, handler EventHandler, opts ...SubscribeOption) error {
+	return nil
+}
+
+func (b *stubBus) fail(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.err = err
+}
+
+func (b *stubBus) events() []ddd.DomainEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]ddd.DomainEvent(nil), b.published...)
+}
+
+func newTestEvent(value string) testEvent {
+	return testEvent{BaseDomainEvent: ddd.NewBaseDomainEvent(), Value: value}
+}
+
+func newTestOutbox(t *testing.T) (*Outbox, *gorm.DB) {
+	t.Helper()
+	db := openTestDB(t)
+	if err := MigrateOutbox(db); err != nil {
+		t.Fatal(err)
+	}
+	return NewOutbox(db, WithOutboxRegistry(newTestRegistry())), db
+}
+
+func newTestRelay(outbox *Outbox, bus EventBus, opts ...OutboxRelayOption) *OutboxRelay {
+	opts = append([]OutboxRelayOption{WithRelayRegistry(newTestRegistry()), WithRelayLogger(watermill.NopLogger{})}, opts...)
+	return NewOutboxRelay(outbox, bus, opts...)
+}
+
+func outboxRow(t *testing.T, db *gorm.DB, id string) OutboxMessage {
+	t.Helper()
+	var msg OutboxMessage
+	if err := db.First(&msg, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+// makeDue lets a failed message be retried right away.
+func makeDue(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	if err := db.Model(&OutboxMessage{}).Where("1 = 1").Update("next_attempt_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOutboxStoreJoinsTransaction(t *testing.T) {
+	outbox, db := newTestOutbox(t)
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+
+	if err := orm.Transaction(ctx, db, func(ctx context.Context) error {
+		return outbox.Store(ctx, newTestEvent("committed"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := orm.Transaction(ctx, db, func(ctx context.Context) error {
+		if err := outbox.Store(ctx, newTestEvent("rolled back")); err != nil {
+			return err
+		}
+		return errRollback
+	}); !errors.Is(err, errRollback) {
+		t.Fatalf("Transaction = %v, want %v", err, errRollback)
+	}
+
+	var rows []OutboxMessage
+	if err := db.Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("outbox holds %d rows, want only the committed one", len(rows))
+	}
+}
+
+func TestOutboxRelayDispatchesPendingMessages(t *testing.T) {
+	outbox, db := newTestOutbox(t)
+	first, second := newTestEvent("first"), newTestEvent("second")
+	if err := outbox.Store(context.Background(), first, second); err != nil {
+		t.Fatal(err)
+	}
+	bus := &stubBus{}
+	relay := newTestRelay(outbox, bus)
+
+	n, err := relay.DispatchPending(context.Background())
+	if err != nil || n != 2 {
+		t.Fatalf("DispatchPending = %d, %v; want 2, nil", n, err)
+	}
+	published := bus.events()
+	if len(published) != 2 {
+		t.Fatalf("published %d events, want 2", len(published))
+	}
+	for _, e := range published {
+		id := ddd.MetadataOf(e).EventID
+		if id != first.EventID && id != second.EventID {
+			t.Errorf("published event ID %s, want the stored IDs", id)
+		}
+		if outboxRow(t, db, id).DispatchedAt == nil {
+			t.Errorf("message %s not marked dispatched", id)
+		}
+	}
+
+	if n, err := relay.DispatchPending(context.Background()); err != nil || n != 0 {
+		t.Errorf("second DispatchPending = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestOutboxRelayBacksOffFailedPublish(t *testing.T) {
+	outbox, db := newTestOutbox(t)
+	e := newTestEvent("retried")
+	if err := outbox.Store(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	bus := &stubBus{err: errors.New("broker down")}
+	relay := newTestRelay(outbox, bus, WithRelayRetry(3, time.Hour, 90*time.Minute))
+	ctx := context.Background()
+
+	for attempt, delay := range []time.Duration{time.Hour, 90 * time.Minute} {
+		before := time.Now()
+		if n, err := relay.DispatchPending(ctx); err != nil || n != 0 {
+			t.Fatalf("attempt %d: DispatchPending = %d, %v; want 0, nil", attempt+1, n, err)
+		}
+		row := outboxRow(t, db, e.EventID)
+		if row.Attempts != attempt+1 || row.LastError != "broker down" || row.LockedUntil != nil {
+			t.Fatalf("attempt %d: row = %d attempts, error %q, locked until %v", attempt+1, row.Attempts, row.LastError, row.LockedUntil)
+		}
+		// The second delay is capped at the maximum backoff rather than doubled.
+		if wait := row.NextAttemptAt.Sub(before); wait < delay || wait > delay+time.Minute {
+			t.Fatalf("attempt %d: retried after %v, want %v", attempt+1, wait, delay)
+		}
+		// Not due yet.
+		if n, _ := relay.DispatchPending(ctx); n != 0 || outboxRow(t, db, e.EventID).Attempts != attempt+1 {
+			t.Fatalf("attempt %d: message retried before its backoff", attempt+1)
+		}
+		makeDue(t, db)
+	}
+
+	bus.fail(nil)
+	if n, err := relay.DispatchPending(ctx); err != nil || n != 1 {
+		t.Fatalf("DispatchPending after recovery = %d, %v; want 1, nil", n, err)
+	}
+}
+
+func TestOutboxRelayStopsAfterMaxAttempts(t *testing.T) {
+	outbox, db := newTestOutbox(t)
+	e := newTestEvent("abandoned")
+	if err := outbox.Store(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	bus := &stubBus{err: errors.New("broker down")}
+	relay := newTestRelay(outbox, bus, WithRelayRetry(2, time.Millisecond, time.Millisecond))
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := relay.DispatchPending(ctx); err != nil {
+			t.Fatal(err)
+		}
+		makeDue(t, db)
+	}
+	if row := outboxRow(t, db, e.EventID); row.Attempts != 2 || row.DispatchedAt != nil {
+		t.Fatalf("row = %d attempts, dispatched %v; want 2 attempts, not dispatched", row.Attempts, row.DispatchedAt)
+	}
+
+	bus.fail(nil)
+	if n, _ := relay.DispatchPending(ctx); n != 0 || len(bus.events()) != 0 {
+		t.Errorf("message published after exhausting its attempts")
+	}
+}
+
+func TestOutboxRelaySkipsClaimedMessages(t *testing.T) {
+	outbox, db := newTestOutbox(t)
+	e := newTestEvent("claimed")
+	if err := outbox.Store(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	bus := &stubBus{}
+	relay := newTestRelay(outbox, bus)
+
+	// Another relay holds the lease.
+	until := time.Now().Add(time.Minute)
+	if err := db.Model(&OutboxMessage{}).Where("id = ?", e.EventID).Update("locked_until", &until).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n, err := relay.DispatchPending(context.Background()); err != nil || n != 0 {
+		t.Fatalf("DispatchPending with a held lease = %d, %v; want 0, nil", n, err)
+	}
+
+	// The lease expired: the other relay stopped without marking the message.
+	expired := time.Now().Add(-time.Second)
+	if err := db.Model(&OutboxMessage{}).Where("id = ?", e.EventID).Update("locked_until", &expired).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n, err := relay.DispatchPending(context.Background()); err != nil || n != 1 {
+		t.Fatalf("DispatchPending after the lease expired = %d, %v; want 1, nil", n, err)
+	}
+}
+
+func TestOutboxRelaysDoNotPublishTwice(t *testing.T) {
+	outbox, _ := newTestOutbox(t)
+	events := []ddd.DomainEvent{newTestEvent("a"), newTestEvent("b"), newTestEvent("c")}
+	if err := outbox.Store(context.Background(), events...); err != nil {
+		t.Fatal(err)
+	}
+	bus := &stubBus{}
+	first, second := newTestRelay(outbox, bus), newTestRelay(outbox, bus)
+
+	// The second relay polls while the first one is publishing the batch it has read.
+	bus.beforePublish = func() {
+		if _, err := second.DispatchPending(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := first.DispatchPending(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]int)
+	for _, e := range bus.events() {
+		seen[ddd.MetadataOf(e).EventID]++
+	}
+	if len(seen) != len(events) {
+		t.Errorf("published %d distinct events, want %d", len(seen), len(events))
+	}
+	for id, n := range seen {
+		if n > 1 {
+			t.Errorf("event %s published %d times", id, n)
+		}
+	}
+}
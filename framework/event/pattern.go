@@ -0,0 +1,65 @@
+package event
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// Topic pattern wildcards. Topics are dot-separated, e.g. "order.created".
+const (
+	// WildcardSegment matches exactly one segment: "order.*" matches "order.created".
+	WildcardSegment = "*"
+	// WildcardRest matches one or more trailing segments: "order.>" matches "order.item.added",
+	// and ">" alone matches every topic.
+	WildcardRest = ">"
+)
+
+// PatternSubscriber is implemented by Watermill subscribers whose broker routes wildcard
+// subscriptions natively (e.g. NATS subjects). Messages must carry the event_name metadata.
+// Without it, the event bus subscribes to every registered topic matching the pattern.
+type PatternSubscriber interface {
+	SubscribePattern(ctx context.Context, pattern string) (<-chan *message.Message, error)
+}
+
+// IsPattern reports whether topic contains a wildcard segment.
+func IsPattern(topic string) bool {
+	for _, segment := range strings.Split(topic, ".") {
+		if segment == WildcardSegment || segment == WildcardRest {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchTopic reports whether topic matches pattern.
+func MatchTopic(pattern, topic string) bool {
+	patternSegments := strings.Split(pattern, ".")
+	topicSegments := strings.Split(topic, ".")
+	for i, p := range patternSegments {
+		if p == WildcardRest {
+			return i == len(patternSegments)-1 && len(topicSegments) > i
+		}
+		if i >= len(topicSegments) {
+			return false
+		}
+		if p != WildcardSegment && p != topicSegments[i] {
+			return false
+		}
+	}
+	return len(patternSegments) == len(topicSegments)
+}
+
+// MatchingTopics returns the registered event names matching pattern, sorted.
+func MatchingTopics(registry EventRegistry, pattern string) []string {
+	var topics []string
+	for _, name := range registry.Names() {
+		if MatchTopic(pattern, name) {
+			topics = append(topics, name)
+		}
+	}
+	sort.Strings(topics)
+	return topics
+}
@@ -0,0 +1,138 @@
+package event
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/soliton-go/framework/ddd"
+)
+
+// topicEvent is published under the topic in its Name.
+type topicEvent struct {
+	ddd.BaseDomainEvent
+	Name string `json:"name"`
+}
+
+func (e topicEvent) EventName() string { return e.Name }
+
+func newTopicRegistry(names ...string) *DefaultEventRegistry {
+	registry := NewEventRegistry()
+	for _, name := range names {
+		registry.Register(name, func() ddd.DomainEvent { return &topicEvent{} })
+	}
+	return registry
+}
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		pattern string
+		topic   string
+		want    bool
+	}{
+		{"order.created", "order.created", true},
+		{"order.created", "order.paid", false},
+		{"order.*", "order.created", true},
+		{"order.*", "order", false},
+		{"order.*", "order.item.added", false},
+		{"order.*", "user.created", false},
+		{"*.created", "user.created", true},
+		{"*.created", "order.item.created", false},
+		{"order.*.added", "order.item.added", true},
+		{"order.>", "order.created", true},
+		{"order.>", "order.item.added", true},
+		{"order.>", "order", false},
+		{">", "order.item.added", true},
+		{"order.>.added", "order.item.added", false},
+	}
+	for _, tt := range tests {
+		if got := MatchTopic(tt.pattern, tt.topic); got != tt.want {
+			t.Errorf("MatchTopic(%q, %q) = %t, want %t", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestIsPattern(t *testing.T) {
+	tests := []struct {
+		topic string
+		want  bool
+	}{
+		{"order.created", false},
+		{"order.*", true},
+		{"*.created", true},
+		{"order.>", true},
+		{">", true},
+		// Wildcards only count as whole segments.
+		{"order.created*", false},
+	}
+	for _, tt := range tests {
+		if got := IsPattern(tt.topic); got != tt.want {
+			t.Errorf("IsPattern(%q) = %t, want %t", tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestMatchingTopics(t *testing.T) {
+	registry := newTopicRegistry("order.paid", "order.created", "order.item.added", "user.created")
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"order.*", "order.created order.paid"},
+		{"order.>", "order.created order.item.added order.paid"},
+		{"*.created", "order.created user.created"},
+		{"payment.*", ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(MatchingTopics(registry, tt.pattern), " "); got != tt.want {
+			t.Errorf("MatchingTopics(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestSubscribePatternReceivesMatchingTopics(t *testing.T) {
+	registry := newTopicRegistry("order.created", "order.paid", "order.item.added", "user.created")
+	bus := NewLocalEventBus(WithRegistry(registry), WithLogger(watermill.NopLogger{}))
+	defer bus.Close()
+	ctx := context.Background()
+
+	received := make(chan string, 10)
+	err := bus.Subscribe(ctx, "order.*", func(ctx context.Context, e ddd.DomainEvent) error {
+		received <- e.EventName()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"order.created", "order.item.added", "user.created", "order.paid"} {
+		if err := bus.Publish(ctx, topicEvent{BaseDomainEvent: ddd.NewBaseDomainEvent(), Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	for len(got) < 2 {
+		select {
+		case name := <-received:
+			got = append(got, name)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %v, want order.created and order.paid", got)
+		}
+	}
+	sort.Strings(got)
+	if strings.Join(got, " ") != "order.created order.paid" {
+		t.Errorf("received %v, want order.created and order.paid", got)
+	}
+	select {
+	case name := <-received:
+		t.Errorf("received %s, which does not match order.*", name)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := bus.Subscribe(ctx, "payment.*", func(context.Context, ddd.DomainEvent) error { return nil }); err == nil {
+		t.Error("subscribing to a pattern no registered event matches succeeded")
+	}
+}
@@ -0,0 +1,185 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecordedEvent is the database row of an event kept by an EventRecorder.
+// ID is the position of the event in the log.
+type RecordedEvent struct {
+	ID            uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	MessageID     string    `gorm:"size:64;not null;uniqueIndex" json:"message_id"`
+	Topic         string    `gorm:"size:255;not null;index" json:"topic"`
+	EventName     string    `gorm:"size:255;not null;index" json:"event_name"`
+	AggregateType string    `gorm:"size:255" json:"aggregate_type"`
+	AggregateID   string    `gorm:"size:255;index" json:"aggregate_id"`
+	Payload       []byte    `gorm:"not null" json:"payload"`
+	Metadata      string    `gorm:"type:text" json:"metadata"`
+	OccurredAt    time.Time `gorm:"index" json:"occurred_at"`
+	RecordedAt    time.Time `gorm:"autoCreateTime" json:"recorded_at"`
+}
+
+// TableName overrides the GORM table name.
+func (RecordedEvent) TableName() string {
+	return "event_records"
+}
+
+// Message rebuilds the published message of a recorded event.
+func (r RecordedEvent) Message() (*message.Message, error) {
+	msg := message.NewMessage(r.MessageID, r.Payload)
+	if err := json.Unmarshal([]byte(r.Metadata), &msg.Metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata of recorded event %d: %w", r.ID, err)
+	}
+	return msg, nil
+}
+
+// Decode decodes the recorded event with its envelope, as a handler subscribed to it would receive it.
+func (r RecordedEvent) Decode(registry EventRegistry, codecs Codecs) (ddd.DomainEvent, error) {
+	msg, err := r.Message()
+	if err != nil {
+		return nil, err
+	}
+	event, _, err := decodeMessage(registry, codecs, r.EventName, msg)
+	return event, err
+}
+
+// RecordFilter selects recorded events. Zero fields do not filter.
+type RecordFilter struct {
+	// Topics are topics or topic patterns (see MatchTopic).
+	Topics []string
+	// From and To bound the time the events occurred: From is inclusive, To exclusive.
+	From time.Time
+	To   time.Time
+	// AfterID skips events up to and including this position, e.g. to resume a replay.
+	AfterID uint64
+	// RecordedBefore skips events recorded at or after this time. Readers tailing the log set it
+	// a little in the past, so that an event whose insert commits after a later one is not skipped.
+	RecordedBefore time.Time
+	// Limit caps the number of events returned by Find. It defaults to 100.
+	Limit int
+}
+
+// matches reports whether the topic is selected by the filter.
+func (f RecordFilter) matches(topic string) bool {
+	if len(f.Topics) == 0 {
+		return true
+	}
+	for _, t := range f.Topics {
+		if t == topic || (IsPattern(t) && MatchTopic(t, topic)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MigrateEventRecords creates the event log table if it does not exist.
+func MigrateEventRecords(db *gorm.DB) error {
+	return orm.Primary(db).AutoMigrate(&RecordedEvent{})
+}
+
+// EventRecorder keeps an append-only log of published events, so that they can be replayed
+// to rebuild read models or to backfill new handlers (see Replayer).
+// Install it on a bus with WithRecorder.
+type EventRecorder struct {
+	db *gorm.DB
+}
+
+// NewEventRecorder creates an EventRecorder.
+func NewEventRecorder(db *gorm.DB) *EventRecorder {
+	return &EventRecorder{db: db}
+}
+
+// Record appends a published message to the log. A message already recorded is ignored,
+// so publishing it again does not duplicate it.
+func (r *EventRecorder) Record(ctx context.Context, topic string, msg *message.Message) error {
+	metadata, err := json.Marshal(msg.Metadata)
+	if err != nil {
+		return err
+	}
+	meta := MetadataFromMessage(msg)
+	occurredAt := meta.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = time.Now()
+	}
+	row := RecordedEvent{
+		MessageID:     msg.UUID,
+		Topic:         topic,
+		EventName:     msg.Metadata.Get(MetadataEventName),
+		AggregateType: meta.AggregateType,
+		AggregateID:   meta.AggregateID,
+		Payload:       msg.Payload,
+		Metadata:      string(metadata),
+		OccurredAt:    occurredAt,
+	}
+	return orm.Primary(r.db).WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error
+}
+
+// Find returns recorded events matching the filter in log order.
+// Topic patterns are matched after reading, so fewer than Limit events may be returned
+// even though more follow; continue from the last position read, which is also returned.
+func (r *EventRecorder) Find(ctx context.Context, filter RecordFilter) ([]RecordedEvent, uint64, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	query := orm.Primary(r.db).WithContext(ctx).Where("id > ?", filter.AfterID)
+	if !filter.From.IsZero() {
+		query = query.Where("occurred_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("occurred_at < ?", filter.To)
+	}
+	if !filter.RecordedBefore.IsZero() {
+		query = query.Where("recorded_at < ?", filter.RecordedBefore)
+	}
+	if topics, exact := exactTopics(filter.Topics); exact {
+		query = query.Where("topic IN ?", topics)
+	}
+
+	var rows []RecordedEvent
+	if err := query.Order("id ASC").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, filter.AfterID, err
+	}
+	last := filter.AfterID
+	if len(rows) > 0 {
+		last = rows[len(rows)-1].ID
+	}
+	matched := rows[:0]
+	for _, row := range rows {
+		if filter.matches(row.Topic) {
+			matched = append(matched, row)
+		}
+	}
+	return matched, last, nil
+}
+
+// exactTopics reports whether topics can be matched in SQL, i.e. none of them is a pattern.
+func exactTopics(topics []string) ([]string, bool) {
+	if len(topics) == 0 {
+		return nil, false
+	}
+	for _, t := range topics {
+		if IsPattern(t) {
+			return nil, false
+		}
+	}
+	return topics, true
+}
+
+// WithRecorder records every event the bus publishes successfully, after all publish middleware ran.
+// If recording fails, the failure is logged and Publish still succeeds, since the event was published;
+// the event is then missing from replays and projections.
+func WithRecorder(recorder *EventRecorder) WatermillEventBusOption {
+	return func(b *WatermillEventBus) {
+		b.recorder = recorder
+	}
+}
@@ -0,0 +1,202 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/soliton-go/framework/ddd"
+)
+
+// replayLog records the events a handler received, marking replayed ones.
+type replayLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *replayLog) handler(fail string) EventHandler {
+	return func(ctx context.Context, e ddd.DomainEvent) error {
+		if e.EventName() == fail {
+			return errors.New("handler failed")
+		}
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		name := e.EventName()
+		if IsReplay(ctx) {
+			name += "(replay)"
+		}
+		l.events = append(l.events, name)
+		return nil
+	}
+}
+
+func (l *replayLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.events, " ")
+}
+
+// newRecordingBus returns a bus that records to a fresh event log, with the given topics
+// published in order. Nothing is subscribed yet, so the live messages are not delivered.
+func newRecordingBus(t *testing.T, topics ...string) (*WatermillEventBus, *EventRecorder) {
+	t.Helper()
+	db := openTestDB(t)
+	if err := MigrateEventRecords(db); err != nil {
+		t.Fatal(err)
+	}
+	recorder := NewEventRecorder(db)
+	bus := NewLocalEventBus(
+		WithRegistry(newTopicRegistry("order.created", "order.paid", "order.shipped", "user.created")),
+		WithLogger(watermill.NopLogger{}),
+		WithRecorder(recorder),
+	)
+	t.Cleanup(func() { bus.Close() })
+	for _, topic := range topics {
+		if err := bus.Publish(context.Background(), topicEvent{BaseDomainEvent: ddd.NewBaseDomainEvent(), Name: topic}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return bus, recorder
+}
+
+func recordedTopics(records []RecordedEvent) string {
+	var topics []string
+	for _, r := range records {
+		topics = append(topics, r.Topic)
+	}
+	return strings.Join(topics, " ")
+}
+
+func TestRecorderKeepsPublishOrder(t *testing.T) {
+	_, recorder := newRecordingBus(t, "order.created", "user.created", "order.paid", "order.shipped")
+	ctx := context.Background()
+
+	all, _, err := recorder.Find(ctx, RecordFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := recordedTopics(all); got != "order.created user.created order.paid order.shipped" {
+		t.Fatalf("recorded %s, want the publish order", got)
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].ID <= all[i-1].ID {
+			t.Errorf("position %d follows %d", all[i].ID, all[i-1].ID)
+		}
+	}
+
+	// Recording a message again does not duplicate it.
+	msg, err := all[0].Message()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Record(ctx, all[0].Topic, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter RecordFilter
+		want   string
+		last   uint64
+	}{
+		{"exact topics", RecordFilter{Topics: []string{"order.paid", "user.created"}}, "user.created order.paid", all[2].ID},
+		{"pattern", RecordFilter{Topics: []string{"order.*"}}, "order.created order.paid order.shipped", all[3].ID},
+		{"after a position", RecordFilter{AfterID: all[1].ID}, "order.paid order.shipped", all[3].ID},
+		// Patterns are matched after reading, so a page may hold fewer matches than the limit.
+		{"pattern page", RecordFilter{Topics: []string{"order.*"}, Limit: 2}, "order.created", all[1].ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, last, err := recorder.Find(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := recordedTopics(records); got != tt.want || last != tt.last {
+				t.Errorf("Find = %s up to %d, want %s up to %d", got, last, tt.want, tt.last)
+			}
+		})
+	}
+}
+
+func TestReplayDeliversToNamedHandlerOnly(t *testing.T) {
+	bus, recorder := newRecordingBus(t, "order.created", "user.created", "order.paid", "order.shipped")
+	ctx := context.Background()
+	var orders, created replayLog
+	if err := bus.Subscribe(ctx, "order.*", orders.handler(""), WithHandlerName("test.Orders")); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Subscribe(ctx, "*.created", created.handler(""), WithHandlerName("test.Created")); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Subscribe(ctx, "order.paid", orders.handler(""), WithHandlerName("test.Orders")); err == nil {
+		t.Error("subscribing a second handler with the same name succeeded")
+	}
+	replayer := NewReplayer(recorder, bus)
+	if got := strings.Join(replayer.Handlers(), " "); got != "test.Created test.Orders" {
+		t.Errorf("Handlers = %s, want the named subscriptions", got)
+	}
+
+	result, err := replayer.Replay(ctx, ReplayRequest{Handler: "test.Orders"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := orders.String(); got != "order.created(replay) order.paid(replay) order.shipped(replay)" {
+		t.Errorf("test.Orders received %s, want its topics in log order", got)
+	}
+	if got := created.String(); got != "" {
+		t.Errorf("test.Created received %s, want nothing", got)
+	}
+	if result.Matched != 3 || result.Delivered != 3 {
+		t.Errorf("result = %+v, want 3 matched and delivered", result)
+	}
+
+	// Requested topics are narrowed to the subscribed one.
+	result, err = replayer.Replay(ctx, ReplayRequest{Handler: "test.Created", Topics: []string{">"}, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Matched != 2 || result.Delivered != 0 || created.String() != "" {
+		t.Errorf("dry run = %+v, delivered %q; want 2 matched and nothing delivered", result, created.String())
+	}
+
+	if _, err := replayer.Replay(ctx, ReplayRequest{Handler: "test.Missing"}); !errors.Is(err, ErrHandlerNotFound) {
+		t.Errorf("Replay to an unknown handler = %v, want ErrHandlerNotFound", err)
+	}
+	if _, err := NewReplayer(recorder, &stubBus{}).Replay(ctx, ReplayRequest{Handler: "test.Orders"}); !errors.Is(err, ErrReplayNotSupported) {
+		t.Errorf("Replay on another bus = %v, want ErrReplayNotSupported", err)
+	}
+}
+
+func TestReplayStopsAtFailureAndResumes(t *testing.T) {
+	bus, recorder := newRecordingBus(t, "order.created", "order.paid", "order.shipped")
+	ctx := context.Background()
+	var orders replayLog
+	err := bus.Subscribe(ctx, "order.*", orders.handler("order.paid"), WithHandlerName("test.Orders"), WithRetry(RetryPolicy{MaxAttempts: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayer := NewReplayer(recorder, bus)
+
+	result, err := replayer.Replay(ctx, ReplayRequest{Handler: "test.Orders"})
+	if err == nil || !strings.Contains(err.Error(), "(order.paid) failed") {
+		t.Fatalf("Replay = %v, want the failure on order.paid", err)
+	}
+	if result.Delivered != 1 || orders.String() != "order.created(replay)" {
+		t.Fatalf("delivered %d: %s, want only order.created", result.Delivered, orders.String())
+	}
+
+	// Resuming after the last delivered event skips what was already replayed.
+	if err := bus.Subscribe(ctx, "order.*", orders.handler(""), WithHandlerName("test.Fixed")); err != nil {
+		t.Fatal(err)
+	}
+	result, err = replayer.Replay(ctx, ReplayRequest{Handler: "test.Fixed", AfterID: result.LastID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := orders.String(); got != "order.created(replay) order.paid(replay) order.shipped(replay)" {
+		t.Errorf("received %s after resuming, want every event once", got)
+	}
+}
@@ -1,6 +1,8 @@
 package event
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -10,6 +12,13 @@ import (
 // EventFactory is a function that creates a new instance of a domain event.
 type EventFactory func() ddd.DomainEvent
 
+// Upcaster transforms a JSON payload from one schema version of an event to the next.
+type Upcaster func(payload []byte) ([]byte, error)
+
+// ErrUnsupportedSchemaVersion is returned when a payload has a newer schema version than
+// this process knows, typically because it was written by a newer deployment.
+var ErrUnsupportedSchemaVersion = errors.New("unsupported event schema version")
+
 // EventRegistry manages the mapping between event names and their factories.
 // This enables proper deserialization of events from JSON to their concrete types.
 type EventRegistry interface {
@@ -19,18 +28,27 @@ type EventRegistry interface {
 	Create(eventName string) (ddd.DomainEvent, error)
 	// Has checks if an event type is registered.
 	Has(eventName string) bool
+	// Names returns the names of all registered events.
+	Names() []string
+	// SchemaVersion returns the current schema version of an event.
+	SchemaVersion(eventName string) int
+	// Decode upcasts a payload of the given schema version to the current one and unmarshals it.
+	// A zero schemaVersion is read from the payload's envelope, defaulting to 1.
+	Decode(eventName string, schemaVersion int, payload []byte) (ddd.DomainEvent, error)
 }
 
 // DefaultEventRegistry is the default in-memory implementation of EventRegistry.
 type DefaultEventRegistry struct {
 	mu        sync.RWMutex
 	factories map[string]EventFactory
+	upcasters map[string]map[int]Upcaster
 }
 
 // NewEventRegistry creates a new DefaultEventRegistry.
 func NewEventRegistry() *DefaultEventRegistry {
 	return &DefaultEventRegistry{
 		factories: make(map[string]EventFactory),
+		upcasters: make(map[string]map[int]Upcaster),
 	}
 }
 
@@ -61,6 +79,96 @@ func (r *DefaultEventRegistry) Has(eventName string) bool {
 	return ok
 }
 
+// Names returns the names of all registered events.
+func (r *DefaultEventRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	return names
+}
+
+// RegisterUpcaster registers the transformation of an event payload from fromVersion to fromVersion+1.
+// The current schema version of an event is one above its highest upcaster, or 1 without upcasters,
+// so renaming a field in OrderCreatedEvent is done by registering an upcaster from version 1.
+func (r *DefaultEventRegistry) RegisterUpcaster(eventName string, fromVersion int, upcaster Upcaster) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.upcasters[eventName] == nil {
+		r.upcasters[eventName] = make(map[int]Upcaster)
+	}
+	r.upcasters[eventName][fromVersion] = upcaster
+}
+
+// SchemaVersion returns the current schema version of an event.
+func (r *DefaultEventRegistry) SchemaVersion(eventName string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.schemaVersion(eventName)
+}
+
+func (r *DefaultEventRegistry) schemaVersion(eventName string) int {
+	current := ddd.CurrentSchemaVersion
+	for from := range r.upcasters[eventName] {
+		if from+1 > current {
+			current = from + 1
+		}
+	}
+	return current
+}
+
+// Decode upcasts a payload of the given schema version to the current one and unmarshals it.
+// The decoded event carries the current schema version in its envelope.
+func (r *DefaultEventRegistry) Decode(eventName string, schemaVersion int, payload []byte) (ddd.DomainEvent, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[eventName]
+	current := r.schemaVersion(eventName)
+	chain := r.upcasters[eventName]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("event type not registered: %s", eventName)
+	}
+
+	if schemaVersion == 0 {
+		schemaVersion = payloadSchemaVersion(payload)
+	}
+	if schemaVersion > current {
+		return nil, fmt.Errorf("%w: %s v%d (current v%d)", ErrUnsupportedSchemaVersion, eventName, schemaVersion, current)
+	}
+	for v := schemaVersion; v < current; v++ {
+		upcaster, ok := chain[v]
+		if !ok {
+			return nil, fmt.Errorf("no upcaster for %s from v%d to v%d", eventName, v, v+1)
+		}
+		var err error
+		if payload, err = upcaster(payload); err != nil {
+			return nil, fmt.Errorf("failed to upcast %s from v%d: %w", eventName, v, err)
+		}
+	}
+
+	e := factory()
+	if err := json.Unmarshal(payload, e); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event %s: %w", eventName, err)
+	}
+	return ddd.WithMetadata(e, func(meta *ddd.EventMetadata) {
+		meta.SchemaVersion = current
+	}), nil
+}
+
+// payloadSchemaVersion reads schema_version from a JSON payload, defaulting to 1 for
+// payloads written before events carried a version.
+func payloadSchemaVersion(payload []byte) int {
+	var envelope struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(payload, &envelope); err != nil || envelope.SchemaVersion == 0 {
+		return 1
+	}
+	return envelope.SchemaVersion
+}
+
 // globalRegistry is the default global event registry.
 var (
 	globalRegistry     *DefaultEventRegistry
@@ -80,3 +188,8 @@ func GlobalRegistry() *DefaultEventRegistry {
 func RegisterEvent(eventName string, factory EventFactory) {
 	GlobalRegistry().Register(eventName, factory)
 }
+
+// RegisterUpcaster is a convenience function to register an upcaster in the global registry.
+func RegisterUpcaster(eventName string, fromVersion int, upcaster Upcaster) {
+	GlobalRegistry().RegisterUpcaster(eventName, fromVersion, upcaster)
+}
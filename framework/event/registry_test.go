@@ -0,0 +1,123 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/soliton-go/framework/ddd"
+)
+
+// orderPlaced is at schema version 3: v2 renamed amount to total, v3 added currency.
+type orderPlaced struct {
+	ddd.BaseDomainEvent
+	Total    int    `json:"total"`
+	Currency string `json:"currency"`
+}
+
+func (orderPlaced) EventName() string { return "order.placed" }
+
+// upcastJSON applies change to a JSON object payload.
+func upcastJSON(change func(fields map[string]any)) Upcaster {
+	return func(payload []byte) ([]byte, error) {
+		var fields map[string]any
+		if err := json.Unmarshal(payload, &fields); err != nil {
+			return nil, err
+		}
+		change(fields)
+		return json.Marshal(fields)
+	}
+}
+
+func newUpcastingRegistry() *DefaultEventRegistry {
+	registry := NewEventRegistry()
+	registry.Register("order.placed", func() ddd.DomainEvent { return &orderPlaced{} })
+	registry.RegisterUpcaster("order.placed", 1, upcastJSON(func(fields map[string]any) {
+		fields["total"] = fields["amount"]
+		delete(fields, "amount")
+	}))
+	registry.RegisterUpcaster("order.placed", 2, upcastJSON(func(fields map[string]any) {
+		if _, ok := fields["currency"]; !ok {
+			fields["currency"] = "EUR"
+		}
+	}))
+	return registry
+}
+
+func TestRegistryDecodeUpcastsToCurrentVersion(t *testing.T) {
+	registry := newUpcastingRegistry()
+	if v := registry.SchemaVersion("order.placed"); v != 3 {
+		t.Fatalf("SchemaVersion = %d, want 3", v)
+	}
+
+	tests := []struct {
+		name          string
+		schemaVersion int
+		payload       string
+		wantTotal     int
+		wantCurrency  string
+	}{
+		{"v1 through the whole chain", 1, `{"amount":10}`, 10, "EUR"},
+		{"v2 through the last upcaster", 2, `{"total":20}`, 20, "EUR"},
+		{"current version as is", 3, `{"total":30,"currency":"USD"}`, 30, "USD"},
+		{"zero version read from the payload", 0, `{"schema_version":2,"total":40}`, 40, "EUR"},
+		{"zero version without one in the payload is v1", 0, `{"amount":50}`, 50, "EUR"},
+		{"explicit version wins over the payload", 2, `{"schema_version":1,"total":60}`, 60, "EUR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := registry.Decode("order.placed", tt.schemaVersion, []byte(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := e.(*orderPlaced)
+			if !ok {
+				t.Fatalf("decoded %T, want *orderPlaced", e)
+			}
+			if got.Total != tt.wantTotal || got.Currency != tt.wantCurrency {
+				t.Errorf("decoded total %d %s, want %d %s", got.Total, got.Currency, tt.wantTotal, tt.wantCurrency)
+			}
+			if v := ddd.MetadataOf(e).SchemaVersion; v != 3 {
+				t.Errorf("decoded schema version %d, want 3", v)
+			}
+		})
+	}
+}
+
+func TestRegistryDecodeErrors(t *testing.T) {
+	registry := newUpcastingRegistry()
+	registry.Register("order.shipped", func() ddd.DomainEvent { return &orderPlaced{} })
+	// The chain of order.shipped starts at v2, so v1 payloads cannot be upcast.
+	registry.RegisterUpcaster("order.shipped", 2, upcastJSON(func(map[string]any) {}))
+	registry.Register("order.cancelled", func() ddd.DomainEvent { return &orderPlaced{} })
+	registry.RegisterUpcaster("order.cancelled", 1, func([]byte) ([]byte, error) { return nil, errors.New("bad payload") })
+
+	tests := []struct {
+		name          string
+		eventName     string
+		schemaVersion int
+		wantErr       string
+	}{
+		{"newer than current", "order.placed", 4, "unsupported event schema version"},
+		{"not registered", "order.refunded", 1, "event type not registered"},
+		{"gap in the chain", "order.shipped", 1, "no upcaster for order.shipped from v1 to v2"},
+		{"failing upcaster", "order.cancelled", 1, "failed to upcast order.cancelled from v1: bad payload"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := registry.Decode(tt.eventName, tt.schemaVersion, []byte(`{}`))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Decode = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	_, err := registry.Decode("order.placed", 4, []byte(`{}`))
+	if !errors.Is(err, ErrUnsupportedSchemaVersion) {
+		t.Errorf("Decode of a newer version = %v, want ErrUnsupportedSchemaVersion", err)
+	}
+	if v := newTestRegistry().SchemaVersion("test.happened"); v != ddd.CurrentSchemaVersion {
+		t.Errorf("SchemaVersion without upcasters = %d, want %d", v, ddd.CurrentSchemaVersion)
+	}
+}
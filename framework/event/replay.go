@@ -0,0 +1,231 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+var (
+	// ErrHandlerNotFound is returned when replaying to a handler name no subscription uses.
+	ErrHandlerNotFound = errors.New("event handler not found")
+	// ErrReplayNotSupported is returned when the event bus cannot deliver to a single handler.
+	ErrReplayNotSupported = errors.New("event bus does not support replay")
+)
+
+// WithHandlerName names the handler of a subscription, e.g. "report.SalesReportHandler",
+// so that recorded events can be replayed to it alone (see Replayer). Names are unique per bus.
+func WithHandlerName(name string) SubscribeOption {
+	return func(c *subscriptionConfig) {
+		c.name = name
+	}
+}
+
+type replayKey struct{}
+
+// IsReplay reports whether the handler runs for a replayed event rather than a live one.
+// Handlers with side effects outside their own read model, such as sending mail, should skip them.
+func IsReplay(ctx context.Context) bool {
+	replay, _ := ctx.Value(replayKey{}).(bool)
+	return replay
+}
+
+// namedHandler is a subscription registered with WithHandlerName.
+type namedHandler struct {
+	topic  string
+	handle HandleFunc
+	cfg    subscriptionConfig
+}
+
+func (b *WatermillEventBus) registerHandler(topic string, handle HandleFunc, cfg subscriptionConfig) error {
+	if cfg.name == "" {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, exists := b.handlers[cfg.name]; exists {
+		return fmt.Errorf("event handler %s is already subscribed", cfg.name)
+	}
+	b.handlers[cfg.name] = namedHandler{topic: topic, handle: handle, cfg: cfg}
+	return nil
+}
+
+// HandlerNames returns the names of the subscribed handlers, sorted.
+func (b *WatermillEventBus) HandlerNames() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	names := make([]string, 0, len(b.handlers))
+	for name := range b.handlers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (b *WatermillEventBus) handler(name string) (namedHandler, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	h, ok := b.handlers[name]
+	return h, ok
+}
+
+// replay delivers a recorded event to a named handler, marked as a replay (see IsReplay).
+func (b *WatermillEventBus) replay(ctx context.Context, h namedHandler, record RecordedEvent) error {
+	msg, err := record.Message()
+	if err != nil {
+		return err
+	}
+	return b.deliverTo(context.WithValue(ctx, replayKey{}, true), h, record.Topic, record.EventName, msg)
+}
+
+// deliverTo delivers a message to a named handler directly, bypassing the transport,
+// and retries it under the subscription's retry policy. Failures are returned, not dead-lettered.
+func (b *WatermillEventBus) deliverTo(ctx context.Context, h namedHandler, topic, eventName string, msg *message.Message) error {
+	event, meta, err := b.decode(eventName, msg)
+	if err != nil {
+		return err
+	}
+	m := &EventMessage{Topic: topic, EventName: eventName, Event: event, Message: msg}
+	ctx = withMessageID(ContextForEvent(ctx, meta), msg.UUID)
+
+	attempts := 0
+	for {
+		attempts++
+		err := b.attempt(ctx, h.cfg.timeout, func(ctx context.Context) error {
+			return h.handle(ctx, m)
+		})
+		if err == nil || attempts >= h.cfg.retry.MaxAttempts || IsPermanent(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(h.cfg.retry.backoff(attempts)):
+		}
+	}
+}
+
+// ReplayRequest selects the recorded events to replay and the handler to deliver them to.
+type ReplayRequest struct {
+	// Handler is the name given to the subscription with WithHandlerName.
+	Handler string `json:"handler"`
+	// Topics are topics or patterns to replay. They default to the handler's subscribed topic,
+	// and are always narrowed to it.
+	Topics []string `json:"topics"`
+	// From and To bound the time the events occurred: From is inclusive, To exclusive.
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// AfterID resumes a replay after the given log position.
+	AfterID uint64 `json:"after_id"`
+	// Rate limits delivery to this many events per second; 0 does not limit it.
+	Rate float64 `json:"rate"`
+	// DryRun counts the matching events without delivering them.
+	DryRun bool `json:"dry_run"`
+}
+
+// ReplayResult reports what a replay did. LastID is the position of the last event delivered
+// (or counted, in a dry run); pass it as AfterID to resume a replay that failed.
+type ReplayResult struct {
+	Handler   string `json:"handler"`
+	DryRun    bool   `json:"dry_run"`
+	Matched   int    `json:"matched"`
+	Delivered int    `json:"delivered"`
+	FirstID   uint64 `json:"first_id,omitempty"`
+	LastID    uint64 `json:"last_id,omitempty"`
+}
+
+// Replayer re-delivers recorded events to a single named handler, e.g. to rebuild a projection
+// or to backfill a new handler from history. Other subscribers do not see replayed events.
+type Replayer struct {
+	recorder  *EventRecorder
+	bus       EventBus
+	batchSize int
+}
+
+// NewReplayer creates a Replayer. Replay requires bus to be a WatermillEventBus.
+func NewReplayer(recorder *EventRecorder, bus EventBus) *Replayer {
+	return &Replayer{recorder: recorder, bus: bus, batchSize: 100}
+}
+
+// Handlers returns the names of the handlers events can be replayed to.
+func (r *Replayer) Handlers() []string {
+	if bus, ok := r.bus.(*WatermillEventBus); ok {
+		return bus.HandlerNames()
+	}
+	return nil
+}
+
+// Replay delivers the selected events to the handler in log order, one at a time, and stops at
+// the first event the handler fails on. Handlers that deduplicate with an Inbox skip events they
+// already processed, so clear their inbox entries before rebuilding a read model from scratch.
+func (r *Replayer) Replay(ctx context.Context, req ReplayRequest) (ReplayResult, error) {
+	result := ReplayResult{Handler: req.Handler, DryRun: req.DryRun}
+	bus, ok := r.bus.(*WatermillEventBus)
+	if !ok {
+		return result, ErrReplayNotSupported
+	}
+	h, ok := bus.handler(req.Handler)
+	if !ok {
+		return result, fmt.Errorf("%w: %s", ErrHandlerNotFound, req.Handler)
+	}
+
+	topics := req.Topics
+	if len(topics) == 0 {
+		topics = []string{h.topic}
+	}
+	filter := RecordFilter{Topics: topics, From: req.From, To: req.To, AfterID: req.AfterID, Limit: r.batchSize}
+	var throttle <-chan time.Time
+	if req.Rate > 0 && !req.DryRun {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / req.Rate))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	for {
+		records, last, err := r.recorder.Find(ctx, filter)
+		if err != nil {
+			return result, err
+		}
+		for _, record := range records {
+			if !selectedTopic(h.topic, req.Topics, record.Topic) {
+				continue
+			}
+			result.Matched++
+			if result.FirstID == 0 {
+				result.FirstID = record.ID
+			}
+			if req.DryRun {
+				result.LastID = record.ID
+				continue
+			}
+			if throttle != nil {
+				select {
+				case <-ctx.Done():
+					return result, ctx.Err()
+				case <-throttle:
+				}
+			}
+			if err := bus.replay(ctx, h, record); err != nil {
+				return result, fmt.Errorf("replay of event %d (%s) failed: %w", record.ID, record.EventName, err)
+			}
+			result.Delivered++
+			result.LastID = record.ID
+		}
+		if last == filter.AfterID {
+			return result, nil
+		}
+		filter.AfterID = last
+	}
+}
+
+// selectedTopic reports whether a recorded topic is handled by the subscription and selected by the request.
+func selectedTopic(subscribed string, requested []string, topic string) bool {
+	if subscribed != topic && !(IsPattern(subscribed) && MatchTopic(subscribed, topic)) {
+		return false
+	}
+	return RecordFilter{Topics: requested}.matches(topic)
+}
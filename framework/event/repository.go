@@ -0,0 +1,136 @@
+package event
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+)
+
+// Publisher receives the domain events of saved aggregates.
+// EventBus, SyncDispatcher and Outbox all implement it.
+type Publisher interface {
+	Publish(ctx context.Context, events ...ddd.DomainEvent) error
+}
+
+// Publishers hands events to each publisher in order and stops at the first error.
+type Publishers []Publisher
+
+// Publish implements Publisher.
+func (p Publishers) Publish(ctx context.Context, events ...ddd.DomainEvent) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, events...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishingRepository decorates a repository so that saving an aggregate root publishes
+// the domain events it raised. Entities that are not aggregate roots are saved unchanged.
+type PublishingRepository[T ddd.Entity, ID ddd.ID] struct {
+	orm.Repository[T, ID]
+	publisher Publisher
+	db        *gorm.DB
+}
+
+// PublishingRepositoryOption is a functional option for PublishingRepository.
+type PublishingRepositoryOption func(*publishingRepositoryOptions)
+
+type publishingRepositoryOptions struct {
+	db *gorm.DB
+}
+
+// WithPublishTransaction saves and publishes in one transaction on db (see orm.Transaction),
+// so that an Outbox or SyncDispatcher failure rolls back the save.
+func WithPublishTransaction(db *gorm.DB) PublishingRepositoryOption {
+	return func(o *publishingRepositoryOptions) {
+		o.db = db
+	}
+}
+
+// NewPublishingRepository wraps repo so that Save publishes aggregate events to publisher.
+func NewPublishingRepository[T ddd.Entity, ID ddd.ID](repo orm.Repository[T, ID], publisher Publisher, opts ...PublishingRepositoryOption) *PublishingRepository[T, ID] {
+	var options publishingRepositoryOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &PublishingRepository[T, ID]{
+		Repository: repo,
+		publisher:  publisher,
+		db:         options.db,
+	}
+}
+
+// Save saves the entity, then pulls and publishes its domain events.
+// If publishing fails the events are put back on the aggregate and, with
+// WithPublishTransaction, the save is rolled back.
+func (r *PublishingRepository[T, ID]) Save(ctx context.Context, entity T) error {
+	aggregate, ok := any(entity).(ddd.AggregateRoot)
+	if !ok || r.publisher == nil {
+		return r.Repository.Save(ctx, entity)
+	}
+	save := func(ctx context.Context) error {
+		return r.persistAndPublish(ctx, aggregate, func(ctx context.Context) error {
+			return r.Repository.Save(ctx, entity)
+		})
+	}
+	if r.db == nil {
+		return save(ctx)
+	}
+
+	// A rolled-back save must not leave the aggregate on the version it would have had.
+	versioned, isVersioned := any(entity).(ddd.Versioned)
+	var version int64
+	if isVersioned {
+		version = versioned.GetVersion()
+	}
+	err := orm.Transaction(ctx, r.db, save)
+	if err != nil && isVersioned {
+		versioned.SetVersion(version)
+	}
+	return err
+}
+
+// Remove deletes the entity by its ID, then pulls and publishes the domain events it raised, such
+// as its deleted event, with the same guarantees as Save.
+func (r *PublishingRepository[T, ID]) Remove(ctx context.Context, entity T) error {
+	id, ok := entity.GetID().(ID)
+	if !ok {
+		return fmt.Errorf("entity ID %v is not a %T", entity.GetID(), id)
+	}
+	aggregate, ok := any(entity).(ddd.AggregateRoot)
+	if !ok || r.publisher == nil {
+		return r.Repository.Delete(ctx, id)
+	}
+	remove := func(ctx context.Context) error {
+		return r.persistAndPublish(ctx, aggregate, func(ctx context.Context) error {
+			return r.Repository.Delete(ctx, id)
+		})
+	}
+	if r.db == nil {
+		return remove(ctx)
+	}
+	return orm.Transaction(ctx, r.db, remove)
+}
+
+// persistAndPublish runs persist, then publishes the events pulled from aggregate. If publishing
+// fails the events are put back on the aggregate.
+func (r *PublishingRepository[T, ID]) persistAndPublish(ctx context.Context, aggregate ddd.AggregateRoot, persist func(ctx context.Context) error) error {
+	if err := persist(ctx); err != nil {
+		return err
+	}
+	events := aggregate.PullDomainEvents()
+	if len(events) == 0 {
+		return nil
+	}
+	if err := r.publisher.Publish(ctx, events...); err != nil {
+		for _, e := range events {
+			aggregate.AddDomainEvent(e)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package event
+
+import (
+	"context"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// Message metadata keys added when a message is moved to a dead-letter topic.
+const (
+	MetadataDeadLetterTopic    = "dead_letter_original_topic"
+	MetadataDeadLetterError    = "dead_letter_error"
+	MetadataDeadLetterAttempts = "dead_letter_attempts"
+	MetadataDeadLetterAt       = "dead_letter_at"
+	MetadataDeadLetterHandler  = "dead_letter_handler"
+)
+
+// DeadLetterSuffix is appended to a topic to build its default dead-letter topic.
+const DeadLetterSuffix = ".dead_letter"
+
+// RetryPolicy controls how a failing handler is retried before its message is dead-lettered.
+// A zero MaxAttempts keeps the transport's behaviour: the message is nacked and redelivered.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of handler attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the delay before the second attempt; it doubles on every further attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts.
+	MaxBackoff time.Duration
+	// Jitter randomises each delay by up to this fraction (0-1) to avoid retry storms.
+	Jitter float64
+	// DeadLetterTopic receives messages that exhausted their attempts.
+	// Defaults to the subscribed topic plus DeadLetterSuffix.
+	DeadLetterTopic string
+}
+
+// DefaultRetryPolicy is a reasonable policy for most handlers.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	Jitter:         0.2,
+}
+
+// backoff returns the delay to wait after the given failed attempt (1-based).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff << (attempt - 1)
+	if delay <= 0 || (p.MaxBackoff > 0 && delay > p.MaxBackoff) {
+		delay = p.MaxBackoff
+	}
+	if p.Jitter > 0 && delay > 0 {
+		spread := float64(delay) * p.Jitter
+		delay += time.Duration((rand.Float64()*2 - 1) * spread)
+	}
+	return delay
+}
+
+// SubscribeOption configures a single subscription.
+type SubscribeOption func(*subscriptionConfig)
+
+type subscriptionConfig struct {
+	retry         RetryPolicy
+	timeout       time.Duration
+	workers       int
+	maxInFlight   int
+	partitionKey  PartitionKeyFunc
+	consumerGroup string
+	name          string
+}
+
+// WithRetry sets the retry and dead-letter policy of a subscription.
+func WithRetry(policy RetryPolicy) SubscribeOption {
+	return func(c *subscriptionConfig) {
+		c.retry = policy
+	}
+}
+
+// WithHandlerTimeout cancels the context of each handler attempt after d.
+func WithHandlerTimeout(d time.Duration) SubscribeOption {
+	return func(c *subscriptionConfig) {
+		c.timeout = d
+	}
+}
+
+// WithDefaultRetryPolicy sets the retry policy of subscriptions that do not set their own.
+func WithDefaultRetryPolicy(policy RetryPolicy) WatermillEventBusOption {
+	return func(b *WatermillEventBus) {
+		b.defaultRetry = policy
+	}
+}
+
+// WithDeadLetterStore records dead-lettered messages so they can be listed and replayed.
+func WithDeadLetterStore(store *DeadLetterStore) WatermillEventBusOption {
+	return func(b *WatermillEventBus) {
+		b.deadLetters = store
+	}
+}
+
+func (b *WatermillEventBus) subscriptionConfig(opts []SubscribeOption) subscriptionConfig {
+	cfg := subscriptionConfig{retry: b.defaultRetry, partitionKey: PartitionByAggregate}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// deliver runs fn under the subscription's retry policy and acks, nacks or dead-letters the message.
+func (b *WatermillEventBus) deliver(ctx context.Context, topic string, msg *message.Message, cfg subscriptionConfig, fn func(ctx context.Context) error) {
+	fields := watermill.LogFields{
+		"topic":      topic,
+		"event_name": msg.Metadata.Get(MetadataEventName),
+		"message_id": msg.UUID,
+	}
+
+	attempts := 0
+	for {
+		attempts++
+		err := b.attempt(ctx, cfg.timeout, fn)
+		if err == nil {
+			msg.Ack()
+			return
+		}
+
+		fields["attempt"] = attempts
+		b.logger.Error("Event handler failed", err, fields)
+		if cfg.retry.MaxAttempts <= 0 {
+			msg.Nack()
+			return
+		}
+		if attempts >= cfg.retry.MaxAttempts || IsPermanent(err) {
+			b.deadLetter(ctx, topic, msg, cfg, err, attempts)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			msg.Nack()
+			return
+		case <-time.After(cfg.retry.backoff(attempts)):
+		}
+	}
+}
+
+func (b *WatermillEventBus) attempt(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return fn(attemptCtx)
+}
+
+// reject handles a message that can never succeed, such as one that cannot be decoded.
+func (b *WatermillEventBus) reject(ctx context.Context, topic string, msg *message.Message, cfg subscriptionConfig, cause error) {
+	b.logger.Error("Rejecting undeliverable message", cause, watermill.LogFields{
+		"topic":      topic,
+		"event_name": msg.Metadata.Get(MetadataEventName),
+		"message_id": msg.UUID,
+	})
+	if cfg.retry.MaxAttempts <= 0 {
+		msg.Nack()
+		return
+	}
+	b.deadLetter(ctx, topic, msg, cfg, cause, 0)
+}
+
+// deadLetter moves the message to the dead-letter topic and acks the original.
+// If the dead-letter copy cannot be published the original is nacked, so nothing is lost.
+func (b *WatermillEventBus) deadLetter(ctx context.Context, topic string, msg *message.Message, cfg subscriptionConfig, cause error, attempts int) {
+	dlqTopic := cfg.retry.DeadLetterTopic
+	if dlqTopic == "" {
+		dlqTopic = topic + DeadLetterSuffix
+	}
+
+	dead := msg.Copy()
+	dead.Metadata.Set(MetadataDeadLetterTopic, topic)
+	dead.Metadata.Set(MetadataDeadLetterError, cause.Error())
+	dead.Metadata.Set(MetadataDeadLetterAttempts, strconv.Itoa(attempts))
+	dead.Metadata.Set(MetadataDeadLetterAt, time.Now().Format(time.RFC3339Nano))
+	if cfg.name != "" {
+		dead.Metadata.Set(MetadataDeadLetterHandler, cfg.name)
+	}
+
+	fields := watermill.LogFields{
+		"topic":             topic,
+		"dead_letter_topic": dlqTopic,
+		"message_id":        msg.UUID,
+		"attempts":          attempts,
+	}
+	if b.deadLetters != nil {
+		if err := b.deadLetters.Record(ctx, dlqTopic, dead); err != nil {
+			b.logger.Error("Failed to record dead letter", err, fields)
+			msg.Nack()
+			return
+		}
+	}
+	if err := b.publisher.Publish(dlqTopic, dead); err != nil {
+		b.logger.Error("Failed to publish dead letter", err, fields)
+		msg.Nack()
+		return
+	}
+
+	b.logger.Info("Message moved to dead-letter topic", fields)
+	msg.Ack()
+}
@@ -0,0 +1,392 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+)
+
+// ErrSchedulerNotConfigured is returned by WatermillEventBus.PublishAt when the bus has no Scheduler.
+var ErrSchedulerNotConfigured = errors.New("event scheduler not configured")
+
+// ScheduledEvent is the database row for an event waiting for its due time.
+type ScheduledEvent struct {
+	ID            string     `gorm:"primaryKey;size:64"` // event ID
+	ScheduleKey   string     `gorm:"size:255;not null;index"`
+	EventName     string     `gorm:"size:255;not null"`
+	Payload       []byte     `gorm:"not null"`
+	DueAt         time.Time  `gorm:"not null;index"`
+	Attempts      int        `gorm:"not null;default:0"`
+	LastError     string     `gorm:"size:1024"`
+	NextAttemptAt time.Time  `gorm:"not null;index"`
+	LockedUntil   *time.Time `gorm:"index"`
+	DispatchedAt  *time.Time `gorm:"index"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime"`
+}
+
+// TableName overrides the GORM table name.
+func (ScheduledEvent) TableName() string {
+	return "event_schedule"
+}
+
+// MigrateSchedule creates the schedule table if it does not exist.
+func MigrateSchedule(db *gorm.DB) error {
+	return orm.Primary(db).AutoMigrate(&ScheduledEvent{})
+}
+
+// ScheduledPublisher publishes events at a later time.
+// Scheduler implements it, and so does a WatermillEventBus configured with WithScheduler.
+type ScheduledPublisher interface {
+	PublishAt(ctx context.Context, at time.Time, e ddd.DomainEvent, opts ...ScheduleOption) error
+	PublishAfter(ctx context.Context, delay time.Duration, e ddd.DomainEvent, opts ...ScheduleOption) error
+	CancelScheduled(ctx context.Context, key string) error
+}
+
+// ScheduleOption is a functional option for a scheduled event.
+type ScheduleOption func(*scheduleOptions)
+
+type scheduleOptions struct {
+	key string
+}
+
+// WithScheduleKey identifies the scheduled event for CancelScheduled, e.g. "order.payment_timeout:" + orderID.
+// Scheduling again with the same key replaces the pending event. The default key is the event ID.
+func WithScheduleKey(key string) ScheduleOption {
+	return func(o *scheduleOptions) {
+		o.key = key
+	}
+}
+
+// Scheduler stores events in a SQL table until they are due; a SchedulePoller then publishes
+// them through the event bus. Scheduled events survive restarts.
+type Scheduler struct {
+	db       *gorm.DB
+	registry EventRegistry
+}
+
+// SchedulerOption is a functional option for Scheduler.
+type SchedulerOption func(*Scheduler)
+
+// WithSchedulerRegistry sets the registry that provides the schema version of scheduled events.
+func WithSchedulerRegistry(registry EventRegistry) SchedulerOption {
+	return func(s *Scheduler) {
+		s.registry = registry
+	}
+}
+
+// NewScheduler creates a Scheduler.
+func NewScheduler(db *gorm.DB, opts ...SchedulerOption) *Scheduler {
+	scheduler := &Scheduler{db: db, registry: GlobalRegistry()}
+	for _, opt := range opts {
+		opt(scheduler)
+	}
+	return scheduler
+}
+
+// PublishAt schedules e to be published at the given time. Times in the past are due immediately.
+// It joins the transaction carried by ctx (see orm.Transaction), so the schedule is rolled back
+// together with the command that requested it.
+func (s *Scheduler) PublishAt(ctx context.Context, at time.Time, e ddd.DomainEvent, opts ...ScheduleOption) error {
+	var options scheduleOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// Fix the envelope now so that the event keeps the correlation of the code that scheduled it.
+	e = stampEnvelope(ctx, s.registry, e)
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event %s: %w", e.EventName(), err)
+	}
+	id := ddd.MetadataOf(e).EventID
+	if options.key == "" {
+		options.key = id
+	}
+
+	row := ScheduledEvent{
+		ID:            id,
+		ScheduleKey:   options.key,
+		EventName:     e.EventName(),
+		Payload:       payload,
+		DueAt:         at,
+		NextAttemptAt: at,
+	}
+	return orm.Transaction(ctx, s.db, func(ctx context.Context) error {
+		if err := s.cancel(ctx, options.key); err != nil {
+			return err
+		}
+		return orm.Conn(ctx, s.db).Create(&row).Error
+	})
+}
+
+// PublishAfter schedules e to be published after delay.
+func (s *Scheduler) PublishAfter(ctx context.Context, delay time.Duration, e ddd.DomainEvent, opts ...ScheduleOption) error {
+	return s.PublishAt(ctx, time.Now().Add(delay), e, opts...)
+}
+
+// CancelScheduled removes the pending event with the given key.
+// Cancelling an unknown or already published key is not an error.
+func (s *Scheduler) CancelScheduled(ctx context.Context, key string) error {
+	return s.cancel(ctx, key)
+}
+
+func (s *Scheduler) cancel(ctx context.Context, key string) error {
+	return orm.Conn(ctx, s.db).
+		Where("schedule_key = ? AND dispatched_at IS NULL", key).
+		Delete(&ScheduledEvent{}).Error
+}
+
+// WithScheduler lets the bus publish delayed events through PublishAt and PublishAfter.
+func WithScheduler(scheduler *Scheduler) WatermillEventBusOption {
+	return func(b *WatermillEventBus) {
+		b.scheduler = scheduler
+	}
+}
+
+// PublishAt schedules e to be published at the given time (see Scheduler.PublishAt).
+func (b *WatermillEventBus) PublishAt(ctx context.Context, at time.Time, e ddd.DomainEvent, opts ...ScheduleOption) error {
+	if b.scheduler == nil {
+		return ErrSchedulerNotConfigured
+	}
+	return b.scheduler.PublishAt(ctx, at, e, opts...)
+}
+
+// PublishAfter schedules e to be published after delay.
+func (b *WatermillEventBus) PublishAfter(ctx context.Context, delay time.Duration, e ddd.DomainEvent, opts ...ScheduleOption) error {
+	if b.scheduler == nil {
+		return ErrSchedulerNotConfigured
+	}
+	return b.scheduler.PublishAfter(ctx, delay, e, opts...)
+}
+
+// CancelScheduled removes the pending event with the given key.
+func (b *WatermillEventBus) CancelScheduled(ctx context.Context, key string) error {
+	if b.scheduler == nil {
+		return ErrSchedulerNotConfigured
+	}
+	return b.scheduler.CancelScheduled(ctx, key)
+}
+
+// SchedulePoller periodically publishes due scheduled events through the event bus.
+// Every event is claimed for a lease before it is published, so several instances can poll the
+// same table; delivery is still at-least-once if an instance stops after publishing.
+type SchedulePoller struct {
+	scheduler   *Scheduler
+	bus         EventBus
+	registry    EventRegistry
+	logger      watermill.LoggerAdapter
+	interval    time.Duration
+	batchSize   int
+	lease       time.Duration
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// SchedulePollerOption is a functional option for SchedulePoller.
+type SchedulePollerOption func(*SchedulePoller)
+
+// WithPollInterval sets how often the poller looks for due events.
+func WithPollInterval(interval time.Duration) SchedulePollerOption {
+	return func(p *SchedulePoller) {
+		p.interval = interval
+	}
+}
+
+// WithPollBatchSize sets the maximum number of events published per poll.
+func WithPollBatchSize(size int) SchedulePollerOption {
+	return func(p *SchedulePoller) {
+		p.batchSize = size
+	}
+}
+
+// WithPollLease sets how long a claimed event is hidden from other pollers.
+func WithPollLease(lease time.Duration) SchedulePollerOption {
+	return func(p *SchedulePoller) {
+		p.lease = lease
+	}
+}
+
+// WithPollRetry sets the maximum publish attempts and the base delay of the exponential backoff.
+// Events that exhaust their attempts stay in the table with their last error.
+func WithPollRetry(maxAttempts int, backoff, maxBackoff time.Duration) SchedulePollerOption {
+	return func(p *SchedulePoller) {
+		p.maxAttempts = maxAttempts
+		p.backoff = backoff
+		p.maxBackoff = maxBackoff
+	}
+}
+
+// WithPollRegistry sets the registry used to decode scheduled events.
+func WithPollRegistry(registry EventRegistry) SchedulePollerOption {
+	return func(p *SchedulePoller) {
+		p.registry = registry
+	}
+}
+
+// WithPollLogger sets a custom logger.
+func WithPollLogger(logger watermill.LoggerAdapter) SchedulePollerOption {
+	return func(p *SchedulePoller) {
+		p.logger = logger
+	}
+}
+
+// NewSchedulePoller creates a SchedulePoller that publishes through bus.
+func NewSchedulePoller(scheduler *Scheduler, bus EventBus, opts ...SchedulePollerOption) *SchedulePoller {
+	poller := &SchedulePoller{
+		scheduler:   scheduler,
+		bus:         bus,
+		registry:    GlobalRegistry(),
+		logger:      watermill.NewStdLogger(false, false),
+		interval:    time.Second,
+		batchSize:   100,
+		lease:       time.Minute,
+		maxAttempts: 10,
+		backoff:     time.Second,
+		maxBackoff:  5 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(poller)
+	}
+	return poller
+}
+
+// Start launches the background polling loop. It matches the fx.Hook OnStart signature.
+func (p *SchedulePoller) Start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			if _, err := p.DispatchDue(runCtx); err != nil && runCtx.Err() == nil {
+				p.logger.Error("Schedule poll failed", err, nil)
+			}
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops the polling loop and waits for the current batch to finish.
+// It matches the fx.Hook OnStop signature.
+func (p *SchedulePoller) Stop(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DispatchDue publishes one batch of due events and returns how many were dispatched.
+func (p *SchedulePoller) DispatchDue(ctx context.Context) (int, error) {
+	db := orm.Primary(p.scheduler.db).WithContext(ctx)
+	now := time.Now()
+
+	var due []ScheduledEvent
+	err := db.
+		Where("dispatched_at IS NULL AND attempts < ? AND next_attempt_at <= ?", p.maxAttempts, now).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Order("due_at ASC").
+		Limit(p.batchSize).
+		Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	dispatched := 0
+	for _, row := range due {
+		if ctx.Err() != nil {
+			return dispatched, ctx.Err()
+		}
+		claimed, err := p.claim(ctx, row)
+		if err != nil {
+			return dispatched, err
+		}
+		if !claimed {
+			continue
+		}
+		if err := p.publish(ctx, row); err != nil {
+			p.markFailed(ctx, row, err)
+			continue
+		}
+		now := time.Now()
+		if err := db.Model(&ScheduledEvent{}).Where("id = ?", row.ID).Update("dispatched_at", &now).Error; err != nil {
+			return dispatched, err
+		}
+		dispatched++
+	}
+	return dispatched, nil
+}
+
+// claim locks a due event for the lease, unless another poller or a cancellation got there first.
+func (p *SchedulePoller) claim(ctx context.Context, row ScheduledEvent) (bool, error) {
+	now := time.Now()
+	until := now.Add(p.lease)
+	result := orm.Primary(p.scheduler.db).WithContext(ctx).Model(&ScheduledEvent{}).
+		Where("id = ? AND dispatched_at IS NULL", row.ID).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Update("locked_until", &until)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (p *SchedulePoller) publish(ctx context.Context, row ScheduledEvent) error {
+	// The schema version is read from the payload, so events scheduled before a deployment are upcast.
+	e, err := p.registry.Decode(row.EventName, 0, row.Payload)
+	if err != nil {
+		return err
+	}
+	return p.bus.Publish(ctx, e)
+}
+
+func (p *SchedulePoller) markFailed(ctx context.Context, row ScheduledEvent, cause error) {
+	attempts := row.Attempts + 1
+	delay := p.backoff << (attempts - 1)
+	if delay <= 0 || delay > p.maxBackoff {
+		delay = p.maxBackoff
+	}
+
+	p.logger.Error("Failed to publish scheduled event", cause, watermill.LogFields{
+		"event_name": row.EventName,
+		"key":        row.ScheduleKey,
+		"attempts":   attempts,
+	})
+
+	lastError := cause.Error()
+	if len(lastError) > 1024 {
+		lastError = lastError[:1024]
+	}
+	err := orm.Primary(p.scheduler.db).WithContext(ctx).Model(&ScheduledEvent{}).Where("id = ?", row.ID).Updates(map[string]any{
+		"attempts":        attempts,
+		"last_error":      lastError,
+		"next_attempt_at": time.Now().Add(delay),
+		"locked_until":    nil,
+	}).Error
+	if err != nil {
+		p.logger.Error("Failed to record scheduled event failure", err, watermill.LogFields{
+			"key": row.ScheduleKey,
+		})
+	}
+}
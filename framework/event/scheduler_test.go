@@ -0,0 +1,164 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+)
+
+func newTestScheduler(t *testing.T) (*Scheduler, *gorm.DB) {
+	t.Helper()
+	db := openTestDB(t)
+	if err := MigrateSchedule(db); err != nil {
+		t.Fatal(err)
+	}
+	return NewScheduler(db, WithSchedulerRegistry(newTestRegistry())), db
+}
+
+func newTestPoller(scheduler *Scheduler, bus EventBus, opts ...SchedulePollerOption) *SchedulePoller {
+	opts = append([]SchedulePollerOption{WithPollRegistry(newTestRegistry()), WithPollLogger(watermill.NopLogger{})}, opts...)
+	return NewSchedulePoller(scheduler, bus, opts...)
+}
+
+// publishedValues returns the values of the test events published through bus.
+func publishedValues(bus *stubBus) []string {
+	var values []string
+	for _, e := range bus.events() {
+		values = append(values, e.(*testEvent).Value)
+	}
+	return values
+}
+
+func scheduledRow(t *testing.T, db *gorm.DB, key string) ScheduledEvent {
+	t.Helper()
+	var row ScheduledEvent
+	if err := db.Where("schedule_key = ?", key).First(&row).Error; err != nil {
+		t.Fatal(err)
+	}
+	return row
+}
+
+func TestCancelledScheduleIsNotDispatched(t *testing.T) {
+	scheduler, db := newTestScheduler(t)
+	bus := &stubBus{}
+	poller := newTestPoller(scheduler, bus)
+	ctx := context.Background()
+	past := time.Now().Add(-time.Minute)
+
+	for _, key := range []string{"cancelled", "kept", "replaced"} {
+		if err := scheduler.PublishAt(ctx, past, newTestEvent(key), WithScheduleKey(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Scheduling again with a key replaces the pending event.
+	if err := scheduler.PublishAt(ctx, past.Add(time.Second), newTestEvent("replacement"), WithScheduleKey("replaced")); err != nil {
+		t.Fatal(err)
+	}
+	if err := scheduler.CancelScheduled(ctx, "cancelled"); err != nil {
+		t.Fatal(err)
+	}
+	// A schedule requested by a command that rolls back is discarded with it.
+	failed := errors.New("command failed")
+	err := orm.Transaction(ctx, db, func(ctx context.Context) error {
+		if err := scheduler.PublishAt(ctx, past, newTestEvent("rolled back"), WithScheduleKey("rolled back")); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("transaction = %v, want the command error", err)
+	}
+
+	if n, err := poller.DispatchDue(ctx); err != nil || n != 2 {
+		t.Fatalf("DispatchDue = %d, %v; want 2, nil", n, err)
+	}
+	if got := publishedValues(bus); len(got) != 2 || got[0] != "kept" || got[1] != "replacement" {
+		t.Errorf("published %v, want [kept replacement]", got)
+	}
+
+	// Cancelling after the event was published changes nothing.
+	if err := scheduler.CancelScheduled(ctx, "kept"); err != nil {
+		t.Fatal(err)
+	}
+	if row := scheduledRow(t, db, "kept"); row.DispatchedAt == nil {
+		t.Error("dispatched event lost its dispatched_at after a late cancel")
+	}
+	if n, _ := poller.DispatchDue(ctx); n != 0 {
+		t.Errorf("DispatchDue dispatched %d events again, want 0", n)
+	}
+}
+
+func TestScheduleLeaseIsHonoured(t *testing.T) {
+	scheduler, _ := newTestScheduler(t)
+	ctx := context.Background()
+	if err := scheduler.PublishAt(ctx, time.Now().Add(-time.Minute), newTestEvent("leased"), WithScheduleKey("leased")); err != nil {
+		t.Fatal(err)
+	}
+	if err := scheduler.PublishAfter(ctx, time.Hour, newTestEvent("later"), WithScheduleKey("later")); err != nil {
+		t.Fatal(err)
+	}
+
+	// While the first poller publishes, the event is claimed and hidden from a second one.
+	other := &stubBus{}
+	otherPoller := newTestPoller(scheduler, other)
+	var otherDispatched int
+	bus := &stubBus{beforePublish: func() {
+		otherDispatched, _ = otherPoller.DispatchDue(ctx)
+	}}
+	poller := newTestPoller(scheduler, bus, WithPollLease(time.Hour))
+
+	if n, err := poller.DispatchDue(ctx); err != nil || n != 1 {
+		t.Fatalf("DispatchDue = %d, %v; want 1, nil", n, err)
+	}
+	if otherDispatched != 0 || len(other.events()) != 0 {
+		t.Errorf("second poller dispatched %d events during the lease, want 0", otherDispatched)
+	}
+	if got := publishedValues(bus); len(got) != 1 || got[0] != "leased" {
+		t.Errorf("published %v, want only the due event", got)
+	}
+}
+
+func TestScheduleLeaseExpiresAndFailuresBackOff(t *testing.T) {
+	scheduler, db := newTestScheduler(t)
+	bus := &stubBus{}
+	poller := newTestPoller(scheduler, bus, WithPollRetry(3, time.Hour, time.Hour))
+	ctx := context.Background()
+	if err := scheduler.PublishAt(ctx, time.Now().Add(-time.Minute), newTestEvent("claimed"), WithScheduleKey("claimed")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Claimed by a poller that is still within its lease.
+	until := time.Now().Add(time.Hour)
+	if err := db.Model(&ScheduledEvent{}).Where("schedule_key = ?", "claimed").Update("locked_until", &until).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := poller.DispatchDue(ctx); n != 0 {
+		t.Fatalf("DispatchDue dispatched %d events under another poller's lease, want 0", n)
+	}
+
+	// The poller stopped without publishing and its lease expired.
+	expired := time.Now().Add(-time.Second)
+	if err := db.Model(&ScheduledEvent{}).Where("schedule_key = ?", "claimed").Update("locked_until", &expired).Error; err != nil {
+		t.Fatal(err)
+	}
+	bus.fail(errors.New("broker down"))
+	if n, _ := poller.DispatchDue(ctx); n != 0 {
+		t.Fatalf("DispatchDue = %d while publishing fails, want 0", n)
+	}
+	row := scheduledRow(t, db, "claimed")
+	if row.Attempts != 1 || row.LastError != "broker down" || row.LockedUntil != nil || !row.NextAttemptAt.After(time.Now()) {
+		t.Errorf("failed row = attempts %d, error %q, locked %v, next %v; want it released with a backoff",
+			row.Attempts, row.LastError, row.LockedUntil, row.NextAttemptAt)
+	}
+
+	// The backoff defers the retry.
+	bus.fail(nil)
+	if n, _ := poller.DispatchDue(ctx); n != 0 {
+		t.Errorf("DispatchDue retried %d events before the backoff, want 0", n)
+	}
+}
@@ -0,0 +1,98 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/soliton-go/framework/ddd"
+)
+
+// SyncDispatcher runs handlers inline, in the publisher's goroutine and context.
+// When Publish is called inside orm.Transaction, handlers join the same transaction,
+// so a handler error rolls back the command together with every handler's writes.
+// It implements EventBus and sits alongside the asynchronous WatermillEventBus.
+type SyncDispatcher struct {
+	mu         sync.RWMutex
+	handlers   []syncSubscription
+	registry   EventRegistry
+	middleware []HandlerMiddleware
+}
+
+type syncSubscription struct {
+	topic  string
+	handle HandleFunc
+}
+
+// SyncDispatcherOption is a functional option for SyncDispatcher.
+type SyncDispatcherOption func(*SyncDispatcher)
+
+// WithSyncRegistry sets the registry that provides the schema version of dispatched events.
+func WithSyncRegistry(registry EventRegistry) SyncDispatcherOption {
+	return func(d *SyncDispatcher) {
+		d.registry = registry
+	}
+}
+
+// WithSyncMiddleware wraps every handler call. The message passed to the middleware has no payload.
+func WithSyncMiddleware(mw ...HandlerMiddleware) SyncDispatcherOption {
+	return func(d *SyncDispatcher) {
+		d.middleware = append(d.middleware, mw...)
+	}
+}
+
+// NewSyncDispatcher creates a SyncDispatcher.
+func NewSyncDispatcher(opts ...SyncDispatcherOption) *SyncDispatcher {
+	d := &SyncDispatcher{registry: GlobalRegistry()}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Subscribe registers a handler for a topic or topic pattern (see MatchTopic).
+// The context is only used by asynchronous buses and subscribe options are ignored:
+// errors are returned to the publisher instead of being retried.
+func (d *SyncDispatcher) Subscribe(ctx context.Context, topic string, handler EventHandler, opts ...SubscribeOption) error {
+	handle := chainHandler(func(ctx context.Context, m *EventMessage) error {
+		return handler(ctx, m.Event)
+	}, d.middleware)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers = append(d.handlers, syncSubscription{topic: topic, handle: handle})
+	return nil
+}
+
+// Publish runs the handlers of each event in registration order and stops at the first error.
+// Events published by handlers are dispatched recursively, before Publish returns.
+func (d *SyncDispatcher) Publish(ctx context.Context, events ...ddd.DomainEvent) error {
+	for _, e := range events {
+		e = stampEnvelope(ctx, d.registry, e)
+		m := &EventMessage{
+			Topic:     e.EventName(),
+			EventName: e.EventName(),
+			Event:     e,
+			Message:   newEventMessage(e, nil),
+		}
+		handlerCtx := ContextForEvent(ctx, ddd.MetadataOf(e))
+		for _, sub := range d.subscriptions(m.Topic) {
+			if err := sub.handle(handlerCtx, m); err != nil {
+				return fmt.Errorf("sync handler for %s failed: %w", m.EventName, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *SyncDispatcher) subscriptions(topic string) []syncSubscription {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var matched []syncSubscription
+	for _, sub := range d.handlers {
+		if sub.topic == topic || (IsPattern(sub.topic) && MatchTopic(sub.topic, topic)) {
+			matched = append(matched, sub)
+		}
+	}
+	return matched
+}
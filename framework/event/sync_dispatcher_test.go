@@ -0,0 +1,79 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+)
+
+// effectWriter returns a handler that writes an effect with id in the context transaction.
+func effectWriter(db *gorm.DB, id string) EventHandler {
+	return func(ctx context.Context, e ddd.DomainEvent) error {
+		return orm.Conn(ctx, db).Create(&inboxEffect{ID: id}).Error
+	}
+}
+
+// runCommand writes the command's own row and publishes e in one transaction.
+func runCommand(db *gorm.DB, d *SyncDispatcher, e ddd.DomainEvent) error {
+	return orm.Transaction(context.Background(), db, func(ctx context.Context) error {
+		if err := orm.Conn(ctx, db).Create(&inboxEffect{ID: "command"}).Error; err != nil {
+			return err
+		}
+		return d.Publish(ctx, e)
+	})
+}
+
+func TestSyncDispatcherCommitsWithCommand(t *testing.T) {
+	_, db := newTestInbox(t)
+	d := NewSyncDispatcher(WithSyncRegistry(newTestRegistry()))
+	if err := d.Subscribe(context.Background(), "test.happened", effectWriter(db, "handler")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runCommand(db, d, newTestEvent("committed")); err != nil {
+		t.Fatal(err)
+	}
+	if n := countEffects(t, db); n != 2 {
+		t.Errorf("%d rows committed, want the command and the handler's", n)
+	}
+}
+
+func TestSyncHandlerErrorRollsBackCommand(t *testing.T) {
+	_, db := newTestInbox(t)
+	d := NewSyncDispatcher(WithSyncRegistry(newTestRegistry()))
+	failed := errors.New("promotion used up")
+	var calls []string
+	ctx := context.Background()
+	d.Subscribe(ctx, "test.happened", func(ctx context.Context, e ddd.DomainEvent) error {
+		calls = append(calls, "first")
+		return effectWriter(db, "first")(ctx, e)
+	})
+	d.Subscribe(ctx, "test.*", func(ctx context.Context, e ddd.DomainEvent) error {
+		calls = append(calls, "second")
+		return failed
+	})
+	d.Subscribe(ctx, "test.happened", func(ctx context.Context, e ddd.DomainEvent) error {
+		calls = append(calls, "third")
+		return nil
+	})
+
+	err := runCommand(db, d, newTestEvent("rolled back"))
+	if !errors.Is(err, failed) {
+		t.Fatalf("command = %v, want the handler error", err)
+	}
+	if !strings.Contains(err.Error(), "sync handler for test.happened failed") {
+		t.Errorf("error %q does not name the event", err)
+	}
+	// Handlers run in registration order and stop at the first error.
+	if got := strings.Join(calls, " "); got != "first second" {
+		t.Errorf("handlers ran %q, want first second", got)
+	}
+	if n := countEffects(t, db); n != 0 {
+		t.Errorf("%d rows kept, want the command and the handlers' writes rolled back", n)
+	}
+}
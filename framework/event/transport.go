@@ -0,0 +1,266 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/soliton-go/framework/core/config"
+	"gorm.io/gorm"
+)
+
+// Transport types accepted in event.transport.type.
+const (
+	TransportGoChannel   = "gochannel"
+	TransportSQL         = "sql"
+	TransportRedisStream = "redis"
+	TransportNATS        = "nats"
+)
+
+// Transport carries event messages between publishers and subscribers.
+type Transport interface {
+	message.Publisher
+	message.Subscriber
+}
+
+// TransportConfig selects and configures the event transport (config key event.transport).
+type TransportConfig struct {
+	// Type is one of gochannel (default, in-memory), sql, redis or nats.
+	Type string `mapstructure:"type"`
+	// ConsumerGroup names this service's subscriptions on durable transports.
+	// Instances sharing a group split the messages of a topic between them.
+	// A subscription can name its own group with WithConsumerGroup.
+	ConsumerGroup string `mapstructure:"consumer_group"`
+
+	SQL   SQLTransportConfig   `mapstructure:"sql"`
+	Redis RedisTransportConfig `mapstructure:"redis"`
+	NATS  NATSTransportConfig  `mapstructure:"nats"`
+}
+
+// DefaultTransportConfig returns the configuration used for unset keys.
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		Type:          TransportGoChannel,
+		ConsumerGroup: "app",
+		SQL: SQLTransportConfig{
+			PollInterval: time.Second,
+			BatchSize:    100,
+			Lease:        time.Minute,
+			NackDelay:    time.Second,
+		},
+		Redis: RedisTransportConfig{
+			Addr:          "localhost:6379",
+			Block:         time.Second,
+			BatchSize:     100,
+			ClaimInterval: 30 * time.Second,
+			MinIdle:       5 * time.Minute,
+			NackDelay:     time.Second,
+		},
+		NATS: NATSTransportConfig{
+			URL:           "nats://localhost:4222",
+			Stream:        "EVENTS",
+			SubjectPrefix: "events.",
+			AckWait:       30 * time.Second,
+			NackDelay:     time.Second,
+		},
+	}
+}
+
+// LoadTransportConfig reads event.transport from cfg on top of DefaultTransportConfig.
+func LoadTransportConfig(cfg *config.Config) (TransportConfig, error) {
+	tc := DefaultTransportConfig()
+	if err := cfg.UnmarshalKey("event.transport", &tc); err != nil {
+		return tc, fmt.Errorf("invalid event.transport config: %w", err)
+	}
+	return tc, nil
+}
+
+// NewTransport builds the transport selected by tc. The SQL transport uses db, the application's own database.
+func NewTransport(tc TransportConfig, db *gorm.DB, logger watermill.LoggerAdapter) (Transport, error) {
+	switch tc.Type {
+	case "", TransportGoChannel:
+		return gochannel.NewGoChannel(gochannel.Config{}, logger), nil
+	case TransportSQL:
+		if db == nil {
+			return nil, fmt.Errorf("sql event transport requires a database")
+		}
+		return NewSQLTransport(db, tc.ConsumerGroup, tc.SQL, logger)
+	case TransportRedisStream:
+		return NewRedisStreamTransport(tc.ConsumerGroup, tc.Redis, logger)
+	case TransportNATS:
+		return NewNATSTransport(tc.ConsumerGroup, tc.NATS, logger)
+	default:
+		return nil, fmt.Errorf("unsupported event transport: %s", tc.Type)
+	}
+}
+
+// NewEventBusFromConfig creates a WatermillEventBus on the transport configured under event.transport.
+// Call Close on shutdown to stop the transport's subscriptions and connections.
+func NewEventBusFromConfig(cfg *config.Config, db *gorm.DB, opts ...WatermillEventBusOption) (*WatermillEventBus, error) {
+	tc, err := LoadTransportConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	logger := watermill.NewStdLogger(false, false)
+	transport, err := NewTransport(tc, db, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s event transport: %w", tc.Type, err)
+	}
+	return newWatermillEventBus(transport, transport, logger, opts), nil
+}
+
+// Close closes the bus's publisher and subscriber.
+func (b *WatermillEventBus) Close() error {
+	err := b.publisher.Close()
+	if closer, ok := b.subscriber.(message.Publisher); !ok || closer != b.publisher {
+		if subErr := b.subscriber.Close(); err == nil {
+			err = subErr
+		}
+	}
+	return err
+}
+
+// consumerGroups names the consumer group of each subscription. The first subscription to a topic
+// uses the configured group; further subscriptions in the same process get a numbered group, so that
+// every handler receives every message. Handlers must be subscribed in the same order on every
+// instance, which fx module registration guarantees.
+type consumerGroups struct {
+	mu    sync.Mutex
+	base  string
+	count map[string]int
+}
+
+func newConsumerGroups(base string) *consumerGroups {
+	return &consumerGroups{base: base, count: make(map[string]int)}
+}
+
+func (g *consumerGroups) next(topic string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.count[topic]++
+	if n := g.count[topic]; n > 1 {
+		return g.base + "-" + strconv.Itoa(n)
+	}
+	return g.base
+}
+
+// forSubscription returns the group named by the subscription (see WithConsumerGroup), if any,
+// and otherwise the next group for topic.
+func (g *consumerGroups) forSubscription(ctx context.Context, topic string) string {
+	if group := subscriptionFromContext(ctx).consumerGroup; group != "" {
+		return group
+	}
+	return g.next(topic)
+}
+
+// ackWindow hands messages to a subscriber, keeping up to the subscription's in-flight limit
+// (see WithMaxInFlight) of them unacknowledged. A nacked message keeps its slot and is handed over
+// again after nackDelay: with a single slot nothing else is sent in between, with more it may come
+// after messages sent later.
+type ackWindow struct {
+	ctx       context.Context
+	closing   <-chan struct{}
+	output    chan<- *message.Message
+	nackDelay time.Duration
+	slots     chan struct{}
+	wg        sync.WaitGroup
+}
+
+func newAckWindow(ctx context.Context, closing <-chan struct{}, output chan<- *message.Message, nackDelay time.Duration) *ackWindow {
+	return &ackWindow{
+		ctx:       ctx,
+		closing:   closing,
+		output:    output,
+		nackDelay: nackDelay,
+		slots:     make(chan struct{}, subscriptionFromContext(ctx).maxInFlight),
+	}
+}
+
+// send waits for a free slot and hands over the message built by build, which is called again
+// for every redelivery. settle is called once, with true when the message is acked and false
+// when the subscription is closed first. send returns an error if it is closed before the
+// message is handed over; settle is not called then.
+func (w *ackWindow) send(build func() *message.Message, settle func(acked bool)) error {
+	select {
+	case w.slots <- struct{}{}:
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	case <-w.closing:
+		return errTransportClosed
+	}
+	msg := build()
+	if err := w.handOver(msg); err != nil {
+		<-w.slots
+		return err
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer func() { <-w.slots }()
+		for {
+			acked, err := w.awaitAck(msg)
+			if err == nil && !acked && sleep(w.ctx, w.closing, w.nackDelay) {
+				msg = build()
+				err = w.handOver(msg)
+				if err == nil {
+					continue
+				}
+			}
+			settle(acked)
+			return
+		}
+	}()
+	return nil
+}
+
+// wait blocks until every message sent is settled.
+func (w *ackWindow) wait() {
+	w.wg.Wait()
+}
+
+func (w *ackWindow) handOver(msg *message.Message) error {
+	select {
+	case w.output <- msg:
+		return nil
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	case <-w.closing:
+		return errTransportClosed
+	}
+}
+
+func (w *ackWindow) awaitAck(msg *message.Message) (bool, error) {
+	select {
+	case <-msg.Acked():
+		return true, nil
+	case <-msg.Nacked():
+		return false, nil
+	case <-w.ctx.Done():
+		return false, w.ctx.Err()
+	case <-w.closing:
+		return false, errTransportClosed
+	}
+}
+
+var errTransportClosed = errors.New("event transport closed")
+
+// sleep waits for d unless ctx or the transport is closed first.
+func sleep(ctx context.Context, closing <-chan struct{}, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-closing:
+		return false
+	}
+}
@@ -0,0 +1,230 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSTransportConfig configures the NATS JetStream transport (config key event.transport.nats).
+type NATSTransportConfig struct {
+	URL string `mapstructure:"url"`
+	// Stream is the JetStream stream holding the events. It is created if it does not exist.
+	Stream string `mapstructure:"stream"`
+	// SubjectPrefix is prepended to topics to form subjects; the stream captures SubjectPrefix + ">".
+	SubjectPrefix string `mapstructure:"subject_prefix"`
+	// AckWait is how long the server waits for an ack before redelivering.
+	// Handlers still running are kept alive with progress acks.
+	AckWait time.Duration `mapstructure:"ack_wait"`
+	// NackDelay is how long a nacked message waits before it is redelivered.
+	NackDelay time.Duration `mapstructure:"nack_delay"`
+}
+
+// natsUUIDHeader carries the message UUID; it is also sent as Nats-Msg-Id for deduplication.
+const natsUUIDHeader = "Watermill-Message-Uuid"
+
+// NATSTransport publishes to a JetStream stream and consumes it with durable pull consumers,
+// one per consumer group and topic. Topic patterns are passed to NATS, whose wildcards match
+// the syntax of MatchTopic, so it implements PatternSubscriber. Delivery is at-least-once.
+type NATSTransport struct {
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	cfg    NATSTransportConfig
+	groups *consumerGroups
+	logger watermill.LoggerAdapter
+
+	closeOnce sync.Once
+	closing   chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewNATSTransport connects to NATS and creates the stream if needed.
+func NewNATSTransport(consumerGroup string, cfg NATSTransportConfig, logger watermill.LoggerAdapter) (*NATSTransport, error) {
+	conn, err := nats.Connect(cfg.URL, nats.Name(consumerGroup))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     cfg.Stream,
+		Subjects: []string{cfg.SubjectPrefix + ">"},
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create stream %s: %w", cfg.Stream, err)
+	}
+
+	return &NATSTransport{
+		conn:    conn,
+		js:      js,
+		cfg:     cfg,
+		groups:  newConsumerGroups(consumerGroup),
+		logger:  logger,
+		closing: make(chan struct{}),
+	}, nil
+}
+
+// Publish implements message.Publisher.
+func (t *NATSTransport) Publish(topic string, messages ...*message.Message) error {
+	for _, msg := range messages {
+		m := nats.NewMsg(t.cfg.SubjectPrefix + topic)
+		m.Data = msg.Payload
+		for key, value := range msg.Metadata {
+			m.Header.Set(key, value)
+		}
+		m.Header.Set(natsUUIDHeader, msg.UUID)
+		m.Header.Set(jetstream.MsgIDHeader, msg.UUID)
+		if _, err := t.js.PublishMsg(msg.Context(), m); err != nil {
+			return fmt.Errorf("failed to publish to %s: %w", m.Subject, err)
+		}
+	}
+	return nil
+}
+
+// Subscribe implements message.Subscriber.
+func (t *NATSTransport) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	group := t.groups.forSubscription(ctx, topic)
+	consumer, err := t.js.CreateOrUpdateConsumer(ctx, t.cfg.Stream, jetstream.ConsumerConfig{
+		Durable:       natsDurableName(group, topic),
+		FilterSubject: t.cfg.SubjectPrefix + topic,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       t.cfg.AckWait,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer for %s: %w", topic, err)
+	}
+	iter, err := consumer.Messages()
+	if err != nil {
+		return nil, err
+	}
+
+	output := make(chan *message.Message)
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer close(output)
+		t.consume(ctx, iter, output)
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-t.closing:
+		}
+		iter.Stop()
+	}()
+	return output, nil
+}
+
+// SubscribePattern implements PatternSubscriber.
+func (t *NATSTransport) SubscribePattern(ctx context.Context, pattern string) (<-chan *message.Message, error) {
+	return t.Subscribe(ctx, pattern)
+}
+
+// Close stops all subscriptions and closes the connection.
+func (t *NATSTransport) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.closing)
+		t.wg.Wait()
+		t.conn.Close()
+	})
+	return nil
+}
+
+func (t *NATSTransport) consume(ctx context.Context, iter jetstream.MessagesContext, output chan<- *message.Message) {
+	window := newAckWindow(ctx, t.closing, output, t.cfg.NackDelay)
+	defer window.wait()
+	for {
+		m, err := iter.Next()
+		if err != nil {
+			if errors.Is(err, jetstream.ErrMsgIteratorClosed) {
+				return
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			t.logger.Error("Failed to read from nats consumer", err, nil)
+			if !sleep(ctx, t.closing, t.cfg.NackDelay) {
+				return
+			}
+			continue
+		}
+		if err := t.deliver(m, window); err != nil {
+			// Let the server redeliver it, to this instance after a restart or to another one.
+			_ = m.Nak()
+			return
+		}
+	}
+}
+
+// deliver hands m to the subscriber and acks it once the handler acks it, sending progress acks
+// until then. If the subscription is closed first, m is nacked so that the server redelivers it.
+func (t *NATSTransport) deliver(m jetstream.Msg, window *ackWindow) error {
+	headers := m.Headers()
+	uuid := headers.Get(natsUUIDHeader)
+	if uuid == "" {
+		uuid = watermill.NewUUID()
+	}
+	build := func() *message.Message {
+		msg := message.NewMessage(uuid, m.Data())
+		for key, values := range headers {
+			if len(values) > 0 && key != natsUUIDHeader && key != jetstream.MsgIDHeader {
+				msg.Metadata.Set(key, values[0])
+			}
+		}
+		return msg
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(t.cfg.AckWait / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = m.InProgress()
+			}
+		}
+	}()
+
+	err := window.send(build, func(acked bool) {
+		close(done)
+		var err error
+		if acked {
+			err = m.Ack()
+		} else {
+			err = m.Nak()
+		}
+		if err != nil {
+			// The server redelivers the message after AckWait.
+			t.logger.Error("Failed to acknowledge nats message", err, watermill.LogFields{"message_id": uuid})
+		}
+	})
+	if err != nil {
+		close(done)
+	}
+	return err
+}
+
+// natsDurableName builds a consumer name; NATS names cannot contain '.', '*', '>' or whitespace.
+func natsDurableName(group, topic string) string {
+	replacer := strings.NewReplacer(".", "_", "*", "star", ">", "all", " ", "_", "/", "_", "\\", "_")
+	return replacer.Replace(group + "__" + topic)
+}
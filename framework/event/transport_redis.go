@@ -0,0 +1,275 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisTransportConfig configures the Redis Streams transport (config key event.transport.redis).
+type RedisTransportConfig struct {
+	Addr     string `mapstructure:"addr"`
+	Password string `mapstructure:"password"`
+	DB       int    `mapstructure:"db"`
+	// StreamPrefix is prepended to topics to form stream keys.
+	StreamPrefix string `mapstructure:"stream_prefix"`
+	// MaxLen approximately caps the length of each stream; 0 keeps every message.
+	MaxLen int64 `mapstructure:"max_len"`
+	// Consumer names this instance within its consumer groups. It defaults to the host name.
+	Consumer string `mapstructure:"consumer"`
+	// Block is how long a read waits for new messages.
+	Block time.Duration `mapstructure:"block"`
+	// BatchSize is the maximum number of messages read at once.
+	BatchSize int64 `mapstructure:"batch_size"`
+	// ClaimInterval is how often pending messages of stopped consumers are claimed.
+	ClaimInterval time.Duration `mapstructure:"claim_interval"`
+	// MinIdle is how long a message stays pending before another consumer may claim it.
+	MinIdle time.Duration `mapstructure:"min_idle"`
+	// NackDelay is how long a nacked message waits before it is redelivered.
+	NackDelay time.Duration `mapstructure:"nack_delay"`
+}
+
+// Stream entry fields written by the Redis Streams transport.
+const (
+	redisFieldUUID     = "uuid"
+	redisFieldPayload  = "payload"
+	redisFieldMetadata = "metadata"
+)
+
+// RedisStreamTransport publishes to Redis Streams and consumes them with consumer groups.
+// A new consumer group starts at the beginning of the stream. Messages left pending by a
+// consumer that stopped are claimed by the others after MinIdle. Delivery is at-least-once.
+type RedisStreamTransport struct {
+	client   redis.UniversalClient
+	cfg      RedisTransportConfig
+	groups   *consumerGroups
+	consumer string
+	logger   watermill.LoggerAdapter
+
+	closeOnce sync.Once
+	closing   chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewRedisStreamTransport connects to Redis and creates a RedisStreamTransport.
+func NewRedisStreamTransport(consumerGroup string, cfg RedisTransportConfig, logger watermill.LoggerAdapter) (*RedisStreamTransport, error) {
+	client := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:    strings.Split(cfg.Addr, ","),
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	return NewRedisStreamTransportWithClient(client, consumerGroup, cfg, logger), nil
+}
+
+// NewRedisStreamTransportWithClient creates a RedisStreamTransport on an existing client.
+// Close closes the client.
+func NewRedisStreamTransportWithClient(client redis.UniversalClient, consumerGroup string, cfg RedisTransportConfig, logger watermill.LoggerAdapter) *RedisStreamTransport {
+	consumer := cfg.Consumer
+	if consumer == "" {
+		consumer, _ = os.Hostname()
+	}
+	if consumer == "" {
+		consumer = watermill.NewShortUUID()
+	}
+	return &RedisStreamTransport{
+		client:   client,
+		cfg:      cfg,
+		groups:   newConsumerGroups(consumerGroup),
+		consumer: consumer,
+		logger:   logger,
+		closing:  make(chan struct{}),
+	}
+}
+
+// Publish implements message.Publisher.
+func (t *RedisStreamTransport) Publish(topic string, messages ...*message.Message) error {
+	for _, msg := range messages {
+		metadata, err := json.Marshal(msg.Metadata)
+		if err != nil {
+			return err
+		}
+		err = t.client.XAdd(msg.Context(), &redis.XAddArgs{
+			Stream: t.cfg.StreamPrefix + topic,
+			MaxLen: t.cfg.MaxLen,
+			Approx: t.cfg.MaxLen > 0,
+			Values: map[string]any{
+				redisFieldUUID:     msg.UUID,
+				redisFieldPayload:  []byte(msg.Payload),
+				redisFieldMetadata: metadata,
+			},
+		}).Err()
+		if err != nil {
+			return fmt.Errorf("failed to add message to stream %s: %w", t.cfg.StreamPrefix+topic, err)
+		}
+	}
+	return nil
+}
+
+// Subscribe implements message.Subscriber.
+func (t *RedisStreamTransport) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	stream := t.cfg.StreamPrefix + topic
+	group := t.groups.forSubscription(ctx, topic)
+	err := t.client.XGroupCreateMkStream(ctx, stream, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, fmt.Errorf("failed to create consumer group %s on %s: %w", group, stream, err)
+	}
+
+	output := make(chan *message.Message)
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer close(output)
+		t.consume(ctx, stream, group, output)
+	}()
+	return output, nil
+}
+
+// Close stops all subscriptions and closes the Redis client.
+func (t *RedisStreamTransport) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		close(t.closing)
+		t.wg.Wait()
+		err = t.client.Close()
+	})
+	return err
+}
+
+func (t *RedisStreamTransport) consume(ctx context.Context, stream, group string, output chan<- *message.Message) {
+	window := newAckWindow(ctx, t.closing, output, t.cfg.NackDelay)
+	defer window.wait()
+
+	// Messages this consumer read before a restart are still pending: deliver them first.
+	id := "0"
+	lastClaim := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.closing:
+			return
+		default:
+		}
+
+		if time.Since(lastClaim) >= t.cfg.ClaimInterval {
+			// Settle the messages in flight first, so that none of them is claimed back.
+			window.wait()
+			lastClaim = time.Now()
+			if err := t.claim(ctx, stream, group, window); err != nil {
+				if errors.Is(err, errTransportClosed) || ctx.Err() != nil {
+					return
+				}
+				t.logger.Error("Failed to claim pending stream messages", err, watermill.LogFields{"stream": stream, "consumer_group": group})
+			}
+		}
+
+		streams, err := t.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: t.consumer,
+			Streams:  []string{stream, id},
+			Count:    t.cfg.BatchSize,
+			Block:    t.cfg.Block,
+		}).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			if ctx.Err() != nil {
+				return
+			}
+			t.logger.Error("Failed to read stream", err, watermill.LogFields{"stream": stream, "consumer_group": group})
+			if !sleep(ctx, t.closing, t.cfg.NackDelay) {
+				return
+			}
+			continue
+		}
+
+		var entries []redis.XMessage
+		for _, s := range streams {
+			entries = append(entries, s.Messages...)
+		}
+		if len(entries) == 0 && id == "0" {
+			id = ">"
+			continue
+		}
+		if err := t.deliverAll(ctx, stream, group, entries, window); err != nil {
+			return
+		}
+		if id == "0" {
+			// Reading pending messages again returns those still in flight.
+			window.wait()
+		}
+	}
+}
+
+// claim takes over messages that other consumers of the group left pending for longer than MinIdle.
+func (t *RedisStreamTransport) claim(ctx context.Context, stream, group string, window *ackWindow) error {
+	start := "0-0"
+	for {
+		entries, next, err := t.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   stream,
+			Group:    group,
+			Consumer: t.consumer,
+			MinIdle:  t.cfg.MinIdle,
+			Start:    start,
+			Count:    t.cfg.BatchSize,
+		}).Result()
+		if err != nil {
+			return err
+		}
+		if err := t.deliverAll(ctx, stream, group, entries, window); err != nil {
+			return err
+		}
+		if next == "0-0" || len(entries) == 0 {
+			return nil
+		}
+		start = next
+	}
+}
+
+// deliverAll hands entries to the subscriber and acks each one in the stream once it is acked.
+func (t *RedisStreamTransport) deliverAll(ctx context.Context, stream, group string, entries []redis.XMessage, window *ackWindow) error {
+	for _, entry := range entries {
+		err := window.send(func() *message.Message { return t.message(entry) }, func(acked bool) {
+			if !acked {
+				// Left pending: it is read again after a restart or claimed by another consumer.
+				return
+			}
+			if err := t.client.XAck(context.WithoutCancel(ctx), stream, group, entry.ID).Err(); err != nil {
+				t.logger.Error("Failed to ack stream message", err, watermill.LogFields{"stream": stream, "id": entry.ID})
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// message builds the message of a stream entry.
+func (t *RedisStreamTransport) message(entry redis.XMessage) *message.Message {
+	uuid, _ := entry.Values[redisFieldUUID].(string)
+	payload, _ := entry.Values[redisFieldPayload].(string)
+	metadata, _ := entry.Values[redisFieldMetadata].(string)
+	if uuid == "" {
+		uuid = entry.ID
+	}
+	msg := message.NewMessage(uuid, []byte(payload))
+	if err := json.Unmarshal([]byte(metadata), &msg.Metadata); err != nil {
+		// Deliver it anyway: the bus dead-letters messages it cannot decode instead of blocking the stream.
+		t.logger.Error("Invalid stream message metadata", err, watermill.LogFields{"id": entry.ID})
+	}
+	return msg
+}
@@ -0,0 +1,92 @@
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedisTransport(t *testing.T, server *miniredis.Miniredis, consumer string) *RedisStreamTransport {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	transport := NewRedisStreamTransportWithClient(client, "app", RedisTransportConfig{
+		Consumer:      consumer,
+		Block:         10 * time.Millisecond,
+		BatchSize:     10,
+		ClaimInterval: 10 * time.Millisecond,
+		MinIdle:       50 * time.Millisecond,
+		NackDelay:     10 * time.Millisecond,
+	}, watermill.NopLogger{})
+	t.Cleanup(func() { transport.Close() })
+	return transport
+}
+
+func subscribeRedisTest(t *testing.T, transport *RedisStreamTransport) <-chan *message.Message {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	messages, err := transport.Subscribe(ctx, testTopic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return messages
+}
+
+func publishRedisTest(t *testing.T, transport *RedisStreamTransport, uuids ...string) {
+	t.Helper()
+	for _, uuid := range uuids {
+		if err := transport.Publish(testTopic, message.NewMessage(uuid, []byte(`{}`))); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRedisStreamTransportRoundTrip(t *testing.T) {
+	transport := newTestRedisTransport(t, miniredis.RunT(t), "a")
+	messages := subscribeRedisTest(t, transport)
+
+	publishRedisTest(t, transport, "m1", "m2", "m3")
+	for _, want := range []string{"m1", "m2", "m3"} {
+		msg := receive(t, messages)
+		if msg.UUID != want {
+			t.Fatalf("received %s, want %s", msg.UUID, want)
+		}
+		msg.Ack()
+	}
+}
+
+func TestRedisStreamTransportRedeliversNackedMessage(t *testing.T) {
+	transport := newTestRedisTransport(t, miniredis.RunT(t), "a")
+	messages := subscribeRedisTest(t, transport)
+
+	publishRedisTest(t, transport, "m1")
+	msg := receive(t, messages)
+	msg.Nack()
+	if msg = receive(t, messages); msg.UUID != "m1" {
+		t.Fatalf("received %s after nack, want m1 again", msg.UUID)
+	}
+	msg.Ack()
+}
+
+func TestRedisStreamTransportPendingMessageIsClaimedAfterClose(t *testing.T) {
+	server := miniredis.RunT(t)
+	first := newTestRedisTransport(t, server, "a")
+	messages := subscribeRedisTest(t, first)
+	publishRedisTest(t, first, "m1")
+	receive(t, messages) // never acked
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	second := newTestRedisTransport(t, server, "b")
+	msg := receive(t, subscribeRedisTest(t, second))
+	if msg.UUID != "m1" {
+		t.Fatalf("received %s, want the pending m1", msg.UUID)
+	}
+	msg.Ack()
+}
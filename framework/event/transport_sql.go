@@ -0,0 +1,323 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SQLTransportConfig configures the SQL transport (config key event.transport.sql).
+type SQLTransportConfig struct {
+	// PollInterval is how long an idle subscription waits before looking for new messages.
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	// BatchSize is the maximum number of messages read per poll.
+	BatchSize int `mapstructure:"batch_size"`
+	// Lease is how long an instance owns a consumer group's position on a topic without renewing it.
+	Lease time.Duration `mapstructure:"lease"`
+	// NackDelay is how long a nacked message waits before it is redelivered.
+	NackDelay time.Duration `mapstructure:"nack_delay"`
+}
+
+// SQLMessage is the database row of a message published on the SQL transport.
+type SQLMessage struct {
+	ID uint64 `gorm:"primaryKey;autoIncrement"`
+	// Seq is the position of the message in its topic. Unlike ID, which is taken when the insert
+	// starts, it is assigned once the message is committed (see SQLTransport.sequence), so a
+	// message committed after a later ID cannot end up behind a consumer's position.
+	Seq       *uint64   `gorm:"uniqueIndex;index:idx_event_messages_topic_seq,priority:2"`
+	Topic     string    `gorm:"size:255;not null;index:idx_event_messages_topic_seq,priority:1"`
+	UUID      string    `gorm:"size:64;not null"`
+	Payload   []byte    `gorm:"not null"`
+	Metadata  string    `gorm:"type:text"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName overrides the GORM table name.
+func (SQLMessage) TableName() string {
+	return "event_messages"
+}
+
+// SQLConsumerOffset records the Seq of the last message a consumer group handled on a topic,
+// and which instance currently consumes it.
+type SQLConsumerOffset struct {
+	ConsumerGroup string `gorm:"primaryKey;size:255"`
+	Topic         string `gorm:"primaryKey;size:255"`
+	LastSeq       uint64 `gorm:"not null;default:0"`
+	LockedBy      string `gorm:"size:64"`
+	LockedUntil   *time.Time
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName overrides the GORM table name.
+func (SQLConsumerOffset) TableName() string {
+	return "event_consumer_offsets"
+}
+
+// SQLSequence holds the last Seq assigned to event_messages. Its row is locked while messages are
+// numbered.
+type SQLSequence struct {
+	Name    string `gorm:"primaryKey;size:64"`
+	LastSeq uint64 `gorm:"not null;default:0"`
+}
+
+// TableName overrides the GORM table name.
+func (SQLSequence) TableName() string {
+	return "event_sequences"
+}
+
+const sqlMessageSequence = "event_messages"
+
+// MigrateSQLTransport creates the tables of the SQL transport if they do not exist.
+// NewSQLTransport calls it, so it is only needed to prepare the schema ahead of time.
+func MigrateSQLTransport(db *gorm.DB) error {
+	db = orm.Primary(db)
+	if err := db.AutoMigrate(&SQLMessage{}, &SQLConsumerOffset{}, &SQLSequence{}); err != nil {
+		return err
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&SQLSequence{Name: sqlMessageSequence}).Error
+}
+
+// SQLTransport is a durable pub/sub on the application's own database.
+// Messages are appended to event_messages and numbered in the order they are committed; each
+// consumer group reads a topic in that order from its last position. One instance of a group consumes a topic at a time, holding a lease that
+// other instances take over when it stops renewing it. With WithMaxInFlight several messages are
+// handed out before the earlier ones are acked; the position only advances past acked messages.
+// Delivery is at-least-once. Messages are kept after they are consumed.
+type SQLTransport struct {
+	db       *gorm.DB
+	cfg      SQLTransportConfig
+	groups   *consumerGroups
+	instance string
+	logger   watermill.LoggerAdapter
+
+	closeOnce sync.Once
+	closing   chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewSQLTransport creates a SQLTransport and migrates its tables.
+func NewSQLTransport(db *gorm.DB, consumerGroup string, cfg SQLTransportConfig, logger watermill.LoggerAdapter) (*SQLTransport, error) {
+	if err := MigrateSQLTransport(db); err != nil {
+		return nil, err
+	}
+	return &SQLTransport{
+		db:       db,
+		cfg:      cfg,
+		groups:   newConsumerGroups(consumerGroup),
+		instance: watermill.NewUUID(),
+		logger:   logger,
+		closing:  make(chan struct{}),
+	}, nil
+}
+
+// Publish implements message.Publisher.
+func (t *SQLTransport) Publish(topic string, messages ...*message.Message) error {
+	rows := make([]SQLMessage, 0, len(messages))
+	for _, msg := range messages {
+		metadata, err := json.Marshal(msg.Metadata)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, SQLMessage{Topic: topic, UUID: msg.UUID, Payload: msg.Payload, Metadata: string(metadata)})
+	}
+	return t.db.Create(&rows).Error
+}
+
+// Subscribe implements message.Subscriber.
+func (t *SQLTransport) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	group := t.groups.forSubscription(ctx, topic)
+	err := t.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&SQLConsumerOffset{ConsumerGroup: group, Topic: topic}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer offset: %w", err)
+	}
+
+	output := make(chan *message.Message)
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer close(output)
+		t.consume(ctx, group, topic, output)
+	}()
+	return output, nil
+}
+
+// Close stops all subscriptions and releases their leases, so that another instance can take over
+// without waiting for them to expire.
+func (t *SQLTransport) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		close(t.closing)
+		t.wg.Wait()
+		err = t.db.Model(&SQLConsumerOffset{}).Where("locked_by = ?", t.instance).Update("locked_until", nil).Error
+	})
+	return err
+}
+
+func (t *SQLTransport) consume(ctx context.Context, group, topic string, output chan<- *message.Message) {
+	for {
+		delivered, err := t.poll(ctx, group, topic, output)
+		if err != nil && err != errTransportClosed && ctx.Err() == nil {
+			t.logger.Error("SQL transport poll failed", err, watermill.LogFields{"topic": topic, "consumer_group": group})
+		}
+		if delivered > 0 && err == nil {
+			continue
+		}
+		if !sleep(ctx, t.closing, t.cfg.PollInterval) {
+			return
+		}
+	}
+}
+
+// poll delivers the next batch of messages if this instance holds the group's lease on the topic.
+func (t *SQLTransport) poll(ctx context.Context, group, topic string, output chan<- *message.Message) (int, error) {
+	db := orm.Primary(t.db).WithContext(ctx)
+	offset, ok, err := t.claim(ctx, group, topic)
+	if err != nil || !ok {
+		return 0, err
+	}
+	if err := t.sequence(ctx); err != nil {
+		return 0, fmt.Errorf("failed to number messages: %w", err)
+	}
+
+	var rows []SQLMessage
+	err = db.Where("topic = ? AND seq > ?", topic, offset.LastSeq).Order("seq ASC").Limit(t.cfg.BatchSize).Find(&rows).Error
+	if err != nil {
+		return 0, err
+	}
+
+	// Messages may be acked out of order; the position only advances past acked messages
+	// that follow the last position without a gap.
+	var (
+		mu        sync.Mutex
+		acked     = make([]bool, len(rows))
+		committed int
+		commitErr error
+	)
+	commit := func(i int) {
+		mu.Lock()
+		defer mu.Unlock()
+		acked[i] = true
+		next := committed
+		for next < len(rows) && acked[next] {
+			next++
+		}
+		if next == committed || commitErr != nil {
+			return
+		}
+		until := time.Now().Add(t.cfg.Lease)
+		result := db.Model(&SQLConsumerOffset{}).
+			Where("consumer_group = ? AND topic = ? AND locked_by = ?", group, topic, t.instance).
+			Updates(map[string]any{"last_seq": *rows[next-1].Seq, "locked_until": &until})
+		switch {
+		case result.Error != nil:
+			commitErr = result.Error
+		case result.RowsAffected == 0:
+			commitErr = fmt.Errorf("lost lease on %s for consumer group %s", topic, group)
+		default:
+			committed = next
+		}
+	}
+	failed := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		return commitErr
+	}
+
+	window := newAckWindow(ctx, t.closing, output, t.cfg.NackDelay)
+	for i, row := range rows {
+		if err = failed(); err != nil {
+			break
+		}
+		err = window.send(func() *message.Message { return t.message(row) }, func(ok bool) {
+			if ok {
+				commit(i)
+			}
+		})
+		if err != nil {
+			break
+		}
+	}
+	window.wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err == nil {
+		err = commitErr
+	}
+	return committed, err
+}
+
+// sequence numbers the committed messages that have no Seq yet, in the order of their IDs.
+// It holds the lock on the event_sequences row while doing so, so numbers become visible in
+// increasing order: a message whose insert commits after messages with higher IDs were read
+// gets a number after theirs instead of one behind the consumers' positions.
+func (t *SQLTransport) sequence(ctx context.Context) error {
+	db := orm.Primary(t.db).WithContext(ctx)
+	var pending []uint64
+	if err := db.Model(&SQLMessage{}).Where("seq IS NULL").Limit(1).Pluck("id", &pending).Error; err != nil || len(pending) == 0 {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Writing the row takes its lock before anything is read, so the reads below see the
+		// numbers committed by the transaction that held it before.
+		err := tx.Model(&SQLSequence{}).Where("name = ?", sqlMessageSequence).
+			Update("last_seq", gorm.Expr("last_seq")).Error
+		if err != nil {
+			return err
+		}
+		var seq SQLSequence
+		if err := tx.Where("name = ?", sqlMessageSequence).First(&seq).Error; err != nil {
+			return err
+		}
+		var ids []uint64
+		err = tx.Model(&SQLMessage{}).Where("seq IS NULL").Order("id ASC").Limit(t.cfg.BatchSize).Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		for _, id := range ids {
+			seq.LastSeq++
+			if err := tx.Model(&SQLMessage{}).Where("id = ?", id).Update("seq", seq.LastSeq).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&SQLSequence{}).Where("name = ?", sqlMessageSequence).Update("last_seq", seq.LastSeq).Error
+	})
+}
+
+// claim takes or renews the lease on the group's position and returns it.
+func (t *SQLTransport) claim(ctx context.Context, group, topic string) (SQLConsumerOffset, bool, error) {
+	db := orm.Primary(t.db).WithContext(ctx)
+	now := time.Now()
+	until := now.Add(t.cfg.Lease)
+	result := db.Model(&SQLConsumerOffset{}).
+		Where("consumer_group = ? AND topic = ?", group, topic).
+		Where("locked_by = ? OR locked_until IS NULL OR locked_until < ?", t.instance, now).
+		Updates(map[string]any{"locked_by": t.instance, "locked_until": &until})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return SQLConsumerOffset{}, false, result.Error
+	}
+
+	var offset SQLConsumerOffset
+	err := db.Where("consumer_group = ? AND topic = ?", group, topic).First(&offset).Error
+	return offset, err == nil, err
+}
+
+// message builds the message of a row.
+func (t *SQLTransport) message(row SQLMessage) *message.Message {
+	msg := message.NewMessage(row.UUID, row.Payload)
+	if err := json.Unmarshal([]byte(row.Metadata), &msg.Metadata); err != nil {
+		// Deliver it anyway: the bus dead-letters messages it cannot decode instead of blocking the topic.
+		t.logger.Error("Invalid SQL transport message metadata", err, watermill.LogFields{"message_id": row.UUID})
+	}
+	return msg
+}
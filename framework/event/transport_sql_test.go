@@ -0,0 +1,189 @@
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"gorm.io/gorm"
+)
+
+const testTopic = "test.happened"
+
+func newTestSQLTransport(t *testing.T, db *gorm.DB) *SQLTransport {
+	t.Helper()
+	transport, err := NewSQLTransport(db, "app", SQLTransportConfig{
+		PollInterval: 10 * time.Millisecond,
+		BatchSize:    10,
+		Lease:        time.Minute,
+		NackDelay:    10 * time.Millisecond,
+	}, watermill.NopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { transport.Close() })
+	return transport
+}
+
+func subscribeTest(t *testing.T, transport *SQLTransport, maxInFlight int) <-chan *message.Message {
+	t.Helper()
+	ctx, cancel := context.WithCancel(withSubscription(context.Background(), subscriptionConfig{workers: maxInFlight, maxInFlight: maxInFlight}))
+	t.Cleanup(cancel)
+	messages, err := transport.Subscribe(ctx, testTopic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return messages
+}
+
+func publishTest(t *testing.T, transport *SQLTransport, uuids ...string) {
+	t.Helper()
+	for _, uuid := range uuids {
+		if err := transport.Publish(testTopic, message.NewMessage(uuid, []byte(`{}`))); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func receive(t *testing.T, messages <-chan *message.Message) *message.Message {
+	t.Helper()
+	select {
+	case msg, ok := <-messages:
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+		return nil
+	}
+}
+
+func expectNoMessage(t *testing.T, messages <-chan *message.Message) {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		t.Fatalf("unexpected message %s", msg.UUID)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func lastSeq(t *testing.T, db *gorm.DB) uint64 {
+	t.Helper()
+	var offset SQLConsumerOffset
+	if err := db.Where("consumer_group = ? AND topic = ?", "app", testTopic).First(&offset).Error; err != nil {
+		t.Fatal(err)
+	}
+	return offset.LastSeq
+}
+
+func TestSQLTransportRoundTrip(t *testing.T) {
+	transport := newTestSQLTransport(t, openTestDB(t))
+	messages := subscribeTest(t, transport, 1)
+
+	publishTest(t, transport, "m1", "m2", "m3")
+	for _, want := range []string{"m1", "m2", "m3"} {
+		msg := receive(t, messages)
+		if msg.UUID != want {
+			t.Fatalf("received %s, want %s", msg.UUID, want)
+		}
+		msg.Ack()
+	}
+}
+
+func TestSQLTransportDeliversMessageCommittedAfterLaterID(t *testing.T) {
+	db := openTestDB(t)
+	transport := newTestSQLTransport(t, db)
+	messages := subscribeTest(t, transport, 1)
+
+	// ID 2 commits while the insert of ID 1 is still in flight.
+	if err := db.Create(&SQLMessage{ID: 2, Topic: testTopic, UUID: "later-id", Payload: []byte(`{}`)}).Error; err != nil {
+		t.Fatal(err)
+	}
+	msg := receive(t, messages)
+	if msg.UUID != "later-id" {
+		t.Fatalf("received %s, want later-id", msg.UUID)
+	}
+	msg.Ack()
+
+	if err := db.Create(&SQLMessage{ID: 1, Topic: testTopic, UUID: "earlier-id", Payload: []byte(`{}`)}).Error; err != nil {
+		t.Fatal(err)
+	}
+	msg = receive(t, messages)
+	if msg.UUID != "earlier-id" {
+		t.Fatalf("received %s, want earlier-id", msg.UUID)
+	}
+	msg.Ack()
+}
+
+func TestSQLTransportRedeliversNackedMessage(t *testing.T) {
+	transport := newTestSQLTransport(t, openTestDB(t))
+	messages := subscribeTest(t, transport, 1)
+
+	publishTest(t, transport, "m1", "m2")
+	msg := receive(t, messages)
+	if msg.UUID != "m1" {
+		t.Fatalf("received %s, want m1", msg.UUID)
+	}
+	msg.Nack()
+
+	msg = receive(t, messages)
+	if msg.UUID != "m1" {
+		t.Fatalf("received %s after nack, want m1 again", msg.UUID)
+	}
+	msg.Ack()
+	if msg = receive(t, messages); msg.UUID != "m2" {
+		t.Fatalf("received %s, want m2", msg.UUID)
+	}
+	msg.Ack()
+}
+
+func TestSQLTransportLeaseIsTakenOverAfterClose(t *testing.T) {
+	db := openTestDB(t)
+	first := newTestSQLTransport(t, db)
+	messages := subscribeTest(t, first, 1)
+	publishTest(t, first, "m1")
+	receive(t, messages).Ack()
+
+	second := newTestSQLTransport(t, db)
+	taken := subscribeTest(t, second, 1)
+	publishTest(t, second, "m2")
+	// The first instance holds the lease, so only it receives m2.
+	expectNoMessage(t, taken)
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// m2 was handed to the first instance but never acked, so the second one receives it.
+	msg := receive(t, taken)
+	if msg.UUID != "m2" {
+		t.Fatalf("received %s, want m2", msg.UUID)
+	}
+	msg.Ack()
+}
+
+func TestSQLTransportAdvancesOnlyPastContiguousAcks(t *testing.T) {
+	db := openTestDB(t)
+	transport := newTestSQLTransport(t, db)
+	messages := subscribeTest(t, transport, 3)
+
+	publishTest(t, transport, "m1", "m2", "m3")
+	m1, m2, m3 := receive(t, messages), receive(t, messages), receive(t, messages)
+	m3.Ack()
+	m2.Ack()
+	time.Sleep(100 * time.Millisecond)
+	if got := lastSeq(t, db); got != 0 {
+		t.Fatalf("position after acking m2 and m3 = %d, want 0", got)
+	}
+
+	m1.Ack()
+	deadline := time.Now().Add(5 * time.Second)
+	for lastSeq(t, db) != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("position after acking every message = %d, want 3", lastSeq(t, db))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -2,9 +2,9 @@ package event
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"time"
+	"sync"
 
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill/message"
@@ -15,7 +15,7 @@ import (
 // EventBus dispatches domain events.
 type EventBus interface {
 	Publish(ctx context.Context, events ...ddd.DomainEvent) error
-	Subscribe(ctx context.Context, topic string, handler EventHandler) error
+	Subscribe(ctx context.Context, topic string, handler EventHandler, opts ...SubscribeOption) error
 }
 
 // EventHandler handles a domain event.
@@ -30,6 +30,27 @@ type WatermillEventBus struct {
 	subscriber message.Subscriber
 	registry   EventRegistry
 	logger     watermill.LoggerAdapter
+
+	defaultRetry RetryPolicy
+	deadLetters  *DeadLetterStore
+
+	publishMiddleware []PublishMiddleware
+	handlerMiddleware []HandlerMiddleware
+
+	codec       Codec
+	topicCodecs []topicCodec
+	codecs      Codecs
+
+	scheduler *Scheduler
+	recorder  *EventRecorder
+
+	mu       sync.RWMutex
+	handlers map[string]namedHandler
+}
+
+type topicCodec struct {
+	pattern string
+	codec   Codec
 }
 
 // WatermillEventBusOption is a functional option for WatermillEventBus.
@@ -49,33 +70,61 @@ func WithLogger(logger watermill.LoggerAdapter) WatermillEventBusOption {
 	}
 }
 
+// WithCodec sets the codec used to publish events. The default is JSONCodec.
+// Subscribers pick the decoder from the message's content type, whatever codec the bus publishes with.
+func WithCodec(codec Codec) WatermillEventBusOption {
+	return func(b *WatermillEventBus) {
+		b.codec = codec
+		b.codecs[codec.ContentType()] = codec
+	}
+}
+
+// WithTopicCodec publishes the events of a topic or topic pattern (see MatchTopic) with codec,
+// e.g. WithTopicCodec("inventory.*", MsgpackCodec). The first matching topic codec wins.
+func WithTopicCodec(topic string, codec Codec) WatermillEventBusOption {
+	return func(b *WatermillEventBus) {
+		b.topicCodecs = append(b.topicCodecs, topicCodec{pattern: topic, codec: codec})
+		b.codecs[codec.ContentType()] = codec
+	}
+}
+
+// WithCodecs makes additional codecs available for decoding. The built-in codecs are always available.
+func WithCodecs(codecs ...Codec) WatermillEventBusOption {
+	return func(b *WatermillEventBus) {
+		for _, codec := range codecs {
+			b.codecs[codec.ContentType()] = codec
+		}
+	}
+}
+
 // NewLocalEventBus creates a WatermillEventBus using Go channels (in-memory).
 func NewLocalEventBus(opts ...WatermillEventBusOption) *WatermillEventBus {
 	logger := watermill.NewStdLogger(false, false)
 	pubsub := gochannel.NewGoChannel(gochannel.Config{}, logger)
-	bus := &WatermillEventBus{
-		publisher:  pubsub,
-		subscriber: pubsub,
-		registry:   GlobalRegistry(),
-		logger:     logger,
-	}
-	for _, opt := range opts {
-		opt(bus)
-	}
-	return bus
+	return newWatermillEventBus(pubsub, pubsub, logger, opts)
 }
 
 // NewWatermillEventBus creates a WatermillEventBus with provided publisher/subscriber (e.g. Redis).
 func NewWatermillEventBus(pub message.Publisher, sub message.Subscriber, opts ...WatermillEventBusOption) *WatermillEventBus {
+	return newWatermillEventBus(pub, sub, watermill.NewStdLogger(false, false), opts)
+}
+
+func newWatermillEventBus(pub message.Publisher, sub message.Subscriber, logger watermill.LoggerAdapter, opts []WatermillEventBusOption) *WatermillEventBus {
 	bus := &WatermillEventBus{
 		publisher:  pub,
 		subscriber: sub,
 		registry:   GlobalRegistry(),
-		logger:     watermill.NewStdLogger(false, false),
+		logger:     logger,
+		codec:      JSONCodec,
+		codecs:     DefaultCodecs(),
+		handlers:   make(map[string]namedHandler),
 	}
 	for _, opt := range opts {
 		opt(bus)
 	}
+	// Recoverer is the outermost middleware. It is added after the options so that it logs to
+	// the logger they set.
+	bus.handlerMiddleware = append([]HandlerMiddleware{Recoverer(bus.logger)}, bus.handlerMiddleware...)
 	return bus
 }
 
@@ -84,123 +133,217 @@ func (b *WatermillEventBus) Registry() EventRegistry {
 	return b.registry
 }
 
+// Publisher returns the underlying Watermill publisher, e.g. for replaying dead letters.
+func (b *WatermillEventBus) Publisher() message.Publisher {
+	return b.publisher
+}
+
 func (b *WatermillEventBus) Publish(ctx context.Context, events ...ddd.DomainEvent) error {
+	publish := chainPublish(func(ctx context.Context, m *EventMessage) error {
+		if err := b.publisher.Publish(m.Topic, m.Message); err != nil {
+			return err
+		}
+		if b.recorder != nil {
+			// The broker already accepted the message: failing here would make callers such as the
+			// outbox relay publish it again, so the gap in the record is only logged.
+			if err := b.recorder.Record(ctx, m.Topic, m.Message); err != nil {
+				b.logger.Error("Event published but not recorded", err, watermill.LogFields{
+					"event_name": m.EventName,
+					"message_id": m.Message.UUID,
+				})
+			}
+		}
+		return nil
+	}, b.publishMiddleware)
+
 	for _, event := range events {
-		payload, err := json.Marshal(event)
+		event = stampEnvelope(ctx, b.registry, event)
+		codec := b.codecFor(event.EventName())
+		payload, err := codec.Marshal(event)
 		if err != nil {
 			return fmt.Errorf("failed to marshal event %s: %w", event.EventName(), err)
 		}
 
-		msg := message.NewMessage(watermill.NewUUID(), payload)
-		msg.Metadata.Set("occurred_on", event.OccurredOn().Format(time.RFC3339))
-		msg.Metadata.Set("event_name", event.EventName())
-
-		if err := b.publisher.Publish(event.EventName(), msg); err != nil {
+		m := &EventMessage{
+			Topic:     event.EventName(),
+			EventName: event.EventName(),
+			Event:     event,
+			Message:   newEventMessage(event, payload),
+		}
+		m.Message.Metadata.Set(MetadataContentType, codec.ContentType())
+		if err := publish(ctx, m); err != nil {
 			return fmt.Errorf("failed to publish event %s: %w", event.EventName(), err)
 		}
 	}
 	return nil
 }
 
+// codecFor returns the codec events of topic are published with.
+func (b *WatermillEventBus) codecFor(topic string) Codec {
+	for _, tc := range b.topicCodecs {
+		if tc.pattern == topic || (IsPattern(tc.pattern) && MatchTopic(tc.pattern, topic)) {
+			return tc.codec
+		}
+	}
+	return b.codec
+}
+
 // Subscribe registers a handler for events on the given topic.
 // The handler receives properly deserialized events based on the registered event types.
-func (b *WatermillEventBus) Subscribe(ctx context.Context, topic string, handler EventHandler) error {
-	messages, err := b.subscriber.Subscribe(ctx, topic)
-	if err != nil {
-		return fmt.Errorf("failed to subscribe to topic %s: %w", topic, err)
+// The topic may be a pattern such as "order.*" or ">" (see MatchTopic).
+func (b *WatermillEventBus) Subscribe(ctx context.Context, topic string, handler EventHandler, opts ...SubscribeOption) error {
+	cfg := b.subscriptionConfig(opts)
+	handle := chainHandler(func(ctx context.Context, m *EventMessage) error {
+		return handler(ctx, m.Event)
+	}, b.handlerMiddleware)
+	if err := b.registerHandler(topic, handle, cfg); err != nil {
+		return err
 	}
 
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case msg, ok := <-messages:
-				if !ok {
-					return
-				}
-				b.handleMessage(ctx, msg, handler)
-			}
+	return b.subscribe(ctx, topic, cfg, func(topic string, msg *message.Message) {
+		b.handleMessage(ctx, topic, msg, handle, cfg)
+	})
+}
+
+// SubscribeRaw registers a handler that receives raw event data without deserialization.
+// Use this when you need to handle events dynamically or when type registration is not possible.
+// Patterns are resolved like in Subscribe. The payload is passed as published, in the codec
+// named by the message's content_type metadata (see MetadataContentType).
+func (b *WatermillEventBus) SubscribeRaw(ctx context.Context, topic string, handler RawEventHandler, opts ...SubscribeOption) error {
+	cfg := b.subscriptionConfig(opts)
+	handle := chainHandler(func(ctx context.Context, m *EventMessage) error {
+		return handler(ctx, m.EventName, m.Message.Payload)
+	}, b.handlerMiddleware)
+
+	return b.subscribe(ctx, topic, cfg, func(topic string, msg *message.Message) {
+		m := &EventMessage{Topic: topic, EventName: msg.Metadata.Get(MetadataEventName), Message: msg}
+		handlerCtx := withMessageID(ContextForEvent(ctx, MetadataFromMessage(msg)), msg.UUID)
+		b.deliver(handlerCtx, topic, msg, cfg, func(ctx context.Context) error {
+			return handle(ctx, m)
+		})
+	})
+}
+
+// subscribe feeds every message of topic to process until ctx is done.
+// Patterns are routed by the transport if it implements PatternSubscriber,
+// and otherwise expanded to the matching topics known to the registry.
+// The consumer group and in-flight limit of cfg are passed to the transport through the context.
+func (b *WatermillEventBus) subscribe(ctx context.Context, topic string, cfg subscriptionConfig, process func(topic string, msg *message.Message)) error {
+	ctx = withSubscription(ctx, cfg)
+	if !IsPattern(topic) {
+		messages, err := b.subscriber.Subscribe(ctx, topic)
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to topic %s: %w", topic, err)
 		}
-	}()
+		go b.consume(ctx, topic, messages, cfg, process)
+		return nil
+	}
+
+	if ps, ok := b.subscriber.(PatternSubscriber); ok {
+		messages, err := ps.SubscribePattern(ctx, topic)
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to pattern %s: %w", topic, err)
+		}
+		go b.consume(ctx, "", messages, cfg, process)
+		return nil
+	}
 
+	topics := MatchingTopics(b.registry, topic)
+	if len(topics) == 0 {
+		return fmt.Errorf("no registered event matches pattern %s", topic)
+	}
+	for _, t := range topics {
+		messages, err := b.subscriber.Subscribe(ctx, t)
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to topic %s: %w", t, err)
+		}
+		go b.consume(ctx, t, messages, cfg, process)
+	}
 	return nil
 }
 
-// SubscribeRaw registers a handler that receives raw event data without deserialization.
-// Use this when you need to handle events dynamically or when type registration is not possible.
-func (b *WatermillEventBus) SubscribeRaw(ctx context.Context, topic string, handler RawEventHandler) error {
-	messages, err := b.subscriber.Subscribe(ctx, topic)
-	if err != nil {
-		return fmt.Errorf("failed to subscribe to topic %s: %w", topic, err)
+// consume reads messages until ctx is done. An empty topic means the messages come from a
+// transport-level pattern subscription, where the event name identifies the topic.
+// With several workers, messages are processed in parallel except those sharing a partition key,
+// and at most maxInFlight messages are read ahead of the ones being processed.
+func (b *WatermillEventBus) consume(ctx context.Context, topic string, messages <-chan *message.Message, cfg subscriptionConfig, process func(topic string, msg *message.Message)) {
+	workers, maxInFlight := cfg.inFlight()
+	var executor *keyedExecutor
+	if workers > 1 {
+		executor = newKeyedExecutor(workers)
+		defer executor.wait()
 	}
+	inFlight := make(chan struct{}, maxInFlight)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case inFlight <- struct{}{}:
+		}
 
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
+		var msg *message.Message
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok := <-messages:
+			if !ok {
 				return
-			case msg, ok := <-messages:
-				if !ok {
-					return
-				}
-				eventName := msg.Metadata.Get("event_name")
-				if err := handler(ctx, eventName, msg.Payload); err != nil {
-					b.logger.Error("Failed to handle raw event", err, watermill.LogFields{
-						"event_name": eventName,
-						"message_id": msg.UUID,
-					})
-					msg.Nack()
-					continue
-				}
-				msg.Ack()
 			}
+			msg = m
 		}
-	}()
 
-	return nil
+		msgTopic := topic
+		if msgTopic == "" {
+			msgTopic = msg.Metadata.Get(MetadataEventName)
+		}
+		if executor == nil {
+			process(msgTopic, msg)
+			<-inFlight
+			continue
+		}
+		executor.submit(cfg.partitionKey(msg), func() {
+			defer func() { <-inFlight }()
+			process(msgTopic, msg)
+		})
+	}
 }
 
-func (b *WatermillEventBus) handleMessage(ctx context.Context, msg *message.Message, handler EventHandler) {
-	eventName := msg.Metadata.Get("event_name")
+func (b *WatermillEventBus) handleMessage(ctx context.Context, topic string, msg *message.Message, handle HandleFunc, cfg subscriptionConfig) {
+	eventName := msg.Metadata.Get(MetadataEventName)
 	if eventName == "" {
-		b.logger.Error("Message missing event_name metadata", nil, watermill.LogFields{
-			"message_id": msg.UUID,
-		})
-		msg.Nack()
+		b.reject(ctx, topic, msg, cfg, errors.New("message missing event_name metadata"))
 		return
 	}
 
-	// Try to create event instance from registry
-	event, err := b.registry.Create(eventName)
+	event, meta, err := b.decode(eventName, msg)
 	if err != nil {
-		b.logger.Error("Failed to create event from registry", err, watermill.LogFields{
-			"event_name": eventName,
-			"message_id": msg.UUID,
-		})
-		msg.Nack()
+		b.reject(ctx, topic, msg, cfg, err)
 		return
 	}
 
-	// Unmarshal the payload into the event
-	if err := json.Unmarshal(msg.Payload, event); err != nil {
-		b.logger.Error("Failed to unmarshal event", err, watermill.LogFields{
-			"event_name": eventName,
-			"message_id": msg.UUID,
-		})
-		msg.Nack()
-		return
-	}
+	// Call the handler under the subscription's retry policy
+	m := &EventMessage{Topic: topic, EventName: eventName, Event: event, Message: msg}
+	b.deliver(withMessageID(ContextForEvent(ctx, meta), msg.UUID), topic, msg, cfg, func(ctx context.Context) error {
+		return handle(ctx, m)
+	})
+}
 
-	// Call the handler
-	if err := handler(ctx, event); err != nil {
-		b.logger.Error("Event handler failed", err, watermill.LogFields{
-			"event_name": eventName,
-			"message_id": msg.UUID,
-		})
-		msg.Nack()
-		return
-	}
+// decode decodes a message into its registered event type, upcast to the current schema,
+// and restores the envelope from the message metadata.
+func (b *WatermillEventBus) decode(eventName string, msg *message.Message) (ddd.DomainEvent, ddd.EventMetadata, error) {
+	return decodeMessage(b.registry, b.codecs, eventName, msg)
+}
 
-	msg.Ack()
+func decodeMessage(registry EventRegistry, codecs Codecs, eventName string, msg *message.Message) (ddd.DomainEvent, ddd.EventMetadata, error) {
+	meta := MetadataFromMessage(msg)
+	codec, err := codecs.Lookup(msg.Metadata.Get(MetadataContentType))
+	if err != nil {
+		return nil, meta, err
+	}
+	event, err := DecodeEvent(registry, codec, eventName, meta.SchemaVersion, msg.Payload)
+	if err != nil {
+		return nil, meta, fmt.Errorf("failed to decode event: %w", err)
+	}
+	return restoreEnvelope(event, meta), meta, nil
 }
@@ -0,0 +1,77 @@
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/soliton-go/framework/ddd"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type testEvent struct {
+	ddd.BaseDomainEvent
+	Value string `json:"value"`
+}
+
+func (testEvent) EventName() string { return "test.happened" }
+
+func newTestRegistry() *DefaultEventRegistry {
+	registry := NewEventRegistry()
+	registry.Register("test.happened", func() ddd.DomainEvent { return &testEvent{} })
+	return registry
+}
+
+// openTestDB opens a private in-memory sqlite database on a single connection, so that
+// concurrent pollers wait for each other instead of failing on sqlite's table locks.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestPublishSucceedsWhenOnlyRecordingFails(t *testing.T) {
+	// The event_records table is not migrated, so every Record fails.
+	recorder := NewEventRecorder(openTestDB(t))
+	logger := watermill.NewCaptureLogger()
+	bus := NewLocalEventBus(WithRegistry(newTestRegistry()), WithRecorder(recorder), WithLogger(logger))
+	defer bus.Close()
+
+	ctx := context.Background()
+	received := make(chan string, 1)
+	err := bus.Subscribe(ctx, "test.happened", func(ctx context.Context, e ddd.DomainEvent) error {
+		received <- e.(*testEvent).Value
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bus.Publish(ctx, testEvent{BaseDomainEvent: ddd.NewBaseDomainEvent(), Value: "hello"}); err != nil {
+		t.Fatalf("Publish = %v, want nil", err)
+	}
+	select {
+	case got := <-received:
+		if got != "hello" {
+			t.Errorf("received %q, want hello", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+
+	var logged bool
+	for _, m := range logger.Captured()[watermill.ErrorLogLevel] {
+		logged = logged || m.Msg == "Event published but not recorded"
+	}
+	if !logged {
+		t.Error("recording failure was not logged")
+	}
+}
@@ -0,0 +1,115 @@
+package eventsourcing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/soliton-go/framework/ddd"
+)
+
+// Repository loads and saves event-sourced aggregates through an EventStore.
+type Repository[T ddd.EventSourcedAggregate, ID ddd.ID] struct {
+	store         EventStore
+	factory       func() T
+	snapshotEvery int64
+}
+
+// RepositoryOption is a functional option for Repository.
+type RepositoryOption func(*repositoryOptions)
+
+type repositoryOptions struct {
+	snapshotEvery int64
+}
+
+// WithSnapshotEvery stores a snapshot each time a stream crosses a multiple of n events.
+// Loading then starts from the latest snapshot and only replays the events after it.
+func WithSnapshotEvery(n int64) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.snapshotEvery = n
+	}
+}
+
+// NewRepository creates a Repository. The factory must return a new, empty aggregate.
+func NewRepository[T ddd.EventSourcedAggregate, ID ddd.ID](store EventStore, factory func() T, opts ...RepositoryOption) *Repository[T, ID] {
+	var options repositoryOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &Repository[T, ID]{
+		store:         store,
+		factory:       factory,
+		snapshotEvery: options.snapshotEvery,
+	}
+}
+
+// StreamID returns the stream identifier of an aggregate, e.g. "payment-42".
+func StreamID(aggregateType string, id ddd.ID) string {
+	return aggregateType + "-" + id.String()
+}
+
+// Find rebuilds an aggregate from its latest snapshot and the events recorded after it.
+func (r *Repository[T, ID]) Find(ctx context.Context, id ID) (T, error) {
+	aggregate := r.factory()
+	streamID := StreamID(aggregate.AggregateType(), id)
+
+	version, found, err := r.store.LoadSnapshot(ctx, streamID, aggregate)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	events, err := r.store.Load(ctx, streamID, version)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if !found && len(events) == 0 {
+		var zero T
+		return zero, fmt.Errorf("%w: %s", ErrStreamNotFound, streamID)
+	}
+
+	for _, recorded := range events {
+		aggregate.Apply(recorded.Event)
+		version = recorded.Version
+	}
+	aggregate.SetVersion(version)
+	return aggregate, nil
+}
+
+// Save appends the aggregate's uncommitted events to its stream.
+// It fails with orm.ErrConcurrencyConflict if the stream moved since the aggregate was loaded;
+// in that case the uncommitted events are kept on the aggregate.
+func (r *Repository[T, ID]) Save(ctx context.Context, aggregate T) error {
+	events := aggregate.PullDomainEvents()
+	if len(events) == 0 {
+		return nil
+	}
+
+	streamID := StreamID(aggregate.AggregateType(), aggregate.GetID())
+	expected := aggregate.GetVersion()
+	stamped := make([]ddd.DomainEvent, len(events))
+	for i, e := range events {
+		version := expected + int64(i) + 1
+		stamped[i] = ddd.WithMetadata(e, func(meta *ddd.EventMetadata) {
+			meta.AggregateType = aggregate.AggregateType()
+			meta.AggregateID = aggregate.GetID().String()
+			meta.AggregateVersion = version
+		})
+	}
+	if err := r.store.Append(ctx, streamID, expected, stamped...); err != nil {
+		for _, e := range events {
+			aggregate.AddDomainEvent(e)
+		}
+		return err
+	}
+
+	version := expected + int64(len(events))
+	aggregate.SetVersion(version)
+
+	if r.snapshotEvery > 0 && version/r.snapshotEvery > expected/r.snapshotEvery {
+		// Snapshots are only an optimisation: the events are already committed,
+		// so a failed snapshot must not fail the save.
+		_ = r.store.SaveSnapshot(ctx, streamID, version, aggregate)
+	}
+	return nil
+}
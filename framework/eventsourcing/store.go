@@ -0,0 +1,244 @@
+package eventsourcing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/event"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AnyVersion disables the expected-version check on Append.
+const AnyVersion int64 = -1
+
+// ErrStreamNotFound is returned when a stream has neither events nor a snapshot.
+var ErrStreamNotFound = errors.New("event stream not found")
+
+// RecordedEvent is a domain event loaded back from a stream.
+type RecordedEvent struct {
+	StreamID   string
+	Version    int64
+	Event      ddd.DomainEvent
+	OccurredOn time.Time
+	RecordedAt time.Time
+}
+
+// EventStore persists and loads per-stream event histories.
+type EventStore interface {
+	// Append writes events to the end of a stream.
+	// It fails with orm.ErrConcurrencyConflict if the stream is not at expectedVersion.
+	Append(ctx context.Context, streamID string, expectedVersion int64, events ...ddd.DomainEvent) error
+	// Load returns the events of a stream with a version greater than afterVersion, in order.
+	Load(ctx context.Context, streamID string, afterVersion int64) ([]RecordedEvent, error)
+	// Version returns the current version of a stream, or 0 if it does not exist.
+	Version(ctx context.Context, streamID string) (int64, error)
+	// SaveSnapshot stores the state of a stream at the given version, replacing an older snapshot.
+	// A snapshot at the same or a newer version is kept.
+	SaveSnapshot(ctx context.Context, streamID string, version int64, state any) error
+	// LoadSnapshot decodes the latest snapshot into dest and returns its version.
+	LoadSnapshot(ctx context.Context, streamID string, dest any) (version int64, found bool, err error)
+}
+
+// StoredEvent is the database row for a single event in a stream.
+type StoredEvent struct {
+	ID        uint64 `gorm:"primaryKey;autoIncrement"`
+	StreamID  string `gorm:"size:255;not null;uniqueIndex:idx_event_store_stream_version"`
+	Version   int64  `gorm:"not null;uniqueIndex:idx_event_store_stream_version"`
+	EventName string `gorm:"size:255;not null;index"`
+	// SchemaVersion is the event's schema version when it was written; older events are upcast on load.
+	SchemaVersion int       `gorm:"not null;default:1"`
+	Payload       []byte    `gorm:"not null"`
+	OccurredOn    time.Time `gorm:"not null"`
+	RecordedAt    time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName overrides the GORM table name.
+func (StoredEvent) TableName() string {
+	return "event_store_events"
+}
+
+// StoredSnapshot is the database row for the latest snapshot of a stream.
+type StoredSnapshot struct {
+	StreamID  string    `gorm:"primaryKey;size:255"`
+	Version   int64     `gorm:"not null"`
+	Payload   []byte    `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName overrides the GORM table name.
+func (StoredSnapshot) TableName() string {
+	return "event_store_snapshots"
+}
+
+// Migrate creates the event store tables if they do not exist.
+func Migrate(db *gorm.DB) error {
+	return orm.Primary(db).AutoMigrate(&StoredEvent{}, &StoredSnapshot{})
+}
+
+// GormEventStore implements EventStore on top of GORM (sqlite, postgres or mysql).
+// It writes through the transaction carried by the context (see orm.Transaction), so events can
+// be appended together with outbox rows or other writes, and reads from the primary database so
+// that the version check never sees a lagging replica.
+type GormEventStore struct {
+	db       *gorm.DB
+	registry event.EventRegistry
+}
+
+// GormEventStoreOption is a functional option for GormEventStore.
+type GormEventStoreOption func(*GormEventStore)
+
+// WithRegistry sets the registry used to decode stored events.
+func WithRegistry(registry event.EventRegistry) GormEventStoreOption {
+	return func(s *GormEventStore) {
+		s.registry = registry
+	}
+}
+
+// NewGormEventStore creates a GormEventStore, typically on the *gorm.DB from orm.NewGormDB.
+func NewGormEventStore(db *gorm.DB, opts ...GormEventStoreOption) *GormEventStore {
+	store := &GormEventStore{
+		db:       db,
+		registry: event.GlobalRegistry(),
+	}
+	for _, opt := range opts {
+		opt(store)
+	}
+	return store
+}
+
+func (s *GormEventStore) Append(ctx context.Context, streamID string, expectedVersion int64, events ...ddd.DomainEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	err := orm.Transaction(ctx, s.db, func(ctx context.Context) error {
+		tx := orm.Conn(ctx, s.db)
+		current, err := streamVersion(tx, streamID)
+		if err != nil {
+			return err
+		}
+		if expectedVersion != AnyVersion && current != expectedVersion {
+			return conflictError(streamID, expectedVersion, current)
+		}
+
+		records := make([]StoredEvent, 0, len(events))
+		for i, e := range events {
+			schemaVersion := s.registry.SchemaVersion(e.EventName())
+			e = ddd.WithMetadata(e, func(meta *ddd.EventMetadata) {
+				meta.SchemaVersion = schemaVersion
+			})
+			payload, err := json.Marshal(e)
+			if err != nil {
+				return fmt.Errorf("failed to marshal event %s: %w", e.EventName(), err)
+			}
+			records = append(records, StoredEvent{
+				StreamID:      streamID,
+				Version:       current + int64(i) + 1,
+				EventName:     e.EventName(),
+				SchemaVersion: schemaVersion,
+				Payload:       payload,
+				OccurredOn:    e.OccurredOn(),
+			})
+		}
+		return tx.Create(&records).Error
+	})
+	if err == nil || errors.Is(err, orm.ErrConcurrencyConflict) || expectedVersion == AnyVersion {
+		return err
+	}
+
+	// A concurrent writer may have taken the same versions between the check and the insert,
+	// in which case the unique index rejected our rows.
+	if current, verr := s.Version(ctx, streamID); verr == nil && current != expectedVersion {
+		return conflictError(streamID, expectedVersion, current)
+	}
+	return err
+}
+
+func (s *GormEventStore) Load(ctx context.Context, streamID string, afterVersion int64) ([]RecordedEvent, error) {
+	var rows []StoredEvent
+	err := s.conn(ctx).
+		Where("stream_id = ? AND version > ?", streamID, afterVersion).
+		Order("version ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := make([]RecordedEvent, 0, len(rows))
+	for _, row := range rows {
+		e, err := s.registry.Decode(row.EventName, row.SchemaVersion, row.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s@%d: %w", streamID, row.Version, err)
+		}
+		recorded = append(recorded, RecordedEvent{
+			StreamID:   row.StreamID,
+			Version:    row.Version,
+			Event:      e,
+			OccurredOn: row.OccurredOn,
+			RecordedAt: row.RecordedAt,
+		})
+	}
+	return recorded, nil
+}
+
+func (s *GormEventStore) Version(ctx context.Context, streamID string) (int64, error) {
+	return streamVersion(s.conn(ctx), streamID)
+}
+
+func (s *GormEventStore) SaveSnapshot(ctx context.Context, streamID string, version int64, state any) error {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot for %s: %w", streamID, err)
+	}
+	db := orm.Conn(ctx, s.db)
+
+	// Replace only an older snapshot, so that a slow writer cannot roll a newer one back.
+	result := db.Model(&StoredSnapshot{}).
+		Where("stream_id = ? AND version < ?", streamID, version).
+		Updates(map[string]any{"version": version, "payload": payload})
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+	snapshot := StoredSnapshot{StreamID: streamID, Version: version, Payload: payload}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&snapshot).Error
+}
+
+func (s *GormEventStore) LoadSnapshot(ctx context.Context, streamID string, dest any) (int64, bool, error) {
+	var snapshot StoredSnapshot
+	result := s.conn(ctx).Where("stream_id = ?", streamID).Limit(1).Find(&snapshot)
+	if result.Error != nil {
+		return 0, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, false, nil
+	}
+	if err := json.Unmarshal(snapshot.Payload, dest); err != nil {
+		return 0, false, fmt.Errorf("failed to unmarshal snapshot for %s: %w", streamID, err)
+	}
+	return snapshot.Version, true, nil
+}
+
+// conn returns the transaction carried by ctx, or the primary database.
+func (s *GormEventStore) conn(ctx context.Context) *gorm.DB {
+	return orm.Conn(orm.ContextWithPrimary(ctx), s.db)
+}
+
+func streamVersion(db *gorm.DB, streamID string) (int64, error) {
+	var version int64
+	err := db.Model(&StoredEvent{}).
+		Where("stream_id = ?", streamID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error
+	return version, err
+}
+
+func conflictError(streamID string, expected, actual int64) error {
+	return fmt.Errorf("%w: stream %s is at version %d (expected %d)",
+		orm.ErrConcurrencyConflict, streamID, actual, expected)
+}
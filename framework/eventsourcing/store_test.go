@@ -0,0 +1,232 @@
+package eventsourcing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/event"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type accountID string
+
+func (id accountID) String() string { return string(id) }
+
+type accountOpened struct {
+	ddd.BaseDomainEvent
+	AccountID string `json:"account_id"`
+}
+
+func (accountOpened) EventName() string { return "account.opened" }
+
+type moneyDeposited struct {
+	ddd.BaseDomainEvent
+	Amount int `json:"amount"`
+}
+
+func (moneyDeposited) EventName() string { return "account.deposited" }
+
+// account is an event-sourced aggregate; Applied counts the events replayed into it.
+type account struct {
+	ddd.BaseEventSourcedAggregate
+	ID      accountID `json:"id"`
+	Balance int       `json:"balance"`
+	Applied int       `json:"-"`
+}
+
+func (a *account) GetID() ddd.ID         { return a.ID }
+func (a *account) AggregateType() string { return "account" }
+
+func (a *account) Apply(e ddd.DomainEvent) {
+	a.Applied++
+	switch e := e.(type) {
+	case *accountOpened:
+		a.ID = accountID(e.AccountID)
+	case accountOpened:
+		a.ID = accountID(e.AccountID)
+	case *moneyDeposited:
+		a.Balance += e.Amount
+	case moneyDeposited:
+		a.Balance += e.Amount
+	}
+}
+
+func opened(id string) accountOpened {
+	return accountOpened{BaseDomainEvent: ddd.NewBaseDomainEvent(), AccountID: id}
+}
+
+func deposited(amount int) moneyDeposited {
+	return moneyDeposited{BaseDomainEvent: ddd.NewBaseDomainEvent(), Amount: amount}
+}
+
+func newTestStore(t *testing.T) (*GormEventStore, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := event.NewEventRegistry()
+	registry.Register("account.opened", func() ddd.DomainEvent { return &accountOpened{} })
+	registry.Register("account.deposited", func() ddd.DomainEvent { return &moneyDeposited{} })
+	return NewGormEventStore(db, WithRegistry(registry)), db
+}
+
+func TestAppendAndLoad(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+
+	if err := store.Append(ctx, "account-1", 0, opened("1"), deposited(10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append(ctx, "account-1", 2, deposited(5)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append(ctx, "account-2", 0, opened("2")); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := store.Load(ctx, "account-1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("loaded %d events, want 3", len(events))
+	}
+	for i, recorded := range events {
+		if recorded.Version != int64(i+1) || recorded.StreamID != "account-1" {
+			t.Errorf("event %d is %s@%d", i, recorded.StreamID, recorded.Version)
+		}
+	}
+	if e, ok := events[2].Event.(*moneyDeposited); !ok || e.Amount != 5 {
+		t.Errorf("third event = %#v, want a deposit of 5", events[2].Event)
+	}
+
+	tail, err := store.Load(ctx, "account-1", 2)
+	if err != nil || len(tail) != 1 || tail[0].Version != 3 {
+		t.Errorf("Load after version 2 = %d events, %v; want version 3 only", len(tail), err)
+	}
+	if version, err := store.Version(ctx, "account-1"); err != nil || version != 3 {
+		t.Errorf("Version = %d, %v; want 3", version, err)
+	}
+	if version, err := store.Version(ctx, "account-3"); err != nil || version != 0 {
+		t.Errorf("Version of a missing stream = %d, %v; want 0", version, err)
+	}
+}
+
+func TestAppendRejectsUnexpectedVersion(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+
+	if err := store.Append(ctx, "account-1", 0, opened("1")); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []int64{0, 2} {
+		if err := store.Append(ctx, "account-1", expected, deposited(10)); !errors.Is(err, orm.ErrConcurrencyConflict) {
+			t.Errorf("Append at expected version %d = %v, want ErrConcurrencyConflict", expected, err)
+		}
+	}
+	if err := store.Append(ctx, "account-1", AnyVersion, deposited(10)); err != nil {
+		t.Errorf("Append at AnyVersion = %v", err)
+	}
+	if version, _ := store.Version(ctx, "account-1"); version != 2 {
+		t.Errorf("Version = %d, want 2", version)
+	}
+}
+
+func TestAppendJoinsTransaction(t *testing.T) {
+	store, db := newTestStore(t)
+	errRollback := errors.New("rollback")
+
+	err := orm.Transaction(context.Background(), db, func(ctx context.Context) error {
+		if err := store.Append(ctx, "account-1", 0, opened("1")); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("Transaction = %v, want %v", err, errRollback)
+	}
+	if version, _ := store.Version(context.Background(), "account-1"); version != 0 {
+		t.Errorf("Version after rollback = %d, want 0", version)
+	}
+}
+
+func TestSaveSnapshotKeepsNewerSnapshot(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+
+	for _, version := range []int64{2, 5, 3} {
+		if err := store.SaveSnapshot(ctx, "account-1", version, account{Balance: int(version)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var state account
+	version, found, err := store.LoadSnapshot(ctx, "account-1", &state)
+	if err != nil || !found {
+		t.Fatalf("LoadSnapshot = %v, found %t", err, found)
+	}
+	if version != 5 || state.Balance != 5 {
+		t.Errorf("snapshot at version %d with balance %d, want 5 and 5", version, state.Balance)
+	}
+}
+
+func TestRepositoryLoadsFromSnapshotAndTail(t *testing.T) {
+	store, _ := newTestStore(t)
+	repo := NewRepository[*account, accountID](store, func() *account { return &account{} }, WithSnapshotEvery(3))
+	ctx := context.Background()
+
+	a := &account{}
+	ddd.Raise(a, opened("1"))
+	ddd.Raise(a, deposited(10))
+	ddd.Raise(a, deposited(20))
+	if err := repo.Save(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	ddd.Raise(a, deposited(5))
+	if err := repo.Save(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+
+	if version, found, _ := store.LoadSnapshot(ctx, "account-1", &account{}); !found || version != 3 {
+		t.Fatalf("snapshot at version %d (found %t), want 3", version, found)
+	}
+	loaded, err := repo.Find(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Balance != 35 || loaded.GetVersion() != 4 {
+		t.Errorf("loaded balance %d at version %d, want 35 at version 4", loaded.Balance, loaded.GetVersion())
+	}
+	// Only the event after the snapshot is replayed.
+	if loaded.Applied != 1 {
+		t.Errorf("replayed %d events, want 1", loaded.Applied)
+	}
+
+	stale, err := repo.Find(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ddd.Raise(loaded, deposited(1))
+	if err := repo.Save(ctx, loaded); err != nil {
+		t.Fatal(err)
+	}
+	ddd.Raise(stale, deposited(2))
+	if err := repo.Save(ctx, stale); !errors.Is(err, orm.ErrConcurrencyConflict) {
+		t.Errorf("saving a stale aggregate = %v, want ErrConcurrencyConflict", err)
+	}
+
+	if _, err := repo.Find(ctx, "missing"); !errors.Is(err, ErrStreamNotFound) {
+		t.Errorf("Find of a missing stream = %v, want ErrStreamNotFound", err)
+	}
+}
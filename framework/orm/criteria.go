@@ -0,0 +1,246 @@
+package orm
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidCriteria is returned when a Criteria names a column the entity does not have,
+// sorts by a column that is not sortable, or uses an operator with the wrong kind of value.
+var ErrInvalidCriteria = errors.New("invalid criteria")
+
+// Operator compares a column with a value.
+type Operator string
+
+// Operators.
+const (
+	OpEq      Operator = "eq"
+	OpNe      Operator = "ne"
+	OpIn      Operator = "in"
+	OpGt      Operator = "gt"
+	OpGte     Operator = "gte"
+	OpLt      Operator = "lt"
+	OpLte     Operator = "lte"
+	OpLike    Operator = "like"
+	OpIsNull  Operator = "is_null"
+	OpNotNull Operator = "not_null"
+)
+
+// Condition filters on one column, or groups conditions with AND or OR.
+// Build conditions with Eq, In, Range, Like, IsNull, And, Or and the other constructors.
+type Condition struct {
+	Column   string
+	Operator Operator
+	Value    any
+
+	// Group makes the condition a group of sub-conditions, joined by OR if Or is set and by AND otherwise.
+	Group []Condition
+	Or    bool
+}
+
+// Eq matches rows where column equals value.
+func Eq(column string, value any) Condition {
+	return Condition{Column: column, Operator: OpEq, Value: value}
+}
+
+// Ne matches rows where column does not equal value.
+func Ne(column string, value any) Condition {
+	return Condition{Column: column, Operator: OpNe, Value: value}
+}
+
+// In matches rows where column equals one of values. An empty list matches nothing.
+func In[V any](column string, values ...V) Condition {
+	list := make([]any, len(values))
+	for i, v := range values {
+		list[i] = v
+	}
+	return Condition{Column: column, Operator: OpIn, Value: list}
+}
+
+// Gt matches rows where column is greater than value.
+func Gt(column string, value any) Condition {
+	return Condition{Column: column, Operator: OpGt, Value: value}
+}
+
+// Gte matches rows where column is greater than or equal to value.
+func Gte(column string, value any) Condition {
+	return Condition{Column: column, Operator: OpGte, Value: value}
+}
+
+// Lt matches rows where column is less than value.
+func Lt(column string, value any) Condition {
+	return Condition{Column: column, Operator: OpLt, Value: value}
+}
+
+// Lte matches rows where column is less than or equal to value.
+func Lte(column string, value any) Condition {
+	return Condition{Column: column, Operator: OpLte, Value: value}
+}
+
+// Range matches rows where column lies between from and to, both inclusive.
+// A nil bound (or nil pointer) leaves that end open.
+func Range(column string, from, to any) Condition {
+	var bounds []Condition
+	if !isNil(from) {
+		bounds = append(bounds, Gte(column, from))
+	}
+	if !isNil(to) {
+		bounds = append(bounds, Lte(column, to))
+	}
+	return And(bounds...)
+}
+
+// Like matches rows where column matches the SQL LIKE pattern, e.g. "gift%".
+// "!" escapes a wildcard, e.g. "100!%" matches "100%".
+func Like(column, pattern string) Condition {
+	return Condition{Column: column, Operator: OpLike, Value: pattern}
+}
+
+// Contains matches rows where column contains s. Wildcards in s are matched literally.
+func Contains(column, s string) Condition {
+	return Like(column, "%"+escapeLike(s)+"%")
+}
+
+// IsNull matches rows where column is NULL.
+func IsNull(column string) Condition {
+	return Condition{Column: column, Operator: OpIsNull}
+}
+
+// NotNull matches rows where column is not NULL.
+func NotNull(column string) Condition {
+	return Condition{Column: column, Operator: OpNotNull}
+}
+
+// And matches rows that match all conditions. An empty group matches every row.
+func And(conditions ...Condition) Condition {
+	return Condition{Group: conditions}
+}
+
+// Or matches rows that match any of the conditions. An empty group matches every row.
+func Or(conditions ...Condition) Condition {
+	return Condition{Group: conditions, Or: true}
+}
+
+// isGroup reports whether the condition is a group rather than a column filter.
+func (c Condition) isGroup() bool {
+	return c.Column == "" && c.Operator == ""
+}
+
+// Sort orders results by a column.
+type Sort struct {
+	Column string
+	Desc   bool
+}
+
+// Asc sorts by column in ascending order.
+func Asc(column string) Sort {
+	return Sort{Column: column}
+}
+
+// Desc sorts by column in descending order.
+func Desc(column string) Sort {
+	return Sort{Column: column, Desc: true}
+}
+
+// ParseSort builds a Sort from request parameters such as ?sort_by=created_at&sort_order=asc.
+// Any order other than "asc" sorts in descending order. The column is checked by the repository.
+func ParseSort(column, order string) Sort {
+	return Sort{
+		Column: strings.ToLower(strings.TrimSpace(column)),
+		Desc:   !strings.EqualFold(strings.TrimSpace(order), "asc"),
+	}
+}
+
+// Criteria describes which entities a query returns, in which order and which page of them.
+// It is a value: the builder methods return a modified copy.
+//
+//	criteria := orm.NewCriteria(
+//	    orm.Eq("user_id", userID),
+//	    orm.Or(orm.Eq("status", "paid"), orm.Gte("total", 100)),
+//	).OrderBy(orm.Desc("created_at")).Paginate(1, 20)
+type Criteria struct {
+	// Conditions are joined by AND.
+	Conditions []Condition
+	Sorts      []Sort
+	// Page starts at 1. A zero PageSize returns all matching rows.
+	Page     int
+	PageSize int
+
+	// Cursor, Limit and CountTotal are used by FindByCursor instead of Page and PageSize.
+	// An empty Cursor starts at the first row; a zero Limit returns all remaining rows.
+	Cursor     string
+	Limit      int
+	CountTotal bool
+}
+
+// NewCriteria creates a Criteria matching rows that match all conditions.
+func NewCriteria(conditions ...Condition) Criteria {
+	return Criteria{Conditions: conditions}
+}
+
+// Where adds conditions, joined with the existing ones by AND.
+func (c Criteria) Where(conditions ...Condition) Criteria {
+	c.Conditions = append(append([]Condition(nil), c.Conditions...), conditions...)
+	return c
+}
+
+// OrderBy adds sort columns after the existing ones.
+func (c Criteria) OrderBy(sorts ...Sort) Criteria {
+	c.Sorts = append(append([]Sort(nil), c.Sorts...), sorts...)
+	return c
+}
+
+// Paginate selects one page of pageSize rows; pages start at 1.
+func (c Criteria) Paginate(page, pageSize int) Criteria {
+	c.Page, c.PageSize = page, pageSize
+	return c
+}
+
+// After selects up to limit rows following cursor, the NextCursor of the previous CursorPage.
+func (c Criteria) After(cursor string, limit int) Criteria {
+	c.Cursor, c.Limit = cursor, limit
+	return c
+}
+
+// WithTotal makes FindByCursor count the matching rows across all pages.
+func (c Criteria) WithTotal() Criteria {
+	c.CountTotal = true
+	return c
+}
+
+// Offset returns the number of rows before the selected page.
+func (c Criteria) Offset() int {
+	if c.Page < 1 || c.PageSize < 1 {
+		return 0
+	}
+	return (c.Page - 1) * c.PageSize
+}
+
+// TotalPages returns how many pages of PageSize rows hold total rows.
+func (c Criteria) TotalPages(total int64) int {
+	if c.PageSize < 1 {
+		return min(int(total), 1)
+	}
+	return int((total + int64(c.PageSize) - 1) / int64(c.PageSize))
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// likeEscape is the escape character of Contains patterns. A backslash would need different
+// quoting in MySQL and PostgreSQL string literals.
+const likeEscape = "!"
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_").Replace(s)
+}
@@ -0,0 +1,180 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// CursorPage is one page of entities returned by FindByCursor.
+type CursorPage[T any] struct {
+	Items []T
+	// NextCursor continues after the last item; it is empty on the last page.
+	NextCursor string
+	// Total is the number of matching entities across all pages, set only if Criteria.CountTotal is.
+	Total *int64
+}
+
+// cursor is the decoded form of an opaque cursor: the sort columns it was issued for, with a "-"
+// prefix for descending ones, and the values of the last entity of the page in those columns,
+// with JSON null for NULL.
+type cursor struct {
+	Keys   []string          `json:"k"`
+	Values []json.RawMessage `json:"v"`
+}
+
+// cursorKeys describes sorts the way a cursor records them.
+func cursorKeys(sorts []Sort) []string {
+	keys := make([]string, len(sorts))
+	for i, sort := range sorts {
+		keys[i] = sort.Column
+		if sort.Desc {
+			keys[i] = "-" + sort.Column
+		}
+	}
+	return keys
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// nullableSorts reports which sort columns may hold NULL: those of pointer or driver.Valuer
+// fields, such as *time.Time or sql.NullString, unless they are declared NOT NULL.
+func nullableSorts(s *schema.Schema, sorts []Sort) []bool {
+	nullable := make([]bool, len(sorts))
+	for i, sort := range sorts {
+		field := s.FieldsByDBName[sort.Column]
+		nullable[i] = !field.NotNull && !field.PrimaryKey &&
+			(field.FieldType.Kind() == reflect.Pointer || field.FieldType.Implements(valuerType))
+	}
+	return nullable
+}
+
+// encodeCursor returns the cursor that continues after entity in the order of sorts.
+func encodeCursor(ctx context.Context, s *schema.Schema, sorts []Sort, entity any) (string, error) {
+	c := cursor{Keys: cursorKeys(sorts), Values: make([]json.RawMessage, len(sorts))}
+	for i, sort := range sorts {
+		value, _ := s.FieldsByDBName[sort.Column].ValueOf(ctx, reflect.ValueOf(entity))
+		if isNull(value) {
+			c.Values[i] = json.RawMessage("null")
+			continue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode cursor value of %s: %w", sort.Column, err)
+		}
+		c.Values[i] = raw
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// isNull reports whether a field value is stored as NULL.
+func isNull(value any) bool {
+	if valuer, ok := value.(driver.Valuer); ok && !isNil(value) {
+		v, err := valuer.Value()
+		return err == nil && v == nil
+	}
+	return isNil(value)
+}
+
+// decodeCursor returns the values recorded in token, converted to the Go types of their columns,
+// with nil for NULL. The cursor must have been issued for the same sorts.
+func decodeCursor(s *schema.Schema, sorts []Sort, nullable []bool, token string) ([]any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidCriteria)
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || len(c.Values) != len(c.Keys) {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidCriteria)
+	}
+	if !reflect.DeepEqual(c.Keys, cursorKeys(sorts)) {
+		return nil, fmt.Errorf("%w: cursor was issued for a different sort order", ErrInvalidCriteria)
+	}
+
+	values := make([]any, len(sorts))
+	for i, sort := range sorts {
+		if string(c.Values[i]) == "null" {
+			if !nullable[i] {
+				return nil, fmt.Errorf("%w: malformed cursor value for %s", ErrInvalidCriteria, sort.Column)
+			}
+			continue
+		}
+		ptr := reflect.New(s.FieldsByDBName[sort.Column].FieldType)
+		if err := json.Unmarshal(c.Values[i], ptr.Interface()); err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor value for %s", ErrInvalidCriteria, sort.Column)
+		}
+		if values[i] = ptr.Elem().Interface(); isNull(values[i]) {
+			values[i] = nil
+		}
+	}
+	return values, nil
+}
+
+// keysetOrder orders by sorts with NULL below every other value, as seekCondition expects:
+// first in ascending order and last in descending order, whatever the database's default.
+func keysetOrder(sorts []Sort, nullable []bool) clause.OrderBy {
+	terms := make([]string, 0, 2*len(sorts))
+	vars := make([]any, 0, 2*len(sorts))
+	for i, sort := range sorts {
+		column := clause.Column{Name: sort.Column}
+		direction := "ASC"
+		if sort.Desc {
+			direction = "DESC"
+		}
+		if nullable[i] {
+			// "column IS NULL" is 1 (true) for NULL, so it sorts in the opposite direction.
+			nulls := "DESC"
+			if sort.Desc {
+				nulls = "ASC"
+			}
+			terms, vars = append(terms, "? IS NULL "+nulls), append(vars, column)
+		}
+		terms, vars = append(terms, "? "+direction), append(vars, column)
+	}
+	return clause.OrderBy{Expression: clause.Expr{SQL: strings.Join(terms, ", "), Vars: vars}}
+}
+
+// seekCondition matches the rows after values in the order of sorts:
+// (a > va) OR (a = va AND b > vb) OR ..., with < for descending columns. NULL sorts below every
+// other value (see keysetOrder), so equality with NULL is IS NULL, every value is after NULL in
+// ascending order, and NULL is after every value in descending order.
+func seekCondition(sorts []Sort, nullable []bool, values []any) Condition {
+	branches := make([]Condition, 0, len(sorts))
+	for i, sort := range sorts {
+		terms := make([]Condition, 0, i+1)
+		for j := range i {
+			if values[j] == nil {
+				terms = append(terms, IsNull(sorts[j].Column))
+			} else {
+				terms = append(terms, Eq(sorts[j].Column, values[j]))
+			}
+		}
+		switch {
+		case values[i] == nil && sort.Desc:
+			// Nothing comes after NULL. The primary key, the last sort, is never NULL, so at
+			// least one branch remains.
+			continue
+		case values[i] == nil:
+			terms = append(terms, NotNull(sort.Column))
+		case sort.Desc && nullable[i]:
+			terms = append(terms, Or(Lt(sort.Column, values[i]), IsNull(sort.Column)))
+		case sort.Desc:
+			terms = append(terms, Lt(sort.Column, values[i]))
+		default:
+			terms = append(terms, Gt(sort.Column, values[i]))
+		}
+		branches = append(branches, And(terms...))
+	}
+	return Or(branches...)
+}
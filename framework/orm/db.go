@@ -2,6 +2,7 @@ package orm
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/soliton-go/framework/core/config"
 	"go.uber.org/zap"
@@ -9,35 +10,161 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
+// DatabaseConfig configures the database connection (config key database).
+type DatabaseConfig struct {
+	// Driver is one of sqlite (default), mysql or postgres.
+	Driver string `mapstructure:"driver"`
+	DSN    string `mapstructure:"dsn"`
+	// Replicas are DSNs of read replicas of the same driver. Queries outside a transaction go to
+	// a random replica; writes, transactions and reads forced with ContextWithPrimary go to DSN.
+	Replicas []string `mapstructure:"replicas"`
+
+	Pool    PoolConfig    `mapstructure:"pool"`
+	Connect ConnectConfig `mapstructure:"connect"`
+}
+
+// PoolConfig sizes the connection pools of the primary and of every replica. Zero values keep
+// the database/sql defaults.
+type PoolConfig struct {
+	MaxOpenConns    int           `mapstructure:"max_open_conns"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+}
+
+// ConnectConfig retries the initial connection to the primary, e.g. while its container starts.
+type ConnectConfig struct {
+	// Retries is the number of attempts after the first one fails.
+	Retries int `mapstructure:"retries"`
+	// Backoff is the delay before the first retry; it doubles on every retry up to MaxBackoff.
+	Backoff    time.Duration `mapstructure:"backoff"`
+	MaxBackoff time.Duration `mapstructure:"max_backoff"`
+}
+
+// DefaultDatabaseConfig returns the configuration used for unset keys.
+func DefaultDatabaseConfig() DatabaseConfig {
+	return DatabaseConfig{
+		Connect: ConnectConfig{
+			Backoff:    time.Second,
+			MaxBackoff: 30 * time.Second,
+		},
+	}
+}
+
+// LoadDatabaseConfig reads database from cfg on top of DefaultDatabaseConfig.
+func LoadDatabaseConfig(cfg *config.Config) (DatabaseConfig, error) {
+	dc := DefaultDatabaseConfig()
+	if err := cfg.UnmarshalKey("database", &dc); err != nil {
+		return dc, fmt.Errorf("invalid database config: %w", err)
+	}
+	// Read separately so that DATABASE_DRIVER and DATABASE_DSN override the file.
+	dc.Driver = cfg.GetString("database.driver")
+	dc.DSN = cfg.GetString("database.dsn")
+	return dc, nil
+}
+
 // NewGormDB creates a new GORM database connection.
 func NewGormDB(cfg *config.Config, logger *zap.Logger) (*gorm.DB, error) {
-	driver := cfg.GetString("database.driver")
-	dsn := cfg.GetString("database.dsn")
+	dc, err := LoadDatabaseConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return OpenDatabase(dc, logger)
+}
+
+// OpenDatabase connects to the database described by dc, retrying as configured in dc.Connect.
+func OpenDatabase(dc DatabaseConfig, logger *zap.Logger) (*gorm.DB, error) {
+	if dc.Driver == "" {
+		// Default fallback to sqlite in memory if not configured
+		logger.Info("No database driver specified, defaulting to sqlite in-memory")
+		dc.Driver, dc.DSN = "sqlite", "file::memory:?cache=shared"
+	}
+	dialector, err := openDialector(dc.Driver, dc.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	backoff := dc.Connect.Backoff
+	var db *gorm.DB
+	for attempt := 0; ; attempt++ {
+		db, err = gorm.Open(dialector, &gorm.Config{})
+		if err == nil {
+			break
+		}
+		if attempt >= dc.Connect.Retries {
+			return nil, fmt.Errorf("failed to connect to database: %w", err)
+		}
+		logger.Warn("Failed to connect to database, retrying",
+			zap.Error(err), zap.Int("attempt", attempt+1), zap.Duration("backoff", backoff))
+		time.Sleep(backoff)
+		backoff = min(backoff*2, dc.Connect.MaxBackoff)
+	}
 
-	var dialector gorm.Dialector
+	if len(dc.Replicas) == 0 {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		if dc.Pool.MaxOpenConns > 0 {
+			sqlDB.SetMaxOpenConns(dc.Pool.MaxOpenConns)
+		}
+		if dc.Pool.MaxIdleConns > 0 {
+			sqlDB.SetMaxIdleConns(dc.Pool.MaxIdleConns)
+		}
+		if dc.Pool.ConnMaxLifetime > 0 {
+			sqlDB.SetConnMaxLifetime(dc.Pool.ConnMaxLifetime)
+		}
+		if dc.Pool.ConnMaxIdleTime > 0 {
+			sqlDB.SetConnMaxIdleTime(dc.Pool.ConnMaxIdleTime)
+		}
+		return db, nil
+	}
+
+	replicas := make([]gorm.Dialector, len(dc.Replicas))
+	for i, dsn := range dc.Replicas {
+		if replicas[i], err = openDialector(dc.Driver, dsn); err != nil {
+			return nil, err
+		}
+	}
+	// The resolver sizes the pools of the primary and the replicas alike.
+	resolver := dbresolver.Register(dbresolver.Config{Replicas: replicas, Policy: dbresolver.RandomPolicy{}})
+	if dc.Pool.MaxOpenConns > 0 {
+		resolver.SetMaxOpenConns(dc.Pool.MaxOpenConns)
+	}
+	if dc.Pool.MaxIdleConns > 0 {
+		resolver.SetMaxIdleConns(dc.Pool.MaxIdleConns)
+	}
+	if dc.Pool.ConnMaxLifetime > 0 {
+		resolver.SetConnMaxLifetime(dc.Pool.ConnMaxLifetime)
+	}
+	if dc.Pool.ConnMaxIdleTime > 0 {
+		resolver.SetConnMaxIdleTime(dc.Pool.ConnMaxIdleTime)
+	}
+	if err := db.Use(resolver); err != nil {
+		return nil, fmt.Errorf("failed to connect to database replicas: %w", err)
+	}
+	return db, nil
+}
+
+func openDialector(driver, dsn string) (gorm.Dialector, error) {
 	switch driver {
 	case "mysql":
-		dialector = mysql.Open(dsn)
+		return mysql.Open(dsn), nil
 	case "postgres":
-		dialector = postgres.Open(dsn)
+		return postgres.Open(dsn), nil
 	case "sqlite":
-		dialector = sqlite.Open(dsn)
+		return sqlite.Open(dsn), nil
 	default:
-		// Default fallback to sqlite in memory if not configured, or error out
-		if driver == "" {
-			logger.Info("No database driver specified, defaulting to sqlite in-memory")
-			dialector = sqlite.Open("file::memory:?cache=shared")
-		} else {
-			return nil, fmt.Errorf("unsupported database driver: %s", driver)
-		}
-	}
-
-	db, err := gorm.Open(dialector, &gorm.Config{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, fmt.Errorf("unsupported database driver: %s", driver)
 	}
+}
 
-	return db, nil
+// Primary returns db with its queries sent to the primary database, for reads that must not lag
+// behind writes, such as polling a table other instances update, and for migrations, whose
+// schema checks must see the primary. It has no effect without replicas.
+func Primary(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Write).Session(&gorm.Session{})
 }
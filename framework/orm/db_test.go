@@ -0,0 +1,196 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soliton-go/framework/core/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestLoadDatabaseConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	data := `database:
+  driver: postgres
+  dsn: host=primary
+  replicas:
+    - host=replica-1
+    - host=replica-2
+  pool:
+    max_open_conns: 20
+    max_idle_conns: 5
+    conn_max_lifetime: 30m
+    conn_max_idle_time: 5m
+  connect:
+    retries: 3
+    backoff: 500ms
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DATABASE_DSN", "host=from-env")
+
+	cfg, err := config.NewConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc, err := LoadDatabaseConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := DatabaseConfig{
+		Driver:   "postgres",
+		DSN:      "host=from-env",
+		Replicas: []string{"host=replica-1", "host=replica-2"},
+		Pool:     PoolConfig{MaxOpenConns: 20, MaxIdleConns: 5, ConnMaxLifetime: 30 * time.Minute, ConnMaxIdleTime: 5 * time.Minute},
+		// MaxBackoff is not set in the file and keeps its default.
+		Connect: ConnectConfig{Retries: 3, Backoff: 500 * time.Millisecond, MaxBackoff: 30 * time.Second},
+	}
+	if fmt.Sprintf("%+v", dc) != fmt.Sprintf("%+v", want) {
+		t.Errorf("config = %+v\nwant %+v", dc, want)
+	}
+}
+
+func TestOpenDatabaseRetriesConnect(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	dc := DatabaseConfig{
+		Driver:  "sqlite",
+		DSN:     filepath.Join(t.TempDir(), "missing", "app.db"),
+		Connect: ConnectConfig{Retries: 2, Backoff: time.Millisecond, MaxBackoff: time.Millisecond},
+	}
+
+	if _, err := OpenDatabase(dc, zap.New(core)); err == nil {
+		t.Fatal("OpenDatabase of an unreachable database succeeded")
+	}
+	if n := logs.FilterMessage("Failed to connect to database, retrying").Len(); n != 2 {
+		t.Errorf("logged %d retries, want 2", n)
+	}
+
+	if _, err := OpenDatabase(DatabaseConfig{Driver: "oracle"}, zap.NewNop()); err == nil {
+		t.Error("OpenDatabase with an unsupported driver succeeded")
+	}
+}
+
+func TestOpenDatabaseSizesPool(t *testing.T) {
+	db, err := OpenDatabase(DatabaseConfig{
+		Driver: "sqlite",
+		DSN:    filepath.Join(t.TempDir(), "app.db"),
+		Pool:   PoolConfig{MaxOpenConns: 7},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if n := sqlDB.Stats().MaxOpenConnections; n != 7 {
+		t.Errorf("MaxOpenConnections = %d, want 7", n)
+	}
+}
+
+// openReplicatedDB opens a primary and a replica that are separate sqlite files, each holding one
+// item named after the database, so that the item read shows which database served a query.
+func openReplicatedDB(t *testing.T) (db, primary *gorm.DB) {
+	t.Helper()
+	dir := t.TempDir()
+	paths := map[string]string{"primary": filepath.Join(dir, "primary.db"), "replica": filepath.Join(dir, "replica.db")}
+	for name, path := range paths {
+		direct, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqlDB, _ := direct.DB()
+		t.Cleanup(func() { sqlDB.Close() })
+		if err := direct.AutoMigrate(&testItem{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := direct.Create(&testItem{ID: testItemID(name)}).Error; err != nil {
+			t.Fatal(err)
+		}
+		if name == "primary" {
+			primary = direct
+		}
+	}
+
+	db, err := OpenDatabase(DatabaseConfig{
+		Driver:   "sqlite",
+		DSN:      paths["primary"],
+		Replicas: []string{paths["replica"]},
+		Pool:     PoolConfig{MaxOpenConns: 2},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return db, primary
+}
+
+// servedBy returns the name of the database db reads from.
+func servedBy(t *testing.T, db *gorm.DB) string {
+	t.Helper()
+	var items []*testItem
+	if err := db.Order("id").Find(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprint(itemIDs(items))
+}
+
+func TestPrimaryAndConnRouting(t *testing.T) {
+	db, primary := openReplicatedDB(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		db   *gorm.DB
+		want string
+	}{
+		{"plain read", db, "[replica]"},
+		{"Primary", Primary(db), "[primary]"},
+		{"Conn", Conn(ctx, db), "[replica]"},
+		{"Conn with ContextWithPrimary", Conn(ContextWithPrimary(ctx), db), "[primary]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := servedBy(t, tt.db); got != tt.want {
+				t.Errorf("read %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	err := Transaction(ctx, db, func(ctx context.Context) error {
+		if err := Conn(ctx, db).Create(&testItem{ID: "written"}).Error; err != nil {
+			return err
+		}
+		// The transaction sees its own write, so it runs on the primary.
+		if got := servedBy(t, Conn(ctx, db)); got != "[primary written]" {
+			t.Errorf("read in a transaction %s, want [primary written]", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&testItem{ID: "unscoped"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := Primary(db).Create(&testItem{ID: "forced"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if got := servedBy(t, primary); got != "[forced primary unscoped written]" {
+		t.Errorf("primary holds %s, want every write", got)
+	}
+	if got := servedBy(t, db); got != "[replica]" {
+		t.Errorf("replica holds %s, want no writes", got)
+	}
+}
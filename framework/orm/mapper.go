@@ -20,6 +20,7 @@ type SQLMapper[T any] interface {
 }
 
 // GormMapper is the GORM-based implementation of SQLMapper.
+// Statements run in the transaction carried by the context, if any (see Conn).
 type GormMapper[T any] struct {
 	db *gorm.DB
 }
@@ -31,7 +32,7 @@ func NewGormMapper[T any](db *gorm.DB) *GormMapper[T] {
 
 func (m *GormMapper[T]) SelectOne(ctx context.Context, sql string, args ...interface{}) (*T, error) {
 	var entity T
-	err := m.db.WithContext(ctx).Raw(sql, args...).Scan(&entity).Error
+	err := Conn(ctx, m.db).Raw(sql, args...).Scan(&entity).Error
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +41,7 @@ func (m *GormMapper[T]) SelectOne(ctx context.Context, sql string, args ...inter
 
 func (m *GormMapper[T]) SelectList(ctx context.Context, sql string, args ...interface{}) ([]*T, error) {
 	var entities []*T
-	err := m.db.WithContext(ctx).Raw(sql, args...).Scan(&entities).Error
+	err := Conn(ctx, m.db).Raw(sql, args...).Scan(&entities).Error
 	if err != nil {
 		return nil, err
 	}
@@ -48,11 +49,11 @@ func (m *GormMapper[T]) SelectList(ctx context.Context, sql string, args ...inte
 }
 
 func (m *GormMapper[T]) Exec(ctx context.Context, sql string, args ...interface{}) error {
-	return m.db.WithContext(ctx).Exec(sql, args...).Error
+	return Conn(ctx, m.db).Exec(sql, args...).Error
 }
 
 func (m *GormMapper[T]) Count(ctx context.Context, sql string, args ...interface{}) (int64, error) {
 	var count int64
-	err := m.db.WithContext(ctx).Raw(sql, args...).Scan(&count).Error
+	err := Conn(ctx, m.db).Raw(sql, args...).Scan(&count).Error
 	return count, err
 }
@@ -5,11 +5,18 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sync"
 
 	"github.com/soliton-go/framework/ddd"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
+// ErrConcurrencyConflict is returned by Save when a versioned aggregate was modified
+// by someone else since it was loaded.
+var ErrConcurrencyConflict = errors.New("concurrency conflict")
+
 // Repository is a generic interface for repositories.
 type Repository[T ddd.Entity, ID ddd.ID] interface {
 	Find(ctx context.Context, id ID) (T, error)
@@ -18,14 +25,55 @@ type Repository[T ddd.Entity, ID ddd.ID] interface {
 	Delete(ctx context.Context, id ID) error
 }
 
+// CriteriaRepository finds entities by Criteria. GormRepository implements it.
+type CriteriaRepository[T ddd.Entity] interface {
+	FindByCriteria(ctx context.Context, criteria Criteria) ([]T, int64, error)
+}
+
+// CursorRepository finds entities by Criteria one cursor page at a time. GormRepository implements it.
+type CursorRepository[T ddd.Entity] interface {
+	FindByCursor(ctx context.Context, criteria Criteria) (CursorPage[T], error)
+}
+
 // GormRepository is a generic implementation of Repository using GORM.
 type GormRepository[T ddd.Entity, ID ddd.ID] struct {
-	db *gorm.DB
+	db       *gorm.DB
+	sortable map[string]struct{}
+
+	schemaOnce sync.Once
+	schema     *schema.Schema
+	schemaErr  error
+}
+
+// RepositoryOption is a functional option for GormRepository.
+type RepositoryOption func(*repositoryOptions)
+
+type repositoryOptions struct {
+	sortable []string
+}
+
+// WithSortable limits the columns FindByCriteria sorts by, e.g. to indexed columns.
+// Without it, every column of the entity can be sorted by.
+func WithSortable(columns ...string) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.sortable = append(o.sortable, columns...)
+	}
 }
 
 // NewGormRepository creates a new GormRepository.
-func NewGormRepository[T ddd.Entity, ID ddd.ID](db *gorm.DB) *GormRepository[T, ID] {
-	return &GormRepository[T, ID]{db: db}
+func NewGormRepository[T ddd.Entity, ID ddd.ID](db *gorm.DB, opts ...RepositoryOption) *GormRepository[T, ID] {
+	var options repositoryOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	r := &GormRepository[T, ID]{db: db}
+	if options.sortable != nil {
+		r.sortable = make(map[string]struct{}, len(options.sortable))
+		for _, column := range options.sortable {
+			r.sortable[column] = struct{}{}
+		}
+	}
+	return r
 }
 
 func (r *GormRepository[T, ID]) Find(ctx context.Context, id ID) (T, error) {
@@ -42,7 +90,7 @@ func (r *GormRepository[T, ID]) Find(ctx context.Context, id ID) (T, error) {
 		entity = typed
 		dest = entity
 	}
-	result := r.db.WithContext(ctx).First(dest, "id = ?", id.String())
+	result := Conn(ctx, r.db).First(dest, "id = ?", id.String())
 	if result.Error != nil {
 		var zero T
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -55,25 +103,307 @@ func (r *GormRepository[T, ID]) Find(ctx context.Context, id ID) (T, error) {
 
 func (r *GormRepository[T, ID]) FindAll(ctx context.Context) ([]T, error) {
 	var entities []T
-	result := r.db.WithContext(ctx).Find(&entities)
+	result := Conn(ctx, r.db).Find(&entities)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return entities, nil
 }
 
+// FindByCriteria returns the entities matching criteria, in its order and page, and the number of
+// entities matching it across all pages. Columns are checked against the entity's schema and
+// quoted rather than interpolated, so criteria may be built from request parameters. Paginated
+// results are ordered by the primary key after the criteria's sorts, so pages do not overlap.
+// It fails with ErrInvalidCriteria for unknown or unsortable columns.
+func (r *GormRepository[T, ID]) FindByCriteria(ctx context.Context, criteria Criteria) ([]T, int64, error) {
+	where, sorts, err := r.compile(criteria, criteria.PageSize > 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	db := Conn(ctx, r.db)
+
+	var total int64
+	if err := db.Model(r.newModel()).Clauses(where...).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entities []T
+	query := db.Clauses(where...)
+	if len(sorts) > 0 {
+		query = query.Clauses(orderByClause(sorts))
+	}
+	if criteria.PageSize > 0 {
+		query = query.Offset(criteria.Offset()).Limit(criteria.PageSize)
+	}
+	if err := query.Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
+// FindByCursor returns the entities matching criteria that follow criteria.Cursor in the
+// criteria's order, at most criteria.Limit of them, and the cursor of the next page. Unlike
+// FindByCriteria it seeks past the previous page on the sort columns instead of skipping rows
+// with OFFSET, and only counts the matching entities if criteria.CountTotal is set, so its cost
+// does not grow with the page number. The primary key is always the last sort column, and NULL
+// sorts before other values in ascending order and after them in descending order, on every
+// database. Page and PageSize are ignored. It fails with ErrInvalidCriteria for unknown or unsortable
+// columns, and for malformed cursors or cursors issued for a different order.
+func (r *GormRepository[T, ID]) FindByCursor(ctx context.Context, criteria Criteria) (CursorPage[T], error) {
+	where, sorts, err := r.compile(criteria, true)
+	if err != nil {
+		return CursorPage[T]{}, err
+	}
+	s, err := r.entitySchema()
+	if err != nil {
+		return CursorPage[T]{}, err
+	}
+	db := Conn(ctx, r.db)
+
+	var page CursorPage[T]
+	if criteria.CountTotal {
+		var total int64
+		if err := db.Model(r.newModel()).Clauses(where...).Count(&total).Error; err != nil {
+			return CursorPage[T]{}, err
+		}
+		page.Total = &total
+	}
+
+	nullable := nullableSorts(s, sorts)
+	query := db.Clauses(where...)
+	if criteria.Cursor != "" {
+		values, err := decodeCursor(s, sorts, nullable, criteria.Cursor)
+		if err != nil {
+			return CursorPage[T]{}, err
+		}
+		expr, err := compileCondition(s, seekCondition(sorts, nullable, values))
+		if err != nil {
+			return CursorPage[T]{}, err
+		}
+		query = query.Clauses(clause.Where{Exprs: []clause.Expression{expr}})
+	}
+	query = query.Clauses(keysetOrder(sorts, nullable))
+	if criteria.Limit > 0 {
+		// One extra row tells whether there is a next page.
+		query = query.Limit(criteria.Limit + 1)
+	}
+	if err := query.Find(&page.Items).Error; err != nil {
+		return CursorPage[T]{}, err
+	}
+
+	if criteria.Limit > 0 && len(page.Items) > criteria.Limit {
+		page.Items = page.Items[:criteria.Limit]
+		page.NextCursor, err = encodeCursor(ctx, s, sorts, page.Items[criteria.Limit-1])
+		if err != nil {
+			return CursorPage[T]{}, err
+		}
+	}
+	return page, nil
+}
+
 func (r *GormRepository[T, ID]) Save(ctx context.Context, entity T) error {
-	// If it's an AggregateRoot, we should dispatch events here.
-	// But first, let's just save.
-	return r.db.WithContext(ctx).Save(entity).Error
+	// Domain events are left on the aggregate; wrap the repository in
+	// event.PublishingRepository to publish them after a successful save.
+	if versioned, ok := any(entity).(ddd.Versioned); ok {
+		return r.saveVersioned(ctx, entity, versioned)
+	}
+	return Conn(ctx, r.db).Save(entity).Error
+}
+
+// saveVersioned persists a versioned entity with a compare-and-swap on the version column.
+// The version is only incremented on the entity when the write succeeds.
+func (r *GormRepository[T, ID]) saveVersioned(ctx context.Context, entity T, versioned ddd.Versioned) error {
+	db := Conn(ctx, r.db)
+	current := versioned.GetVersion()
+	versioned.SetVersion(current + 1)
+
+	result := db.Model(entity).Where("version = ?", current).Select("*").Updates(entity)
+	if result.Error != nil {
+		versioned.SetVersion(current)
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	// Nothing matched: either the entity is new or another writer got there first.
+	var count int64
+	if err := db.Model(r.newModel()).Where("id = ?", entity.GetID().String()).Count(&count).Error; err != nil {
+		versioned.SetVersion(current)
+		return err
+	}
+	if count > 0 || current != 0 {
+		versioned.SetVersion(current)
+		return fmt.Errorf("%w: %T %s was modified concurrently (expected version %d)",
+			ErrConcurrencyConflict, entity, entity.GetID().String(), current)
+	}
+	if err := db.Create(entity).Error; err != nil {
+		versioned.SetVersion(current)
+		return err
+	}
+	return nil
 }
 
 func (r *GormRepository[T, ID]) Delete(ctx context.Context, id ID) error {
+	return Conn(ctx, r.db).Delete(r.newModel(), "id = ?", id.String()).Error
+}
+
+// newModel returns a pointer to a zero value of the entity type, suitable for Model/Delete.
+func (r *GormRepository[T, ID]) newModel() any {
 	var entity T
-	model := any(&entity)
 	entityType := reflect.TypeOf(entity)
 	if entityType != nil && entityType.Kind() == reflect.Ptr {
-		model = reflect.New(entityType.Elem()).Interface()
+		return reflect.New(entityType.Elem()).Interface()
+	}
+	return &entity
+}
+
+// entitySchema returns the parsed GORM schema of the entity type.
+func (r *GormRepository[T, ID]) entitySchema() (*schema.Schema, error) {
+	r.schemaOnce.Do(func() {
+		stmt := &gorm.Statement{DB: r.db}
+		r.schemaErr = stmt.Parse(r.newModel())
+		r.schema = stmt.Schema
+	})
+	return r.schema, r.schemaErr
+}
+
+// compile turns the conditions of criteria into GORM clauses and checks its sorts. With tiebreak,
+// the primary key is appended to the sorts unless they already include it, so the order is total.
+func (r *GormRepository[T, ID]) compile(criteria Criteria, tiebreak bool) ([]clause.Expression, []Sort, error) {
+	s, err := r.entitySchema()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var where []clause.Expression
+	if expr, err := compileCondition(s, And(criteria.Conditions...)); err != nil {
+		return nil, nil, err
+	} else if expr != nil {
+		where = append(where, clause.Where{Exprs: []clause.Expression{expr}})
+	}
+
+	sorts := make([]Sort, 0, len(criteria.Sorts)+1)
+	sortedByKey := false
+	for _, sort := range criteria.Sorts {
+		if _, err := lookupColumn(s, sort.Column); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := r.sortable[sort.Column]; r.sortable != nil && !ok {
+			return nil, nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidCriteria, sort.Column)
+		}
+		sorts = append(sorts, sort)
+		sortedByKey = sortedByKey || (s.PrioritizedPrimaryField != nil && sort.Column == s.PrioritizedPrimaryField.DBName)
+	}
+	if tiebreak && !sortedByKey && s.PrioritizedPrimaryField != nil {
+		sorts = append(sorts, Asc(s.PrioritizedPrimaryField.DBName))
+	}
+	return where, sorts, nil
+}
+
+// orderByClause turns checked sorts into an ORDER BY clause.
+func orderByClause(sorts []Sort) clause.OrderBy {
+	var orderBy clause.OrderBy
+	for _, sort := range sorts {
+		orderBy.Columns = append(orderBy.Columns, clause.OrderByColumn{Column: clause.Column{Name: sort.Column}, Desc: sort.Desc})
+	}
+	return orderBy
+}
+
+// compileCondition turns a condition into a GORM expression; an empty group yields nil.
+func compileCondition(s *schema.Schema, c Condition) (clause.Expression, error) {
+	if c.isGroup() {
+		exprs := make([]clause.Expression, 0, len(c.Group))
+		for _, sub := range c.Group {
+			expr, err := compileCondition(s, sub)
+			if err != nil {
+				return nil, err
+			}
+			if expr != nil {
+				exprs = append(exprs, expr)
+			}
+		}
+		switch {
+		case len(exprs) == 0:
+			return nil, nil
+		case len(exprs) == 1:
+			// GORM joins a single-element OR group to the preceding condition with OR.
+			return exprs[0], nil
+		case c.Or:
+			return clause.Or(exprs...), nil
+		default:
+			return clause.And(exprs...), nil
+		}
+	}
+
+	column, err := lookupColumn(s, c.Column)
+	if err != nil {
+		return nil, err
+	}
+	switch c.Operator {
+	case OpEq:
+		if isNil(c.Value) {
+			return nil, fmt.Errorf("%w: %s compared with nil, use IsNull", ErrInvalidCriteria, c.Column)
+		}
+		return clause.Eq{Column: column, Value: c.Value}, nil
+	case OpNe:
+		if isNil(c.Value) {
+			return nil, fmt.Errorf("%w: %s compared with nil, use NotNull", ErrInvalidCriteria, c.Column)
+		}
+		return clause.Neq{Column: column, Value: c.Value}, nil
+	case OpIn:
+		values, err := listValues(c)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) == 0 {
+			return clause.Expr{SQL: "1 = 0"}, nil
+		}
+		return clause.IN{Column: column, Values: values}, nil
+	case OpGt:
+		return clause.Gt{Column: column, Value: c.Value}, nil
+	case OpGte:
+		return clause.Gte{Column: column, Value: c.Value}, nil
+	case OpLt:
+		return clause.Lt{Column: column, Value: c.Value}, nil
+	case OpLte:
+		return clause.Lte{Column: column, Value: c.Value}, nil
+	case OpLike:
+		pattern, ok := c.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: LIKE pattern for %s must be a string", ErrInvalidCriteria, c.Column)
+		}
+		return clause.Expr{SQL: "? LIKE ? ESCAPE '" + likeEscape + "'", Vars: []any{column, pattern}}, nil
+	case OpIsNull:
+		return clause.Eq{Column: column, Value: nil}, nil
+	case OpNotNull:
+		return clause.Neq{Column: column, Value: nil}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidCriteria, c.Operator)
+	}
+}
+
+// lookupColumn accepts only database column names of the entity.
+func lookupColumn(s *schema.Schema, name string) (clause.Column, error) {
+	field, ok := s.FieldsByDBName[name]
+	if !ok {
+		return clause.Column{}, fmt.Errorf("%w: %s has no column %q", ErrInvalidCriteria, s.Name, name)
+	}
+	return clause.Column{Name: field.DBName}, nil
+}
+
+// listValues returns the values of an IN condition, which may be any slice.
+func listValues(c Condition) ([]any, error) {
+	if values, ok := c.Value.([]any); ok {
+		return values, nil
+	}
+	rv := reflect.ValueOf(c.Value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%w: IN values for %s must be a slice", ErrInvalidCriteria, c.Column)
+	}
+	values := make([]any, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
 	}
-	return r.db.WithContext(ctx).Delete(model, "id = ?", id.String()).Error
+	return values, nil
 }
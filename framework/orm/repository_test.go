@@ -0,0 +1,284 @@
+package orm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/soliton-go/framework/ddd"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type testItemID string
+
+func (id testItemID) String() string { return string(id) }
+
+type testItem struct {
+	ID    testItemID `gorm:"primaryKey"`
+	Name  string
+	Price int
+	Score *int
+}
+
+func (i *testItem) GetID() ddd.ID { return i.ID }
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// newTestItemRepository returns a repository over a fresh table holding items.
+func newTestItemRepository(t *testing.T, items []*testItem, opts ...RepositoryOption) *GormRepository[*testItem, testItemID] {
+	t.Helper()
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&testItem{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) > 0 {
+		if err := db.Create(items).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewGormRepository[*testItem, testItemID](db, opts...)
+}
+
+func score(n int) *int { return &n }
+
+func itemIDs(items []*testItem) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = string(item.ID)
+	}
+	return ids
+}
+
+func TestFindByCursorPagesAcrossNulls(t *testing.T) {
+	repo := newTestItemRepository(t, []*testItem{
+		{ID: "a", Score: score(2)},
+		{ID: "b"},
+		{ID: "c", Score: score(1)},
+		{ID: "d"},
+		{ID: "e", Score: score(2)},
+		{ID: "f"},
+		{ID: "g", Score: score(3)},
+	})
+
+	tests := []struct {
+		sort Sort
+		want []string
+	}{
+		{Asc("score"), []string{"b", "d", "f", "c", "a", "e", "g"}},
+		{Desc("score"), []string{"g", "a", "e", "c", "b", "d", "f"}},
+	}
+	for _, tt := range tests {
+		for _, limit := range []int{1, 2, 3} {
+			t.Run(fmt.Sprintf("%s desc=%t limit=%d", tt.sort.Column, tt.sort.Desc, limit), func(t *testing.T) {
+				var got []string
+				criteria := Criteria{}.OrderBy(tt.sort)
+				for cursor, pages := "", 0; ; pages++ {
+					if pages > len(tt.want) {
+						t.Fatalf("no last page after %d pages: %v", pages, got)
+					}
+					page, err := repo.FindByCursor(context.Background(), criteria.After(cursor, limit))
+					if err != nil {
+						t.Fatal(err)
+					}
+					got = append(got, itemIDs(page.Items)...)
+					if cursor = page.NextCursor; cursor == "" {
+						break
+					}
+				}
+				if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+					t.Errorf("paged %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestFindByCursorRejectsNullForNotNullColumn(t *testing.T) {
+	repo := newTestItemRepository(t, []*testItem{{ID: "a"}, {ID: "b"}})
+
+	data, _ := json.Marshal(cursor{Keys: []string{"price", "id"}, Values: []json.RawMessage{json.RawMessage("null"), json.RawMessage(`"a"`)}})
+	forged := base64.RawURLEncoding.EncodeToString(data)
+	_, err := repo.FindByCursor(context.Background(), Criteria{}.OrderBy(Asc("price")).After(forged, 1))
+	if !errors.Is(err, ErrInvalidCriteria) {
+		t.Errorf("cursor with NULL price = %v, want ErrInvalidCriteria", err)
+	}
+}
+
+func criteriaTestItems() []*testItem {
+	return []*testItem{
+		{ID: "a", Name: "apple", Price: 10, Score: score(1)},
+		{ID: "b", Name: "banana", Price: 20},
+		{ID: "c", Name: "cherry", Price: 30, Score: score(3)},
+		{ID: "d", Name: "100% juice", Price: 40},
+	}
+}
+
+func TestFindByCriteriaOperators(t *testing.T) {
+	repo := newTestItemRepository(t, criteriaTestItems())
+
+	tests := []struct {
+		name      string
+		condition Condition
+		want      []string
+	}{
+		{"eq", Eq("name", "banana"), []string{"b"}},
+		{"ne", Ne("name", "banana"), []string{"a", "c", "d"}},
+		{"in", In("price", 10, 30), []string{"a", "c"}},
+		{"in typed slice", Condition{Column: "price", Operator: OpIn, Value: []int{20, 40}}, []string{"b", "d"}},
+		{"empty in", In[int]("price"), []string{}},
+		{"gt", Gt("price", 20), []string{"c", "d"}},
+		{"gte", Gte("price", 20), []string{"b", "c", "d"}},
+		{"lt", Lt("price", 20), []string{"a"}},
+		{"lte", Lte("price", 20), []string{"a", "b"}},
+		{"range", Range("price", 15, 35), []string{"b", "c"}},
+		{"open range", Range("price", nil, 15), []string{"a"}},
+		{"like", Like("name", "%an%"), []string{"b"}},
+		{"contains escapes wildcards", Contains("name", "0%"), []string{"d"}},
+		{"is null", IsNull("score"), []string{"b", "d"}},
+		{"not null", NotNull("score"), []string{"a", "c"}},
+		{"or", Or(Eq("name", "apple"), Gte("price", 40)), []string{"a", "d"}},
+		{"and", And(Gt("price", 10), NotNull("score")), []string{"c"}},
+		{"empty group", And(), []string{"a", "b", "c", "d"}},
+		{"single-element or", And(Gt("price", 10), Or(Lt("price", 30))), []string{"b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, total, err := repo.FindByCriteria(context.Background(), NewCriteria(tt.condition).OrderBy(Asc("id")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := itemIDs(items); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("found %v, want %v", got, tt.want)
+			}
+			if total != int64(len(tt.want)) {
+				t.Errorf("total = %d, want %d", total, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestFindByCriteriaPaginates(t *testing.T) {
+	repo := newTestItemRepository(t, criteriaTestItems())
+
+	items, total, err := repo.FindByCriteria(context.Background(), NewCriteria(Gte("price", 20)).OrderBy(Desc("price")).Paginate(2, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := itemIDs(items); fmt.Sprint(got) != "[b]" || total != 3 {
+		t.Errorf("page 2 = %v of %d, want [b] of 3", got, total)
+	}
+}
+
+func TestFindByCriteriaRejectsInvalidCriteria(t *testing.T) {
+	repo := newTestItemRepository(t, criteriaTestItems(), WithSortable("price"))
+
+	tests := []struct {
+		name     string
+		criteria Criteria
+	}{
+		{"unknown column", NewCriteria(Eq("password", "x"))},
+		{"field name instead of column", NewCriteria(Eq("Name", "apple"))},
+		{"injected column", NewCriteria(Eq("name = name OR 1", 1))},
+		{"unknown column in group", NewCriteria(Or(Eq("name", "apple"), Eq("secret", 1)))},
+		{"eq nil", NewCriteria(Eq("score", nil))},
+		{"ne nil", NewCriteria(Ne("score", nil))},
+		{"in without a slice", NewCriteria(Condition{Column: "price", Operator: OpIn, Value: 10})},
+		{"like without a string", NewCriteria(Condition{Column: "name", Operator: OpLike, Value: 1})},
+		{"unknown operator", NewCriteria(Condition{Column: "name", Operator: "regexp", Value: "a"})},
+		{"unknown sort column", NewCriteria().OrderBy(Asc("rank"))},
+		{"sort column not allowed", NewCriteria().OrderBy(Asc("name"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := repo.FindByCriteria(context.Background(), tt.criteria); !errors.Is(err, ErrInvalidCriteria) {
+				t.Errorf("FindByCriteria = %v, want ErrInvalidCriteria", err)
+			}
+		})
+	}
+
+	if _, _, err := repo.FindByCriteria(context.Background(), NewCriteria().OrderBy(Desc("price"))); err != nil {
+		t.Errorf("sorting by an allowed column = %v, want nil", err)
+	}
+}
+
+func TestFindByCriteriaNamesUnknownColumn(t *testing.T) {
+	repo := newTestItemRepository(t, nil)
+
+	_, _, err := repo.FindByCriteria(context.Background(), NewCriteria(Eq("password", "x")))
+	if err == nil || err.Error() != `invalid criteria: testItem has no column "password"` {
+		t.Errorf("FindByCriteria = %v, want the unknown column named", err)
+	}
+}
+
+type testAccount struct {
+	ID      testItemID `gorm:"primaryKey"`
+	Balance int
+	Version int64
+}
+
+func (a *testAccount) GetID() ddd.ID      { return a.ID }
+func (a *testAccount) GetVersion() int64  { return a.Version }
+func (a *testAccount) SetVersion(v int64) { a.Version = v }
+
+func TestSaveVersionedRejectsStaleWrite(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&testAccount{}); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewGormRepository[*testAccount, testItemID](db)
+	ctx := context.Background()
+
+	if err := repo.Save(ctx, &testAccount{ID: "acc", Balance: 100}); err != nil {
+		t.Fatal(err)
+	}
+	first, err := repo.Find(ctx, "acc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := repo.Find(ctx, "acc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first.Balance = 150
+	if err := repo.Save(ctx, first); err != nil {
+		t.Fatalf("first save = %v", err)
+	}
+	if first.Version != 2 {
+		t.Errorf("version after first save = %d, want 2", first.Version)
+	}
+
+	second.Balance = 50
+	if err := repo.Save(ctx, second); !errors.Is(err, ErrConcurrencyConflict) {
+		t.Fatalf("stale save = %v, want ErrConcurrencyConflict", err)
+	}
+	if second.Version != 1 {
+		t.Errorf("version after stale save = %d, want it left at 1", second.Version)
+	}
+
+	stored, err := repo.Find(ctx, "acc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Balance != 150 || stored.Version != 2 {
+		t.Errorf("stored balance %d at version %d, want 150 at version 2", stored.Balance, stored.Version)
+	}
+
+	// A new aggregate reusing an existing ID conflicts instead of overwriting it.
+	if err := repo.Save(ctx, &testAccount{ID: "acc"}); !errors.Is(err, ErrConcurrencyConflict) {
+		t.Errorf("saving a new account over an existing one = %v, want ErrConcurrencyConflict", err)
+	}
+}
@@ -0,0 +1,150 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// ErrUnmanagedTransaction is returned when work that must wait for a commit runs in a transaction
+// that was not opened by Transaction or a UnitOfWork, so nothing knows when it commits.
+var ErrUnmanagedTransaction = errors.New("transaction not opened by orm.Transaction or a UnitOfWork")
+
+// ContextWithTx returns a context carrying an open GORM transaction.
+// Repositories and stores that receive this context write through the transaction. Prefer
+// Transaction or a UnitOfWork, which also run AfterCommit and AfterRollback callbacks.
+func ContextWithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the transaction stored in ctx, if any.
+func TxFromContext(ctx context.Context) (*gorm.DB, bool) {
+	tx, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return tx, ok && tx != nil
+}
+
+// Transaction runs fn inside a transaction on db and passes it on through the context.
+// If ctx already carries a transaction, fn joins it instead of opening a new one; use a
+// UnitOfWork to run nested work in a savepoint instead.
+func Transaction(ctx context.Context, db *gorm.DB, fn func(ctx context.Context) error) error {
+	if _, ok := TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+	return runTransaction(ctx, db, fn)
+}
+
+// runTransaction opens a transaction on db and runs the callbacks registered in it once it has
+// committed or rolled back.
+func runTransaction(ctx context.Context, db *gorm.DB, fn func(ctx context.Context) error) error {
+	scope := &txScope{}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ContextWithTx(ctx, tx), scopeKey{}, scope))
+	})
+	if err != nil {
+		scope.rolledBack(ctx)
+		return err
+	}
+	scope.committed(ctx)
+	return nil
+}
+
+type primaryKey struct{}
+
+// ContextWithPrimary returns a context whose queries go to the primary database rather than a
+// replica (see Conn), for reads that must see a write made just before. Transactions always
+// use the primary.
+func ContextWithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// Conn returns the transaction from ctx if there is one, otherwise db, bound to ctx.
+// Without a transaction, reads go to a replica unless ctx was made with ContextWithPrimary.
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx.WithContext(ctx)
+	}
+	if primary, _ := ctx.Value(primaryKey{}).(bool); primary {
+		return Primary(db).WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+type scopeKey struct{}
+
+// Managed reports whether ctx carries a transaction opened by Transaction or a UnitOfWork,
+// whose AfterCommit callbacks wait for it to commit.
+func Managed(ctx context.Context) bool {
+	_, ok := ctx.Value(scopeKey{}).(*txScope)
+	return ok
+}
+
+// txScope collects the callbacks registered in a transaction or one of its savepoints.
+type txScope struct {
+	mu            sync.Mutex
+	afterCommit   []func(ctx context.Context)
+	afterRollback []func(ctx context.Context)
+}
+
+// AfterCommit registers fn to run once the transaction carried by ctx has committed, e.g. to
+// publish events to a broker outside the database. Callbacks run in registration order with
+// the context the transaction was opened with. If ctx carries no transaction opened by
+// Transaction or a UnitOfWork, fn runs immediately, even if ContextWithTx attached one: check
+// Managed first where that matters.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	scope, ok := ctx.Value(scopeKey{}).(*txScope)
+	if !ok {
+		fn(ctx)
+		return
+	}
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	scope.afterCommit = append(scope.afterCommit, fn)
+}
+
+// AfterRollback registers fn to run once the transaction carried by ctx, or the savepoint it
+// was registered in, has rolled back, e.g. to undo side effects outside the database. If ctx
+// carries no transaction opened by Transaction or a UnitOfWork, fn never runs.
+func AfterRollback(ctx context.Context, fn func(ctx context.Context)) {
+	scope, ok := ctx.Value(scopeKey{}).(*txScope)
+	if !ok {
+		return
+	}
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	scope.afterRollback = append(scope.afterRollback, fn)
+}
+
+func (s *txScope) committed(ctx context.Context) {
+	for _, fn := range s.callbacks(&s.afterCommit) {
+		fn(ctx)
+	}
+}
+
+func (s *txScope) rolledBack(ctx context.Context) {
+	for _, fn := range s.callbacks(&s.afterRollback) {
+		fn(ctx)
+	}
+}
+
+// released hands the callbacks of a savepoint that succeeded to the enclosing scope, which
+// decides whether its work commits.
+func (s *txScope) released(parent *txScope) {
+	commit, rollback := s.callbacks(&s.afterCommit), s.callbacks(&s.afterRollback)
+	parent.mu.Lock()
+	defer parent.mu.Unlock()
+	parent.afterCommit = append(parent.afterCommit, commit...)
+	parent.afterRollback = append(parent.afterRollback, rollback...)
+}
+
+// callbacks takes the callbacks in list.
+func (s *txScope) callbacks(list *[]func(ctx context.Context)) []func(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fns := *list
+	*list = nil
+	return fns
+}
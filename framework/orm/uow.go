@@ -0,0 +1,59 @@
+package orm
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// UnitOfWork runs a business operation in one transaction that every repository, mapper and
+// store receiving its context writes through (see Conn), so that, for example, reserving stock
+// and recording a payment commit or roll back together:
+//
+//	err := uow.Do(ctx, func(ctx context.Context) error {
+//	    if _, err := inventories.ReserveStock(ctx, reserve); err != nil {
+//	        return err
+//	    }
+//	    return payments.Save(ctx, payment)
+//	})
+//
+// Register AfterCommit callbacks inside Do to act once the work is durable.
+type UnitOfWork struct {
+	db *gorm.DB
+}
+
+// NewUnitOfWork creates a UnitOfWork opening its transactions on db.
+func NewUnitOfWork(db *gorm.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do runs fn in a transaction, committed if fn returns nil and rolled back otherwise.
+//
+// If ctx already carries a transaction, fn runs in a savepoint of it instead: an error rolls back
+// only the writes of fn and is returned, so the caller may handle it and carry on. AfterRollback
+// callbacks registered in a rolled-back savepoint run right away, and its AfterCommit callbacks
+// are dropped; those of a successful savepoint wait for the outer transaction. The outer
+// transaction must have been opened by Transaction or a UnitOfWork, otherwise Do returns
+// ErrUnmanagedTransaction without running fn.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, ok := TxFromContext(ctx)
+	if !ok {
+		return runTransaction(ctx, u.db, fn)
+	}
+	parent, ok := ctx.Value(scopeKey{}).(*txScope)
+	if !ok {
+		return ErrUnmanagedTransaction
+	}
+
+	scope := &txScope{}
+	// GORM turns a transaction opened inside another one into a savepoint.
+	err := tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ContextWithTx(ctx, tx), scopeKey{}, scope))
+	})
+	if err != nil {
+		scope.rolledBack(ctx)
+		return err
+	}
+	scope.released(parent)
+	return nil
+}
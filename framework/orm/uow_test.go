@@ -0,0 +1,194 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// callbackLog records the transaction callbacks that ran, in order.
+type callbackLog []string
+
+func (l *callbackLog) register(ctx context.Context, name string) {
+	AfterCommit(ctx, func(context.Context) { *l = append(*l, name+" committed") })
+	AfterRollback(ctx, func(context.Context) { *l = append(*l, name+" rolled back") })
+}
+
+func newTestUnitOfWork(t *testing.T) (*UnitOfWork, *gorm.DB) {
+	t.Helper()
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&testItem{}); err != nil {
+		t.Fatal(err)
+	}
+	return NewUnitOfWork(db), db
+}
+
+func createItem(ctx context.Context, db *gorm.DB, id testItemID) error {
+	return Conn(ctx, db).Create(&testItem{ID: id}).Error
+}
+
+func storedItemIDs(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	var items []*testItem
+	if err := db.Order("id").Find(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	return itemIDs(items)
+}
+
+func TestUnitOfWorkFailedSavepointInCommittedTransaction(t *testing.T) {
+	uow, db := newTestUnitOfWork(t)
+	errFailed := errors.New("savepoint failed")
+	var log callbackLog
+
+	err := uow.Do(context.Background(), func(ctx context.Context) error {
+		log.register(ctx, "outer")
+		if err := createItem(ctx, db, "outer"); err != nil {
+			return err
+		}
+		if err := uow.Do(ctx, func(ctx context.Context) error {
+			log.register(ctx, "released")
+			return createItem(ctx, db, "released")
+		}); err != nil {
+			return err
+		}
+		if err := uow.Do(ctx, func(ctx context.Context) error {
+			log.register(ctx, "failed")
+			if err := createItem(ctx, db, "failed"); err != nil {
+				return err
+			}
+			return errFailed
+		}); !errors.Is(err, errFailed) {
+			return fmt.Errorf("failed savepoint returned %v", err)
+		}
+		if fmt.Sprint(log) != "[failed rolled back]" {
+			return fmt.Errorf("callbacks before commit = %v", log)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "[failed rolled back outer committed released committed]"; fmt.Sprint(log) != want {
+		t.Errorf("callbacks = %v, want %s", log, want)
+	}
+	if got := storedItemIDs(t, db); fmt.Sprint(got) != "[outer released]" {
+		t.Errorf("stored %v, want [outer released]", got)
+	}
+}
+
+func TestUnitOfWorkRollbackRunsReleasedSavepointCallbacks(t *testing.T) {
+	uow, db := newTestUnitOfWork(t)
+	errFailed := errors.New("outer failed")
+	var log callbackLog
+
+	err := uow.Do(context.Background(), func(ctx context.Context) error {
+		log.register(ctx, "outer")
+		if err := uow.Do(ctx, func(ctx context.Context) error {
+			log.register(ctx, "released")
+			return createItem(ctx, db, "released")
+		}); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Do = %v, want %v", err, errFailed)
+	}
+
+	if want := "[outer rolled back released rolled back]"; fmt.Sprint(log) != want {
+		t.Errorf("callbacks = %v, want %s", log, want)
+	}
+	if got := storedItemIDs(t, db); len(got) != 0 {
+		t.Errorf("stored %v after rollback, want nothing", got)
+	}
+}
+
+func TestTransactionJoinsTransactionInContext(t *testing.T) {
+	uow, db := newTestUnitOfWork(t)
+	errFailed := errors.New("outer failed")
+
+	err := uow.Do(context.Background(), func(ctx context.Context) error {
+		if err := Transaction(ctx, db, func(ctx context.Context) error {
+			return createItem(ctx, db, "joined")
+		}); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Do = %v, want %v", err, errFailed)
+	}
+	if got := storedItemIDs(t, db); len(got) != 0 {
+		t.Errorf("stored %v, want the joined write rolled back with the outer transaction", got)
+	}
+}
+
+func TestCallbacksWithoutTransaction(t *testing.T) {
+	var log callbackLog
+	log.register(context.Background(), "plain")
+
+	if fmt.Sprint(log) != "[plain committed]" {
+		t.Errorf("callbacks = %v, want AfterCommit to run immediately and AfterRollback never", log)
+	}
+}
+
+func TestTransactionRollbackAfterInnerDo(t *testing.T) {
+	uow, db := newTestUnitOfWork(t)
+	errFailed := errors.New("outer failed")
+	var log callbackLog
+
+	err := Transaction(context.Background(), db, func(ctx context.Context) error {
+		if err := uow.Do(ctx, func(ctx context.Context) error {
+			log.register(ctx, "inner")
+			return createItem(ctx, db, "inner")
+		}); err != nil {
+			return err
+		}
+		if len(log) != 0 {
+			return fmt.Errorf("callbacks ran before the outer transaction ended: %v", log)
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Transaction = %v, want %v", err, errFailed)
+	}
+
+	if fmt.Sprint(log) != "[inner rolled back]" {
+		t.Errorf("callbacks = %v, want only the inner rollback", log)
+	}
+	if got := storedItemIDs(t, db); len(got) != 0 {
+		t.Errorf("stored %v, want the inner write rolled back with the outer transaction", got)
+	}
+}
+
+func TestUnitOfWorkRefusesUnmanagedTransaction(t *testing.T) {
+	uow, db := newTestUnitOfWork(t)
+	errRollback := errors.New("rollback")
+	ran := false
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		ctx := ContextWithTx(context.Background(), tx)
+		if Managed(ctx) {
+			t.Error("Managed reports a transaction attached with ContextWithTx")
+		}
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			ran = true
+			return nil
+		})
+		if !errors.Is(err, ErrUnmanagedTransaction) {
+			t.Errorf("Do = %v, want ErrUnmanagedTransaction", err)
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatal(err)
+	}
+	if ran {
+		t.Error("Do ran its function in an unmanaged transaction")
+	}
+}
@@ -0,0 +1,412 @@
+// Package projection builds denormalized read models from the event log kept by event.EventRecorder.
+//
+// A Projection consumes the recorded events of chosen topics in log order and updates its read
+// tables. The Projector applies each event in a transaction that also advances the projection's
+// checkpoint, so every event is applied exactly once, and can rebuild a projection from scratch.
+package projection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/event"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrProjectionNotFound is returned for a projection name that was not registered.
+	ErrProjectionNotFound = errors.New("projection not found")
+	// errCheckpointMoved means another projector advanced or reset the checkpoint concurrently.
+	errCheckpointMoved = errors.New("projection checkpoint moved")
+)
+
+// Projection builds a read model from events.
+type Projection interface {
+	// Name identifies the projection and its checkpoint, e.g. "order.user_order_summary".
+	Name() string
+	// Topics are the topics or topic patterns (see event.MatchTopic) the projection consumes.
+	Topics() []string
+	// Apply updates the read model for one event. ctx carries the transaction that also advances
+	// the checkpoint (see orm.Conn), so writes made through it commit or roll back with it.
+	Apply(ctx context.Context, e ddd.DomainEvent) error
+	// Reset deletes the read model before a rebuild. It runs in the transaction that rewinds the checkpoint.
+	Reset(ctx context.Context) error
+}
+
+// Checkpoint is the database row recording how far a projection has read the event log.
+type Checkpoint struct {
+	Name string `gorm:"primaryKey;size:255" json:"name"`
+	// Position is the log position (event.RecordedEvent.ID) of the last event read.
+	Position  uint64     `gorm:"not null;default:0" json:"position"`
+	LastError string     `gorm:"type:text" json:"last_error,omitempty"`
+	RebuiltAt *time.Time `json:"rebuilt_at,omitempty"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName overrides the GORM table name.
+func (Checkpoint) TableName() string {
+	return "projection_checkpoints"
+}
+
+// MigrateCheckpoints creates the checkpoint table if it does not exist.
+func MigrateCheckpoints(db *gorm.DB) error {
+	return orm.Primary(db).AutoMigrate(&Checkpoint{})
+}
+
+// Status reports the progress of a registered projection.
+type Status struct {
+	Checkpoint
+	Topics []string `json:"topics"`
+}
+
+// registered is a projection with the lock that serializes its catch-ups and rebuilds in this process.
+type registered struct {
+	Projection
+	mu sync.Mutex
+}
+
+// Projector keeps registered projections up to date by polling the event log.
+// Several processes may run projectors on the same database: checkpoints are advanced with a
+// compare-and-swap, so an event applied by one is rolled back by the others.
+type Projector struct {
+	db        *gorm.DB
+	recorder  *event.EventRecorder
+	registry  event.EventRegistry
+	codecs    event.Codecs
+	logger    watermill.LoggerAdapter
+	interval  time.Duration
+	settle    time.Duration
+	batchSize int
+
+	mu          sync.RWMutex
+	projections []*registered
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Option is a functional option for Projector.
+type Option func(*Projector)
+
+// WithInterval sets how often the event log is polled. It defaults to one second.
+func WithInterval(interval time.Duration) Option {
+	return func(p *Projector) {
+		p.interval = interval
+	}
+}
+
+// WithSettleDelay sets how old a recorded event must be before it is projected, so that an event
+// whose insert commits after a later one is not skipped. It defaults to one second.
+func WithSettleDelay(delay time.Duration) Option {
+	return func(p *Projector) {
+		p.settle = delay
+	}
+}
+
+// WithBatchSize sets how many events are read from the log at a time. It defaults to 100.
+func WithBatchSize(n int) Option {
+	return func(p *Projector) {
+		if n > 0 {
+			p.batchSize = n
+		}
+	}
+}
+
+// WithRegistry sets the registry events are decoded with. It defaults to event.GlobalRegistry().
+func WithRegistry(registry event.EventRegistry) Option {
+	return func(p *Projector) {
+		p.registry = registry
+	}
+}
+
+// WithCodecs makes additional codecs available for decoding. The built-in codecs are always available.
+func WithCodecs(codecs ...event.Codec) Option {
+	return func(p *Projector) {
+		for _, c := range codecs {
+			p.codecs[c.ContentType()] = c
+		}
+	}
+}
+
+// WithLogger sets a custom logger.
+func WithLogger(logger watermill.LoggerAdapter) Option {
+	return func(p *Projector) {
+		p.logger = logger
+	}
+}
+
+// NewProjector creates a Projector reading the log kept by recorder.
+func NewProjector(db *gorm.DB, recorder *event.EventRecorder, opts ...Option) *Projector {
+	p := &Projector{
+		db:        db,
+		recorder:  recorder,
+		registry:  event.GlobalRegistry(),
+		codecs:    event.DefaultCodecs(),
+		logger:    watermill.NewStdLogger(false, false),
+		interval:  time.Second,
+		settle:    time.Second,
+		batchSize: 100,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Register adds projections. Names must be unique.
+func (p *Projector) Register(projections ...Projection) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, projection := range projections {
+		name := projection.Name()
+		if name == "" {
+			return fmt.Errorf("projection %T has no name", projection)
+		}
+		if p.lookup(name) != nil {
+			return fmt.Errorf("projection %s is already registered", name)
+		}
+		p.projections = append(p.projections, &registered{Projection: projection})
+	}
+	return nil
+}
+
+func (p *Projector) lookup(name string) *registered {
+	for _, r := range p.projections {
+		if r.Name() == name {
+			return r
+		}
+	}
+	return nil
+}
+
+func (p *Projector) projection(name string) (*registered, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if r := p.lookup(name); r != nil {
+		return r, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrProjectionNotFound, name)
+}
+
+func (p *Projector) registered() []*registered {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return slices.Clone(p.projections)
+}
+
+// Start launches the background polling loop. It matches the fx.Hook OnStart signature.
+func (p *Projector) Start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			for _, r := range p.registered() {
+				if _, err := p.catchUp(runCtx, r); err != nil && runCtx.Err() == nil {
+					p.logger.Error("Projection failed", err, watermill.LogFields{"projection": r.Name()})
+				}
+			}
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops the polling loop and waits for the current batch to finish.
+// It matches the fx.Hook OnStop signature.
+func (p *Projector) Stop(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CatchUp applies the events recorded since the projection's checkpoint and returns how many were applied.
+// It stops at the first event the projection fails on; the next catch-up starts again from that event.
+func (p *Projector) CatchUp(ctx context.Context, name string) (int, error) {
+	r, err := p.projection(name)
+	if err != nil {
+		return 0, err
+	}
+	return p.catchUp(ctx, r)
+}
+
+// Rebuild resets the projection's read model and checkpoint in one transaction, then replays the
+// whole event log into it. Queries see a partial read model until the rebuild completes, and only
+// events recorded since the recorder was enabled are replayed.
+func (p *Projector) Rebuild(ctx context.Context, name string) (Status, error) {
+	r, err := p.projection(name)
+	if err != nil {
+		return Status{}, err
+	}
+	r.mu.Lock()
+	if _, err := p.checkpoint(ctx, name); err != nil {
+		r.mu.Unlock()
+		return Status{}, err
+	}
+	err = orm.Transaction(ctx, p.db, func(ctx context.Context) error {
+		if err := r.Reset(ctx); err != nil {
+			return err
+		}
+		return orm.Conn(ctx, p.db).Model(&Checkpoint{}).Where("name = ?", name).
+			Updates(map[string]any{"position": 0, "last_error": "", "rebuilt_at": time.Now()}).Error
+	})
+	r.mu.Unlock()
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to reset projection %s: %w", name, err)
+	}
+
+	if _, err := p.catchUp(ctx, r); err != nil {
+		return Status{}, err
+	}
+	return p.status(ctx, r)
+}
+
+// Statuses returns the progress of the registered projections, in registration order.
+func (p *Projector) Statuses(ctx context.Context) ([]Status, error) {
+	projections := p.registered()
+	statuses := make([]Status, 0, len(projections))
+	for _, r := range projections {
+		status, err := p.status(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (p *Projector) status(ctx context.Context, r *registered) (Status, error) {
+	cp, err := p.checkpoint(ctx, r.Name())
+	if err != nil {
+		return Status{}, err
+	}
+	return Status{Checkpoint: cp, Topics: r.Topics()}, nil
+}
+
+// checkpoint returns the checkpoint of a projection, creating it at position 0.
+func (p *Projector) checkpoint(ctx context.Context, name string) (Checkpoint, error) {
+	db := orm.Primary(p.db).WithContext(ctx)
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&Checkpoint{Name: name}).Error; err != nil {
+		return Checkpoint{}, err
+	}
+	var cp Checkpoint
+	err := db.Where("name = ?", name).First(&cp).Error
+	return cp, err
+}
+
+func (p *Projector) catchUp(ctx context.Context, r *registered) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	applied := 0
+	for {
+		n, more, err := p.batch(ctx, r)
+		applied += n
+		if errors.Is(err, errCheckpointMoved) {
+			// Another process is projecting the same events.
+			return applied, nil
+		}
+		if err != nil || !more {
+			return applied, err
+		}
+	}
+}
+
+// batch applies one batch of events and reports whether more may follow.
+func (p *Projector) batch(ctx context.Context, r *registered) (int, bool, error) {
+	name := r.Name()
+	cp, err := p.checkpoint(ctx, name)
+	if err != nil {
+		return 0, false, err
+	}
+	records, last, err := p.recorder.Find(ctx, event.RecordFilter{
+		Topics:         r.Topics(),
+		AfterID:        cp.Position,
+		RecordedBefore: time.Now().Add(-p.settle),
+		Limit:          p.batchSize,
+	})
+	if err != nil || last == cp.Position {
+		return 0, false, err
+	}
+
+	position, applied := cp.Position, 0
+	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return applied, false, err
+		}
+		if err := p.apply(ctx, r, position, record); err != nil {
+			if !errors.Is(err, errCheckpointMoved) {
+				p.recordError(ctx, name, position, err)
+				err = fmt.Errorf("projection %s failed on event %d (%s): %w", name, record.ID, record.EventName, err)
+			}
+			return applied, false, err
+		}
+		position = record.ID
+		applied++
+	}
+	// Skip the events the topic patterns did not match.
+	if position != last {
+		if err := p.advance(ctx, name, position, last); err != nil {
+			return applied, false, err
+		}
+	}
+	return applied, true, nil
+}
+
+func (p *Projector) apply(ctx context.Context, r *registered, from uint64, record event.RecordedEvent) error {
+	e, err := record.Decode(p.registry, p.codecs)
+	if err != nil {
+		return err
+	}
+	return orm.Transaction(ctx, p.db, func(ctx context.Context) error {
+		if err := r.Apply(ctx, e); err != nil {
+			return err
+		}
+		return p.advance(ctx, r.Name(), from, record.ID)
+	})
+}
+
+// advance moves the checkpoint from one position to the next, failing if it is no longer at from.
+func (p *Projector) advance(ctx context.Context, name string, from, to uint64) error {
+	result := orm.Conn(ctx, p.db).Model(&Checkpoint{}).
+		Where("name = ? AND position = ?", name, from).
+		Updates(map[string]any{"position": to, "last_error": ""})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errCheckpointMoved
+	}
+	return nil
+}
+
+func (p *Projector) recordError(ctx context.Context, name string, position uint64, cause error) {
+	err := orm.Primary(p.db).WithContext(context.WithoutCancel(ctx)).Model(&Checkpoint{}).
+		Where("name = ? AND position = ?", name, position).
+		Update("last_error", cause.Error()).Error
+	if err != nil {
+		p.logger.Error("Failed to record projection error", err, watermill.LogFields{"projection": name})
+	}
+}
@@ -0,0 +1,281 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/soliton-go/framework/ddd"
+	"github.com/soliton-go/framework/event"
+	"github.com/soliton-go/framework/orm"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type itemAdded struct {
+	ddd.BaseDomainEvent
+	Value string `json:"value"`
+}
+
+func (itemAdded) EventName() string { return "item.added" }
+
+type itemArchived struct {
+	ddd.BaseDomainEvent
+	Value string `json:"value"`
+}
+
+func (itemArchived) EventName() string { return "item.archived" }
+
+// projectedItem is the read model of itemProjection.
+type projectedItem struct {
+	Value string `gorm:"primaryKey"`
+}
+
+// itemProjection stores the value of every item.added event and fails on the value in failOn.
+type itemProjection struct {
+	db     *gorm.DB
+	failOn string
+	resets int
+}
+
+func (p *itemProjection) Name() string     { return "test.items" }
+func (p *itemProjection) Topics() []string { return []string{"item.*"} }
+
+func (p *itemProjection) Apply(ctx context.Context, e ddd.DomainEvent) error {
+	added, ok := e.(*itemAdded)
+	if !ok {
+		return nil
+	}
+	if err := orm.Conn(ctx, p.db).Create(&projectedItem{Value: added.Value}).Error; err != nil {
+		return err
+	}
+	if added.Value == p.failOn {
+		return errors.New("cannot project " + added.Value)
+	}
+	return nil
+}
+
+func (p *itemProjection) Reset(ctx context.Context) error {
+	p.resets++
+	return orm.Conn(ctx, p.db).Where("1 = 1").Delete(&projectedItem{}).Error
+}
+
+// projectorFixture publishes events into the log and projects them.
+type projectorFixture struct {
+	db         *gorm.DB
+	bus        *event.WatermillEventBus
+	projector  *Projector
+	projection *itemProjection
+}
+
+func newProjectorFixture(t *testing.T) *projectorFixture {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := event.MigrateEventRecords(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateCheckpoints(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&projectedItem{}); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := event.NewEventRegistry()
+	registry.Register("item.added", func() ddd.DomainEvent { return &itemAdded{} })
+	registry.Register("item.archived", func() ddd.DomainEvent { return &itemArchived{} })
+	recorder := event.NewEventRecorder(db)
+	bus := event.NewLocalEventBus(event.WithRegistry(registry), event.WithRecorder(recorder), event.WithLogger(watermill.NopLogger{}))
+	t.Cleanup(func() { bus.Close() })
+
+	projection := &itemProjection{db: db}
+	projector := NewProjector(db, recorder, WithRegistry(registry), WithSettleDelay(0), WithBatchSize(2), WithLogger(watermill.NopLogger{}))
+	if err := projector.Register(projection); err != nil {
+		t.Fatal(err)
+	}
+	return &projectorFixture{db: db, bus: bus, projector: projector, projection: projection}
+}
+
+func (f *projectorFixture) add(t *testing.T, values ...string) {
+	t.Helper()
+	for _, value := range values {
+		if err := f.bus.Publish(context.Background(), itemAdded{BaseDomainEvent: ddd.NewBaseDomainEvent(), Value: value}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func (f *projectorFixture) lastPosition(t *testing.T) uint64 {
+	t.Helper()
+	var last event.RecordedEvent
+	if err := f.db.Order("id DESC").First(&last).Error; err != nil {
+		t.Fatal(err)
+	}
+	return last.ID
+}
+
+func (f *projectorFixture) checkpoint(t *testing.T) Checkpoint {
+	t.Helper()
+	var cp Checkpoint
+	if err := f.db.First(&cp, "name = ?", f.projection.Name()).Error; err != nil {
+		t.Fatal(err)
+	}
+	return cp
+}
+
+func (f *projectorFixture) items(t *testing.T) string {
+	t.Helper()
+	var items []projectedItem
+	if err := f.db.Order("value").Find(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	values := make([]string, len(items))
+	for i, item := range items {
+		values[i] = item.Value
+	}
+	return fmt.Sprint(values)
+}
+
+func TestCatchUpAppliesEventsAndAdvancesCheckpoint(t *testing.T) {
+	f := newProjectorFixture(t)
+	ctx := context.Background()
+
+	f.add(t, "a", "b")
+	if err := f.bus.Publish(ctx, itemArchived{BaseDomainEvent: ddd.NewBaseDomainEvent(), Value: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	f.add(t, "c")
+
+	n, err := f.projector.CatchUp(ctx, "test.items")
+	if err != nil || n != 4 {
+		t.Fatalf("CatchUp = %d, %v; want 4 events applied across batches", n, err)
+	}
+	if got := f.items(t); got != "[a b c]" {
+		t.Errorf("read model = %s, want [a b c]", got)
+	}
+	if cp := f.checkpoint(t); cp.Position != f.lastPosition(t) {
+		t.Errorf("checkpoint at %d, want the last position %d", cp.Position, f.lastPosition(t))
+	}
+
+	if n, err := f.projector.CatchUp(ctx, "test.items"); err != nil || n != 0 {
+		t.Errorf("CatchUp with nothing new = %d, %v; want 0, nil", n, err)
+	}
+	f.add(t, "d")
+	if n, err := f.projector.CatchUp(ctx, "test.items"); err != nil || n != 1 {
+		t.Errorf("CatchUp after a new event = %d, %v; want 1, nil", n, err)
+	}
+	if got := f.items(t); got != "[a b c d]" {
+		t.Errorf("read model = %s, want [a b c d]", got)
+	}
+
+	if _, err := f.projector.CatchUp(ctx, "missing"); !errors.Is(err, ErrProjectionNotFound) {
+		t.Errorf("CatchUp of an unknown projection = %v, want ErrProjectionNotFound", err)
+	}
+	if err := f.projector.Register(&itemProjection{db: f.db}); err == nil {
+		t.Error("registering a second projection with the same name succeeded")
+	}
+}
+
+func TestCatchUpStopsAtFailingEvent(t *testing.T) {
+	f := newProjectorFixture(t)
+	ctx := context.Background()
+	f.projection.failOn = "b"
+
+	f.add(t, "a")
+	failedFrom := f.lastPosition(t)
+	f.add(t, "b", "c")
+
+	n, err := f.projector.CatchUp(ctx, "test.items")
+	if err == nil || !strings.Contains(err.Error(), "cannot project b") || n != 1 {
+		t.Fatalf("CatchUp = %d, %v; want 1 applied and the failure on b", n, err)
+	}
+	// The write made for b rolled back with the checkpoint update.
+	if got := f.items(t); got != "[a]" {
+		t.Errorf("read model = %s, want [a]", got)
+	}
+	cp := f.checkpoint(t)
+	if cp.Position != failedFrom || cp.LastError != "cannot project b" {
+		t.Errorf("checkpoint at %d with error %q, want %d and the failure", cp.Position, cp.LastError, failedFrom)
+	}
+
+	f.projection.failOn = ""
+	if n, err := f.projector.CatchUp(ctx, "test.items"); err != nil || n != 2 {
+		t.Fatalf("CatchUp after the fix = %d, %v; want 2, nil", n, err)
+	}
+	if got := f.items(t); got != "[a b c]" {
+		t.Errorf("read model = %s, want [a b c]", got)
+	}
+	if cp := f.checkpoint(t); cp.LastError != "" {
+		t.Errorf("checkpoint still records %q", cp.LastError)
+	}
+}
+
+func TestApplyRollsBackWhenCheckpointMoved(t *testing.T) {
+	f := newProjectorFixture(t)
+	ctx := context.Background()
+	f.add(t, "a")
+
+	records, _, err := f.projector.recorder.Find(ctx, event.RecordFilter{})
+	if err != nil || len(records) != 1 {
+		t.Fatalf("Find = %d records, %v", len(records), err)
+	}
+	if _, err := f.projector.checkpoint(ctx, "test.items"); err != nil {
+		t.Fatal(err)
+	}
+	r, _ := f.projector.projection("test.items")
+
+	// Another projector already moved the checkpoint past position 0.
+	if err := f.db.Model(&Checkpoint{}).Where("name = ?", "test.items").Update("position", records[0].ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := f.projector.apply(ctx, r, 0, records[0]); !errors.Is(err, errCheckpointMoved) {
+		t.Fatalf("apply from a stale position = %v, want errCheckpointMoved", err)
+	}
+	if got := f.items(t); got != "[]" {
+		t.Errorf("read model = %s, want the write rolled back", got)
+	}
+}
+
+func TestRebuildResetsAndReplays(t *testing.T) {
+	f := newProjectorFixture(t)
+	ctx := context.Background()
+	f.add(t, "a", "b", "c")
+	if _, err := f.projector.CatchUp(ctx, "test.items"); err != nil {
+		t.Fatal(err)
+	}
+	// The read model drifted from the log.
+	if err := f.db.Delete(&projectedItem{Value: "b"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := f.db.Create(&projectedItem{Value: "stray"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := f.projector.Rebuild(ctx, "test.items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.projection.resets != 1 {
+		t.Errorf("Reset ran %d times, want 1", f.projection.resets)
+	}
+	if got := f.items(t); got != "[a b c]" {
+		t.Errorf("read model after rebuild = %s, want [a b c]", got)
+	}
+	if status.Position != f.lastPosition(t) || status.RebuiltAt == nil || fmt.Sprint(status.Topics) != "[item.*]" {
+		t.Errorf("status = position %d, rebuilt at %v, topics %v", status.Position, status.RebuiltAt, status.Topics)
+	}
+
+	statuses, err := f.projector.Statuses(ctx)
+	if err != nil || len(statuses) != 1 || statuses[0].Name != "test.items" {
+		t.Errorf("Statuses = %v, %v", statuses, err)
+	}
+}
@@ -0,0 +1,93 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soliton-go/framework/event"
+)
+
+// DeadLetterHandler exposes dead-lettered events for listing, inspection and replay.
+type DeadLetterHandler struct {
+	store *event.DeadLetterStore
+	bus   event.EventBus
+}
+
+// NewDeadLetterHandler creates a DeadLetterHandler.
+// Replay requires bus to be the event.WatermillEventBus the failed handlers are subscribed on.
+func NewDeadLetterHandler(store *event.DeadLetterStore, bus event.EventBus) *DeadLetterHandler {
+	return &DeadLetterHandler{store: store, bus: bus}
+}
+
+// RegisterRoutes registers the dead-letter endpoints under /admin/events/dead-letters.
+func (h *DeadLetterHandler) RegisterRoutes(r gin.IRouter) {
+	g := r.Group("/admin/events/dead-letters")
+	g.GET("", h.List)
+	g.GET("/:id", h.Get)
+	g.POST("/:id/replay", h.Replay)
+}
+
+// List handles GET /admin/events/dead-letters?topic=&event_name=&handler=&include_replayed=&limit=&offset=
+func (h *DeadLetterHandler) List(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	includeReplayed, _ := strconv.ParseBool(c.DefaultQuery("include_replayed", "false"))
+
+	items, total, err := h.store.List(c.Request.Context(), event.DeadLetterFilter{
+		Topic:           c.Query("topic"),
+		EventName:       c.Query("event_name"),
+		Handler:         c.Query("handler"),
+		IncludeReplayed: includeReplayed,
+		Limit:           limit,
+		Offset:          offset,
+	})
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	success(c, gin.H{"items": items, "total": total})
+}
+
+// Get handles GET /admin/events/dead-letters/:id
+func (h *DeadLetterHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		fail(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+	item, err := h.store.Get(c.Request.Context(), id)
+	if err != nil {
+		h.storeError(c, err)
+		return
+	}
+	success(c, item)
+}
+
+// Replay handles POST /admin/events/dead-letters/:id/replay
+func (h *DeadLetterHandler) Replay(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		fail(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if err := h.store.Replay(c.Request.Context(), id, h.bus); err != nil {
+		h.storeError(c, err)
+		return
+	}
+	success(c, gin.H{"id": id, "replayed": true})
+}
+
+func (h *DeadLetterHandler) storeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, event.ErrDeadLetterNotFound), errors.Is(err, event.ErrHandlerNotFound):
+		fail(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, event.ErrDeadLetterNotReplayable):
+		fail(c, http.StatusConflict, err.Error())
+	case errors.Is(err, event.ErrReplayNotSupported):
+		fail(c, http.StatusNotImplemented, err.Error())
+	default:
+		fail(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,57 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soliton-go/framework/event"
+)
+
+// EventReplayHandler replays recorded events to a single named handler.
+type EventReplayHandler struct {
+	replayer *event.Replayer
+}
+
+// NewEventReplayHandler creates an EventReplayHandler.
+func NewEventReplayHandler(replayer *event.Replayer) *EventReplayHandler {
+	return &EventReplayHandler{replayer: replayer}
+}
+
+// RegisterRoutes registers the replay endpoints under /admin/events.
+func (h *EventReplayHandler) RegisterRoutes(r gin.IRouter) {
+	g := r.Group("/admin/events")
+	g.GET("/handlers", h.Handlers)
+	g.POST("/replay", h.Replay)
+}
+
+// Handlers handles GET /admin/events/handlers
+func (h *EventReplayHandler) Handlers(c *gin.Context) {
+	success(c, gin.H{"items": h.replayer.Handlers()})
+}
+
+// Replay handles POST /admin/events/replay with an event.ReplayRequest body.
+// The request returns when the replay is done; a failed replay reports how far it got.
+func (h *EventReplayHandler) Replay(c *gin.Context) {
+	var req event.ReplayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Handler == "" {
+		fail(c, http.StatusBadRequest, "handler is required")
+		return
+	}
+
+	result, err := h.replayer.Replay(c.Request.Context(), req)
+	switch {
+	case err == nil:
+		success(c, result)
+	case errors.Is(err, event.ErrHandlerNotFound):
+		fail(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, event.ErrReplayNotSupported):
+		fail(c, http.StatusNotImplemented, err.Error())
+	default:
+		c.JSON(http.StatusInternalServerError, response{Code: http.StatusInternalServerError, Message: err.Error(), Data: result})
+	}
+}
@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soliton-go/framework/projection"
+)
+
+// ProjectionHandler reports the progress of read model projections and rebuilds them.
+type ProjectionHandler struct {
+	projector *projection.Projector
+}
+
+// NewProjectionHandler creates a ProjectionHandler.
+func NewProjectionHandler(projector *projection.Projector) *ProjectionHandler {
+	return &ProjectionHandler{projector: projector}
+}
+
+// RegisterRoutes registers the projection endpoints under /admin/projections.
+func (h *ProjectionHandler) RegisterRoutes(r gin.IRouter) {
+	g := r.Group("/admin/projections")
+	g.GET("", h.List)
+	g.POST("/:name/rebuild", h.Rebuild)
+}
+
+// List handles GET /admin/projections
+func (h *ProjectionHandler) List(c *gin.Context) {
+	statuses, err := h.projector.Statuses(c.Request.Context())
+	if err != nil {
+		fail(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	success(c, gin.H{"items": statuses})
+}
+
+// Rebuild handles POST /admin/projections/:name/rebuild
+// The request returns when the projection has caught up with the event log.
+func (h *ProjectionHandler) Rebuild(c *gin.Context) {
+	status, err := h.projector.Rebuild(c.Request.Context(), c.Param("name"))
+	switch {
+	case err == nil:
+		success(c, status)
+	case errors.Is(err, projection.ErrProjectionNotFound):
+		fail(c, http.StatusNotFound, err.Error())
+	default:
+		fail(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,22 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// response mirrors the {code, message, data} envelope used by generated HTTP handlers.
+type response struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func success(c *gin.Context, data any) {
+	c.JSON(http.StatusOK, response{Code: 0, Message: "success", Data: data})
+}
+
+func fail(c *gin.Context, status int, message string) {
+	c.JSON(status, response{Code: status, Message: message})
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/soliton-go/framework/event"
+)
+
+// CorrelationIDHeader is the header used to pass correlation IDs between services.
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// RequestIDHeader is accepted as a fallback when no correlation ID is sent.
+const RequestIDHeader = "X-Request-ID"
+
+// CorrelationID stores the request's correlation ID in the request context,
+// so that domain events published while handling it can be traced back to it.
+// A new ID is generated when the client does not send one, and it is echoed in the response.
+// An incoming W3C traceparent header is kept as well, for event.W3CTraceContext.
+func CorrelationID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(CorrelationIDHeader)
+		if id == "" {
+			id = c.GetHeader(RequestIDHeader)
+		}
+		if id == "" {
+			id = uuid.NewString()
+		}
+
+		ctx := event.WithCorrelationID(c.Request.Context(), id)
+		if parent := c.GetHeader("traceparent"); parent != "" {
+			ctx = event.WithTraceContext(ctx, parent, c.GetHeader("tracestate"))
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(CorrelationIDHeader, id)
+		c.Next()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/gin-gonic/gin"
+	"github.com/soliton-go/framework/web/middleware"
 )
 
 // Server is a wrapper around Gin engine.
@@ -14,6 +15,7 @@ type Server struct {
 // NewServer creates a new Server instance.
 func NewServer() *Server {
 	r := gin.Default()
+	r.Use(middleware.CorrelationID())
 	return &Server{engine: r}
 }
 
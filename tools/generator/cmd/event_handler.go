@@ -9,6 +9,7 @@ import (
 )
 
 var handlerTopicFlag string
+var handlerSyncFlag bool
 
 var eventHandlerCmd = &cobra.Command{
 	Use:   "event-handler [domain] [event]",
@@ -17,7 +18,8 @@ var eventHandlerCmd = &cobra.Command{
 
 Examples:
   soliton-gen event-handler user UserCreated
-  soliton-gen event-handler order OrderPaid --topic "order.paid"`,
+  soliton-gen event-handler order OrderPaid --topic "order.paid"
+  soliton-gen event-handler order OrderCreated --sync`,
 	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		domain := args[0]
@@ -27,6 +29,7 @@ Examples:
 			Domain:    domain,
 			EventName: eventName,
 			Topic:     handlerTopicFlag,
+			Sync:      handlerSyncFlag,
 			Force:     forceFlag,
 		}
 
@@ -48,5 +51,6 @@ Examples:
 func init() {
 	rootCmd.AddCommand(eventHandlerCmd)
 	eventHandlerCmd.Flags().StringVar(&handlerTopicFlag, "topic", "", "Event topic (e.g., 'user.created')")
+	eventHandlerCmd.Flags().BoolVar(&handlerSyncFlag, "sync", false, "Run the handler synchronously inside the command transaction")
 	eventHandlerCmd.Flags().BoolVar(&forceFlag, "force", false, "Force overwrite existing files")
 }
@@ -0,0 +1,377 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	eventsAddrFlag  string
+	eventsTokenFlag string
+)
+var dlqTopicFlag string
+var dlqEventNameFlag string
+var dlqLimitFlag int
+var dlqOffsetFlag int
+var dlqAllFlag bool
+var replayHandlerFlag string
+var replayTopicsFlag []string
+var replayFromFlag string
+var replayToFlag string
+var replayAfterIDFlag uint64
+var replayRateFlag float64
+var replayDryRunFlag bool
+
+// eventsCmd groups commands that operate on a running service's event infrastructure
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Inspect the event infrastructure of a running service",
+}
+
+// dlqCmd manages dead-lettered events
+var dlqCmd = &cobra.Command{
+	Use:   "dlq",
+	Short: "List, inspect and replay dead-lettered events",
+	Long: `List, inspect and replay dead-lettered events through the admin API
+(/admin/events/dead-letters) of a running service.
+
+Examples:
+  soliton-gen events dlq list --topic order.created
+  soliton-gen events dlq show 42
+  soliton-gen events dlq replay 42 --addr http://localhost:8080`,
+}
+
+// dlqListCmd lists dead letters
+var dlqListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List dead-lettered events",
+	Run: func(cmd *cobra.Command, args []string) {
+		query := url.Values{}
+		if dlqTopicFlag != "" {
+			query.Set("topic", dlqTopicFlag)
+		}
+		if dlqEventNameFlag != "" {
+			query.Set("event_name", dlqEventNameFlag)
+		}
+		query.Set("limit", strconv.Itoa(dlqLimitFlag))
+		query.Set("offset", strconv.Itoa(dlqOffsetFlag))
+		query.Set("include_replayed", strconv.FormatBool(dlqAllFlag))
+
+		var page struct {
+			Items []deadLetterView `json:"items"`
+			Total int64            `json:"total"`
+		}
+		if err := callAdminAPI(http.MethodGet, "/admin/events/dead-letters?"+query.Encode(), &page); err != nil {
+			fmt.Printf("❌ 错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(page.Items) == 0 {
+			fmt.Println("没有死信消息")
+			return
+		}
+
+		fmt.Printf("共 %d 条死信消息（显示 %d 条）：\n\n", page.Total, len(page.Items))
+		for _, item := range page.Items {
+			replayed := ""
+			if item.ReplayedAt != nil {
+				replayed = " [已重放]"
+			}
+			fmt.Printf("  • #%d %s %s (尝试 %d 次)%s\n", item.ID, item.Topic, item.EventName, item.Attempts, replayed)
+			fmt.Printf("    %s  %s\n", item.CreatedAt.Format(time.DateTime), firstLine(item.Error))
+		}
+	},
+}
+
+// dlqShowCmd shows a single dead letter
+var dlqShowCmd = &cobra.Command{
+	Use:   "show [id]",
+	Short: "Show a dead-lettered event",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var item deadLetterView
+		if err := callAdminAPI(http.MethodGet, "/admin/events/dead-letters/"+url.PathEscape(args[0]), &item); err != nil {
+			fmt.Printf("❌ 错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("死信 #%d\n\n", item.ID)
+		fmt.Printf("  消息 ID:   %s\n", item.MessageID)
+		fmt.Printf("  事件:      %s\n", item.EventName)
+		fmt.Printf("  原主题:    %s\n", item.Topic)
+		fmt.Printf("  处理器:    %s\n", item.Handler)
+		fmt.Printf("  死信主题:  %s\n", item.DeadLetterTopic)
+		fmt.Printf("  尝试次数:  %d\n", item.Attempts)
+		fmt.Printf("  时间:      %s\n", item.CreatedAt.Format(time.DateTime))
+		if item.ReplayedAt != nil {
+			fmt.Printf("  重放时间:  %s\n", item.ReplayedAt.Format(time.DateTime))
+		}
+		fmt.Printf("  错误:      %s\n", item.Error)
+		fmt.Printf("\n  元数据:\n%s\n", indentJSON(item.Metadata))
+		fmt.Printf("\n  内容:\n%s\n", indentJSON(string(item.Payload)))
+	},
+}
+
+// dlqReplayCmd replays a dead letter to the handler that failed on it
+var dlqReplayCmd = &cobra.Command{
+	Use:   "replay [id]",
+	Short: "Replay a dead-lettered event to the handler that failed on it",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := callAdminAPI(http.MethodPost, "/admin/events/dead-letters/"+url.PathEscape(args[0])+"/replay", nil); err != nil {
+			fmt.Printf("❌ 重放失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ 已重放死信 #%s\n", args[0])
+	},
+}
+
+// eventsHandlersCmd lists the handlers events can be replayed to
+var eventsHandlersCmd = &cobra.Command{
+	Use:   "handlers",
+	Short: "List the named event handlers of a running service",
+	Run: func(cmd *cobra.Command, args []string) {
+		var page struct {
+			Items []string `json:"items"`
+		}
+		if err := callAdminAPI(http.MethodGet, "/admin/events/handlers", &page); err != nil {
+			fmt.Printf("❌ 错误: %v\n", err)
+			os.Exit(1)
+		}
+		if len(page.Items) == 0 {
+			fmt.Println("没有命名的事件处理器（订阅时使用 event.WithHandlerName 命名）")
+			return
+		}
+		for _, name := range page.Items {
+			fmt.Printf("  • %s\n", name)
+		}
+	},
+}
+
+// eventsReplayCmd replays recorded events to one named handler
+var eventsReplayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Replay recorded events to one named handler",
+	Long: `Replay events recorded in event_records to one named handler of a running service,
+through the admin API (/admin/events/replay). Other handlers do not see the replayed events.
+Use it to rebuild a projection or to backfill a new handler from history.
+
+--from and --to accept RFC 3339 times or dates (2006-01-02); --to is exclusive.
+A failed replay prints the last delivered position; pass it to --after-id to resume.
+
+Examples:
+  soliton-gen events replay --handler report.SalesReportHandler --dry-run
+  soliton-gen events replay --handler report.SalesReportHandler --from 2026-01-01 --rate 200
+  soliton-gen events replay --handler report.SalesReportHandler --topic order.paid --after-id 1200`,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, err := parseReplayTime(replayFromFlag)
+		if err != nil {
+			fmt.Printf("❌ 无效的 --from: %v\n", err)
+			os.Exit(1)
+		}
+		to, err := parseReplayTime(replayToFlag)
+		if err != nil {
+			fmt.Printf("❌ 无效的 --to: %v\n", err)
+			os.Exit(1)
+		}
+
+		req := replayRequest{
+			Handler: replayHandlerFlag,
+			Topics:  replayTopicsFlag,
+			AfterID: replayAfterIDFlag,
+			Rate:    replayRateFlag,
+			DryRun:  replayDryRunFlag,
+		}
+		if !from.IsZero() {
+			req.From = &from
+		}
+		if !to.IsZero() {
+			req.To = &to
+		}
+
+		var result replayResult
+		// Replays run until done, so the request has no timeout
+		if err := doAdminAPI(http.MethodPost, "/admin/events/replay", req, 0, &result); err != nil {
+			fmt.Printf("❌ 重放失败: %v\n", err)
+			if result.LastID > 0 {
+				fmt.Printf("   已投递 %d 条，最后位置 #%d，可使用 --after-id %d 继续\n", result.Delivered, result.LastID, result.LastID)
+			}
+			os.Exit(1)
+		}
+
+		if result.DryRun {
+			fmt.Printf("🔍 试运行：%s 将收到 %d 条事件", result.Handler, result.Matched)
+			if result.Matched > 0 {
+				fmt.Printf("（位置 #%d - #%d）", result.FirstID, result.LastID)
+			}
+			fmt.Println()
+			return
+		}
+		fmt.Printf("✅ 已向 %s 重放 %d 条事件", result.Handler, result.Delivered)
+		if result.Delivered > 0 {
+			fmt.Printf("（位置 #%d - #%d）", result.FirstID, result.LastID)
+		}
+		fmt.Println()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(eventsCmd)
+	eventsCmd.PersistentFlags().StringVar(&eventsAddrFlag, "addr", "http://localhost:8080", "Base URL of the running service")
+	eventsCmd.PersistentFlags().StringVar(&eventsTokenFlag, "token", os.Getenv("ADMIN_TOKEN"), "Admin token of the service (admin.token); defaults to $ADMIN_TOKEN")
+
+	eventsCmd.AddCommand(dlqCmd)
+	dlqCmd.AddCommand(dlqListCmd)
+	dlqCmd.AddCommand(dlqShowCmd)
+	dlqCmd.AddCommand(dlqReplayCmd)
+
+	dlqListCmd.Flags().StringVar(&dlqTopicFlag, "topic", "", "Filter by original topic")
+	dlqListCmd.Flags().StringVar(&dlqEventNameFlag, "event", "", "Filter by event name")
+	dlqListCmd.Flags().IntVar(&dlqLimitFlag, "limit", 50, "Maximum number of entries")
+	dlqListCmd.Flags().IntVar(&dlqOffsetFlag, "offset", 0, "Number of entries to skip")
+	dlqListCmd.Flags().BoolVar(&dlqAllFlag, "all", false, "Include already replayed entries")
+
+	eventsCmd.AddCommand(eventsHandlersCmd)
+	eventsCmd.AddCommand(eventsReplayCmd)
+	eventsReplayCmd.Flags().StringVar(&replayHandlerFlag, "handler", "", "Name of the handler to replay to (required)")
+	eventsReplayCmd.Flags().StringSliceVar(&replayTopicsFlag, "topic", nil, "Topics or patterns to replay (default: the handler's topic)")
+	eventsReplayCmd.Flags().StringVar(&replayFromFlag, "from", "", "Replay events that occurred at or after this time")
+	eventsReplayCmd.Flags().StringVar(&replayToFlag, "to", "", "Replay events that occurred before this time")
+	eventsReplayCmd.Flags().Uint64Var(&replayAfterIDFlag, "after-id", 0, "Resume after this event log position")
+	eventsReplayCmd.Flags().Float64Var(&replayRateFlag, "rate", 0, "Maximum events per second (0: unlimited)")
+	eventsReplayCmd.Flags().BoolVar(&replayDryRunFlag, "dry-run", false, "Count the matching events without delivering them")
+	_ = eventsReplayCmd.MarkFlagRequired("handler")
+}
+
+// replayRequest mirrors event.ReplayRequest as accepted by the admin API
+type replayRequest struct {
+	Handler string     `json:"handler"`
+	Topics  []string   `json:"topics,omitempty"`
+	From    *time.Time `json:"from,omitempty"`
+	To      *time.Time `json:"to,omitempty"`
+	AfterID uint64     `json:"after_id,omitempty"`
+	Rate    float64    `json:"rate,omitempty"`
+	DryRun  bool       `json:"dry_run"`
+}
+
+// replayResult mirrors event.ReplayResult as returned by the admin API
+type replayResult struct {
+	Handler   string `json:"handler"`
+	DryRun    bool   `json:"dry_run"`
+	Matched   int    `json:"matched"`
+	Delivered int    `json:"delivered"`
+	FirstID   uint64 `json:"first_id"`
+	LastID    uint64 `json:"last_id"`
+}
+
+// parseReplayTime parses an RFC 3339 time or a local date; an empty value is the zero time
+func parseReplayTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateTime, value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, value, time.Local)
+}
+
+// deadLetterView mirrors event.DeadLetter as returned by the admin API
+type deadLetterView struct {
+	ID              uint64     `json:"id"`
+	MessageID       string     `json:"message_id"`
+	Topic           string     `json:"topic"`
+	DeadLetterTopic string     `json:"dead_letter_topic"`
+	EventName       string     `json:"event_name"`
+	Handler         string     `json:"handler"`
+	Payload         []byte     `json:"payload"`
+	Metadata        string     `json:"metadata"`
+	Error           string     `json:"error"`
+	Attempts        int        `json:"attempts"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ReplayedAt      *time.Time `json:"replayed_at"`
+}
+
+// callAdminAPI calls the service and decodes the data field of the {code, message, data} response
+func callAdminAPI(method, path string, out any) error {
+	return doAdminAPI(method, path, nil, 10*time.Second, out)
+}
+
+// doAdminAPI sends body as JSON, if any, and decodes the data field of the response into out,
+// also when the call fails. A zero timeout waits indefinitely.
+func doAdminAPI(method, path string, body any, timeout time.Duration, out any) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, strings.TrimRight(eventsAddrFlag, "/")+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if eventsTokenFlag != "" {
+		req.Header.Set("Authorization", "Bearer "+eventsTokenFlag)
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var envelope struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return fmt.Errorf("unexpected response (HTTP %d): %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if out != nil && len(envelope.Data) > 0 {
+		if err := json.Unmarshal(envelope.Data, out); err != nil {
+			return err
+		}
+	}
+	if resp.StatusCode >= http.StatusBadRequest || envelope.Code != 0 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, envelope.Message)
+	}
+	return nil
+}
+
+func indentJSON(raw string) string {
+	var v any
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return "    " + raw
+	}
+	b, _ := json.MarshalIndent(v, "    ", "  ")
+	return "    " + string(b)
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
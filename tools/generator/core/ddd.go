@@ -323,6 +323,7 @@ func generateEventHandlerInternal(cfg EventHandlerConfig, previewOnly bool) (*Ge
 		HandlerName:     handlerName,
 		EventTopic:      eventTopic,
 		ModulePath:      layout.ModulePath,
+		Sync:            cfg.Sync,
 	}
 
 	fileName := fmt.Sprintf("event_handler_%s.go", ToSnakeCase(strings.TrimSuffix(eventStructName, "Event")))
@@ -335,12 +336,12 @@ func generateEventHandlerInternal(cfg EventHandlerConfig, previewOnly bool) (*Ge
 		if modulePreview := previewModuleForEventHandler(modulePath, handlerName); modulePreview != nil {
 			result.Files = append(result.Files, *modulePreview)
 		}
-		if mainPreview := previewEventBusProvider(mainGoPath); mainPreview != nil {
+		if mainPreview := previewEventBusProvider(mainGoPath, cfg.Sync); mainPreview != nil {
 			result.Files = append(result.Files, *mainPreview)
 		}
 	} else {
 		_ = updateModuleForEventHandler(modulePath, handlerName)
-		_ = ensureEventBusProvider(mainGoPath)
+		_ = ensureEventBusProvider(mainGoPath, cfg.Sync)
 	}
 
 	result.Message = fmt.Sprintf("Event Handler %s 生成成功", handlerName)
@@ -461,7 +462,7 @@ func updateModuleContentForEventHandler(content string, handlerName string) (str
 	return result, modified
 }
 
-func ensureEventBusProviderContent(content string) (string, bool) {
+func ensureEventBusProviderContent(content string, sync bool) (string, bool) {
 	result := content
 	modified := false
 
@@ -481,10 +482,12 @@ func ensureEventBusProviderContent(content string) (string, bool) {
 
 	provider := "func() event.EventBus { return event.NewLocalEventBus() },"
 	legacyProvider := "event.NewLocalEventBus,"
-	if strings.Contains(result, legacyProvider) && !strings.Contains(result, provider) {
+	// Any constructor returning event.EventBus (e.g. one built from the transport config) counts.
+	hasProvider := strings.Contains(result, ") event.EventBus {") || strings.Contains(result, "(event.EventBus, error) {")
+	if strings.Contains(result, legacyProvider) && !hasProvider {
 		result = strings.Replace(result, legacyProvider, provider, 1)
 		modified = true
-	} else if !strings.Contains(result, provider) {
+	} else if !hasProvider {
 		if strings.Contains(result, "// soliton-gen:providers") {
 			result = strings.Replace(result,
 				"\t\t// soliton-gen:providers",
@@ -500,6 +503,24 @@ func ensureEventBusProviderContent(content string) (string, bool) {
 		}
 	}
 
+	// Synchronous handlers subscribe to the in-transaction dispatcher instead of the bus.
+	syncProvider := "event.NewSyncDispatcher,"
+	if sync && !strings.Contains(result, syncProvider) {
+		if strings.Contains(result, "// soliton-gen:providers") {
+			result = strings.Replace(result,
+				"\t\t// soliton-gen:providers",
+				"\t\t"+syncProvider+"\n\t\t// soliton-gen:providers",
+				1)
+			modified = true
+		} else if strings.Contains(result, "\t\tNewRouter,") {
+			result = strings.Replace(result,
+				"\t\tNewRouter,",
+				"\t\t"+syncProvider+"\n\t\tNewRouter,",
+				1)
+			modified = true
+		}
+	}
+
 	return result, modified
 }
 
@@ -519,12 +540,12 @@ func previewModuleForEventHandler(path, handlerName string) *GeneratedFile {
 	}
 }
 
-func previewEventBusProvider(mainGoPath string) *GeneratedFile {
+func previewEventBusProvider(mainGoPath string, sync bool) *GeneratedFile {
 	content, err := os.ReadFile(mainGoPath)
 	if err != nil {
 		return nil
 	}
-	updated, modified := ensureEventBusProviderContent(string(content))
+	updated, modified := ensureEventBusProviderContent(string(content), sync)
 	if !modified {
 		return nil
 	}
@@ -549,12 +570,12 @@ func updateModuleForEventHandler(path, handlerName string) bool {
 	return os.WriteFile(path, []byte(result), 0644) == nil
 }
 
-func ensureEventBusProvider(mainGoPath string) bool {
+func ensureEventBusProvider(mainGoPath string, sync bool) bool {
 	content, err := os.ReadFile(mainGoPath)
 	if err != nil {
 		return false
 	}
-	result, modified := ensureEventBusProviderContent(string(content))
+	result, modified := ensureEventBusProviderContent(string(content), sync)
 
 	if !modified {
 		return true
@@ -347,6 +347,12 @@ func wireMainGoNew(mainGoPath, entityName, packageName, modulePath, original str
 		modified = true
 	}
 
+	// 6. Ensure the event publisher used by generated repositories
+	if updated, ok := ensureEventPublisherProviderContent(result); ok {
+		result = updated
+		modified = true
+	}
+
 	if !modified {
 		return true // Already wired
 	}
@@ -354,6 +360,34 @@ func wireMainGoNew(mainGoPath, entityName, packageName, modulePath, original str
 	return os.WriteFile(mainGoPath, []byte(result), 0644) == nil
 }
 
+// ensureEventPublisherProviderContent adds an event.Publisher provider to projects created
+// before repositories published domain events. Projects with an outbox and a sync dispatcher
+// get the same publisher as new projects; older ones publish straight to the event bus.
+func ensureEventPublisherProviderContent(content string) (string, bool) {
+	if strings.Contains(content, ") event.Publisher {") {
+		return content, false
+	}
+
+	result, _ := ensureEventBusProviderContent(content, false)
+	provider := "func(bus event.EventBus) event.Publisher { return bus },"
+	if strings.Contains(result, "event.NewOutbox,") && strings.Contains(result, "event.NewSyncDispatcher,") {
+		provider = "func(dispatcher *event.SyncDispatcher, outbox *event.Outbox) event.Publisher { return event.Publishers{dispatcher, outbox} },"
+	}
+	if strings.Contains(result, "// soliton-gen:providers") {
+		return strings.Replace(result,
+			"\t\t// soliton-gen:providers",
+			"\t\t"+provider+"\n\t\t// soliton-gen:providers",
+			1), true
+	}
+	if strings.Contains(result, "\t\tNewRouter,") {
+		return strings.Replace(result,
+			"\t\tNewRouter,",
+			"\t\t"+provider+"\n\t\tNewRouter,",
+			1), true
+	}
+	return content, false
+}
+
 // WireMigrateGo attempts to inject migration calls into cmd/migrate/main.go (or legacy cmd/migrate.go) using marker comments.
 func WireMigrateGo(migrateGoPath, entityName, packageName, modulePath string) bool {
 	content, err := os.ReadFile(migrateGoPath)
@@ -180,7 +180,7 @@ func parseEnumValues(raw string) []string {
 // isReservedField checks if a field name is reserved.
 func isReservedField(snakeName string) bool {
 	switch snakeName {
-	case "id", "created_at", "updated_at", "deleted_at":
+	case "id", "version", "created_at", "updated_at", "deleted_at":
 		return true
 	default:
 		return false
@@ -216,6 +216,7 @@ func convertToFieldsInternal(configs []FieldConfig, entityName, packageName stri
 	// Built-in fields that should be skipped (already defined in template)
 	builtinFields := map[string]bool{
 		"id":        true,
+		"version":   true,
 		"createdat": true,
 		"updatedat": true,
 		"deletedat": true,
@@ -255,12 +256,14 @@ func convertFieldConfig(cfg FieldConfig, entityName, packageName string) Field {
 		field.EnumType = entityName + pascalName
 		field.GoType = field.EnumType
 		field.AppGoType = packageName + "." + field.EnumType
-		field.GormTag = fmt.Sprintf("`gorm:\"size:50;default:'%s'\"`", field.EnumValues[0])
+		field.GormTag = fmt.Sprintf("`gorm:\"size:50;index;default:'%s'\"`", field.EnumValues[0])
+		field.Indexed = true
 		field.JsonTag = fmt.Sprintf("`json:\"%s\"`", snakeName)
 	} else {
 		field.GoType, field.GormTag = mapFieldType(cfg.Type, snakeName)
 		field.AppGoType = field.GoType
 		field.IsPointer = strings.HasPrefix(field.GoType, "*")
+		field.Indexed = strings.Contains(field.GormTag, "index")
 		field.JsonTag = fmt.Sprintf("`json:\"%s\"`", snakeName)
 	}
 
@@ -268,9 +271,13 @@ func convertFieldConfig(cfg FieldConfig, entityName, packageName string) Field {
 }
 
 // mapFieldType maps type shorthand to Go type and GORM tag.
+// String references to other aggregates (e.g. user_id) are indexed.
 func mapFieldType(typeName string, snakeName string) (goType, gormTag string) {
 	switch strings.ToLower(typeName) {
 	case "string", "str", "":
+		if strings.HasSuffix(snakeName, "_id") {
+			return "string", "`gorm:\"size:255;index\"`"
+		}
 		return "string", "`gorm:\"size:255\"`"
 	case "text":
 		return "string", "`gorm:\"type:text\"`"
@@ -153,6 +153,7 @@ func initProjectInternal(cfg ProjectConfig, previewOnly bool) (*GenerationResult
 		{"configs/config.yaml", ConfigTemplate},
 		{"configs/config.example.yaml", ConfigExampleTemplate},
 		{"internal/interfaces/http/response.go", ResponseTemplate},
+		{"internal/interfaces/http/command_handler.go", CommandHandlerTemplate},
 		{".gitignore", GitignoreTemplate},
 		{"README.md", ReadmeTemplate},
 		{"Makefile", MakefileTemplate},
@@ -146,6 +146,8 @@ func init() {
 	event.RegisterEvent("{{.EventTopic}}", func() ddd.DomainEvent {
 		return &{{.EventStructName}}{}
 	})
+	// 字段发生不兼容变更时，注册从旧版本到新版本的升级函数，已存储或传输中的旧消息会先转换再反序列化：
+	// event.RegisterUpcaster("{{.EventTopic}}", 1, func(payload []byte) ([]byte, error) { ... })
 }
 `
 
@@ -181,11 +183,17 @@ func (h *{{.HandlerName}}) Handle(ctx context.Context, evt ddd.DomainEvent) erro
 	return nil
 }
 
-// Register{{.HandlerName}} 注册事件处理器。
+{{if .Sync -}}
+// Register{{.HandlerName}} 将事件处理器注册到同步分发器。
+// 处理器在发布事件的命令事务内同步执行，返回错误时整个命令回滚。
+func Register{{.HandlerName}}(lc fx.Lifecycle, bus *event.SyncDispatcher, handler *{{.HandlerName}}) {
+{{- else -}}
+// Register{{.HandlerName}} 注册事件处理器，处理器名用于事件重放（soliton-gen events replay --handler）。
 func Register{{.HandlerName}}(lc fx.Lifecycle, bus event.EventBus, handler *{{.HandlerName}}) {
+{{- end}}
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			return bus.Subscribe(ctx, "{{.EventTopic}}", handler.Handle)
+			return bus.Subscribe(ctx, "{{.EventTopic}}", handler.Handle{{if not .Sync}}, event.WithHandlerName("{{.DomainPackage}}.{{.HandlerName}}"){{end}})
 		},
 		OnStop: func(ctx context.Context) error {
 			return nil
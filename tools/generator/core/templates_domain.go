@@ -51,8 +51,9 @@ type {{.EntityName}} struct {
 {{- range .Fields}}
 	{{.Name}} {{.GoType}} {{.GormTag}}{{if .Comment}} // {{.Comment}}{{end}}
 {{- end}}
-	CreatedAt time.Time ` + "`gorm:\"autoCreateTime\"`" + `
-	UpdatedAt time.Time ` + "`gorm:\"autoUpdateTime\"`" + `
+	Version int64 ` + "`gorm:\"not null;default:0\"`" + ` // 乐观锁版本号
+	CreatedAt time.Time ` + "`gorm:\"autoCreateTime;index\"`" + `
+	UpdatedAt time.Time ` + "`gorm:\"autoUpdateTime;index\"`" + `
 {{- if .SoftDelete}}
 	DeletedAt gorm.DeletedAt ` + "`gorm:\"index\"`" + `
 {{- end}}
@@ -91,10 +92,25 @@ func (e *{{.EntityName}}) Update({{range $i, $f := .Fields}}{{if $i}}, {{end}}{{
 	e.AddDomainEvent(New{{.EntityName}}UpdatedEvent(string(e.ID)))
 }
 
+// MarkDeleted 记录 {{.EntityName}}DeletedEvent，随后由仓储的 Remove 删除实体并发布该事件。
+func (e *{{.EntityName}}) MarkDeleted() {
+	e.AddDomainEvent(New{{.EntityName}}DeletedEvent(string(e.ID)))
+}
+
 // GetID 返回实体 ID。
 func (e *{{.EntityName}}) GetID() ddd.ID {
 	return e.ID
 }
+
+// GetVersion 返回乐观锁版本号。
+func (e *{{.EntityName}}) GetVersion() int64 {
+	return e.Version
+}
+
+// SetVersion 设置乐观锁版本号（由仓储在保存成功后维护）。
+func (e *{{.EntityName}}) SetVersion(version int64) {
+	e.Version = version
+}
 `
 
 const DomainServiceTemplate = `package {{.PackageName}}
@@ -129,8 +145,12 @@ import (
 // {{.EntityName}}Repository 定义 {{.EntityName}} 的持久化接口。
 type {{.EntityName}}Repository interface {
 	orm.Repository[*{{.EntityName}}, {{.EntityName}}ID]
-	// FindPaginated 返回分页数据和总数。
-	FindPaginated(ctx context.Context, page, pageSize int, sortBy, sortOrder string) ([]*{{.EntityName}}, int64, error)
+	// FindByCriteria 按条件、排序和分页查询，返回当前页数据和总数。
+	orm.CriteriaRepository[*{{.EntityName}}]
+	// FindByCursor 按游标分页查询，适合大表的连续翻页。
+	orm.CursorRepository[*{{.EntityName}}]
+	// Remove 删除实体并发布其记录的领域事件（如 MarkDeleted 记录的删除事件）。
+	Remove(ctx context.Context, entity *{{.EntityName}}) error
 }
 `
 
@@ -155,7 +175,7 @@ func (e {{.EntityName}}CreatedEvent) EventName() string {
 
 func New{{.EntityName}}CreatedEvent(id string) {{.EntityName}}CreatedEvent {
 	return {{.EntityName}}CreatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("{{.PackageName}}", id),
 		{{.EntityName}}ID: id,
 	}
 }
@@ -172,7 +192,7 @@ func (e {{.EntityName}}UpdatedEvent) EventName() string {
 
 func New{{.EntityName}}UpdatedEvent(id string) {{.EntityName}}UpdatedEvent {
 	return {{.EntityName}}UpdatedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("{{.PackageName}}", id),
 		{{.EntityName}}ID: id,
 	}
 }
@@ -190,7 +210,7 @@ func (e {{.EntityName}}DeletedEvent) EventName() string {
 
 func New{{.EntityName}}DeletedEvent(id string) {{.EntityName}}DeletedEvent {
 	return {{.EntityName}}DeletedEvent{
-		BaseDomainEvent: ddd.NewBaseDomainEvent(),
+		BaseDomainEvent: ddd.NewAggregateEvent("{{.PackageName}}", id),
 		{{.EntityName}}ID: id,
 		DeletedAt: time.Now(),
 	}
@@ -214,52 +234,51 @@ const RepoImplTemplate = `package persistence
 
 import (
 	"context"
-	"fmt"
 
 	"{{.ModulePath}}/internal/domain/{{.PackageName}}"
+	"github.com/soliton-go/framework/event"
 	"github.com/soliton-go/framework/orm"
 	"gorm.io/gorm"
 )
 
 type {{.EntityName}}RepoImpl struct {
-	*orm.GormRepository[*{{.PackageName}}.{{.EntityName}}, {{.PackageName}}.{{.EntityName}}ID]
-	db *gorm.DB
+	*event.PublishingRepository[*{{.PackageName}}.{{.EntityName}}, {{.PackageName}}.{{.EntityName}}ID]
+	criteria *orm.GormRepository[*{{.PackageName}}.{{.EntityName}}, {{.PackageName}}.{{.EntityName}}ID]
+	db       *gorm.DB
 }
 
-func New{{.EntityName}}Repository(db *gorm.DB) {{.PackageName}}.{{.EntityName}}Repository {
+// New{{.EntityName}}Repository 创建仓储；保存聚合根后将其领域事件交给 publisher。
+func New{{.EntityName}}Repository(db *gorm.DB, publisher event.Publisher) {{.PackageName}}.{{.EntityName}}Repository {
+	// 列表只允许按带索引的列排序
+	repo := orm.NewGormRepository[*{{.PackageName}}.{{.EntityName}}, {{.PackageName}}.{{.EntityName}}ID](db,
+		orm.WithSortable("id", "created_at", "updated_at"{{range .Fields}}{{if .Indexed}}, "{{.SnakeName}}"{{end}}{{end}}),
+	)
 	return &{{.EntityName}}RepoImpl{
-		GormRepository: orm.NewGormRepository[*{{.PackageName}}.{{.EntityName}}, {{.PackageName}}.{{.EntityName}}ID](db),
-		db:             db,
+		PublishingRepository: event.NewPublishingRepository[*{{.PackageName}}.{{.EntityName}}, {{.PackageName}}.{{.EntityName}}ID](
+			repo,
+			publisher,
+			event.WithPublishTransaction(db),
+		),
+		criteria: repo,
+		db:       db,
 	}
 }
 
-// FindPaginated 返回分页数据和总数。
-func (r *{{.EntityName}}RepoImpl) FindPaginated(ctx context.Context, page, pageSize int, sortBy, sortOrder string) ([]*{{.PackageName}}.{{.EntityName}}, int64, error) {
-	var entities []*{{.PackageName}}.{{.EntityName}}
-	var total int64
-
-	// 查询总数
-	baseQuery := r.db.WithContext(ctx).Model(&{{.PackageName}}.{{.EntityName}}{})
-	if err := baseQuery.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// 获取分页数据
-	offset := (page - 1) * pageSize
-	query := r.db.WithContext(ctx).Offset(offset).Limit(pageSize)
-	if sortBy != "" {
-		query = query.Order(fmt.Sprintf("%s %s", sortBy, sortOrder))
-	}
-	if err := query.Find(&entities).Error; err != nil {
-		return nil, 0, err
-	}
+// FindByCriteria 按条件、排序和分页查询，返回当前页数据和总数。
+// 列名须是 {{.EntityName}} 的数据库列名，排序字段限于仓储的排序白名单，否则返回 orm.ErrInvalidCriteria。
+func (r *{{.EntityName}}RepoImpl) FindByCriteria(ctx context.Context, criteria orm.Criteria) ([]*{{.PackageName}}.{{.EntityName}}, int64, error) {
+	return r.criteria.FindByCriteria(ctx, criteria)
+}
 
-	return entities, total, nil
+// FindByCursor 返回 criteria.Cursor 之后的至多 criteria.Limit 条数据和下一页的游标。
+// 游标只能用于签发时的排序方式，否则返回 orm.ErrInvalidCriteria。
+func (r *{{.EntityName}}RepoImpl) FindByCursor(ctx context.Context, criteria orm.Criteria) (orm.CursorPage[*{{.PackageName}}.{{.EntityName}}], error) {
+	return r.criteria.FindByCursor(ctx, criteria)
 }
 
 // Migrate{{.EntityName}} 创建数据库表（如不存在）。
 func Migrate{{.EntityName}}(db *gorm.DB) error {
-	return db.AutoMigrate(&{{.PackageName}}.{{.EntityName}}{})
+	return orm.Primary(db).AutoMigrate(&{{.PackageName}}.{{.EntityName}}{})
 }
 `
 
@@ -267,12 +286,14 @@ const CommandsTemplate = `package {{.PackageName}}app
 
 import (
 	"context"
+	"errors"
 {{- if .HasTime}}
 	"time"
 {{- end}}
 {{- if .HasJSON}}
 	"gorm.io/datatypes"
 {{- end}}
+	"gorm.io/gorm"
 
 	"{{.ModulePath}}/internal/domain/{{.PackageName}}"
 )
@@ -289,8 +310,6 @@ type Create{{.EntityName}}Command struct {
 type Create{{.EntityName}}Handler struct {
 	repo {{.PackageName}}.{{.EntityName}}Repository
 	service *{{.PackageName}}.{{.EntityName}}DomainService
-	// 可选：添加事件总线用于发布领域事件
-	// eventBus event.EventBus
 }
 
 func NewCreate{{.EntityName}}Handler(repo {{.PackageName}}.{{.EntityName}}Repository, service *{{.PackageName}}.{{.EntityName}}DomainService) *Create{{.EntityName}}Handler {
@@ -299,19 +318,11 @@ func NewCreate{{.EntityName}}Handler(repo {{.PackageName}}.{{.EntityName}}Reposi
 
 func (h *Create{{.EntityName}}Handler) Handle(ctx context.Context, cmd Create{{.EntityName}}Command) (*{{.PackageName}}.{{.EntityName}}, error) {
 	entity := {{.PackageName}}.New{{.EntityName}}(cmd.ID{{range .Fields}}, cmd.{{.Name}}{{end}})
+	// 保存成功后，仓储会自动发布实体产生的领域事件（见 event.PublishingRepository）
 	if err := h.repo.Save(ctx, entity); err != nil {
 		return nil, err
 	}
 
-	// 可选：发布领域事件
-	// 取消注释以启用事件发布：
-	// events := entity.PullDomainEvents()
-	// if len(events) > 0 {
-	//     if err := h.eventBus.Publish(ctx, events...); err != nil {
-	//         return nil, err
-	//     }
-	// }
-
 	return entity, nil
 }
 
@@ -360,8 +371,18 @@ func NewDelete{{.EntityName}}Handler(repo {{.PackageName}}.{{.EntityName}}Reposi
 	return &Delete{{.EntityName}}Handler{repo: repo, service: service}
 }
 
+// Handle 加载 {{.EntityName}} 并记录删除事件后删除，使投影等订阅者收到 {{.EntityName}}DeletedEvent；
+// {{.EntityName}} 不存在时视为已删除。
 func (h *Delete{{.EntityName}}Handler) Handle(ctx context.Context, cmd Delete{{.EntityName}}Command) error {
-	return h.repo.Delete(ctx, {{.PackageName}}.{{.EntityName}}ID(cmd.ID))
+	entity, err := h.repo.Find(ctx, {{.PackageName}}.{{.EntityName}}ID(cmd.ID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	entity.MarkDeleted()
+	return h.repo.Remove(ctx, entity)
 }
 `
 
@@ -371,6 +392,8 @@ import (
 	"context"
 	"strings"
 
+	"github.com/soliton-go/framework/orm"
+
 	"{{.ModulePath}}/internal/domain/{{.PackageName}}"
 )
 
@@ -396,17 +419,22 @@ func (h *Get{{.EntityName}}Handler) Handle(ctx context.Context, query Get{{.Enti
 type List{{.EntityName}}sQuery struct {
 	Page     int // 页码（从 1 开始）
 	PageSize int // 每页数量（默认: 20, 最大: 100）
-	SortBy   string // 排序字段（默认: id）
-	SortOrder string // 排序方式（asc/desc）
+	SortBy   string // 排序字段（默认: id），须在仓储的排序白名单中
+	SortOrder string // 排序方式（asc/desc，默认: desc）
+	Filters  []orm.Condition // 过滤条件，按 AND 组合
+	Cursor    string // 游标（上一页的 next_cursor）；设置 Cursor 或 Limit 时按游标分页，忽略页码
+	Limit     int    // 游标分页的每页数量（默认: 20, 最大: 100）
+	WithTotal bool   // 游标分页时是否统计总数
 }
 
 // List{{.EntityName}}sResult 是分页查询结果。
 type List{{.EntityName}}sResult struct {
 	Items      []*{{.PackageName}}.{{.EntityName}}
-	Total      int64
+	Total      int64 // 游标分页且未要求统计时为 -1
 	Page       int
 	PageSize   int
 	TotalPages int
+	NextCursor string // 游标分页时下一页的游标，为空表示没有更多数据
 }
 
 // List{{.EntityName}}sHandler 处理 List{{.EntityName}}sQuery。
@@ -432,33 +460,23 @@ func (h *List{{.EntityName}}sHandler) Handle(ctx context.Context, query List{{.E
 		pageSize = 100
 	}
 
-	// 排序字段白名单
-	sortBy := strings.ToLower(strings.TrimSpace(query.SortBy))
-	sortOrder := strings.ToLower(strings.TrimSpace(query.SortOrder))
-	if sortOrder != "asc" && sortOrder != "desc" {
-		sortOrder = "desc"
-	}
-	allowedSorts := map[string]struct{}{
-		"id":         {},
-		"created_at": {},
-		"updated_at": {},
-{{- range .Fields}}
-		"{{.SnakeName}}": {},
-{{- end}}
-	}
-	if _, ok := allowedSorts[sortBy]; !ok {
+	// 排序字段和过滤列由仓储校验，无效时返回 orm.ErrInvalidCriteria
+	sortBy := query.SortBy
+	if strings.TrimSpace(sortBy) == "" {
 		sortBy = "id"
 	}
+	criteria := orm.NewCriteria(query.Filters...).
+		OrderBy(orm.ParseSort(sortBy, query.SortOrder))
 
-	// 获取总数和分页数据
-	items, total, err := h.repo.FindPaginated(ctx, page, pageSize, sortBy, sortOrder)
-	if err != nil {
-		return nil, err
+	if query.Cursor != "" || query.Limit > 0 {
+		return h.handleCursor(ctx, criteria, query)
 	}
 
-	totalPages := int(total) / pageSize
-	if int(total)%pageSize > 0 {
-		totalPages++
+	criteria = criteria.Paginate(page, pageSize)
+
+	items, total, err := h.repo.FindByCriteria(ctx, criteria)
+	if err != nil {
+		return nil, err
 	}
 
 	return &List{{.EntityName}}sResult{
@@ -466,9 +484,40 @@ func (h *List{{.EntityName}}sHandler) Handle(ctx context.Context, query List{{.E
 		Total:      total,
 		Page:       page,
 		PageSize:   pageSize,
-		TotalPages: totalPages,
+		TotalPages: criteria.TotalPages(total),
 	}, nil
 }
+
+// handleCursor 按游标分页：每页只读取 Limit+1 行，不随翻页深度变慢，仅在 WithTotal 时统计总数。
+func (h *List{{.EntityName}}sHandler) handleCursor(ctx context.Context, criteria orm.Criteria, query List{{.EntityName}}sQuery) (*List{{.EntityName}}sResult, error) {
+	limit := query.Limit
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	criteria = criteria.After(query.Cursor, limit)
+	if query.WithTotal {
+		criteria = criteria.WithTotal()
+	}
+
+	page, err := h.repo.FindByCursor(ctx, criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &List{{.EntityName}}sResult{
+		Items:      page.Items,
+		Total:      -1,
+		PageSize:   limit,
+		NextCursor: page.NextCursor,
+	}
+	if page.Total != nil {
+		result.Total = *page.Total
+	}
+	return result, nil
+}
 `
 
 const DTOTemplate = `package {{.PackageName}}app
@@ -502,6 +551,7 @@ type {{.EntityName}}Response struct {
 {{- range .Fields}}
 	{{.Name}} {{if .IsEnum}}string{{else}}{{.AppGoType}}{{end}} {{.JsonTag}}
 {{- end}}
+	Version   int64     ` + "`json:\"version\"`" + `
 	CreatedAt time.Time ` + "`json:\"created_at\"`" + `
 	UpdatedAt time.Time ` + "`json:\"updated_at\"`" + `
 }
@@ -513,6 +563,7 @@ func To{{.EntityName}}Response(e *{{.PackageName}}.{{.EntityName}}) {{.EntityNam
 {{- range .Fields}}
 		{{.Name}}: {{if .IsEnum}}string(e.{{.Name}}){{else}}e.{{.Name}}{{end}},
 {{- end}}
+		Version:   e.Version,
 		CreatedAt: e.CreatedAt,
 		UpdatedAt: e.UpdatedAt,
 	}
@@ -530,6 +581,15 @@ func To{{.EntityName}}ResponseList(entities []*{{.PackageName}}.{{.EntityName}})
 
 const HelpersHTTPTemplate = `package http
 
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soliton-go/framework/cqrs"
+	"github.com/soliton-go/framework/orm"
+)
+
 // EnumPtr 是一个辅助函数，用于将 *string 转换为枚举类型的 *T。
 // 适用于处理更新请求中的可选枚举字段。
 func EnumPtr[T any](v *string, parse func(string) T) *T {
@@ -539,6 +599,77 @@ func EnumPtr[T any](v *string, parse func(string) T) *T {
 	parsed := parse(*v)
 	return &parsed
 }
+
+// DispatchError 将 CQRS 总线返回的错误映射为标准 API 响应：
+// 校验失败（含无效的排序或过滤列）、未认证、无权限与并发冲突各有对应响应，处理器 panic 返回不含细节的 500，
+// 其余错误交给 fallback 处理。
+func DispatchError(c *gin.Context, err error, fallback func(c *gin.Context, message string)) {
+	message := err.Error()
+	switch {
+	case errors.Is(err, cqrs.ErrInvalidMessage):
+		ValidationError(c, message)
+	case errors.Is(err, cqrs.ErrUnauthenticated):
+		Unauthorized(c, message)
+	case errors.Is(err, cqrs.ErrForbidden):
+		Forbidden(c, message)
+	case errors.Is(err, orm.ErrInvalidCriteria):
+		ValidationError(c, message)
+	case errors.Is(err, orm.ErrConcurrencyConflict):
+		Conflict(c, message)
+	case errors.Is(err, cqrs.ErrPanic):
+		// panic 的细节已由 cqrs.Recoverer 记入日志，不返回给调用方
+		InternalError(c, "internal server error")
+	default:
+		fallback(c, message)
+	}
+}
+
+// listRanges 是范围过滤参数的后缀及对应的条件。
+var listRanges = []struct {
+	suffix string
+	build  func(column string, value any) orm.Condition
+}{
+	{"_gt", orm.Gt},
+	{"_gte", orm.Gte},
+	{"_lt", orm.Lt},
+	{"_lte", orm.Lte},
+}
+
+// ListFilters 将查询参数转换为列表过滤条件，只接受 columns 中列出的列，其余参数忽略：
+//
+//	?status=paid                  等于（值中的逗号不拆分）
+//	?status=paid&status=shipped   属于其中之一
+//	?total_gte=100                大于等于（另有 _gt、_lt、_lte）
+//	?notes_like=gift              包含
+//	?paid_at_null=true            为空（false 表示不为空）
+func ListFilters(c *gin.Context, columns ...string) []orm.Condition {
+	query := c.Request.URL.Query()
+	var filters []orm.Condition
+	for _, column := range columns {
+		switch values := query[column]; {
+		case len(values) > 1:
+			filters = append(filters, orm.In(column, values...))
+		case len(values) == 1 && values[0] != "":
+			filters = append(filters, orm.Eq(column, values[0]))
+		}
+		for _, r := range listRanges {
+			if v := query.Get(column + r.suffix); v != "" {
+				filters = append(filters, r.build(column, v))
+			}
+		}
+		if v := query.Get(column + "_like"); v != "" {
+			filters = append(filters, orm.Contains(column, v))
+		}
+		if isNull, err := strconv.ParseBool(query.Get(column + "_null")); err == nil {
+			if isNull {
+				filters = append(filters, orm.IsNull(column))
+			} else {
+				filters = append(filters, orm.NotNull(column))
+			}
+		}
+	}
+	return filters
+}
 `
 
 const HandlerTemplate = `package http
@@ -548,37 +679,21 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/soliton-go/framework/cqrs"
 
 	{{.PackageName}}app "{{.ModulePath}}/internal/application/{{.PackageName}}"
-{{- if .HasEnums}}
 	"{{.ModulePath}}/internal/domain/{{.PackageName}}"
-{{- end}}
 )
 
-// {{.EntityName}}Handler 处理 {{.EntityName}} 相关的 HTTP 请求。
+// {{.EntityName}}Handler 处理 {{.EntityName}} 相关的 HTTP 请求，命令与查询经 CQRS 总线分发。
 type {{.EntityName}}Handler struct {
-	createHandler *{{.PackageName}}app.Create{{.EntityName}}Handler
-	updateHandler *{{.PackageName}}app.Update{{.EntityName}}Handler
-	deleteHandler *{{.PackageName}}app.Delete{{.EntityName}}Handler
-	getHandler    *{{.PackageName}}app.Get{{.EntityName}}Handler
-	listHandler   *{{.PackageName}}app.List{{.EntityName}}sHandler
+	commands *cqrs.InMemoryCommandBus
+	queries  *cqrs.InMemoryQueryBus
 }
 
 // New{{.EntityName}}Handler 创建 {{.EntityName}}Handler 实例。
-func New{{.EntityName}}Handler(
-	createHandler *{{.PackageName}}app.Create{{.EntityName}}Handler,
-	updateHandler *{{.PackageName}}app.Update{{.EntityName}}Handler,
-	deleteHandler *{{.PackageName}}app.Delete{{.EntityName}}Handler,
-	getHandler *{{.PackageName}}app.Get{{.EntityName}}Handler,
-	listHandler *{{.PackageName}}app.List{{.EntityName}}sHandler,
-) *{{.EntityName}}Handler {
-	return &{{.EntityName}}Handler{
-		createHandler: createHandler,
-		updateHandler: updateHandler,
-		deleteHandler: deleteHandler,
-		getHandler:    getHandler,
-		listHandler:   listHandler,
-	}
+func New{{.EntityName}}Handler(commands *cqrs.InMemoryCommandBus, queries *cqrs.InMemoryQueryBus) *{{.EntityName}}Handler {
+	return &{{.EntityName}}Handler{commands: commands, queries: queries}
 }
 
 // RegisterRoutes 注册 {{.EntityName}} 相关路由。
@@ -609,9 +724,9 @@ func (h *{{.EntityName}}Handler) Create(c *gin.Context) {
 {{- end}}
 	}
 
-	entity, err := h.createHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[{{.PackageName}}app.Create{{.EntityName}}Command, *{{.PackageName}}.{{.EntityName}}](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -622,9 +737,9 @@ func (h *{{.EntityName}}Handler) Create(c *gin.Context) {
 func (h *{{.EntityName}}Handler) Get(c *gin.Context) {
 	id := c.Param("id")
 
-	entity, err := h.getHandler.Handle(c.Request.Context(), {{.PackageName}}app.Get{{.EntityName}}Query{ID: id})
+	entity, err := cqrs.Query[{{.PackageName}}app.Get{{.EntityName}}Query, *{{.PackageName}}.{{.EntityName}}](c.Request.Context(), h.queries, {{.PackageName}}app.Get{{.EntityName}}Query{ID: id})
 	if err != nil {
-		NotFound(c, "{{.PackageName}} not found")
+		DispatchError(c, err, func(c *gin.Context, _ string) { NotFound(c, "{{.PackageName}} not found") })
 		return
 	}
 
@@ -632,20 +747,42 @@ func (h *{{.EntityName}}Handler) Get(c *gin.Context) {
 }
 
 // List 处理 GET /api/{{.PackageName}}s?page=1&page_size=20&sort_by=id&sort_order=desc
+// 游标分页使用 ?limit=20&cursor=<next_cursor>，加 with_total=true 时统计总数
+// 过滤参数见 ListFilters，例如 ?created_at_gte=2026-01-01
 func (h *{{.EntityName}}Handler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	sortBy := c.DefaultQuery("sort_by", "id")
 	sortOrder := c.DefaultQuery("sort_order", "desc")
+	cursor := c.Query("cursor")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	withTotal, _ := strconv.ParseBool(c.Query("with_total"))
 
-	result, err := h.listHandler.Handle(c.Request.Context(), {{.PackageName}}app.List{{.EntityName}}sQuery{
+	result, err := cqrs.Query[{{.PackageName}}app.List{{.EntityName}}sQuery, *{{.PackageName}}app.List{{.EntityName}}sResult](c.Request.Context(), h.queries, {{.PackageName}}app.List{{.EntityName}}sQuery{
 		Page:     page,
 		PageSize: pageSize,
 		SortBy:   sortBy,
 		SortOrder: sortOrder,
+		Filters:  ListFilters(c, "id", "created_at", "updated_at"{{range .Fields}}, "{{.SnakeName}}"{{end}}),
+		Cursor:    cursor,
+		Limit:     limit,
+		WithTotal: withTotal,
 	})
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
+		return
+	}
+
+	if cursor != "" || limit > 0 {
+		data := gin.H{
+			"items":       {{.PackageName}}app.To{{.EntityName}}ResponseList(result.Items),
+			"limit":       result.PageSize,
+			"next_cursor": result.NextCursor,
+		}
+		if result.Total >= 0 {
+			data["total"] = result.Total
+		}
+		Success(c, data)
 		return
 	}
 
@@ -679,9 +816,9 @@ func (h *{{.EntityName}}Handler) Update(c *gin.Context) {
 {{- end}}
 	}
 
-	entity, err := h.updateHandler.Handle(c.Request.Context(), cmd)
+	entity, err := cqrs.Dispatch[{{.PackageName}}app.Update{{.EntityName}}Command, *{{.PackageName}}.{{.EntityName}}](c.Request.Context(), h.commands, cmd)
 	if err != nil {
-		InternalError(c, err.Error())
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -693,8 +830,8 @@ func (h *{{.EntityName}}Handler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
 	cmd := {{.PackageName}}app.Delete{{.EntityName}}Command{ID: id}
-	if err := h.deleteHandler.Handle(c.Request.Context(), cmd); err != nil {
-		InternalError(c, err.Error())
+	if err := cqrs.Send(c.Request.Context(), h.commands, cmd); err != nil {
+		DispatchError(c, err, InternalError)
 		return
 	}
 
@@ -705,6 +842,10 @@ func (h *{{.EntityName}}Handler) Delete(c *gin.Context) {
 const FxModuleTemplate = `package {{.PackageName}}app
 
 import (
+	"errors"
+
+	"github.com/soliton-go/framework/cqrs"
+	"github.com/soliton-go/framework/event"
 	"go.uber.org/fx"
 
 	"{{.ModulePath}}/internal/domain/{{.PackageName}}"
@@ -715,8 +856,8 @@ import (
 // Module 提供 {{.EntityName}} 的所有 Fx 依赖。
 var Module = fx.Options(
 	// Repository
-	fx.Provide(func(db *gorm.DB) {{.PackageName}}.{{.EntityName}}Repository {
-		return persistence.New{{.EntityName}}Repository(db)
+	fx.Provide(func(db *gorm.DB, publisher event.Publisher) {{.PackageName}}.{{.EntityName}}Repository {
+		return persistence.New{{.EntityName}}Repository(db, publisher)
 	}),
 
 	// Domain Services
@@ -734,20 +875,22 @@ var Module = fx.Options(
 	// soliton-gen:services
 	// soliton-gen:event-handlers
 
-	// 可选：注册到 CQRS 总线
-	// 取消注释以启用 CQRS 模式：
-	// fx.Invoke(func(cmdBus *cqrs.InMemoryCommandBus, queryBus *cqrs.InMemoryQueryBus,
-	//     createHandler *Create{{.EntityName}}Handler,
-	//     updateHandler *Update{{.EntityName}}Handler,
-	//     deleteHandler *Delete{{.EntityName}}Handler,
-	//     getHandler *Get{{.EntityName}}Handler,
-	//     listHandler *List{{.EntityName}}sHandler) {
-	//     cmdBus.Register(Create{{.EntityName}}Command{}, createHandler.Handle)
-	//     cmdBus.Register(Update{{.EntityName}}Command{}, updateHandler.Handle)
-	//     cmdBus.Register(Delete{{.EntityName}}Command{}, deleteHandler.Handle)
-	//     queryBus.Register(Get{{.EntityName}}Query{}, getHandler.Handle)
-	//     queryBus.Register(List{{.EntityName}}sQuery{}, listHandler.Handle)
-	// }),
+	// 注册到 CQRS 总线：HTTP 处理器经总线分发，总线中间件（校验、事务、授权、日志）对每个命令与查询生效。
+	// 处理器签名在编译期检查，重复注册返回错误。
+	fx.Invoke(func(cmdBus *cqrs.InMemoryCommandBus, queryBus *cqrs.InMemoryQueryBus,
+		createHandler *Create{{.EntityName}}Handler,
+		updateHandler *Update{{.EntityName}}Handler,
+		deleteHandler *Delete{{.EntityName}}Handler,
+		getHandler *Get{{.EntityName}}Handler,
+		listHandler *List{{.EntityName}}sHandler) error {
+		return errors.Join(
+			cqrs.RegisterCommand(cmdBus, createHandler.Handle),
+			cqrs.RegisterCommand(cmdBus, updateHandler.Handle),
+			cqrs.RegisterVoidCommand(cmdBus, deleteHandler.Handle),
+			cqrs.RegisterQuery(queryBus, getHandler.Handle),
+			cqrs.RegisterQuery(queryBus, listHandler.Handle),
+		)
+	}),
 )
 
 // RegisterMigration 注册 {{.EntityName}} 表的数据库迁移。
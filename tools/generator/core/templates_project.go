@@ -34,8 +34,14 @@ import (
 
 	"github.com/soliton-go/framework/core/config"
 	"github.com/soliton-go/framework/core/logger"
+	"github.com/soliton-go/framework/cqrs"
+	"github.com/soliton-go/framework/event"
 	"github.com/soliton-go/framework/orm"
+	"github.com/soliton-go/framework/projection"
+	"github.com/soliton-go/framework/web/admin"
+	"github.com/soliton-go/framework/web/middleware"
 
+	interfaceshttp "{{.ModuleName}}/internal/interfaces/http"
 	// soliton-gen:imports
 )
 
@@ -45,8 +51,77 @@ func main() {
 			config.NewConfig,
 			logger.NewLogger,
 			orm.NewGormDB,
+			// 工作单元：跨仓储的写操作共用一个事务，嵌套调用使用保存点
+			orm.NewUnitOfWork,
+			event.NewDeadLetterStore,
+			event.NewScheduler,
+			event.NewEventRecorder,
+			// 事件传输由 event.transport 配置选择（gochannel / sql / redis / nats）
+			func(lc fx.Lifecycle, cfg *config.Config, db *gorm.DB, dlq *event.DeadLetterStore, scheduler *event.Scheduler, recorder *event.EventRecorder, logger *zap.Logger) (event.EventBus, error) {
+				bus, err := event.NewEventBusFromConfig(cfg, db,
+					event.WithDefaultRetryPolicy(event.DefaultRetryPolicy),
+					event.WithDeadLetterStore(dlq),
+					event.WithScheduler(scheduler),
+					event.WithRecorder(recorder),
+					event.WithLogging(logger),
+					event.WithTracing(event.W3CTraceContext{}),
+				)
+				if err != nil {
+					return nil, err
+				}
+				lc.Append(fx.Hook{
+					OnStop: func(ctx context.Context) error { return bus.Close() },
+				})
+				return bus, nil
+			},
+			admin.NewDeadLetterHandler,
+			// 事件记录与重放：已发布事件写入 event_records，可重放给单个命名处理器
+			event.NewReplayer,
+			admin.NewEventReplayHandler,
+			// 读模型投影：按检查点读取事件记录，更新各模块注册的读模型
+			projection.NewProjector,
+			admin.NewProjectionHandler,
+			event.NewSyncDispatcher,
+			// 仓储保存聚合后，领域事件先在同一事务内交给同步处理器，再写入 Outbox 由中继异步发布
+			func(dispatcher *event.SyncDispatcher, outbox *event.Outbox) event.Publisher {
+				return event.Publishers{dispatcher, outbox}
+			},
+			event.NewOutbox,
+			func(outbox *event.Outbox, bus event.EventBus) *event.OutboxRelay {
+				return event.NewOutboxRelay(outbox, bus)
+			},
+			// 延迟事件：PublishAt / PublishAfter 写入调度表，到期后由轮询器发布到事件总线
+			func(scheduler *event.Scheduler) event.ScheduledPublisher { return scheduler },
+			func(scheduler *event.Scheduler, bus event.EventBus) *event.SchedulePoller {
+				return event.NewSchedulePoller(scheduler, bus)
+			},
+			// CQRS 总线：各模块把命令与查询处理器注册到总线，HTTP 处理器经总线分发。
+			// 中间件依次记录日志、恢复 panic、校验 validate 标签，命令还包在数据库事务中；
+			// 接入认证后可加入 cqrs.Authorization，调用方由 HTTP 中间件通过 cqrs.WithCaller 写入上下文。
+			// cqrs.WithAsync 允许经 DispatchAsync 把耗时命令写入 async_commands 表，由 CommandWorker 在后台执行
+			func(logger *zap.Logger, db *gorm.DB) *cqrs.InMemoryCommandBus {
+				return cqrs.NewCommandBus(cqrs.WithAsync(db), cqrs.WithMiddleware(
+					cqrs.Logging(logger),
+					cqrs.Recoverer(logger),
+					cqrs.Validation(cqrs.ValidateStruct(), cqrs.ValidateSelf),
+					cqrs.Transaction(db),
+				))
+			},
+			func(logger *zap.Logger) *cqrs.InMemoryQueryBus {
+				return cqrs.NewQueryBus(cqrs.WithMiddleware(
+					cqrs.Logging(logger),
+					cqrs.Recoverer(logger),
+					cqrs.Validation(cqrs.ValidateStruct(), cqrs.ValidateSelf),
+				))
+			},
+			func(bus *cqrs.InMemoryCommandBus, logger *zap.Logger) *cqrs.CommandWorker {
+				return cqrs.NewCommandWorker(bus, cqrs.WithWorkerLogger(logger))
+			},
+			cqrs.NewGetCommandHandler,
+			interfaceshttp.NewCommandHandler,
 			// soliton-gen:providers
 			NewRouter,
+			NewAdminRouter,
 		),
 
 		// soliton-gen:modules
@@ -55,6 +130,44 @@ func main() {
 
 		// soliton-gen:routes
 
+		// 启动 Outbox 中继
+		fx.Invoke(event.MigrateOutbox),
+		fx.Invoke(StartOutboxRelay),
+
+		// 启动延迟事件轮询器
+		fx.Invoke(event.MigrateSchedule),
+		fx.Invoke(StartSchedulePoller),
+
+		// 死信队列及管理接口（管理接口均挂在 AdminRouter 上，需携带 admin.token 配置的令牌）
+		fx.Invoke(event.MigrateDeadLetters),
+		fx.Invoke(func(r AdminRouter, h *admin.DeadLetterHandler) {
+			h.RegisterRoutes(r)
+		}),
+
+		// 事件记录及重放接口
+		fx.Invoke(event.MigrateEventRecords),
+		fx.Invoke(func(r AdminRouter, h *admin.EventReplayHandler) {
+			h.RegisterRoutes(r)
+		}),
+
+		// 启动读模型投影及管理接口
+		fx.Invoke(projection.MigrateCheckpoints),
+		fx.Invoke(StartProjector),
+		fx.Invoke(func(r AdminRouter, h *admin.ProjectionHandler) {
+			h.RegisterRoutes(r)
+		}),
+
+		// 启动异步命令执行器，命令状态经 GET /api/commands/:id 查询
+		fx.Invoke(cqrs.MigrateAsyncCommands),
+		fx.Invoke(StartCommandWorker),
+		fx.Invoke(func(r *gin.Engine, queries *cqrs.InMemoryQueryBus, getCommand *cqrs.GetCommandHandler, h *interfaceshttp.CommandHandler) error {
+			if err := cqrs.RegisterQuery(queries, getCommand.Handle); err != nil {
+				return err
+			}
+			h.RegisterRoutes(r)
+			return nil
+		}),
+
 		// 启动服务器
 		fx.Invoke(StartServer),
 	).Run()
@@ -63,6 +176,7 @@ func main() {
 // NewRouter 创建 Gin 引擎并注册基础路由。
 func NewRouter() *gin.Engine {
 	r := gin.Default()
+	r.Use(middleware.CorrelationID())
 
 	// 健康检查
 	r.GET("/health", func(c *gin.Context) {
@@ -72,6 +186,49 @@ func NewRouter() *gin.Engine {
 	return r
 }
 
+// AdminRouter 是管理接口（死信、事件重放、投影）的路由组。
+type AdminRouter struct {
+	gin.IRouter
+}
+
+// NewAdminRouter 创建管理接口路由组，请求须携带 Authorization: Bearer <admin.token>；
+// 未配置 admin.token（或环境变量 ADMIN_TOKEN）时管理接口拒绝所有请求。
+func NewAdminRouter(r *gin.Engine, cfg *config.Config) AdminRouter {
+	return AdminRouter{r.Group("", admin.RequireToken(cfg.GetString("admin.token")))}
+}
+
+// StartOutboxRelay 启动 Outbox 中继，将事务内写入的领域事件发布到事件总线（带 Fx 生命周期管理）。
+func StartOutboxRelay(lc fx.Lifecycle, relay *event.OutboxRelay) {
+	lc.Append(fx.Hook{
+		OnStart: relay.Start,
+		OnStop:  relay.Stop,
+	})
+}
+
+// StartSchedulePoller 启动延迟事件轮询器，将到期的调度事件发布到事件总线（带 Fx 生命周期管理）。
+func StartSchedulePoller(lc fx.Lifecycle, poller *event.SchedulePoller) {
+	lc.Append(fx.Hook{
+		OnStart: poller.Start,
+		OnStop:  poller.Stop,
+	})
+}
+
+// StartProjector 启动读模型投影，将新记录的事件应用到已注册的投影（带 Fx 生命周期管理）。
+func StartProjector(lc fx.Lifecycle, projector *projection.Projector) {
+	lc.Append(fx.Hook{
+		OnStart: projector.Start,
+		OnStop:  projector.Stop,
+	})
+}
+
+// StartCommandWorker 启动异步命令执行器，执行经 DispatchAsync 提交的命令（带 Fx 生命周期管理）。
+func StartCommandWorker(lc fx.Lifecycle, worker *cqrs.CommandWorker) {
+	lc.Append(fx.Hook{
+		OnStart: worker.Start,
+		OnStop:  worker.Stop,
+	})
+}
+
 // StartServer 启动 HTTP 服务器（带 Fx 生命周期管理）。
 func StartServer(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger, r *gin.Engine) {
 	addr := fmt.Sprintf("%s:%d", cfg.GetString("server.host"), cfg.GetInt("server.port"))
@@ -102,7 +259,10 @@ import (
 
 	"github.com/soliton-go/framework/core/config"
 	"github.com/soliton-go/framework/core/logger"
+	"github.com/soliton-go/framework/cqrs"
+	"github.com/soliton-go/framework/event"
 	"github.com/soliton-go/framework/orm"
+	"github.com/soliton-go/framework/projection"
 
 	// soliton-gen:imports
 )
@@ -135,6 +295,24 @@ func main() {
 }
 
 func migrateAll(db *gorm.DB) error {
+	if err := event.MigrateOutbox(db); err != nil {
+		return err
+	}
+	if err := event.MigrateDeadLetters(db); err != nil {
+		return err
+	}
+	if err := event.MigrateSchedule(db); err != nil {
+		return err
+	}
+	if err := event.MigrateEventRecords(db); err != nil {
+		return err
+	}
+	if err := projection.MigrateCheckpoints(db); err != nil {
+		return err
+	}
+	if err := cqrs.MigrateAsyncCommands(db); err != nil {
+		return err
+	}
 	// soliton-gen:migrations
 	return nil
 }
@@ -150,6 +328,11 @@ database:
 
 log:
   level: info
+
+event:
+  transport:
+    type: gochannel
+    consumer_group: {{.ProjectName}}
 `
 
 const ConfigExampleTemplate = `# Server Configuration
@@ -171,9 +354,57 @@ database:
   # driver: mysql
   # dsn: user:password@tcp(127.0.0.1:3306)/myapp?charset=utf8mb4&parseTime=True&loc=Local
 
+  # Read replicas (same driver): reads outside transactions go to a random replica
+  # replicas:
+  #   - user:password@tcp(replica-1:3306)/myapp?charset=utf8mb4&parseTime=True&loc=Local
+
+  # Connection pool (0 keeps the database/sql default)
+  # pool:
+  #   max_open_conns: 50
+  #   max_idle_conns: 10
+  #   conn_max_lifetime: 30m
+  #   conn_max_idle_time: 5m
+
+  # Retry the initial connection, e.g. while the database container starts
+  # connect:
+  #   retries: 5
+  #   backoff: 1s
+  #   max_backoff: 30s
+
+# Admin endpoints (dead letters, event replay, projections)
+admin:
+  # Bearer token required by /admin/*; the endpoints reject every request while it is empty.
+  # Prefer setting it through the ADMIN_TOKEN environment variable.
+  token: ""
+
 # Logging
 log:
   level: info  # debug, info, warn, error
+
+# Event transport
+event:
+  transport:
+    # Options: gochannel (in-memory), sql (the database above), redis (Redis Streams), nats (NATS JetStream)
+    type: gochannel
+    # Instances sharing a consumer group split the events of each topic between them
+    consumer_group: {{.ProjectName}}
+
+    # sql:
+    #   poll_interval: 1s
+    #   batch_size: 100
+    #   lease: 1m
+
+    # redis:
+    #   addr: localhost:6379
+    #   password: ""
+    #   db: 0
+    #   max_len: 100000
+
+    # nats:
+    #   url: nats://localhost:4222
+    #   stream: EVENTS
+    #   subject_prefix: events.
+    #   ack_wait: 30s
 `
 
 const ResponseTemplate = `package http
@@ -215,6 +446,15 @@ func Success(c *gin.Context, data interface{}) {
 	})
 }
 
+// Accepted 返回 202 响应，表示请求已受理、稍后执行（如异步命令）。
+func Accepted(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusAccepted, Response{
+		Code:    CodeSuccess,
+		Message: "accepted",
+		Data:    data,
+	})
+}
+
 // BadRequest 返回 400 错误响应。
 func BadRequest(c *gin.Context, message string) {
 	c.JSON(http.StatusBadRequest, Response{
@@ -239,6 +479,14 @@ func InternalError(c *gin.Context, message string) {
 	})
 }
 
+// Conflict 返回 409 业务冲突响应。
+func Conflict(c *gin.Context, message string) {
+	c.JSON(http.StatusConflict, Response{
+		Code:    CodeConflict,
+		Message: message,
+	})
+}
+
 // ValidationError 返回校验错误响应。
 func ValidationError(c *gin.Context, message string) {
 	c.JSON(http.StatusBadRequest, Response{
@@ -246,6 +494,63 @@ func ValidationError(c *gin.Context, message string) {
 		Message: message,
 	})
 }
+
+// Unauthorized 返回 401 未认证响应。
+func Unauthorized(c *gin.Context, message string) {
+	c.JSON(http.StatusUnauthorized, Response{
+		Code:    CodeUnauthorized,
+		Message: message,
+	})
+}
+
+// Forbidden 返回 403 无权限响应。
+func Forbidden(c *gin.Context, message string) {
+	c.JSON(http.StatusForbidden, Response{
+		Code:    CodeForbidden,
+		Message: message,
+	})
+}
+`
+
+const CommandHandlerTemplate = `package http
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soliton-go/framework/cqrs"
+)
+
+// CommandHandler 查询经 DispatchAsync 异步执行的命令的状态和结果。
+type CommandHandler struct {
+	queries *cqrs.InMemoryQueryBus
+}
+
+// NewCommandHandler 创建 CommandHandler 实例。
+func NewCommandHandler(queries *cqrs.InMemoryQueryBus) *CommandHandler {
+	return &CommandHandler{queries: queries}
+}
+
+// RegisterRoutes 注册异步命令相关路由。
+func (h *CommandHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/api/commands/:id", h.Get)
+}
+
+// Get 处理 GET /api/commands/:id
+// 返回命令状态（pending / running / succeeded / failed），成功时包含结果，失败时包含错误信息。
+func (h *CommandHandler) Get(c *gin.Context) {
+	command, err := cqrs.Query[cqrs.GetCommandQuery, *cqrs.AsyncCommand](c.Request.Context(), h.queries, cqrs.GetCommandQuery{ID: c.Param("id")})
+	if errors.Is(err, cqrs.ErrCommandNotFound) {
+		NotFound(c, "command not found")
+		return
+	}
+	if err != nil {
+		InternalError(c, err.Error())
+		return
+	}
+
+	Success(c, command)
+}
 `
 
 const GitignoreTemplate = `# Binaries
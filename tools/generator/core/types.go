@@ -92,6 +92,7 @@ type EventHandlerConfig struct {
 	Domain    string `json:"domain"`
 	EventName string `json:"event_name"`
 	Topic     string `json:"topic,omitempty"`
+	Sync      bool   `json:"sync,omitempty"` // run inside the command transaction via event.SyncDispatcher
 	Force     bool   `json:"force"`
 }
 
@@ -140,6 +141,7 @@ type Field struct {
 	EnumValues []string // Enum values if IsEnum is true
 	EnumType   string   // Enum type name (e.g., "UserStatus")
 	IsPointer  bool     // True if GoType is a pointer type
+	Indexed    bool     // True if the column has an index, which makes it sortable in lists
 }
 
 // TemplateData holds all data for domain template generation.
@@ -228,4 +230,5 @@ type EventHandlerData struct {
 	HandlerName     string
 	EventTopic      string
 	ModulePath      string
+	Sync            bool
 }
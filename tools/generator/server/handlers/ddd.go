@@ -40,6 +40,7 @@ type EventHandlerRequest struct {
 	Domain    string `json:"domain" binding:"required"`
 	EventName string `json:"event_name" binding:"required"`
 	Topic     string `json:"topic"`
+	Sync      bool   `json:"sync"`
 	Force     bool   `json:"force"`
 }
 
@@ -280,6 +281,7 @@ func GenerateEventHandler(c *gin.Context) {
 		Domain:    req.Domain,
 		EventName: req.EventName,
 		Topic:     req.Topic,
+		Sync:      req.Sync,
 		Force:     req.Force,
 	}
 
@@ -308,6 +310,7 @@ func PreviewEventHandler(c *gin.Context) {
 		Domain:    req.Domain,
 		EventName: req.EventName,
 		Topic:     req.Topic,
+		Sync:      req.Sync,
 		Force:     req.Force,
 	}
 
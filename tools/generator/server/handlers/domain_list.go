@@ -127,6 +127,7 @@ func parseEntityFields(filePath string) []string {
 				// Skip built-in fields (already defined in template)
 				builtinFields := map[string]bool{
 					"ID":        true,
+					"Version":   true,
 					"CreatedAt": true,
 					"UpdatedAt": true,
 					"DeletedAt": true,
@@ -275,6 +276,7 @@ func parseEntityFieldsDetailed(filePath string) []FieldDetail {
 				// Skip built-in fields (already defined in template)
 				builtinFields := map[string]bool{
 					"ID":        true,
+					"Version":   true,
 					"CreatedAt": true,
 					"UpdatedAt": true,
 					"DeletedAt": true,
@@ -70,6 +70,7 @@ export interface EventHandlerConfig {
   domain: string
   event_name: string
   topic?: string
+  sync?: boolean
   force: boolean
 }
 